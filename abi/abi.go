@@ -77,10 +77,36 @@ func (t Type) String() string {
 	case CommitmentsMap:
 		return "map[string]Commitments"
 	default:
+		if ct, ok := compositeOf(t); ok {
+			return ct.String()
+		}
 		return "<unknown type>"
 	}
 }
 
+// MarshalText encodes a type as its name, since the numbers of composite
+// types depend on the order they were made in.
+func (t Type) MarshalText() ([]byte, error) {
+	if t != Invalid && goTypeOf(t) == nil {
+		return nil, fmt.Errorf("unrecognized Type: %d", t)
+	}
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText decodes a type from its name.
+func (t *Type) UnmarshalText(text []byte) error {
+	if string(text) == Invalid.String() {
+		*t = Invalid
+		return nil
+	}
+	parsed, err := parseType(string(text))
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
 // Value pairs a go value with its ABI type
 type Value struct {
 	Type Type
@@ -116,6 +142,9 @@ func (av *Value) String() string {
 	case CommitmentsMap:
 		return fmt.Sprint(av.Val.(map[string]types.Commitments))
 	default:
+		if ct, ok := compositeOf(av.Type); ok {
+			return ct.valueString(av.Val)
+		}
 		return "<unknown type>"
 	}
 }
@@ -215,12 +244,16 @@ func (av *Value) Serialize() ([]byte, error) {
 
 		return cbor.DumpObject(m)
 	default:
+		if ct, ok := compositeOf(av.Type); ok {
+			return ct.serialize(av.Val)
+		}
 		return nil, fmt.Errorf("unrecognized Type: %d", av.Type)
 	}
 }
 
 // ToValues converts from a slice of go abi-compatible values to abi values.
-// empty slices are normalized to nil
+// Values that are already abi values are kept as they are, which is the only
+// way to pass an optional value. empty slices are normalized to nil
 func ToValues(i []interface{}) ([]*Value, error) {
 	if len(i) == 0 {
 		return nil, nil
@@ -253,8 +286,14 @@ func ToValues(i []interface{}) ([]*Value, error) {
 			out = append(out, &Value{Type: SectorID, Val: v})
 		case map[string]types.Commitments:
 			out = append(out, &Value{Type: CommitmentsMap, Val: v})
+		case *Value:
+			out = append(out, v)
 		default:
-			return nil, fmt.Errorf("unsupported type: %T", v)
+			t, ok := typeOf(reflect.TypeOf(v))
+			if !ok {
+				return nil, fmt.Errorf("unsupported type: %T", v)
+			}
+			out = append(out, &Value{Type: t, Val: v})
 		}
 	}
 	return out, nil
@@ -360,7 +399,18 @@ func Deserialize(data []byte, t Type) (*Value, error) {
 	case Invalid:
 		return nil, ErrInvalidType
 	default:
-		return nil, fmt.Errorf("unrecognized Type: %d", t)
+		ct, ok := compositeOf(t)
+		if !ok {
+			return nil, fmt.Errorf("unrecognized Type: %d", t)
+		}
+		val, err := ct.deserialize(data)
+		if err != nil {
+			return nil, err
+		}
+		return &Value{
+			Type: t,
+			Val:  val,
+		}, nil
 	}
 }
 
@@ -381,9 +431,6 @@ var typeTable = map[Type]reflect.Type{
 
 // TypeMatches returns whether or not 'val' is the go type expected for the given ABI type
 func TypeMatches(t Type, val reflect.Type) bool {
-	rt, ok := typeTable[t]
-	if !ok {
-		return false
-	}
-	return rt == val
+	rt := goTypeOf(t)
+	return rt != nil && rt == val
 }
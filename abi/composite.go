@@ -0,0 +1,345 @@
+package abi
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	cbor "gx/ipfs/QmRoARq3nkUb13HSKZGepCZSWe5GrVPwx7xURJGZ7KWv9V/go-ipld-cbor"
+)
+
+// compositeBase is the first Type of composite types, well clear of the
+// scalar types above.
+const compositeBase = Type(1 << 32)
+
+type compositeKind Type
+
+const (
+	arrayKind compositeKind = iota
+	optionalKind
+	structKind
+)
+
+// compositeType describes a Type built from other types, or registered by an
+// actor, rather than one of the fixed scalar types.
+type compositeType struct {
+	kind compositeKind
+	// elem is the element type of an array or optional type.
+	elem Type
+	// goType is the go type of values of this type.
+	goType reflect.Type
+}
+
+var (
+	compositesLk  sync.RWMutex
+	composites    = map[Type]*compositeType{}
+	arrayTypes    = map[Type]Type{}
+	optionalTypes = map[Type]Type{}
+	structTypes   = map[reflect.Type]Type{}
+)
+
+// ArrayOf returns the type of arrays of elem. The go type of an array is a
+// slice of the go type of elem. Arrays of the same element type are the same
+// type, and arrays of SectorID are UintArray.
+func ArrayOf(elem Type) Type {
+	if elem == SectorID {
+		return UintArray
+	}
+	elemGoType := goTypeOf(elem)
+	if elemGoType == nil {
+		panic(fmt.Sprintf("ArrayOf called with invalid element type: %d", elem))
+	}
+
+	compositesLk.Lock()
+	defer compositesLk.Unlock()
+	if t, ok := arrayTypes[elem]; ok {
+		return t
+	}
+	t := compositeID(arrayKind, elem)
+	composites[t] = &compositeType{kind: arrayKind, elem: elem, goType: reflect.SliceOf(elemGoType)}
+	arrayTypes[elem] = t
+	return t
+}
+
+// OptionalOf returns the type of values of elem that may be absent. The go
+// type of an optional value is the go type of elem if that can be nil, or a
+// pointer to it otherwise. A nil value is absent.
+func OptionalOf(elem Type) Type {
+	elemGoType := goTypeOf(elem)
+	if elemGoType == nil {
+		panic(fmt.Sprintf("OptionalOf called with invalid element type: %d", elem))
+	}
+
+	compositesLk.Lock()
+	defer compositesLk.Unlock()
+	if t, ok := optionalTypes[elem]; ok {
+		return t
+	}
+	goType := elemGoType
+	if !nillable(goType) {
+		goType = reflect.PtrTo(goType)
+	}
+	t := compositeID(optionalKind, elem)
+	composites[t] = &compositeType{kind: optionalKind, elem: elem, goType: goType}
+	optionalTypes[elem] = t
+	return t
+}
+
+// RegisterStruct returns the type of values that have the go type of proto,
+// which must be a pointer to a struct. Values are encoded as CBOR, so the
+// struct must also be registered with cbor.RegisterCborType.
+//
+// The type is identified by id, so that it is the same in every process
+// whatever order types are registered in. Each struct must be registered with
+// an id of its own, which must not change once the type is in use.
+// Registering the same struct twice with the same id returns the same type.
+func RegisterStruct(id uint32, proto interface{}) Type {
+	goType := reflect.TypeOf(proto)
+	if goType == nil || goType.Kind() != reflect.Ptr || goType.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("RegisterStruct must receive a pointer to a struct, but got: %T", proto))
+	}
+
+	compositesLk.Lock()
+	defer compositesLk.Unlock()
+	t := compositeID(structKind, Type(id))
+	if ct, ok := composites[t]; ok {
+		if ct.goType != goType {
+			panic(fmt.Sprintf("RegisterStruct called with id %d of %s for %s", id, ct.goType, goType))
+		}
+		return t
+	}
+	if other, ok := structTypes[goType]; ok {
+		panic(fmt.Sprintf("RegisterStruct called for %s with id %d, but it has type %d", goType, id, other))
+	}
+	composites[t] = &compositeType{kind: structKind, goType: goType}
+	structTypes[goType] = t
+	return t
+}
+
+// compositeID returns the type of the composite of the given kind built from
+// n, the element type of arrays and optionals or the id of structs. Types
+// derived this way do not depend on the order composites are created in.
+func compositeID(kind compositeKind, n Type) Type {
+	return compositeBase + (n << 2) + Type(kind)
+}
+
+func compositeOf(t Type) (*compositeType, bool) {
+	compositesLk.RLock()
+	defer compositesLk.RUnlock()
+	ct, ok := composites[t]
+	return ct, ok
+}
+
+// goTypeOf returns the go type of values of type t, or nil if t is not a
+// known type.
+func goTypeOf(t Type) reflect.Type {
+	if rt, ok := typeTable[t]; ok {
+		return rt
+	}
+	if ct, ok := compositeOf(t); ok {
+		return ct.goType
+	}
+	return nil
+}
+
+// typeOf returns the type of go values of type rt. Optional types cannot be
+// inferred from a go type, so are never returned.
+func typeOf(rt reflect.Type) (Type, bool) {
+	if rt == nil {
+		return Invalid, false
+	}
+	for t, tt := range typeTable {
+		if tt == rt {
+			return t, true
+		}
+	}
+
+	compositesLk.RLock()
+	t, ok := structTypes[rt]
+	compositesLk.RUnlock()
+	if ok {
+		return t, true
+	}
+
+	if rt.Kind() == reflect.Slice {
+		if elem, ok := typeOf(rt.Elem()); ok {
+			return ArrayOf(elem), true
+		}
+	}
+	return Invalid, false
+}
+
+func nillable(rt reflect.Type) bool {
+	switch rt.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		return true
+	default:
+		return false
+	}
+}
+
+// reflectValue returns the reflect value of val, a value of go type rt. A nil
+// val becomes the zero value of rt.
+func reflectValue(val interface{}, rt reflect.Type) reflect.Value {
+	if val == nil {
+		return reflect.Zero(rt)
+	}
+	return reflect.ValueOf(val)
+}
+
+func (ct *compositeType) String() string {
+	if ct.kind == optionalKind {
+		return "optional " + ct.elem.String()
+	}
+	return ct.goType.String()
+}
+
+func (ct *compositeType) valueString(val interface{}) string {
+	rv := reflectValue(val, ct.goType)
+	switch ct.kind {
+	case arrayKind:
+		elems := make([]string, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			v := &Value{Type: ct.elem, Val: rv.Index(i).Interface()}
+			elems = append(elems, v.String())
+		}
+		return "[" + strings.Join(elems, " ") + "]"
+	case optionalKind:
+		if rv.IsNil() {
+			return "<none>"
+		}
+		v := &Value{Type: ct.elem, Val: ct.present(rv)}
+		return v.String()
+	default:
+		if rv.IsNil() {
+			return "<nil>"
+		}
+		return fmt.Sprintf("%+v", rv.Elem().Interface())
+	}
+}
+
+// present returns the element value of a present optional value.
+func (ct *compositeType) present(rv reflect.Value) interface{} {
+	if ct.goType == goTypeOf(ct.elem) {
+		return rv.Interface()
+	}
+	return rv.Elem().Interface()
+}
+
+func (ct *compositeType) serialize(val interface{}) ([]byte, error) {
+	if val != nil && reflect.TypeOf(val) != ct.goType {
+		return nil, &typeError{reflect.Zero(ct.goType).Interface(), val}
+	}
+	rv := reflectValue(val, ct.goType)
+
+	switch ct.kind {
+	case arrayKind:
+		arr := make([][]byte, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			data, err := (&Value{Type: ct.elem, Val: rv.Index(i).Interface()}).Serialize()
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, data)
+		}
+		return cbor.DumpObject(arr)
+	case optionalKind:
+		arr := [][]byte{}
+		if !rv.IsNil() {
+			data, err := (&Value{Type: ct.elem, Val: ct.present(rv)}).Serialize()
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, data)
+		}
+		return cbor.DumpObject(arr)
+	default:
+		if rv.IsNil() {
+			return nil, fmt.Errorf("cannot serialize nil %s", ct.goType)
+		}
+		return cbor.DumpObject(rv.Interface())
+	}
+}
+
+func (ct *compositeType) deserialize(data []byte) (interface{}, error) {
+	switch ct.kind {
+	case arrayKind:
+		var arr [][]byte
+		if err := cbor.DecodeInto(data, &arr); err != nil {
+			return nil, err
+		}
+		elemGoType := ct.goType.Elem()
+		out := reflect.MakeSlice(ct.goType, 0, len(arr))
+		for _, elemData := range arr {
+			v, err := Deserialize(elemData, ct.elem)
+			if err != nil {
+				return nil, err
+			}
+			out = reflect.Append(out, reflectValue(v.Val, elemGoType))
+		}
+		return out.Interface(), nil
+	case optionalKind:
+		var arr [][]byte
+		if err := cbor.DecodeInto(data, &arr); err != nil {
+			return nil, err
+		}
+		switch len(arr) {
+		case 0:
+			return reflect.Zero(ct.goType).Interface(), nil
+		case 1:
+		default:
+			return nil, fmt.Errorf("expected at most one optional value, but got %d", len(arr))
+		}
+
+		v, err := Deserialize(arr[0], ct.elem)
+		if err != nil {
+			return nil, err
+		}
+		if ct.goType == goTypeOf(ct.elem) {
+			return v.Val, nil
+		}
+		ptr := reflect.New(ct.goType.Elem())
+		ptr.Elem().Set(reflect.ValueOf(v.Val))
+		return ptr.Interface(), nil
+	default:
+		ptr := reflect.New(ct.goType.Elem())
+		if err := cbor.DecodeInto(data, ptr.Interface()); err != nil {
+			return nil, err
+		}
+		return ptr.Interface(), nil
+	}
+}
+
+// parseType returns the type whose String is name.
+func parseType(name string) (Type, error) {
+	for t := Invalid + 1; t <= CommitmentsMap; t++ {
+		if t.String() == name {
+			return t, nil
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(name, "[]"):
+		elem, err := parseType(strings.TrimPrefix(name, "[]"))
+		if err != nil {
+			return Invalid, err
+		}
+		return ArrayOf(elem), nil
+	case strings.HasPrefix(name, "optional "):
+		elem, err := parseType(strings.TrimPrefix(name, "optional "))
+		if err != nil {
+			return Invalid, err
+		}
+		return OptionalOf(elem), nil
+	}
+
+	compositesLk.RLock()
+	defer compositesLk.RUnlock()
+	for rt, t := range structTypes {
+		if rt.String() == name {
+			return t, nil
+		}
+	}
+	return Invalid, fmt.Errorf("unknown type: %s", name)
+}
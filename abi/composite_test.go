@@ -0,0 +1,152 @@
+package abi
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	cbor "gx/ipfs/QmRoARq3nkUb13HSKZGepCZSWe5GrVPwx7xURJGZ7KWv9V/go-ipld-cbor"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type barTestStruct struct {
+	Name  string
+	Price *types.AttoFIL
+}
+
+func init() {
+	cbor.RegisterCborType(barTestStruct{})
+}
+
+type bazTestStruct struct {
+	Name string
+}
+
+var barTestType = RegisterStruct(1, &barTestStruct{})
+
+func TestCompositeTypesAreCanonical(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(ArrayOf(Address), ArrayOf(Address))
+	assert.NotEqual(ArrayOf(Address), ArrayOf(String))
+	assert.Equal(OptionalOf(Address), OptionalOf(Address))
+	assert.NotEqual(ArrayOf(Address), OptionalOf(Address))
+	assert.Equal(barTestType, RegisterStruct(1, &barTestStruct{}))
+
+	assert.Equal("[]address.Address", ArrayOf(Address).String())
+	assert.Equal("optional uint64", OptionalOf(SectorID).String())
+	assert.Equal("*abi.barTestStruct", barTestType.String())
+	assert.Equal("[]*abi.barTestStruct", ArrayOf(barTestType).String())
+}
+
+func TestCompositeTypesAreStable(t *testing.T) {
+	assert := assert.New(t)
+
+	// the types of composites depend on what they are built from only, and
+	// not on the order they are created in
+	assert.Equal(Type(1<<32+4*uint64(Address)), ArrayOf(Address))
+	assert.Equal(Type(1<<32+4*uint64(Address)+1), OptionalOf(Address))
+	assert.Equal(Type(1<<32+4+2), barTestType)
+	assert.Equal(Type(1<<32+4*uint64(barTestType)), ArrayOf(barTestType))
+
+	// struct ids are unique
+	assert.Panics(func() { RegisterStruct(1, &bazTestStruct{}) })
+	assert.Panics(func() { RegisterStruct(2, &barTestStruct{}) })
+}
+
+func TestCompositeEncodingRoundTrip(t *testing.T) {
+	addrGetter := address.NewForTestGetter()
+	sectorID := uint64(7)
+
+	cases := map[string]*Value{
+		"array of addresses":      {Type: ArrayOf(Address), Val: []address.Address{addrGetter(), addrGetter()}},
+		"empty array":             {Type: ArrayOf(Integer), Val: []*big.Int{}},
+		"array of arrays":         {Type: ArrayOf(ArrayOf(String)), Val: [][]string{{"a", "b"}, {}, {"c"}}},
+		"struct":                  {Type: barTestType, Val: &barTestStruct{Name: "bar", Price: types.NewAttoFILFromFIL(3)}},
+		"array of structs":        {Type: ArrayOf(barTestType), Val: []*barTestStruct{{Name: "a", Price: types.NewAttoFILFromFIL(1)}}},
+		"present optional":        {Type: OptionalOf(SectorID), Val: &sectorID},
+		"absent optional":         {Type: OptionalOf(SectorID), Val: (*uint64)(nil)},
+		"present nilable option":  {Type: OptionalOf(AttoFIL), Val: types.NewAttoFILFromFIL(2)},
+		"absent nilable optional": {Type: OptionalOf(barTestType), Val: (*barTestStruct)(nil)},
+	}
+
+	for tname, tcase := range cases {
+		t.Run(tname, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			assert.True(TypeMatches(tcase.Type, reflect.TypeOf(tcase.Val)))
+
+			data, err := EncodeValues([]*Value{tcase})
+			require.NoError(err)
+
+			out, err := DecodeValues(data, []Type{tcase.Type})
+			require.NoError(err)
+			require.Len(out, 1)
+			assert.Equal(tcase.Type, out[0].Type)
+			assert.Equal(tcase.Val, out[0].Val)
+		})
+	}
+}
+
+func TestToValuesInfersCompositeTypes(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	addrs := []address.Address{address.NewForTestGetter()()}
+	bar := &barTestStruct{Name: "bar"}
+	optional := &Value{Type: OptionalOf(String), Val: (*string)(nil)}
+
+	vals, err := ToValues([]interface{}{addrs, bar, []*barTestStruct{bar}, optional})
+	require.NoError(err)
+	require.Len(vals, 4)
+	assert.Equal(ArrayOf(Address), vals[0].Type)
+	assert.Equal(barTestType, vals[1].Type)
+	assert.Equal(ArrayOf(barTestType), vals[2].Type)
+	assert.Equal(optional, vals[3])
+
+	_, err = ToValues([]interface{}{[]int{1}})
+	assert.EqualError(err, "unsupported type: []int")
+}
+
+func TestCompositeValueString(t *testing.T) {
+	assert := assert.New(t)
+
+	s := "x"
+	assert.Equal("[a b]", (&Value{Type: ArrayOf(String), Val: []string{"a", "b"}}).String())
+	assert.Equal("x", (&Value{Type: OptionalOf(String), Val: &s}).String())
+	assert.Equal("<none>", (&Value{Type: OptionalOf(String), Val: (*string)(nil)}).String())
+	assert.Equal("{Name:bar Price:<nil>}", (&Value{Type: barTestType, Val: &barTestStruct{Name: "bar"}}).String())
+}
+
+func TestCompositeSerializeTypeMismatch(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := (&Value{Type: ArrayOf(String), Val: []uint64{1}}).Serialize()
+	assert.Error(err)
+
+	_, err = (&Value{Type: barTestType, Val: (*barTestStruct)(nil)}).Serialize()
+	assert.Error(err)
+}
+
+func TestTypeTextRoundTrip(t *testing.T) {
+	for _, typ := range []Type{Invalid, Address, UintArray, ArrayOf(Address), ArrayOf(ArrayOf(String)), OptionalOf(SectorID), barTestType, ArrayOf(barTestType)} {
+		t.Run(typ.String(), func(t *testing.T) {
+			require := require.New(t)
+
+			text, err := typ.MarshalText()
+			require.NoError(err)
+
+			var out Type
+			require.NoError(out.UnmarshalText(text))
+			require.Equal(typ, out)
+		})
+	}
+
+	var out Type
+	assert.Error(t, out.UnmarshalText([]byte("*abi.nosuchStruct")))
+}
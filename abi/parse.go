@@ -0,0 +1,139 @@
+package abi
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gx/ipfs/QmY5Grm8pJdiSSVsYxx4uNRgweY72EmYwuSDbRnbFok3iY/go-libp2p-peer"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// ParseValue parses the string form of a value of type t, as a user would
+// write it on the command line:
+//   - AttoFIL is a decimal number of FIL
+//   - BytesAmount, ChannelID, BlockHeight, Integer and SectorID are decimal integers
+//   - Bytes are hex encoded, optionally prefixed with 0x
+//   - arrays are comma separated elements
+//   - optional values are absent if s is empty
+//   - structs are JSON objects
+func ParseValue(s string, t Type) (*Value, error) {
+	var val interface{}
+	var ok bool
+	switch t {
+	case Invalid:
+		return nil, ErrInvalidType
+	case Address:
+		addr, err := address.NewFromString(s)
+		if err != nil {
+			return nil, err
+		}
+		val, ok = addr, true
+	case AttoFIL:
+		val, ok = types.NewAttoFILFromFILString(s)
+	case BytesAmount:
+		val, ok = types.NewBytesAmountFromString(s, 10)
+	case ChannelID:
+		val, ok = types.NewChannelIDFromString(s, 10)
+	case BlockHeight:
+		val, ok = types.NewBlockHeightFromString(s, 10)
+	case Integer:
+		val, ok = big.NewInt(0).SetString(s, 10)
+	case Bytes:
+		b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+		if err != nil {
+			return nil, err
+		}
+		val, ok = b, true
+	case String:
+		val, ok = s, true
+	case UintArray:
+		arr := []uint64{}
+		for _, elem := range splitElements(s) {
+			n, err := strconv.ParseUint(elem, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, n)
+		}
+		val, ok = arr, true
+	case PeerID:
+		id, err := peer.IDB58Decode(s)
+		if err != nil {
+			return nil, err
+		}
+		val, ok = id, true
+	case SectorID:
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		val, ok = n, true
+	case CommitmentsMap:
+		return nil, fmt.Errorf("cannot parse a %s", t)
+	default:
+		ct, isComposite := compositeOf(t)
+		if !isComposite {
+			return nil, fmt.Errorf("unrecognized Type: %d", t)
+		}
+		var err error
+		val, err = ct.parse(s)
+		if err != nil {
+			return nil, err
+		}
+		ok = true
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("invalid %s: %q", t, s)
+	}
+	return &Value{Type: t, Val: val}, nil
+}
+
+func splitElements(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func (ct *compositeType) parse(s string) (interface{}, error) {
+	switch ct.kind {
+	case arrayKind:
+		out := reflect.MakeSlice(ct.goType, 0, 0)
+		for _, elem := range splitElements(s) {
+			v, err := ParseValue(elem, ct.elem)
+			if err != nil {
+				return nil, err
+			}
+			out = reflect.Append(out, reflectValue(v.Val, ct.goType.Elem()))
+		}
+		return out.Interface(), nil
+	case optionalKind:
+		if s == "" {
+			return reflect.Zero(ct.goType).Interface(), nil
+		}
+		v, err := ParseValue(s, ct.elem)
+		if err != nil {
+			return nil, err
+		}
+		if ct.goType == goTypeOf(ct.elem) {
+			return v.Val, nil
+		}
+		ptr := reflect.New(ct.goType.Elem())
+		ptr.Elem().Set(reflect.ValueOf(v.Val))
+		return ptr.Interface(), nil
+	default:
+		ptr := reflect.New(ct.goType.Elem())
+		if err := json.Unmarshal([]byte(s), ptr.Interface()); err != nil {
+			return nil, err
+		}
+		return ptr.Interface(), nil
+	}
+}
@@ -0,0 +1,81 @@
+package abi
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseValue(t *testing.T) {
+	addr := address.NewForTestGetter()()
+	sectorID := uint64(12)
+
+	cases := map[string]struct {
+		s   string
+		t   Type
+		exp interface{}
+	}{
+		"address":          {addr.String(), Address, addr},
+		"attofil":          {"1.5", AttoFIL, mustAttoFIL("1500000000000000000")},
+		"block height":     {"42", BlockHeight, types.NewBlockHeight(42)},
+		"channel id":       {"7", ChannelID, types.NewChannelID(7)},
+		"integer":          {"123456789012345678901234567890", Integer, mustBigInt("123456789012345678901234567890")},
+		"hex bytes":        {"0xbeef", Bytes, []byte{0xbe, 0xef}},
+		"unprefixed bytes": {"beef", Bytes, []byte{0xbe, 0xef}},
+		"string":           {"hello", String, "hello"},
+		"uint array":       {"1,2,3", UintArray, []uint64{1, 2, 3}},
+		"sector id":        {"12", SectorID, uint64(12)},
+		"array":            {addr.String() + "," + addr.String(), ArrayOf(Address), []address.Address{addr, addr}},
+		"empty array":      {"", ArrayOf(String), []string{}},
+		"present optional": {"12", OptionalOf(SectorID), &sectorID},
+		"absent optional":  {"", OptionalOf(SectorID), (*uint64)(nil)},
+		"struct":           {`{"Name":"bar"}`, barTestType, &barTestStruct{Name: "bar"}},
+	}
+
+	for name, tcase := range cases {
+		t.Run(name, func(t *testing.T) {
+			require := require.New(t)
+
+			v, err := ParseValue(tcase.s, tcase.t)
+			require.NoError(err)
+			require.Equal(tcase.t, v.Type)
+			require.Equal(tcase.exp, v.Val)
+		})
+	}
+}
+
+func TestParseValueFailures(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := ParseValue("notanumber", BlockHeight)
+	assert.EqualError(err, `invalid *types.BlockHeight: "notanumber"`)
+
+	_, err = ParseValue("zz", Bytes)
+	assert.Error(err)
+
+	_, err = ParseValue("1,x", UintArray)
+	assert.Error(err)
+
+	_, err = ParseValue("", Invalid)
+	assert.Equal(ErrInvalidType, err)
+}
+
+func mustBigInt(s string) *big.Int {
+	i, ok := big.NewInt(0).SetString(s, 10)
+	if !ok {
+		panic(s)
+	}
+	return i
+}
+
+func mustAttoFIL(s string) *types.AttoFIL {
+	af, ok := types.NewAttoFILFromString(s, 10)
+	if !ok {
+		panic(s)
+	}
+	return af
+}
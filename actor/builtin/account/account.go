@@ -1,11 +1,17 @@
 package account
 
 import (
+	cbor "gx/ipfs/QmRoARq3nkUb13HSKZGepCZSWe5GrVPwx7xURJGZ7KWv9V/go-ipld-cbor"
+
 	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/exec"
 	"github.com/filecoin-project/go-filecoin/types"
 )
 
+func init() {
+	cbor.RegisterCborType(State{})
+}
+
 // Actor is the builtin actor responsible for individual accounts.
 // More details on future responsibilities can be found at https://github.com/filecoin-project/specs/blob/master/spec.md#account-actor.
 //
@@ -17,6 +23,48 @@ type Actor struct{}
 // Ensure AccountActor is an ExecutableActor at compile time.
 var _ exec.ExecutableActor = (*Actor)(nil)
 
+// State is the account actor's storage. Accounts have no storage until they
+// send their first message.
+type State struct {
+	// PublicKey is the key messages from the account are verified against.
+	// It is recorded from the first message the account sends.
+	PublicKey []byte
+}
+
+// LoadState decodes the state of an account actor from its storage, without
+// executing any actor code.
+func LoadState(storage exec.Storage) (*State, error) {
+	var state State
+	if err := actor.ReadState(storage, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// LoadPublicKey returns the public key recorded in the storage of an account
+// actor, or nil if none has been recorded yet.
+func LoadPublicKey(storage exec.Storage) ([]byte, error) {
+	if !storage.Head().Defined() {
+		return nil, nil
+	}
+
+	state, err := LoadState(storage)
+	if err != nil {
+		return nil, err
+	}
+	return state.PublicKey, nil
+}
+
+// RecordPublicKey records the public key of an account actor in its storage,
+// replacing any key recorded before.
+func RecordPublicKey(storage exec.Storage, pubKey []byte) error {
+	id, err := storage.Put(&State{PublicKey: pubKey})
+	if err != nil {
+		return err
+	}
+	return storage.Commit(id, storage.Head())
+}
+
 // NewActor creates a new account actor.
 func NewActor(balance *types.AttoFIL) (*actor.Actor, error) {
 	return actor.NewActor(types.AccountActorCodeCid, balance), nil
@@ -36,7 +84,8 @@ func (a *Actor) Exports() exec.Exports {
 	return accountExports
 }
 
-// InitializeState for account actors does nothing.
+// InitializeState for account actors does nothing, the public key is recorded
+// when the account sends its first message.
 func (a *Actor) InitializeState(_ exec.Storage, _ interface{}) error {
 	return nil
 }
@@ -2,20 +2,42 @@
 package builtin
 
 import (
+	"context"
+
 	cid "gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	blockstore "gx/ipfs/QmS2aqUZLJp8kF1ihE5rvDGE5LvmKDPnx32w9Z1BW9xLV5/go-ipfs-blockstore"
 
+	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/account"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/governance"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/initactor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/multisig"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
 	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/proofs"
+	"github.com/filecoin-project/go-filecoin/state"
 	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
 )
 
 // Actors is list of all actors that ship with Filecoin.
 // They are indexed by their CID.
 var Actors = map[cid.Cid]exec.ExecutableActor{}
 
+// ActorErrors maps the code of the builtin actors to the coded errors they
+// document, by exit code.
+var ActorErrors = map[cid.Cid]map[uint8]error{}
+
+// StateLoader decodes the state in the storage of an actor, without executing
+// any actor code.
+type StateLoader func(ctx context.Context, storage exec.Storage) (interface{}, error)
+
+// ActorStates maps the code of the builtin actors to the loaders of their
+// state. It is the one registry both LoadState and StorageDecoder read.
+var ActorStates = map[cid.Cid]StateLoader{}
+
 func init() {
 	// Instance Actors
 	Actors[types.AccountActorCodeCid] = &account.Actor{}
@@ -23,4 +45,82 @@ func init() {
 	Actors[types.PaymentBrokerActorCodeCid] = &paymentbroker.Actor{}
 	Actors[types.MinerActorCodeCid] = &miner.Actor{}
 	Actors[types.BootstrapMinerActorCodeCid] = &miner.Actor{Bootstrap: true}
+	Actors[types.MultisigActorCodeCid] = &multisig.Actor{}
+	Actors[types.InitActorCodeCid] = &initactor.Actor{}
+	// networks that seal small sectors are test networks, which are
+	// recreated rather than upgraded
+	Actors[types.GovernanceActorCodeCid] = governance.NewCode(LegacyMigrations(types.NewBytesAmount(proofs.SectorSize(proofs.Live)))...)
+
+	ActorErrors[types.StorageMarketActorCodeCid] = storagemarket.Errors
+	ActorErrors[types.PaymentBrokerActorCodeCid] = paymentbroker.Errors
+	ActorErrors[types.MinerActorCodeCid] = miner.Errors
+	ActorErrors[types.BootstrapMinerActorCodeCid] = miner.Errors
+	ActorErrors[types.MultisigActorCodeCid] = multisig.Errors
+	ActorErrors[types.InitActorCodeCid] = initactor.Errors
+	ActorErrors[types.GovernanceActorCodeCid] = governance.Errors
+
+	ActorStates[types.AccountActorCodeCid] = func(ctx context.Context, storage exec.Storage) (interface{}, error) {
+		return account.LoadState(storage)
+	}
+	ActorStates[types.StorageMarketActorCodeCid] = func(ctx context.Context, storage exec.Storage) (interface{}, error) {
+		return storagemarket.LoadState(storage)
+	}
+	ActorStates[types.PaymentBrokerActorCodeCid] = func(ctx context.Context, storage exec.Storage) (interface{}, error) {
+		return paymentbroker.LoadState(ctx, storage)
+	}
+	ActorStates[types.MinerActorCodeCid] = func(ctx context.Context, storage exec.Storage) (interface{}, error) {
+		return miner.LoadState(storage)
+	}
+	ActorStates[types.BootstrapMinerActorCodeCid] = ActorStates[types.MinerActorCodeCid]
+	ActorStates[types.MultisigActorCodeCid] = func(ctx context.Context, storage exec.Storage) (interface{}, error) {
+		return multisig.LoadState(storage)
+	}
+	ActorStates[types.InitActorCodeCid] = func(ctx context.Context, storage exec.Storage) (interface{}, error) {
+		return initactor.LoadState(storage)
+	}
+	ActorStates[types.GovernanceActorCodeCid] = func(ctx context.Context, storage exec.Storage) (interface{}, error) {
+		return governance.LoadState(storage)
+	}
+}
+
+// LegacyMigrations returns the migrations of the actors whose state layout
+// changed since chains were first created: the storage market, and miners.
+// They keep their code, and get their state converted to the current layout,
+// for a network whose sectors have the given size in bytes. The migration of
+// the storage market reads the legacy state of miners, so it must be
+// scheduled before theirs. The payment broker and the other actors kept
+// their layout.
+func LegacyMigrations(sectorSize *types.BytesAmount) []*governance.Migration {
+	migrateMarket := func(storage exec.Storage, storageOf governance.StorageOf) error {
+		return storagemarket.MigrateLegacyState(storage, storageOf, sectorSize)
+	}
+	migrateMiner := func(storage exec.Storage, _ governance.StorageOf) error {
+		return miner.MigrateLegacyState(storage, sectorSize)
+	}
+
+	return []*governance.Migration{
+		{From: types.StorageMarketActorCodeCid, To: types.StorageMarketActorCodeCid, Migrate: migrateMarket},
+		{From: types.MinerActorCodeCid, To: types.MinerActorCodeCid, Migrate: migrateMiner},
+		{From: types.BootstrapMinerActorCodeCid, To: types.BootstrapMinerActorCodeCid, Migrate: migrateMiner},
+	}
+}
+
+// StorageDecoder returns a state.StorageDecoder for the builtin actors, which
+// decodes their storage with LoadState.
+func StorageDecoder(bs blockstore.Blockstore) state.StorageDecoder {
+	return func(ctx context.Context, code cid.Cid, head cid.Cid) (interface{}, error) {
+		return LoadState(ctx, code, vm.NewStorage(bs, &actor.Actor{Code: code, Head: head}))
+	}
+}
+
+// LoadState decodes the state in the storage of a builtin actor with the given
+// code, without executing any actor code. It returns nil for actors without
+// state, such as accounts that have not sent a message yet, and for actors
+// that are not builtin.
+func LoadState(ctx context.Context, code cid.Cid, storage exec.Storage) (interface{}, error) {
+	load, ok := ActorStates[code]
+	if !ok || !storage.Head().Defined() {
+		return nil, nil
+	}
+	return load(ctx, storage)
 }
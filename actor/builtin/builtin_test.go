@@ -0,0 +1,17 @@
+package builtin_test
+
+import (
+	"testing"
+
+	"github.com/filecoin-project/go-filecoin/actor/builtin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEveryActorHasAStateLoader(t *testing.T) {
+	assert := assert.New(t)
+
+	for code := range builtin.Actors {
+		assert.Contains(builtin.ActorStates, code)
+	}
+	assert.Equal(len(builtin.Actors), len(builtin.ActorStates))
+}
@@ -0,0 +1,308 @@
+// Package governance implements the governance actor, which schedules
+// upgrades of the code of existing actors.
+package governance
+
+import (
+	"context"
+
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	"gx/ipfs/QmRXf2uUSdGSunRJsM9wXSUNVwLUGCY3So5fAs7h2CBJVf/go-hamt-ipld"
+	cbor "gx/ipfs/QmRoARq3nkUb13HSKZGepCZSWe5GrVPwx7xURJGZ7KWv9V/go-ipld-cbor"
+	xerrors "gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm/errors"
+)
+
+const (
+	// ErrNotGovernor indicates an attempt to schedule an upgrade by someone other than the governor.
+	ErrNotGovernor = 33
+	// ErrUnknownMigration indicates an upgrade with a migration that does not exist.
+	ErrUnknownMigration = 34
+	// ErrHeightPassed indicates an upgrade scheduled at a height that is not in the future.
+	ErrHeightPassed = 35
+	// ErrNotSystem indicates an attempt to apply upgrades by someone other than the system.
+	ErrNotSystem = 36
+)
+
+// Errors map error codes to revert errors this actor may return.
+var Errors = map[uint8]error{
+	ErrNotGovernor:      errors.NewCodedRevertError(ErrNotGovernor, "only the governor may schedule upgrades"),
+	ErrUnknownMigration: errors.NewCodedRevertError(ErrUnknownMigration, "migration is unknown"),
+	ErrHeightPassed:     errors.NewCodedRevertError(ErrHeightPassed, "upgrades must be scheduled at a future height"),
+	ErrNotSystem:        errors.NewCodedRevertError(ErrNotSystem, "upgrades are only applied by the system"),
+}
+
+// UpgradeScheduledTopic is the topic of the event emitted when an upgrade is
+// scheduled. Its values are the code migrated from and to, the height and,
+// for upgrades of a single actor, its address.
+const UpgradeScheduledTopic = "upgradeScheduled"
+
+func init() {
+	cbor.RegisterCborType(State{})
+	cbor.RegisterCborType(Upgrade{})
+}
+
+// StorageOf returns the storage of the actor at an address, for migrations
+// that read the state of other actors.
+type StorageOf func(addr address.Address) (exec.Storage, error)
+
+// Migration converts actors running one code to another.
+type Migration struct {
+	// From is the code of the actors the migration applies to.
+	From cid.Cid
+	// To is the code the actors run after the migration.
+	To cid.Cid
+	// Migrate converts the state of an actor in storage to the format of
+	// the new code, and commits it as the new head. It may read, but not
+	// change, the storage of other actors.
+	Migrate func(storage exec.Storage, storageOf StorageOf) error
+}
+
+// migrationKey identifies a migration by the codes it migrates from and to.
+type migrationKey struct {
+	from, to cid.Cid
+}
+
+// Actor lets the governor schedule upgrades of the code of existing actors.
+// The system applies the upgrades that are due at the start of every block,
+// by sending applyUpgrades.
+type Actor struct {
+	migrations map[migrationKey]*Migration
+}
+
+// NewCode returns the code of the governance actor, which lets the governor
+// schedule the given migrations. Fixing a bug in a builtin actor on an
+// existing chain takes adding the fixed actor to builtin.Actors under a new
+// code cid, and a migration to it to the governance actor there.
+func NewCode(migrations ...*Migration) *Actor {
+	ga := &Actor{migrations: make(map[migrationKey]*Migration, len(migrations))}
+	for _, migration := range migrations {
+		ga.migrations[migrationKey{migration.From, migration.To}] = migration
+	}
+	return ga
+}
+
+// Migration returns the migration of actors running the code from to the
+// code to, if the governor may schedule it.
+func (ga *Actor) Migration(from, to cid.Cid) (*Migration, bool) {
+	migration, ok := ga.migrations[migrationKey{from, to}]
+	return migration, ok
+}
+
+// Upgrade is the migration of actors running the code From to the code To at
+// a height. An upgrade with an Actor applies to that actor only, and an
+// upgrade without one to every actor running From.
+type Upgrade struct {
+	Actor  address.Address    `json:"actor"`
+	From   cid.Cid            `json:"from"`
+	To     cid.Cid            `json:"to"`
+	Height *types.BlockHeight `json:"height"`
+}
+
+// State is the governance actor's storage.
+type State struct {
+	// Governor is the only address that may schedule upgrades. Upgrades are
+	// disabled if it is empty.
+	Governor address.Address
+	// Upgrades is a lookup of the scheduled upgrades that have not been
+	// applied yet, by height. The upgrades at a height are kept in the order
+	// they were scheduled.
+	Upgrades cid.Cid `refmt:",omitempty"`
+	// AppliedHeight is the height up to which upgrades have been applied.
+	AppliedHeight *types.BlockHeight
+}
+
+// NewState creates a governance state with the given governor.
+func NewState(governor address.Address) *State {
+	return &State{Governor: governor, AppliedHeight: types.NewBlockHeight(0)}
+}
+
+// LoadState decodes the state of the governance actor from its storage,
+// without executing any actor code.
+func LoadState(storage exec.Storage) (*State, error) {
+	var state State
+	if err := actor.ReadState(storage, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// NewActor returns a new governance actor.
+func NewActor() *actor.Actor {
+	return actor.NewActor(types.GovernanceActorCodeCid, types.NewZeroAttoFIL())
+}
+
+// InitializeState stores the actor's initial data structure.
+func (ga *Actor) InitializeState(storage exec.Storage, initializerData interface{}) error {
+	govState, ok := initializerData.(*State)
+	if !ok {
+		return errors.NewFaultError("Initial state to governance actor is not a governance.State struct")
+	}
+
+	stateBytes, err := cbor.DumpObject(govState)
+	if err != nil {
+		return xerrors.Wrap(err, "failed to cbor marshal object")
+	}
+
+	id, err := storage.Put(stateBytes)
+	if err != nil {
+		return err
+	}
+
+	return storage.Commit(id, cid.Undef)
+}
+
+var _ exec.ExecutableActor = (*Actor)(nil)
+
+// Exports returns the actor's exports.
+func (ga *Actor) Exports() exec.Exports {
+	return governanceExports
+}
+
+var governanceExports = exec.Exports{
+	"scheduleUpgrade": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Bytes, abi.Bytes, abi.BlockHeight},
+		Return: nil,
+	},
+	"scheduleActorUpgrade": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Address, abi.Bytes, abi.Bytes, abi.BlockHeight},
+		Return: nil,
+	},
+	"applyUpgrades": &exec.FunctionSignature{
+		Params: nil,
+		Return: []abi.Type{abi.Bytes},
+	},
+}
+
+// ScheduleUpgrade schedules the migration of every actor running the code
+// with cid from to the code with cid to at the given height. Only the
+// governor may schedule upgrades.
+func (ga *Actor) ScheduleUpgrade(vmctx exec.VMContext, from, to []byte, height *types.BlockHeight) (uint8, error) {
+	return ga.scheduleUpgrade(vmctx, address.Address{}, from, to, height)
+}
+
+// ScheduleActorUpgrade schedules the migration of the actor at addr from the
+// code with cid from to the code with cid to at the given height. Only the
+// governor may schedule upgrades.
+func (ga *Actor) ScheduleActorUpgrade(vmctx exec.VMContext, addr address.Address, from, to []byte, height *types.BlockHeight) (uint8, error) {
+	return ga.scheduleUpgrade(vmctx, addr, from, to, height)
+}
+
+func (ga *Actor) scheduleUpgrade(vmctx exec.VMContext, addr address.Address, from, to []byte, height *types.BlockHeight) (uint8, error) {
+	if err := vmctx.Charge(100); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	fromCode, err := cid.Cast(from)
+	if err != nil {
+		return ErrUnknownMigration, errors.RevertErrorWrap(err, "invalid code to migrate from")
+	}
+	toCode, err := cid.Cast(to)
+	if err != nil {
+		return ErrUnknownMigration, errors.RevertErrorWrap(err, "invalid code to migrate to")
+	}
+
+	var state State
+	_, err = actor.WithState(vmctx, &state, func() (interface{}, error) {
+		if state.Governor.Empty() || vmctx.Message().From != state.Governor {
+			return nil, Errors[ErrNotGovernor]
+		}
+		if _, ok := ga.Migration(fromCode, toCode); !ok {
+			return nil, Errors[ErrUnknownMigration]
+		}
+		if !height.GreaterThan(vmctx.BlockHeight()) {
+			return nil, Errors[ErrHeightPassed]
+		}
+
+		ctx := context.Background()
+		key := height.String()
+		state.Upgrades, err = actor.WithTypedLookup(ctx, vmctx.Storage(), state.Upgrades, []Upgrade{}, func(lookup exec.Lookup) error {
+			var upgrades []Upgrade
+			value, err := lookup.Find(ctx, key)
+			if err == nil {
+				upgrades = value.([]Upgrade)
+			} else if err != hamt.ErrNotFound {
+				return err
+			}
+
+			upgrades = append(upgrades, Upgrade{
+				Actor:  addr,
+				From:   fromCode,
+				To:     toCode,
+				Height: height,
+			})
+			return lookup.Set(ctx, key, upgrades)
+		})
+		if err != nil {
+			return nil, errors.FaultErrorWrapf(err, "could not schedule upgrade at height %s", height)
+		}
+
+		if addr.Empty() {
+			return nil, vmctx.EmitEvent(UpgradeScheduledTopic, from, to, height)
+		}
+		return nil, vmctx.EmitEvent(UpgradeScheduledTopic, from, to, height, addr)
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
+// ApplyUpgrades removes the upgrades that are due at the current height from
+// the schedule, and returns them cbor encoded for the system to apply, in the
+// order they are due. Only the system may apply upgrades.
+func (ga *Actor) ApplyUpgrades(vmctx exec.VMContext) ([]byte, uint8, error) {
+	if err := vmctx.Charge(100); err != nil {
+		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	if vmctx.Message().From != address.SystemAddress {
+		return nil, ErrNotSystem, Errors[ErrNotSystem]
+	}
+
+	var state State
+	ret, err := actor.WithState(vmctx, &state, func() (interface{}, error) {
+		ctx := context.Background()
+		due := []Upgrade{}
+
+		// blocks are not mined at every height, so the upgrades at all
+		// heights since the last block are due
+		lookup, err := actor.LoadTypedLookup(ctx, vmctx.Storage(), state.Upgrades, []Upgrade{})
+		if err != nil {
+			return nil, errors.FaultErrorWrap(err, "could not load upgrades")
+		}
+		for h := state.AppliedHeight.Add(types.NewBlockHeight(1)); h.LessEqual(vmctx.BlockHeight()); h = h.Add(types.NewBlockHeight(1)) {
+			value, err := lookup.Find(ctx, h.String())
+			if err == hamt.ErrNotFound {
+				continue
+			} else if err != nil {
+				return nil, errors.FaultErrorWrapf(err, "could not find upgrades at height %s", h)
+			}
+			due = append(due, value.([]Upgrade)...)
+
+			if err := lookup.Delete(ctx, h.String()); err != nil {
+				return nil, errors.FaultErrorWrapf(err, "could not delete upgrades at height %s", h)
+			}
+		}
+		state.Upgrades, err = lookup.Commit(ctx)
+		if err != nil {
+			return nil, errors.FaultErrorWrap(err, "could not commit upgrades")
+		}
+		if vmctx.BlockHeight().GreaterThan(state.AppliedHeight) {
+			state.AppliedHeight = vmctx.BlockHeight()
+		}
+
+		return cbor.DumpObject(due)
+	})
+	if err != nil {
+		return nil, errors.CodeError(err), err
+	}
+
+	return ret.([]byte), 0, nil
+}
@@ -0,0 +1,283 @@
+package governance_test
+
+import (
+	"context"
+	"testing"
+
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	"gx/ipfs/QmRXf2uUSdGSunRJsM9wXSUNVwLUGCY3So5fAs7h2CBJVf/go-hamt-ipld"
+	"gx/ipfs/QmS2aqUZLJp8kF1ihE5rvDGE5LvmKDPnx32w9Z1BW9xLV5/go-ipfs-blockstore"
+	"gx/ipfs/Qmf4xQhNomPNhrtZc67qSnfJSjxjXs9LWvknJtSXwimPrM/go-datastore"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin"
+	. "github.com/filecoin-project/go-filecoin/actor/builtin/governance"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/multisig"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/state"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testMigration turns accounts into multisig wallets with TestAddress2 as
+// the only signer.
+var testMigration = &Migration{
+	From: types.AccountActorCodeCid,
+	To:   types.MultisigActorCodeCid,
+	Migrate: func(storage exec.Storage, storageOf StorageOf) error {
+		msigState := multisig.NewState([]address.Address{address.TestAddress2}, 1, types.NewZeroAttoFIL(), types.NewBlockHeight(0), types.NewBlockHeight(0))
+		c, err := storage.Put(msigState)
+		if err != nil {
+			return err
+		}
+		return storage.Commit(c, storage.Head())
+	},
+}
+
+// testTypeMigration turns multisig wallets into accounts, keeping their
+// storage.
+var testTypeMigration = &Migration{
+	From: types.MultisigActorCodeCid,
+	To:   types.AccountActorCodeCid,
+	Migrate: func(storage exec.Storage, storageOf StorageOf) error {
+		return nil
+	},
+}
+
+func TestScheduleUpgrade(t *testing.T) {
+	account := types.AccountActorCodeCid.Bytes()
+	msig := types.MultisigActorCodeCid.Bytes()
+
+	t.Run("only the governor may schedule upgrades", func(t *testing.T) {
+		require := require.New(t)
+
+		st, vms := createStorages(t, consensus.Governor(address.TestAddress))
+
+		result := applyMessage(t, st, vms, address.TestAddress2, 0, "scheduleActorUpgrade", address.TestAddress2, account, msig, types.NewBlockHeight(5))
+		require.Error(result.ExecutionError)
+		require.Contains(result.ExecutionError.Error(), Errors[ErrNotGovernor].Error())
+	})
+
+	t.Run("upgrades are disabled without a governor", func(t *testing.T) {
+		require := require.New(t)
+
+		st, vms := createStorages(t)
+
+		result := applyMessage(t, st, vms, address.TestAddress, 0, "scheduleActorUpgrade", address.TestAddress2, account, msig, types.NewBlockHeight(5))
+		require.Error(result.ExecutionError)
+		require.Contains(result.ExecutionError.Error(), Errors[ErrNotGovernor].Error())
+	})
+
+	t.Run("the migration must exist", func(t *testing.T) {
+		require := require.New(t)
+
+		st, vms := createStorages(t, consensus.Governor(address.TestAddress))
+
+		result := applyMessage(t, st, vms, address.TestAddress, 0, "scheduleActorUpgrade", address.TestAddress2, account, types.MinerActorCodeCid.Bytes(), types.NewBlockHeight(5))
+		require.Error(result.ExecutionError)
+		require.Contains(result.ExecutionError.Error(), Errors[ErrUnknownMigration].Error())
+
+		result = applyMessage(t, st, vms, address.TestAddress, 0, "scheduleUpgrade", msig, account, types.NewBlockHeight(5))
+		require.Error(result.ExecutionError)
+		require.Contains(result.ExecutionError.Error(), Errors[ErrUnknownMigration].Error())
+	})
+
+	t.Run("the height must be in the future", func(t *testing.T) {
+		require := require.New(t)
+
+		st, vms := createStorages(t, consensus.Governor(address.TestAddress))
+
+		result := applyMessage(t, st, vms, address.TestAddress, 5, "scheduleActorUpgrade", address.TestAddress2, account, msig, types.NewBlockHeight(5))
+		require.Error(result.ExecutionError)
+		require.Contains(result.ExecutionError.Error(), Errors[ErrHeightPassed].Error())
+	})
+
+	t.Run("scheduled upgrades are stored by height", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		st, vms := createStorages(t, consensus.Governor(address.TestAddress))
+
+		result := applyMessage(t, st, vms, address.TestAddress, 0, "scheduleActorUpgrade", address.TestAddress2, account, msig, types.NewBlockHeight(5))
+		require.NoError(result.ExecutionError)
+		require.Len(result.Receipt.Events, 1)
+		assert.Equal(UpgradeScheduledTopic, result.Receipt.Events[0].Topic)
+
+		result = applyMessage(t, st, vms, address.TestAddress, 0, "scheduleUpgrade", account, msig, types.NewBlockHeight(5))
+		require.NoError(result.ExecutionError)
+
+		upgrades := loadUpgrades(t, st, vms)
+		require.Len(upgrades, 1)
+		require.Len(upgrades["5"], 2)
+		assert.Equal(address.TestAddress2, upgrades["5"][0].Actor)
+		assert.Equal(types.AccountActorCodeCid, upgrades["5"][0].From)
+		assert.Equal(types.MultisigActorCodeCid, upgrades["5"][0].To)
+		assert.Equal(types.NewBlockHeight(5), upgrades["5"][0].Height)
+		assert.True(upgrades["5"][1].Actor.Empty())
+	})
+}
+
+func TestApplyUpgrades(t *testing.T) {
+	account := types.AccountActorCodeCid.Bytes()
+	msig := types.MultisigActorCodeCid.Bytes()
+
+	t.Run("only the system may apply upgrades", func(t *testing.T) {
+		require := require.New(t)
+
+		st, vms := createStorages(t, consensus.Governor(address.TestAddress))
+
+		result := applyMessage(t, st, vms, address.TestAddress, 0, "applyUpgrades")
+		require.Error(result.ExecutionError)
+		require.Contains(result.ExecutionError.Error(), Errors[ErrNotSystem].Error())
+	})
+
+	t.Run("actors are migrated at the scheduled height", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+		ctx := context.Background()
+
+		st, vms := createStorages(t, consensus.Governor(address.TestAddress))
+
+		result := applyMessage(t, st, vms, address.TestAddress, 0, "scheduleActorUpgrade", address.TestAddress2, account, msig, types.NewBlockHeight(5))
+		require.NoError(result.ExecutionError)
+
+		applyMessage(t, st, vms, address.TestAddress, 4, "")
+		act, err := st.GetActor(ctx, address.TestAddress2)
+		require.NoError(err)
+		assert.Equal(types.AccountActorCodeCid, act.Code)
+
+		applyMessage(t, st, vms, address.TestAddress, 5, "")
+		act, err = st.GetActor(ctx, address.TestAddress2)
+		require.NoError(err)
+		assert.Equal(types.MultisigActorCodeCid, act.Code)
+
+		msigState, err := multisig.LoadState(vms.NewStorage(address.TestAddress2, act))
+		require.NoError(err)
+		assert.Equal([]address.Address{address.TestAddress2}, msigState.Signers)
+
+		// applied upgrades are removed from the schedule
+		assert.Empty(loadUpgrades(t, st, vms))
+	})
+
+	t.Run("upgrades at heights without blocks are applied by the next block", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+		ctx := context.Background()
+
+		st, vms := createStorages(t, consensus.Governor(address.TestAddress))
+
+		result := applyMessage(t, st, vms, address.TestAddress, 0, "scheduleActorUpgrade", address.TestAddress2, account, msig, types.NewBlockHeight(5))
+		require.NoError(result.ExecutionError)
+
+		applyMessage(t, st, vms, address.TestAddress, 7, "")
+		act, err := st.GetActor(ctx, address.TestAddress2)
+		require.NoError(err)
+		assert.Equal(types.MultisigActorCodeCid, act.Code)
+		assert.Empty(loadUpgrades(t, st, vms))
+	})
+
+	t.Run("upgrades without an actor migrate every actor running the code", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+		ctx := context.Background()
+
+		wallet1 := address.MakeTestAddress("wallet1")
+		wallet2 := address.MakeTestAddress("wallet2")
+		st, vms := createStorages(t,
+			consensus.Governor(address.TestAddress),
+			consensus.AddActor(wallet1, actor.NewActor(types.MultisigActorCodeCid, types.NewZeroAttoFIL())),
+			consensus.AddActor(wallet2, actor.NewActor(types.MultisigActorCodeCid, types.NewZeroAttoFIL())),
+		)
+
+		result := applyMessage(t, st, vms, address.TestAddress, 0, "scheduleUpgrade", msig, account, types.NewBlockHeight(5))
+		require.NoError(result.ExecutionError)
+
+		applyMessage(t, st, vms, address.TestAddress, 5, "")
+		for _, addr := range []address.Address{wallet1, wallet2} {
+			act, err := st.GetActor(ctx, addr)
+			require.NoError(err)
+			assert.Equal(types.AccountActorCodeCid, act.Code)
+		}
+
+		act, err := st.GetActor(ctx, address.StorageMarketAddress)
+		require.NoError(err)
+		assert.Equal(types.StorageMarketActorCodeCid, act.Code)
+	})
+
+	t.Run("actors that do not run the migrated code are skipped", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+		ctx := context.Background()
+
+		st, vms := createStorages(t, consensus.Governor(address.TestAddress))
+
+		result := applyMessage(t, st, vms, address.TestAddress, 0, "scheduleActorUpgrade", address.StorageMarketAddress, account, msig, types.NewBlockHeight(5))
+		require.NoError(result.ExecutionError)
+
+		result = applyMessage(t, st, vms, address.TestAddress, 5, "")
+		require.NoError(result.ExecutionError)
+
+		act, err := st.GetActor(ctx, address.StorageMarketAddress)
+		require.NoError(err)
+		assert.Equal(types.StorageMarketActorCodeCid, act.Code)
+		assert.Empty(loadUpgrades(t, st, vms))
+	})
+}
+
+func createStorages(t *testing.T, opts ...consensus.GenOption) (state.Tree, vm.StorageMap) {
+	cst := hamt.NewCborStore()
+	bs := blockstore.NewBlockstore(datastore.NewMapDatastore())
+	blk, err := consensus.MakeGenesisFunc(opts...)(cst, bs)
+	require.NoError(t, err)
+
+	// the test migrations are only known to this copy of the builtin actors
+	actors := map[cid.Cid]exec.ExecutableActor{}
+	for code, act := range builtin.Actors {
+		actors[code] = act
+	}
+	actors[types.GovernanceActorCodeCid] = NewCode(testMigration, testTypeMigration)
+
+	st, err := state.LoadStateTree(context.Background(), cst, blk.StateRoot, actors)
+	require.NoError(t, err)
+
+	return st, vm.NewStorageMap(bs)
+}
+
+func applyMessage(t *testing.T, st state.Tree, vms vm.StorageMap, from address.Address, height uint64, method string, params ...interface{}) *consensus.ApplicationResult {
+	to := address.GovernanceAddress
+	if method == "" {
+		to = address.NetworkAddress
+	}
+	msg := types.NewMessage(from, to, 0, types.NewZeroAttoFIL(), method, actor.MustConvertParams(params...))
+	result, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(height))
+	require.NoError(t, err)
+	return result
+}
+
+func loadUpgrades(t *testing.T, st state.Tree, vms vm.StorageMap) map[string][]Upgrade {
+	ctx := context.Background()
+	act, err := st.GetActor(ctx, address.GovernanceAddress)
+	require.NoError(t, err)
+	storage := vms.NewStorage(address.GovernanceAddress, act)
+	govState, err := LoadState(storage)
+	require.NoError(t, err)
+
+	upgrades := map[string][]Upgrade{}
+	err = actor.WithTypedLookupForReading(ctx, storage, govState.Upgrades, []Upgrade{}, func(lookup exec.Lookup) error {
+		kvs, err := lookup.Values(ctx)
+		if err != nil {
+			return err
+		}
+		for _, kv := range kvs {
+			upgrades[kv.Key] = kv.Value.([]Upgrade)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	return upgrades
+}
@@ -0,0 +1,275 @@
+// Package initactor implements the init actor, which assigns ID addresses to
+// actors.
+package initactor
+
+import (
+	"context"
+	"strconv"
+
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	"gx/ipfs/QmRXf2uUSdGSunRJsM9wXSUNVwLUGCY3So5fAs7h2CBJVf/go-hamt-ipld"
+	cbor "gx/ipfs/QmRoARq3nkUb13HSKZGepCZSWe5GrVPwx7xURJGZ7KWv9V/go-ipld-cbor"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm/errors"
+)
+
+const (
+	// ErrUnknownAddress indicates an address that has no id.
+	ErrUnknownAddress = 33
+	// ErrUnknownID indicates an ID address that has not been assigned.
+	ErrUnknownID = 34
+)
+
+// Errors map error codes to revert errors this actor may return.
+var Errors = map[uint8]error{
+	ErrUnknownAddress: errors.NewCodedRevertError(ErrUnknownAddress, "address has no id"),
+	ErrUnknownID:      errors.NewCodedRevertError(ErrUnknownID, "id has not been assigned"),
+}
+
+func init() {
+	cbor.RegisterCborType(State{})
+}
+
+// Actor is the registry of ID addresses. Every actor is assigned the next
+// sequential id when it is created, and may be referred to by the ID address
+// holding it. Unlike the robust address of an actor, which is derived from the
+// message that created it, its ID address is short, but may change when a
+// reorg changes the order in which actors were created.
+type Actor struct{}
+
+// State is the init actor's storage.
+type State struct {
+	// IDs maps the robust addresses of actors, as strings, to their ids.
+	IDs cid.Cid `refmt:",omitempty"`
+	// Addresses maps ids, in decimal, to the robust addresses of the actors
+	// they were assigned to, as strings.
+	Addresses cid.Cid `refmt:",omitempty"`
+	// NextID is the id assigned to the next actor.
+	NextID uint64
+	// Network is the network of the ID addresses the actor assigns.
+	Network address.Network
+}
+
+// NewState creates an empty init actor state assigning ID addresses of the
+// given network.
+func NewState(network address.Network) *State {
+	return &State{Network: network}
+}
+
+// LoadState decodes the state of the init actor from its storage, without
+// executing any actor code.
+func LoadState(storage exec.Storage) (*State, error) {
+	var state State
+	if err := actor.ReadState(storage, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// LookupID returns the ID address of the actor at addr from the storage of
+// the init actor, without executing any actor code.
+func LookupID(ctx context.Context, storage exec.Storage, addr address.Address) (address.Address, bool, error) {
+	state, err := LoadState(storage)
+	if err != nil {
+		return address.Address{}, false, err
+	}
+	return state.LookupID(ctx, storage, addr)
+}
+
+// LookupAddress returns the robust address of the actor with the given ID
+// address from the storage of the init actor, without executing any actor
+// code.
+func LookupAddress(ctx context.Context, storage exec.Storage, idAddr address.Address) (address.Address, bool, error) {
+	state, err := LoadState(storage)
+	if err != nil {
+		return address.Address{}, false, err
+	}
+	return state.LookupAddress(ctx, storage, idAddr)
+}
+
+// NewActor returns a new init actor.
+func NewActor() *actor.Actor {
+	return actor.NewActor(types.InitActorCodeCid, types.NewZeroAttoFIL())
+}
+
+// Register assigns the next id to the actor at addr, and returns its ID
+// address. Actors that already have an id keep it. The lookups of the state
+// are stored in storage, but the state itself is not.
+func (st *State) Register(ctx context.Context, storage exec.Storage, addr address.Address) (address.Address, error) {
+	idAddr, ok, err := st.LookupID(ctx, storage, addr)
+	if err != nil || ok {
+		return idAddr, err
+	}
+
+	id := st.NextID
+	st.IDs, err = setInLookup(ctx, storage, st.IDs, addr.String(), id)
+	if err != nil {
+		return address.Address{}, err
+	}
+	st.Addresses, err = setInLookup(ctx, storage, st.Addresses, strconv.FormatUint(id, 10), addr.String())
+	if err != nil {
+		return address.Address{}, err
+	}
+	st.NextID++
+	return address.NewIDAddress(st.Network, id), nil
+}
+
+// LookupID returns the ID address of the actor at addr. ID addresses are
+// returned as they are.
+func (st *State) LookupID(ctx context.Context, storage exec.Storage, addr address.Address) (address.Address, bool, error) {
+	if addr.IsID() {
+		return addr, true, nil
+	}
+
+	value, err := findInLookup(ctx, storage, st.IDs, addr.String(), uint64(0))
+	if err != nil || value == nil {
+		return address.Address{}, false, err
+	}
+	return address.NewIDAddress(st.Network, value.(uint64)), true, nil
+}
+
+// LookupAddress returns the robust address of the actor with the given ID
+// address. Other addresses are returned as they are. ID addresses of other
+// networks have not been assigned.
+func (st *State) LookupAddress(ctx context.Context, storage exec.Storage, addr address.Address) (address.Address, bool, error) {
+	id, err := addr.ID()
+	if err != nil {
+		return addr, true, nil
+	}
+	if addr.Network() != st.Network {
+		return address.Address{}, false, nil
+	}
+
+	value, err := findInLookup(ctx, storage, st.Addresses, strconv.FormatUint(id, 10), "")
+	if err != nil || value == nil {
+		return address.Address{}, false, err
+	}
+	robust, err := address.NewFromString(value.(string))
+	if err != nil {
+		return address.Address{}, false, err
+	}
+	return robust, true, nil
+}
+
+// findInLookup returns the value under key in the lookup with the given root,
+// or nil if there is none.
+func findInLookup(ctx context.Context, storage exec.Storage, root cid.Cid, key string, valueType interface{}) (interface{}, error) {
+	if !root.Defined() {
+		return nil, nil
+	}
+
+	lookup, err := actor.LoadTypedLookup(ctx, storage, root, valueType)
+	if err != nil {
+		return nil, err
+	}
+	value, err := lookup.Find(ctx, key)
+	if err == hamt.ErrNotFound {
+		return nil, nil
+	}
+	return value, err
+}
+
+// setInLookup sets key to value in the lookup with the given root, and returns
+// the new root.
+func setInLookup(ctx context.Context, storage exec.Storage, root cid.Cid, key string, value interface{}) (cid.Cid, error) {
+	lookup, err := actor.LoadLookup(ctx, storage, root)
+	if err != nil {
+		return cid.Undef, err
+	}
+	if err := lookup.Set(ctx, key, value); err != nil {
+		return cid.Undef, err
+	}
+	return lookup.Commit(ctx)
+}
+
+// InitializeState stores the actor's initial data structure.
+func (ia *Actor) InitializeState(storage exec.Storage, initializerData interface{}) error {
+	initState, ok := initializerData.(*State)
+	if !ok {
+		return errors.NewFaultError("Initial state to init actor is not an initactor.State struct")
+	}
+
+	stateBytes, err := cbor.DumpObject(initState)
+	if err != nil {
+		return err
+	}
+
+	id, err := storage.Put(stateBytes)
+	if err != nil {
+		return err
+	}
+
+	return storage.Commit(id, cid.Undef)
+}
+
+var _ exec.ExecutableActor = (*Actor)(nil)
+
+// Exports returns the actor's exports.
+func (ia *Actor) Exports() exec.Exports {
+	return initExports
+}
+
+var initExports = exec.Exports{
+	"getIdForAddress": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Address},
+		Return: []abi.Type{abi.Address},
+	},
+	"getAddressForId": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Address},
+		Return: []abi.Type{abi.Address},
+	},
+}
+
+// GetIdForAddress returns the ID address of the actor at addr.
+func (ia *Actor) GetIdForAddress(vmctx exec.VMContext, addr address.Address) (address.Address, uint8, error) { // nolint: golint
+	if err := vmctx.Charge(100); err != nil {
+		return address.Address{}, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	ret, err := actor.WithState(vmctx, &state, func() (interface{}, error) {
+		idAddr, ok, err := state.LookupID(context.Background(), vmctx.Storage(), addr)
+		if err != nil {
+			return nil, errors.FaultErrorWrapf(err, "could not look up the id of %s", addr)
+		}
+		if !ok {
+			return nil, Errors[ErrUnknownAddress]
+		}
+		return idAddr, nil
+	})
+	if err != nil {
+		return address.Address{}, errors.CodeError(err), err
+	}
+
+	return ret.(address.Address), 0, nil
+}
+
+// GetAddressForId returns the robust address of the actor with the given ID
+// address.
+func (ia *Actor) GetAddressForId(vmctx exec.VMContext, idAddr address.Address) (address.Address, uint8, error) { // nolint: golint
+	if err := vmctx.Charge(100); err != nil {
+		return address.Address{}, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	ret, err := actor.WithState(vmctx, &state, func() (interface{}, error) {
+		addr, ok, err := state.LookupAddress(context.Background(), vmctx.Storage(), idAddr)
+		if err != nil {
+			return nil, errors.FaultErrorWrapf(err, "could not look up the address of %s", idAddr)
+		}
+		if !ok {
+			return nil, Errors[ErrUnknownID]
+		}
+		return addr, nil
+	})
+	if err != nil {
+		return address.Address{}, errors.CodeError(err), err
+	}
+
+	return ret.(address.Address), 0, nil
+}
@@ -0,0 +1,180 @@
+package initactor_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"gx/ipfs/QmS2aqUZLJp8kF1ihE5rvDGE5LvmKDPnx32w9Z1BW9xLV5/go-ipfs-blockstore"
+	"gx/ipfs/Qmf4xQhNomPNhrtZc67qSnfJSjxjXs9LWvknJtSXwimPrM/go-datastore"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	. "github.com/filecoin-project/go-filecoin/actor/builtin/initactor"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/core"
+	"github.com/filecoin-project/go-filecoin/state"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateRegister(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	addrGetter := address.NewForTestGetter()
+	addr1, addr2 := addrGetter(), addrGetter()
+
+	bs := blockstore.NewBlockstore(datastore.NewMapDatastore())
+	storage := vm.NewStorageMap(bs).NewStorage(address.InitAddress, NewActor())
+
+	st := NewState(address.Testnet)
+	register := func(addr address.Address) address.Address {
+		idAddr, err := st.Register(ctx, storage, addr)
+		require.NoError(err)
+		return idAddr
+	}
+	assert.Equal(address.NewIDAddress(address.Testnet, 0), register(addr1))
+	assert.Equal(address.NewIDAddress(address.Testnet, 1), register(addr2))
+
+	// ids are only assigned once
+	assert.Equal(address.NewIDAddress(address.Testnet, 0), register(addr1))
+
+	idAddr, ok, err := st.LookupID(ctx, storage, addr2)
+	require.NoError(err)
+	require.True(ok)
+	assert.Equal(address.NewIDAddress(address.Testnet, 1), idAddr)
+
+	robust, ok, err := st.LookupAddress(ctx, storage, idAddr)
+	require.NoError(err)
+	require.True(ok)
+	assert.Equal(addr2, robust)
+
+	_, ok, err = st.LookupID(ctx, storage, addrGetter())
+	require.NoError(err)
+	assert.False(ok)
+	_, ok, err = st.LookupAddress(ctx, storage, address.NewIDAddress(address.Testnet, 2))
+	require.NoError(err)
+	assert.False(ok)
+
+	// ids of other networks have not been assigned
+	_, ok, err = st.LookupAddress(ctx, storage, address.NewIDAddress(address.Mainnet, 1))
+	require.NoError(err)
+	assert.False(ok)
+
+	// robust addresses resolve to themselves
+	robust, ok, err = st.LookupAddress(ctx, storage, addr1)
+	require.NoError(err)
+	require.True(ok)
+	assert.Equal(addr1, robust)
+}
+
+func TestInitActorLookup(t *testing.T) {
+	ctx := context.Background()
+	st, vms := core.CreateStorages(ctx, t)
+
+	t.Run("singletons have the first ids", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		result := applyMessage(t, st, vms, address.TestAddress, address.InitAddress, "getIdForAddress", address.StorageMarketAddress)
+		require.NoError(result.ExecutionError)
+		idAddr := requireAddress(t, result)
+		assert.Equal(address.NewIDAddress(address.Mainnet, 1), idAddr)
+
+		result = applyMessage(t, st, vms, address.TestAddress, address.InitAddress, "getAddressForId", idAddr)
+		require.NoError(result.ExecutionError)
+		assert.Equal(address.StorageMarketAddress, requireAddress(t, result))
+	})
+
+	t.Run("unknown addresses have no id", func(t *testing.T) {
+		require := require.New(t)
+
+		result := applyMessage(t, st, vms, address.TestAddress, address.InitAddress, "getIdForAddress", address.NewForTestGetter()())
+		require.Error(result.ExecutionError)
+		require.Contains(result.ExecutionError.Error(), Errors[ErrUnknownAddress].Error())
+
+		result = applyMessage(t, st, vms, address.TestAddress, address.InitAddress, "getAddressForId", address.NewIDAddress(address.Mainnet, 1000))
+		require.Error(result.ExecutionError)
+		require.Contains(result.ExecutionError.Error(), Errors[ErrUnknownID].Error())
+	})
+}
+
+func TestActorsCreatedByActorsHaveIDs(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	st, vms := core.CreateStorages(ctx, t)
+
+	result := applyMessage(t, st, vms, address.TestAddress, address.StorageMarketAddress, "createMultisig", []address.Address{address.TestAddress}, big.NewInt(1), types.NewBlockHeight(0))
+	require.NoError(result.ExecutionError)
+	msigAddr := requireAddress(t, result)
+
+	result = applyMessage(t, st, vms, address.TestAddress, address.InitAddress, "getIdForAddress", msigAddr)
+	require.NoError(result.ExecutionError)
+	idAddr := requireAddress(t, result)
+	assert.True(idAddr.IsID())
+
+	result = applyMessage(t, st, vms, address.TestAddress, address.InitAddress, "getAddressForId", idAddr)
+	require.NoError(result.ExecutionError)
+	assert.Equal(msigAddr, requireAddress(t, result))
+}
+
+func TestMessagesToIDAddresses(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("are delivered to the actor with the id", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		st, vms := core.CreateStorages(ctx, t)
+
+		result := applyMessage(t, st, vms, address.TestAddress, address.InitAddress, "getIdForAddress", address.TestAddress2)
+		require.NoError(result.ExecutionError)
+		idAddr := requireAddress(t, result)
+
+		before := requireBalance(t, st, address.TestAddress2)
+		msg := types.NewMessage(address.TestAddress, idAddr, 0, types.NewAttoFILFromFIL(10), "", nil)
+		result, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(0))
+		require.NoError(err)
+		require.NoError(result.ExecutionError)
+
+		assert.True(before.Add(types.NewAttoFILFromFIL(10)).Equal(requireBalance(t, st, address.TestAddress2)))
+	})
+
+	t.Run("fail if the id has not been assigned", func(t *testing.T) {
+		require := require.New(t)
+
+		st, vms := core.CreateStorages(ctx, t)
+
+		msg := types.NewMessage(address.TestAddress, address.NewIDAddress(address.Mainnet, 1000), 0, types.NewAttoFILFromFIL(10), "", nil)
+		result, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(0))
+		require.NoError(err)
+		require.Error(result.ExecutionError)
+		require.Contains(result.ExecutionError.Error(), Errors[ErrUnknownID].Error())
+	})
+}
+
+func applyMessage(t *testing.T, st state.Tree, vms vm.StorageMap, from, to address.Address, method string, params ...interface{}) *consensus.ApplicationResult {
+	msg := types.NewMessage(from, to, 0, types.NewZeroAttoFIL(), method, actor.MustConvertParams(params...))
+	result, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(0))
+	require.NoError(t, err)
+	return result
+}
+
+func requireAddress(t *testing.T, result *consensus.ApplicationResult) address.Address {
+	addr, err := address.NewFromBytes(result.Receipt.Return[0])
+	require.NoError(t, err)
+	return addr
+}
+
+func requireBalance(t *testing.T, st state.Tree, addr address.Address) *types.AttoFIL {
+	act, err := st.GetActor(context.Background(), addr)
+	require.NoError(t, err)
+	return act.Balance
+}
@@ -0,0 +1,105 @@
+package miner
+
+import (
+	"context"
+	"math/big"
+
+	cbor "gx/ipfs/QmRoARq3nkUb13HSKZGepCZSWe5GrVPwx7xURJGZ7KWv9V/go-ipld-cbor"
+	"gx/ipfs/QmY5Grm8pJdiSSVsYxx4uNRgweY72EmYwuSDbRnbFok3iY/go-libp2p-peer"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm/errors"
+)
+
+func init() {
+	cbor.RegisterCborType(LegacyState{})
+}
+
+// LegacyState is the layout of the miner state on chains created before the
+// asks and sector commitments of miners moved into lookups, and their power
+// was accounted in bytes of sectors of a size recorded at genesis.
+type LegacyState struct {
+	Owner         address.Address
+	PeerID        peer.ID
+	PublicKey     []byte
+	PledgeSectors *big.Int
+	Collateral    *types.AttoFIL
+	Asks          []*Ask
+	NextAskID     *big.Int
+
+	SectorCommitments map[string]types.Commitments
+
+	LastUsedSectorID uint64
+
+	ProvingPeriodStart *types.BlockHeight
+	LastPoSt           *types.BlockHeight
+
+	// Power is the number of sectors the miner has committed.
+	Power *big.Int
+}
+
+// LoadLegacyState decodes the state of a miner in the legacy layout from its
+// storage.
+func LoadLegacyState(storage exec.Storage) (*LegacyState, error) {
+	var state LegacyState
+	if err := actor.ReadState(storage, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// MigrateLegacyState converts the state of a miner in the legacy layout to
+// the current one, for a network whose sectors have the given size in bytes,
+// and commits it as the new head. The migrated sectors do not expire, as the
+// legacy layout does not record when they were committed.
+func MigrateLegacyState(storage exec.Storage, sectorSize *types.BytesAmount) error {
+	old, err := LoadLegacyState(storage)
+	if err != nil {
+		return err
+	}
+	if old.Power == nil || !old.Power.IsUint64() {
+		return errors.NewFaultErrorf("invalid power %s", old.Power)
+	}
+
+	state := &State{
+		Owner:              old.Owner,
+		PeerID:             old.PeerID,
+		PublicKey:          old.PublicKey,
+		PledgeSectors:      old.PledgeSectors,
+		Collateral:         old.Collateral,
+		NextAskID:          old.NextAskID,
+		LastUsedSectorID:   old.LastUsedSectorID,
+		ProvingPeriodStart: old.ProvingPeriodStart,
+		LastPoSt:           old.LastPoSt,
+		Power:              sectorSize.Mul(types.NewBytesAmount(old.Power.Uint64())),
+		SectorSize:         sectorSize,
+	}
+
+	ctx := context.Background()
+	for _, ask := range old.Asks {
+		if err := addAsk(ctx, storage, state, ask); err != nil {
+			return err
+		}
+	}
+
+	state.SectorCommitments, err = actor.WithTypedLookup(ctx, storage, state.SectorCommitments, types.Commitments{}, func(commitments exec.Lookup) error {
+		for sectorID, comms := range old.SectorCommitments {
+			if err := commitments.Set(ctx, sectorID, comms); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.FaultErrorWrap(err, "could not migrate sector commitments")
+	}
+
+	id, err := storage.Put(state)
+	if err != nil {
+		return errors.FaultErrorWrap(err, "could not write migrated state")
+	}
+	return storage.Commit(id, storage.Head())
+}
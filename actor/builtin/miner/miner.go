@@ -1,11 +1,13 @@
 package miner
 
 import (
+	"context"
 	"math/big"
-	"os"
+	"sort"
 	"strconv"
 
 	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	"gx/ipfs/QmRXf2uUSdGSunRJsM9wXSUNVwLUGCY3So5fAs7h2CBJVf/go-hamt-ipld"
 	cbor "gx/ipfs/QmRoARq3nkUb13HSKZGepCZSWe5GrVPwx7xURJGZ7KWv9V/go-ipld-cbor"
 	xerrors "gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
 	"gx/ipfs/QmY5Grm8pJdiSSVsYxx4uNRgweY72EmYwuSDbRnbFok3iY/go-libp2p-peer"
@@ -42,6 +44,14 @@ var ProvingPeriodBlocks = types.NewBlockHeight(20000)
 // See https://github.com/filecoin-project/go-filecoin/issues/1887
 var GracePeriodBlocks = types.NewBlockHeight(100)
 
+// SectorLifetimeWithoutDeals is the number of blocks after which committed
+// sectors that hold no deals expire.
+// TODO: what is a workable value? Value is arbitrary right now.
+var SectorLifetimeWithoutDeals = types.NewBlockHeight(100000)
+
+// MinimumCollateralPerSector is the minimum amount of collateral required per sector
+var MinimumCollateralPerSector, _ = types.NewAttoFILFromFILString("0.001")
+
 const (
 	// ErrPublicKeyTooBig indicates an invalid public key.
 	ErrPublicKeyTooBig = 33
@@ -61,8 +71,24 @@ const (
 	ErrAskNotFound = 40
 	// ErrInvalidSealProof signals that the passed in seal proof was invalid.
 	ErrInvalidSealProof = 41
+	// ErrInsufficientCollateral signals that the collateral would not cover the pledge.
+	ErrInsufficientCollateral = 42
+	// ErrPoStTooLate signals that the PoSt was submitted after the grace period.
+	ErrPoStTooLate = 43
+	// ErrPoStNotMissed signals an attempt to slash a miner that has not missed a PoSt.
+	ErrPoStNotMissed = 44
+	// ErrSectorNotCommitted indicates the sector has not been committed.
+	ErrSectorNotCommitted = 45
 )
 
+// SectorCommittedTopic is the topic of the event emitted when a sector is
+// committed. Its value is the sector id.
+const SectorCommittedTopic = "sectorCommitted"
+
+// SectorRemovedTopic is the topic of the event emitted when a sector expires
+// or is terminated. Its value is the sector id.
+const SectorRemovedTopic = "sectorRemoved"
+
 // Errors map error codes to revert errors this actor may return.
 var Errors = map[uint8]error{
 	ErrPublicKeyTooBig:         errors.NewCodedRevertErrorf(ErrPublicKeyTooBig, "public key must be less than %d bytes", MaximumPublicKeySize),
@@ -74,6 +100,10 @@ var Errors = map[uint8]error{
 	ErrInvalidPoSt:             errors.NewCodedRevertErrorf(ErrInvalidPoSt, "PoSt proof did not validate"),
 	ErrAskNotFound:             errors.NewCodedRevertErrorf(ErrAskNotFound, "no ask was found"),
 	ErrInvalidSealProof:        errors.NewCodedRevertErrorf(ErrInvalidSealProof, "seal proof was invalid"),
+	ErrInsufficientCollateral:  errors.NewCodedRevertErrorf(ErrInsufficientCollateral, "collateral must be more than %s FIL per pledged sector", MinimumCollateralPerSector),
+	ErrPoStTooLate:             errors.NewCodedRevertErrorf(ErrPoStTooLate, "PoSt was submitted after the grace period"),
+	ErrPoStNotMissed:           errors.NewCodedRevertErrorf(ErrPoStNotMissed, "miner has not missed a PoSt"),
+	ErrSectorNotCommitted:      errors.NewCodedRevertErrorf(ErrSectorNotCommitted, "sector not committed"),
 }
 
 // Actor is the miner actor.
@@ -97,6 +127,9 @@ type Ask struct {
 	ID     *big.Int
 }
 
+// AskType is the ABI type of a *Ask.
+var AskType = abi.RegisterStruct(1, &Ask{})
+
 // State is the miner actors storage.
 type State struct {
 	Owner address.Address
@@ -114,23 +147,81 @@ type State struct {
 	// the miners pledge.
 	Collateral *types.AttoFIL
 
-	// Asks is the set of asks this miner has open
-	Asks      []*Ask
+	// Asks is a lookup of the asks this miner has open, by their id.
+	Asks      cid.Cid `refmt:",omitempty"`
 	NextAskID *big.Int
 
-	// SectorCommitments maps sector id to commitments, for all sectors this
-	// miner has committed. Due to a bug in refmt, the sector id-keys need to be
-	// stringified.
-	//
-	// See also: https://github.com/polydawn/refmt/issues/35
-	SectorCommitments map[string]types.Commitments
+	// AskExpiryHeights are the distinct heights at which open asks expire, in
+	// ascending order, and AskExpirations is a lookup of the ids of the asks
+	// that expire at each of them, by height. Adding an ask prunes expired
+	// asks through them without reading all open asks.
+	AskExpiryHeights []*types.BlockHeight
+	AskExpirations   cid.Cid `refmt:",omitempty"`
+
+	// SectorCommitments is a lookup of the commitments of all sectors this
+	// miner has committed, by sector id. Keeping them out of the state
+	// itself means committing a sector does not rewrite all others.
+	SectorCommitments cid.Cid `refmt:",omitempty"`
+
+	// SectorExpirations is a lookup of the heights at which committed sectors
+	// expire, by sector id. A sector expires once all deals it holds have
+	// ended, and sectors without deals expire SectorLifetimeWithoutDeals
+	// blocks after they are committed.
+	SectorExpirations cid.Cid `refmt:",omitempty"`
 
 	LastUsedSectorID uint64
 
 	ProvingPeriodStart *types.BlockHeight
 	LastPoSt           *types.BlockHeight
 
-	Power *big.Int
+	// Power is the number of bytes of storage this miner has committed.
+	Power *types.BytesAmount
+
+	// SectorSize is the size in bytes of the sectors this miner commits. It
+	// is the power each sector adds, and selects how seal proofs are
+	// verified.
+	SectorSize *types.BytesAmount
+}
+
+// LoadState decodes the state of a miner actor from its storage, without
+// executing any actor code.
+func LoadState(storage exec.Storage) (*State, error) {
+	var state State
+	if err := actor.ReadState(storage, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// LoadAsks returns the open asks of a miner, ordered by id, from its storage,
+// without executing any actor code.
+func LoadAsks(ctx context.Context, storage exec.Storage) ([]*Ask, error) {
+	state, err := LoadState(storage)
+	if err != nil {
+		return nil, err
+	}
+	return loadAsks(ctx, storage, state.Asks)
+}
+
+// LoadSectorCommitments returns the commitments of all sectors a miner has
+// committed, by sector id, from its storage, without executing any actor code.
+func LoadSectorCommitments(ctx context.Context, storage exec.Storage) (map[string]types.Commitments, error) {
+	state, err := LoadState(storage)
+	if err != nil {
+		return nil, err
+	}
+	return loadSectorCommitments(ctx, storage, state.SectorCommitments)
+}
+
+// LoadSectorExpirations returns the expiration heights of all committed
+// sectors that hold deals, by sector id, from its storage, without executing
+// any actor code.
+func LoadSectorExpirations(ctx context.Context, storage exec.Storage) (map[string]*types.BlockHeight, error) {
+	state, err := LoadState(storage)
+	if err != nil {
+		return nil, err
+	}
+	return loadSectorExpirations(ctx, storage, state.SectorExpirations)
 }
 
 // NewActor returns a new miner actor
@@ -139,16 +230,16 @@ func NewActor() *actor.Actor {
 }
 
 // NewState creates a miner state struct
-func NewState(owner address.Address, key []byte, pledge *big.Int, pid peer.ID, collateral *types.AttoFIL) *State {
+func NewState(owner address.Address, key []byte, pledge *big.Int, pid peer.ID, collateral *types.AttoFIL, sectorSize *types.BytesAmount) *State {
 	return &State{
-		Owner:             owner,
-		PeerID:            pid,
-		PublicKey:         key,
-		PledgeSectors:     pledge,
-		Collateral:        collateral,
-		SectorCommitments: make(map[string]types.Commitments),
-		Power:             big.NewInt(0),
-		NextAskID:         big.NewInt(0),
+		Owner:         owner,
+		PeerID:        pid,
+		PublicKey:     key,
+		PledgeSectors: pledge,
+		Collateral:    collateral,
+		Power:         types.NewBytesAmount(0),
+		NextAskID:     big.NewInt(0),
+		SectorSize:    sectorSize,
 	}
 }
 
@@ -187,11 +278,11 @@ var minerExports = exec.Exports{
 	},
 	"getAsks": &exec.FunctionSignature{
 		Params: nil,
-		Return: []abi.Type{abi.UintArray},
+		Return: []abi.Type{abi.ArrayOf(AskType)},
 	},
 	"getAsk": &exec.FunctionSignature{
 		Params: []abi.Type{abi.Integer},
-		Return: []abi.Type{abi.Bytes},
+		Return: []abi.Type{AskType},
 	},
 	"getOwner": &exec.FunctionSignature{
 		Params: nil,
@@ -202,7 +293,7 @@ var minerExports = exec.Exports{
 		Return: []abi.Type{abi.SectorID},
 	},
 	"commitSector": &exec.FunctionSignature{
-		Params: []abi.Type{abi.SectorID, abi.Bytes, abi.Bytes, abi.Bytes, abi.Bytes},
+		Params: []abi.Type{abi.SectorID, abi.Bytes, abi.Bytes, abi.Bytes, abi.Bytes, abi.UintArray},
 		Return: []abi.Type{},
 	},
 	"getKey": &exec.FunctionSignature{
@@ -221,12 +312,24 @@ var minerExports = exec.Exports{
 		Params: []abi.Type{},
 		Return: []abi.Type{abi.Integer},
 	},
+	"increasePledge": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Integer},
+		Return: []abi.Type{},
+	},
+	"addCollateral": &exec.FunctionSignature{
+		Params: []abi.Type{},
+		Return: []abi.Type{},
+	},
+	"withdrawCollateral": &exec.FunctionSignature{
+		Params: []abi.Type{abi.AttoFIL},
+		Return: []abi.Type{},
+	},
 	"getPower": &exec.FunctionSignature{
 		Params: []abi.Type{},
-		Return: []abi.Type{abi.Integer},
+		Return: []abi.Type{abi.BytesAmount},
 	},
 	"submitPoSt": &exec.FunctionSignature{
-		Params: []abi.Type{abi.Bytes},
+		Params: []abi.Type{abi.Bytes, abi.UintArray},
 		Return: []abi.Type{},
 	},
 	"getProvingPeriodStart": &exec.FunctionSignature{
@@ -237,6 +340,18 @@ var minerExports = exec.Exports{
 		Params: nil,
 		Return: []abi.Type{abi.CommitmentsMap},
 	},
+	"slashConsensusFault": &exec.FunctionSignature{
+		Params: []abi.Type{},
+		Return: []abi.Type{abi.BytesAmount},
+	},
+	"slashStorageFault": &exec.FunctionSignature{
+		Params: []abi.Type{},
+		Return: []abi.Type{abi.BytesAmount},
+	},
+	"terminateSector": &exec.FunctionSignature{
+		Params: []abi.Type{abi.SectorID},
+		Return: []abi.Type{},
+	},
 }
 
 // Exports returns the miner actors exported functions.
@@ -260,25 +375,22 @@ func (ma *Actor) AddAsk(ctx exec.VMContext, price *types.AttoFIL, expiry *big.In
 		id := big.NewInt(0).Set(state.NextAskID)
 		state.NextAskID = state.NextAskID.Add(state.NextAskID, big.NewInt(1))
 
-		// filter out expired asks
-		asks := state.Asks
-		state.Asks = state.Asks[:0]
-		for _, a := range asks {
-			if ctx.BlockHeight().LessThan(a.Expiry) {
-				state.Asks = append(state.Asks, a)
-			}
-		}
-
 		if !expiry.IsUint64() {
 			return nil, errors.NewRevertError("expiry was invalid")
 		}
 		expiryBH := types.NewBlockHeight(expiry.Uint64())
 
-		state.Asks = append(state.Asks, &Ask{
+		lookupCtx := context.Background()
+		if err := pruneExpiredAsks(lookupCtx, ctx.Storage(), &state, ctx.BlockHeight()); err != nil {
+			return nil, err
+		}
+		if err := addAsk(lookupCtx, ctx.Storage(), &state, &Ask{
 			Price:  price,
 			Expiry: ctx.BlockHeight().Add(expiryBH),
 			ID:     id,
-		})
+		}); err != nil {
+			return nil, err
+		}
 
 		return id, nil
 	})
@@ -294,70 +406,64 @@ func (ma *Actor) AddAsk(ctx exec.VMContext, price *types.AttoFIL, expiry *big.In
 	return askID, 0, nil
 }
 
-// GetAsks returns all the asks for this miner. (TODO: this isnt a great function signature, it returns the asks in a
-// serialized array. Consider doing this some other way)
-func (ma *Actor) GetAsks(ctx exec.VMContext) ([]uint64, uint8, error) {
+// GetAsks returns all the asks for this miner.
+func (ma *Actor) GetAsks(ctx exec.VMContext) ([]*Ask, uint8, error) {
 	if err := ctx.Charge(100); err != nil {
 		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 	var state State
 	out, err := actor.WithState(ctx, &state, func() (interface{}, error) {
-		var askids []uint64
-		for _, ask := range state.Asks {
-			if !ask.ID.IsUint64() {
-				return nil, errors.NewFaultErrorf("miner ask has invalid ID (bad invariant)")
-			}
-			askids = append(askids, ask.ID.Uint64())
+		asks, err := loadAsks(context.Background(), ctx.Storage(), state.Asks)
+		if err != nil {
+			return nil, errors.FaultErrorWrap(err, "could not load asks")
 		}
-
-		return askids, nil
+		return asks, nil
 	})
 	if err != nil {
 		return nil, errors.CodeError(err), err
 	}
 
-	askids, ok := out.([]uint64)
+	asks, ok := out.([]*Ask)
 	if !ok {
-		return nil, 1, errors.NewRevertErrorf("expected a []uint64 return value from call, but got %T instead", out)
+		return nil, 1, errors.NewRevertErrorf("expected a []*Ask return value from call, but got %T instead", out)
 	}
 
-	return askids, 0, nil
+	return asks, 0, nil
 }
 
 // GetAsk returns an ask by ID
-func (ma *Actor) GetAsk(ctx exec.VMContext, askid *big.Int) ([]byte, uint8, error) {
+func (ma *Actor) GetAsk(ctx exec.VMContext, askid *big.Int) (*Ask, uint8, error) {
 	if err := ctx.Charge(100); err != nil {
 		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
 	var state State
 	out, err := actor.WithState(ctx, &state, func() (interface{}, error) {
-		var ask *Ask
-		for _, a := range state.Asks {
-			if a.ID.Cmp(askid) == 0 {
-				ask = a
-				break
-			}
-		}
+		lookupCtx := context.Background()
 
-		if ask == nil {
-			return nil, Errors[ErrAskNotFound]
+		asks, err := actor.LoadTypedLookup(lookupCtx, ctx.Storage(), state.Asks, Ask{})
+		if err != nil {
+			return nil, errors.FaultErrorWrap(err, "could not load asks")
 		}
 
-		out, err := cbor.DumpObject(ask)
+		value, err := asks.Find(lookupCtx, askid.String())
 		if err != nil {
-			return nil, err
+			if err == hamt.ErrNotFound {
+				return nil, Errors[ErrAskNotFound]
+			}
+			return nil, errors.FaultErrorWrapf(err, "could not find ask %s", askid)
 		}
 
-		return out, nil
+		ask := value.(Ask)
+		return &ask, nil
 	})
 	if err != nil {
 		return nil, errors.CodeError(err), err
 	}
 
-	ask, ok := out.([]byte)
+	ask, ok := out.(*Ask)
 	if !ok {
-		return nil, 1, errors.NewRevertErrorf("expected a Bytes return value from call, but got %T instead", out)
+		return nil, 1, errors.NewRevertErrorf("expected an *Ask return value from call, but got %T instead", out)
 	}
 
 	return ask, 0, nil
@@ -414,7 +520,11 @@ func (ma *Actor) GetSectorCommitments(ctx exec.VMContext) (map[string]types.Comm
 
 	var state State
 	out, err := actor.WithState(ctx, &state, func() (interface{}, error) {
-		return state.SectorCommitments, nil
+		commitments, err := loadSectorCommitments(context.Background(), ctx.Storage(), state.SectorCommitments)
+		if err != nil {
+			return nil, errors.FaultErrorWrap(err, "could not load sector commitments")
+		}
+		return commitments, nil
 	})
 	if err != nil {
 		return map[string]types.Commitments{}, errors.CodeError(err), err
@@ -429,8 +539,11 @@ func (ma *Actor) GetSectorCommitments(ctx exec.VMContext) (map[string]types.Comm
 }
 
 // CommitSector adds a commitment to the specified sector. The sector must not
-// already be committed.
-func (ma *Actor) CommitSector(ctx exec.VMContext, sectorID uint64, commD, commR, commRStar, proof []byte) (uint8, error) {
+// already be committed. dealIDs are the ids of the published deals whose
+// pieces the sector holds, which the storage market marks as committed. The
+// sector expires when the longest of these deals ends, or after
+// SectorLifetimeWithoutDeals if it holds no deals.
+func (ma *Actor) CommitSector(ctx exec.VMContext, sectorID uint64, commD, commR, commRStar, proof []byte, dealIDs []uint64) (uint8, error) {
 	if err := ctx.Charge(100); err != nil {
 		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
@@ -444,39 +557,7 @@ func (ma *Actor) CommitSector(ctx exec.VMContext, sectorID uint64, commD, commR,
 		return 1, errors.NewRevertError("invalid sized commRStar")
 	}
 
-	if !ma.Bootstrap {
-		// This unfortunate environment variable-checking needs to happen because
-		// the PoRep verification operation needs to know some things (e.g. size)
-		// about the sector for which the proof was generated in order to verify.
-		//
-		// It is undefined behavior for a miner in "Live" mode to verify a proof
-		// created by a miner in "ProofsTest" mode (and vice-versa).
-		//
-		sectorStoreType := proofs.Live
-		if os.Getenv("FIL_USE_SMALL_SECTORS") == "true" {
-			sectorStoreType = proofs.Test
-		}
-
-		req := proofs.VerifySealRequest{}
-		copy(req.CommD[:], commD)
-		copy(req.CommR[:], commR)
-		copy(req.CommRStar[:], commRStar)
-		copy(req.Proof[:], proof)
-		req.ProverID = sectorbuilder.AddressToProverID(ctx.Message().To)
-		req.SectorID = sectorbuilder.SectorIDToBytes(sectorID)
-		req.StoreType = sectorStoreType
-
-		res, err := (&proofs.RustVerifier{}).VerifySeal(req)
-		if err != nil {
-			return 1, errors.RevertErrorWrap(err, "failed to verify seal proof")
-		}
-		if !res.IsValid {
-			return ErrInvalidSealProof, Errors[ErrInvalidSealProof]
-		}
-	}
-
-	// TODO: use uint64 instead of this abomination, once refmt is fixed
-	// https://github.com/polydawn/refmt/issues/35
+	// lookup keys are strings
 	sectorIDstr := strconv.FormatUint(sectorID, 10)
 
 	var state State
@@ -486,16 +567,48 @@ func (ma *Actor) CommitSector(ctx exec.VMContext, sectorID uint64, commD, commR,
 			return nil, Errors[ErrCallerUnauthorized]
 		}
 
-		_, ok := state.SectorCommitments[sectorIDstr]
-		if ok {
+		if !ma.Bootstrap {
+			storeType, err := proofs.SectorStoreTypeOfSize(state.SectorSize.Uint64())
+			if err != nil {
+				return nil, errors.FaultErrorWrap(err, "invalid sector size")
+			}
+
+			req := proofs.VerifySealRequest{}
+			copy(req.CommD[:], commD)
+			copy(req.CommR[:], commR)
+			copy(req.CommRStar[:], commRStar)
+			copy(req.Proof[:], proof)
+			req.ProverID = sectorbuilder.AddressToProverID(ctx.Message().To)
+			req.SectorID = sectorbuilder.SectorIDToBytes(sectorID)
+			req.StoreType = storeType
+
+			res, err := (&proofs.RustVerifier{}).VerifySeal(req)
+			if err != nil {
+				return nil, errors.RevertErrorWrap(err, "failed to verify seal proof")
+			}
+			if !res.IsValid {
+				return nil, Errors[ErrInvalidSealProof]
+			}
+		}
+
+		lookupCtx := context.Background()
+
+		sectorCommitments, err := actor.LoadTypedLookup(lookupCtx, ctx.Storage(), state.SectorCommitments, types.Commitments{})
+		if err != nil {
+			return nil, errors.FaultErrorWrap(err, "could not load sector commitments")
+		}
+
+		_, err = sectorCommitments.Find(lookupCtx, sectorIDstr)
+		if err == nil {
 			return nil, Errors[ErrSectorCommitted]
+		} else if err != hamt.ErrNotFound {
+			return nil, errors.FaultErrorWrapf(err, "could not find commitments of sector %d", sectorID)
 		}
 
-		if state.Power.Cmp(big.NewInt(0)) == 0 {
+		if state.Power.Equal(types.ZeroBytes) {
 			state.ProvingPeriodStart = ctx.BlockHeight()
 		}
-		inc := big.NewInt(1)
-		state.Power = state.Power.Add(state.Power, inc)
+		state.Power = state.Power.Add(state.SectorSize)
 		comms := types.Commitments{
 			CommD:     proofs.CommD{},
 			CommR:     proofs.CommR{},
@@ -505,15 +618,53 @@ func (ma *Actor) CommitSector(ctx exec.VMContext, sectorID uint64, commD, commR,
 		copy(comms.CommR[:], commR)
 		copy(comms.CommRStar[:], commRStar)
 		state.LastUsedSectorID = sectorID
-		state.SectorCommitments[sectorIDstr] = comms
-		_, ret, err := ctx.Send(address.StorageMarketAddress, "updatePower", nil, []interface{}{inc})
+
+		if err := sectorCommitments.Set(lookupCtx, sectorIDstr, comms); err != nil {
+			return nil, errors.FaultErrorWrapf(err, "could not set commitments of sector %d", sectorID)
+		}
+		state.SectorCommitments, err = sectorCommitments.Commit(lookupCtx)
+		if err != nil {
+			return nil, errors.FaultErrorWrap(err, "could not commit sector commitments")
+		}
+
+		_, ret, err := ctx.Send(address.StorageMarketAddress, "updatePower", nil, []interface{}{state.SectorSize})
 		if err != nil {
 			return nil, err
 		}
 		if ret != 0 {
 			return nil, Errors[ErrStoragemarketCallFailed]
 		}
-		return nil, nil
+
+		lifetime := SectorLifetimeWithoutDeals
+		if len(dealIDs) > 0 {
+			out, ret, err := ctx.Send(address.StorageMarketAddress, "commitDeals", nil, []interface{}{sectorID, dealIDs})
+			if err != nil {
+				return nil, err
+			}
+			if ret != 0 {
+				return nil, Errors[ErrStoragemarketCallFailed]
+			}
+
+			duration, err := abi.Deserialize(out[0], abi.BlockHeight)
+			if err != nil {
+				return nil, errors.FaultErrorWrap(err, "could not deserialize deal duration")
+			}
+			lifetime = duration.Val.(*types.BlockHeight)
+		}
+
+		sectorExpirations, err := actor.LoadTypedLookup(lookupCtx, ctx.Storage(), state.SectorExpirations, types.BlockHeight{})
+		if err != nil {
+			return nil, errors.FaultErrorWrap(err, "could not load sector expirations")
+		}
+		if err := sectorExpirations.Set(lookupCtx, sectorIDstr, ctx.BlockHeight().Add(lifetime)); err != nil {
+			return nil, errors.FaultErrorWrapf(err, "could not set expiration of sector %d", sectorID)
+		}
+		state.SectorExpirations, err = sectorExpirations.Commit(lookupCtx)
+		if err != nil {
+			return nil, errors.FaultErrorWrap(err, "could not commit sector expirations")
+		}
+
+		return nil, ctx.EmitEvent(SectorCommittedTopic, sectorID)
 	})
 	if err != nil {
 		return errors.CodeError(err), err
@@ -551,13 +702,7 @@ func (ma *Actor) GetPeerID(ctx exec.VMContext) (peer.ID, uint8, error) {
 	}
 
 	var state State
-
-	chunk, err := ctx.ReadStorage()
-	if err != nil {
-		return peer.ID(""), errors.CodeError(err), err
-	}
-
-	if err := actor.UnmarshalStorage(chunk, &state); err != nil {
+	if err := actor.ReadState(ctx.Storage(), &state); err != nil {
 		return peer.ID(""), errors.CodeError(err), err
 	}
 
@@ -610,8 +755,106 @@ func (ma *Actor) GetPledge(ctx exec.VMContext) (*big.Int, uint8, error) {
 	return pledgeSectors, 0, nil
 }
 
-// GetPower returns the amount of proven sectors for this miner.
-func (ma *Actor) GetPower(ctx exec.VMContext) (*big.Int, uint8, error) {
+// IncreasePledge adds the given number of sectors to the miner's pledge. The
+// miner's collateral must cover the increased pledge, so collateral may need
+// to be added first. Only the owner may increase the pledge.
+func (ma *Actor) IncreasePledge(ctx exec.VMContext, sectors *big.Int) (uint8, error) {
+	if err := ctx.Charge(100); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	if sectors.Sign() <= 0 {
+		return 1, errors.NewRevertError("pledge increase must be positive")
+	}
+
+	var state State
+	_, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		if ctx.Message().From != state.Owner {
+			return nil, Errors[ErrCallerUnauthorized]
+		}
+
+		pledge := big.NewInt(0).Add(state.PledgeSectors, sectors)
+		if state.Collateral.LessThan(MinimumCollateral(pledge)) {
+			return nil, Errors[ErrInsufficientCollateral]
+		}
+		state.PledgeSectors = pledge
+
+		return nil, nil
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
+// AddCollateral adds the value of the message to the miner's collateral. Only
+// the owner may add collateral.
+func (ma *Actor) AddCollateral(ctx exec.VMContext) (uint8, error) {
+	if err := ctx.Charge(100); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	_, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		if ctx.Message().From != state.Owner {
+			return nil, Errors[ErrCallerUnauthorized]
+		}
+
+		state.Collateral = state.Collateral.Add(ctx.Message().Value)
+
+		return nil, nil
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
+// WithdrawCollateral sends the given amount of collateral back to the owner.
+// The collateral left must still cover the miner's pledge. Only the owner may
+// withdraw collateral.
+func (ma *Actor) WithdrawCollateral(ctx exec.VMContext, amount *types.AttoFIL) (uint8, error) {
+	if err := ctx.Charge(100); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	if !amount.IsPositive() {
+		return 1, errors.NewRevertError("withdrawal must be positive")
+	}
+
+	var state State
+	_, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		if ctx.Message().From != state.Owner {
+			return nil, Errors[ErrCallerUnauthorized]
+		}
+
+		if state.Collateral.LessThan(amount) {
+			return nil, Errors[ErrInsufficientCollateral]
+		}
+		collateral := state.Collateral.Sub(amount)
+		if collateral.LessThan(MinimumCollateral(state.PledgeSectors)) {
+			return nil, Errors[ErrInsufficientCollateral]
+		}
+
+		_, _, err := ctx.Send(state.Owner, "", amount, nil)
+		if err != nil {
+			return nil, err
+		}
+		state.Collateral = collateral
+
+		return nil, nil
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
+// GetPower returns the number of bytes of proven storage for this miner.
+func (ma *Actor) GetPower(ctx exec.VMContext) (*types.BytesAmount, uint8, error) {
 	if err := ctx.Charge(100); err != nil {
 		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
@@ -624,17 +867,25 @@ func (ma *Actor) GetPower(ctx exec.VMContext) (*big.Int, uint8, error) {
 		return nil, errors.CodeError(err), err
 	}
 
-	power, ok := ret.(*big.Int)
+	power, ok := ret.(*types.BytesAmount)
 	if !ok {
-		return nil, 1, errors.NewFaultErrorf("expected *big.Int to be returned, but got %T instead", ret)
+		return nil, 1, errors.NewFaultErrorf("expected *types.BytesAmount to be returned, but got %T instead", ret)
 	}
 
 	return power, 0, nil
 }
 
 // SubmitPoSt is used to submit a coalesced PoST to the chain to convince the chain
-// that you have been actually storing the files you claim to be.
-func (ma *Actor) SubmitPoSt(ctx exec.VMContext, proof []byte) (uint8, error) {
+// that you have been actually storing the files you claim to be. Sectors the
+// miner could not prove are declared in faults. The PoSt is verified against
+// a challenge seed sampled from the chain at the start of the proving period.
+// A PoSt submitted after the proving period but within the grace period is
+// accepted, and a fee proportional to its lateness is burnt from the miner's
+// collateral. Sectors declared faulty are removed, and SectorTerminationPenalty
+// is burnt from the miner's collateral for each of them. Sectors that expire
+// by the end of the proving period are removed once it has been proven, so
+// they no longer count toward power.
+func (ma *Actor) SubmitPoSt(ctx exec.VMContext, proof []byte, faults []uint64) (uint8, error) {
 	if err := ctx.Charge(100); err != nil {
 		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
@@ -650,21 +901,57 @@ func (ma *Actor) SubmitPoSt(ctx exec.VMContext, proof []byte) (uint8, error) {
 			return nil, Errors[ErrCallerUnauthorized]
 		}
 
+		// a miner without committed sectors has no proving period
+		if state.ProvingPeriodStart == nil {
+			return nil, errors.NewRevertError("miner has no proving period")
+		}
+		provingPeriodEnd := state.ProvingPeriodStart.Add(ProvingPeriodBlocks)
+		if ctx.BlockHeight().GreaterThan(provingPeriodEnd.Add(GracePeriodBlocks)) {
+			return nil, Errors[ErrPoStTooLate]
+		}
+
 		// reach in to actor storage to grab comm-r for each committed sector
+		commitments, err := loadSectorCommitments(context.Background(), ctx.Storage(), state.SectorCommitments)
+		if err != nil {
+			return nil, errors.FaultErrorWrap(err, "could not load sector commitments")
+		}
 		var commRs []proofs.CommR
-		for _, v := range state.SectorCommitments {
+		for _, v := range commitments {
 			commRs = append(commRs, v.CommR)
 		}
 
+		// only committed sectors can be faulty, and each only once
+		var faultySectors []string
+		for _, sectorID := range faults {
+			sectorIDstr := strconv.FormatUint(sectorID, 10)
+			if _, ok := commitments[sectorIDstr]; !ok {
+				return nil, Errors[ErrInvalidSector]
+			}
+			for _, faulty := range faultySectors {
+				if faulty == sectorIDstr {
+					return nil, Errors[ErrInvalidSector]
+				}
+			}
+			faultySectors = append(faultySectors, sectorIDstr)
+		}
+
+		randomness, err := ctx.Rand(state.ProvingPeriodStart)
+		if err != nil {
+			// the randomness is missing if the chain the message is applied
+			// on does not reach back to the proving period start, which is
+			// not a fault of the block being processed
+			return nil, errors.RevertErrorWrap(err, "could not sample PoSt challenge seed")
+		}
+
 		// copy message-bytes into PoStProof slice
 		postProof := proofs.PoStProof{}
 		copy(postProof[:], proof)
 
 		// TODO: use IsPoStValidWithProver when proofs are implemented
 		req := proofs.VerifyPoSTRequest{
-			ChallengeSeed: proofs.PoStChallengeSeed{},
+			ChallengeSeed: PoStChallengeSeed(randomness),
 			CommRs:        commRs,
-			Faults:        []uint64{},
+			Faults:        faults,
 			Proof:         postProof,
 		}
 
@@ -677,17 +964,44 @@ func (ma *Actor) SubmitPoSt(ctx exec.VMContext, proof []byte) (uint8, error) {
 		}
 
 		// Check if we submitted it in time
-		provingPeriodEnd := state.ProvingPeriodStart.Add(ProvingPeriodBlocks)
+		if ctx.BlockHeight().GreaterThan(provingPeriodEnd) {
+			fee := LatePoStFee(state.Collateral, provingPeriodEnd, ctx.BlockHeight())
+			if fee.IsPositive() {
+				_, _, err := ctx.Send(address.BurntFundsAddress, "", fee, nil)
+				if err != nil {
+					return nil, err
+				}
+				state.Collateral = state.Collateral.Sub(fee)
+			}
+		}
+
+		if len(faultySectors) > 0 {
+			penalty := SectorTerminationPenalty(state.Collateral, state.PledgeSectors).MulBigInt(big.NewInt(int64(len(faultySectors))))
+			if penalty.GreaterThan(state.Collateral) {
+				penalty = state.Collateral
+			}
+			if penalty.IsPositive() {
+				_, _, err := ctx.Send(address.BurntFundsAddress, "", penalty, nil)
+				if err != nil {
+					return nil, err
+				}
+				state.Collateral = state.Collateral.Sub(penalty)
+			}
 
-		if ctx.BlockHeight().LessEqual(provingPeriodEnd) {
-			state.ProvingPeriodStart = provingPeriodEnd
-			state.LastPoSt = ctx.BlockHeight()
-		} else {
-			// Not great.
-			// TODO: charge penalty
-			return nil, errors.NewRevertErrorf("submitted PoSt late, need to pay a fee")
+			// faulty sectors have lost their data, so they are removed
+			sort.Strings(faultySectors)
+			if err := removeSectors(ctx, &state, faultySectors, true); err != nil {
+				return nil, err
+			}
 		}
 
+		if err := removeExpiredSectors(ctx, &state, provingPeriodEnd); err != nil {
+			return nil, err
+		}
+
+		state.ProvingPeriodStart = provingPeriodEnd
+		state.LastPoSt = ctx.BlockHeight()
+
 		return nil, nil
 	})
 	if err != nil {
@@ -697,21 +1011,475 @@ func (ma *Actor) SubmitPoSt(ctx exec.VMContext, proof []byte) (uint8, error) {
 	return 0, nil
 }
 
-// GetProvingPeriodStart returns the current ProvingPeriodStart value.
-func (ma *Actor) GetProvingPeriodStart(ctx exec.VMContext) (*types.BlockHeight, uint8, error) {
+// TerminateSector removes a committed sector before it expires. Its power is
+// removed, and unless it has already expired, SectorTerminationPenalty is
+// burnt from the miner's collateral.
+func (ma *Actor) TerminateSector(ctx exec.VMContext, sectorID uint64) (uint8, error) {
+	if err := ctx.Charge(100); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	// lookup keys are strings
+	sectorIDstr := strconv.FormatUint(sectorID, 10)
+
+	var state State
+	_, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		// verify that the caller is authorized to perform update
+		if ctx.Message().From != state.Owner {
+			return nil, Errors[ErrCallerUnauthorized]
+		}
+
+		commitments, err := loadSectorCommitments(context.Background(), ctx.Storage(), state.SectorCommitments)
+		if err != nil {
+			return nil, errors.FaultErrorWrap(err, "could not load sector commitments")
+		}
+		if _, ok := commitments[sectorIDstr]; !ok {
+			return nil, Errors[ErrSectorNotCommitted]
+		}
+
+		expirations, err := loadSectorExpirations(context.Background(), ctx.Storage(), state.SectorExpirations)
+		if err != nil {
+			return nil, errors.FaultErrorWrap(err, "could not load sector expirations")
+		}
+		expiration, ok := expirations[sectorIDstr]
+		if !ok || ctx.BlockHeight().LessThan(expiration) {
+			penalty := SectorTerminationPenalty(state.Collateral, state.PledgeSectors)
+			if penalty.IsPositive() {
+				_, _, err := ctx.Send(address.BurntFundsAddress, "", penalty, nil)
+				if err != nil {
+					return nil, err
+				}
+				state.Collateral = state.Collateral.Sub(penalty)
+			}
+		}
+
+		return nil, removeSectors(ctx, &state, []string{sectorIDstr}, true)
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
+// SlashConsensusFault punishes this miner for a consensus fault, such as
+// signing two blocks at the same height. It may only be called by the storage
+// market, which is responsible for verifying the evidence. All collateral is
+// burnt and power is set to zero. The power removed is returned so the storage
+// market can update the network total.
+func (ma *Actor) SlashConsensusFault(ctx exec.VMContext) (*types.BytesAmount, uint8, error) {
+	if err := ctx.Charge(100); err != nil {
+		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	out, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		if ctx.Message().From != address.StorageMarketAddress {
+			return nil, Errors[ErrCallerUnauthorized]
+		}
+
+		return slash(ctx, &state)
+	})
+	if err != nil {
+		return nil, errors.CodeError(err), err
+	}
+
+	slashedPower, ok := out.(*types.BytesAmount)
+	if !ok {
+		return nil, 1, errors.NewFaultErrorf("expected *types.BytesAmount to be returned, but got %T instead", out)
+	}
+
+	return slashedPower, 0, nil
+}
+
+// SlashStorageFault punishes this miner for missing the grace period of its
+// proving period without submitting a PoSt. It may only be called by the
+// storage market. All collateral is burnt and power is set to zero, so the
+// next committed sector starts a new proving period. The power removed is
+// returned so the storage market can update the network total.
+func (ma *Actor) SlashStorageFault(ctx exec.VMContext) (*types.BytesAmount, uint8, error) {
 	if err := ctx.Charge(100); err != nil {
 		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
-	chunk, err := ctx.ReadStorage()
+	var state State
+	out, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		if ctx.Message().From != address.StorageMarketAddress {
+			return nil, Errors[ErrCallerUnauthorized]
+		}
+
+		// a miner without power has no proving period
+		if state.Power.Equal(types.ZeroBytes) {
+			return nil, Errors[ErrPoStNotMissed]
+		}
+		graceEnd := state.ProvingPeriodStart.Add(ProvingPeriodBlocks).Add(GracePeriodBlocks)
+		if ctx.BlockHeight().LessEqual(graceEnd) {
+			return nil, Errors[ErrPoStNotMissed]
+		}
+
+		return slash(ctx, &state)
+	})
 	if err != nil {
 		return nil, errors.CodeError(err), err
 	}
 
+	slashedPower, ok := out.(*types.BytesAmount)
+	if !ok {
+		return nil, 1, errors.NewFaultErrorf("expected *types.BytesAmount to be returned, but got %T instead", out)
+	}
+
+	return slashedPower, 0, nil
+}
+
+// slash burns all collateral of the miner and removes all its sectors, so it
+// is left without power or a proving period. It returns the power removed,
+// which the caller, the storage market, removes from the network total.
+func slash(ctx exec.VMContext, state *State) (*types.BytesAmount, error) {
+	if state.Collateral.IsPositive() {
+		_, _, err := ctx.Send(address.BurntFundsAddress, "", state.Collateral, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	commitments, err := loadSectorCommitments(context.Background(), ctx.Storage(), state.SectorCommitments)
+	if err != nil {
+		return nil, errors.FaultErrorWrap(err, "could not load sector commitments")
+	}
+	var sectorIDs []string
+	for sectorID := range commitments {
+		sectorIDs = append(sectorIDs, sectorID)
+	}
+	sort.Strings(sectorIDs)
+	if err := deleteSectors(ctx, state, sectorIDs); err != nil {
+		return nil, err
+	}
+
+	slashedPower := state.Power
+	state.Collateral = types.NewZeroAttoFIL()
+	state.Power = types.NewBytesAmount(0)
+	state.ProvingPeriodStart = nil
+
+	return slashedPower, nil
+}
+
+// GetProvingPeriodStart returns the current ProvingPeriodStart value.
+func (ma *Actor) GetProvingPeriodStart(ctx exec.VMContext) (*types.BlockHeight, uint8, error) {
+	if err := ctx.Charge(100); err != nil {
+		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
 	var state State
-	if err := actor.UnmarshalStorage(chunk, &state); err != nil {
+	if err := actor.ReadState(ctx.Storage(), &state); err != nil {
 		return nil, errors.CodeError(err), err
 	}
 
 	return state.ProvingPeriodStart, 0, nil
 }
+
+// MinimumCollateral returns the minimum required amount of collateral for a given pledge
+func MinimumCollateral(sectors *big.Int) *types.AttoFIL {
+	return MinimumCollateralPerSector.MulBigInt(sectors)
+}
+
+// LatePoStFee returns the fee for a PoSt submitted at the given height for a
+// proving period ending at provingPeriodEnd. The fee grows linearly from
+// nothing at the end of the proving period to all of the collateral at the end
+// of the grace period.
+func LatePoStFee(collateral *types.AttoFIL, provingPeriodEnd *types.BlockHeight, height *types.BlockHeight) *types.AttoFIL {
+	if height.LessEqual(provingPeriodEnd) {
+		return types.NewZeroAttoFIL()
+	}
+	lateness := height.Sub(provingPeriodEnd)
+	if lateness.GreaterThan(GracePeriodBlocks) {
+		return collateral
+	}
+	return collateral.MulBigInt(lateness.AsBigInt()).DivCeil(types.NewAttoFIL(GracePeriodBlocks.AsBigInt()))
+}
+
+// SectorTerminationPenalty returns the fee for terminating a sector before it
+// expires, which is the share of the collateral backing a single pledged
+// sector.
+func SectorTerminationPenalty(collateral *types.AttoFIL, pledgeSectors *big.Int) *types.AttoFIL {
+	if pledgeSectors.Sign() <= 0 {
+		return collateral
+	}
+	return collateral.DivCeil(types.NewAttoFIL(pledgeSectors))
+}
+
+// PoStChallengeSeed returns the challenge seed of a PoSt derived from the
+// chain randomness sampled at the start of its proving period.
+func PoStChallengeSeed(randomness []byte) proofs.PoStChallengeSeed {
+	seed := proofs.PoStChallengeSeed{}
+	copy(seed[:], randomness)
+	return seed
+}
+
+// loadAsks returns the asks in the lookup with the given cid, ordered by id.
+func loadAsks(ctx context.Context, storage exec.Storage, id cid.Cid) ([]*Ask, error) {
+	var asks []*Ask
+	err := actor.WithTypedLookupForReading(ctx, storage, id, Ask{}, func(lookup exec.Lookup) error {
+		kvs, err := lookup.Values(ctx)
+		if err != nil {
+			return err
+		}
+		for _, kv := range kvs {
+			ask := kv.Value.(Ask)
+			asks = append(asks, &ask)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(asks, func(i, j int) bool {
+		return asks[i].ID.Cmp(asks[j].ID) < 0
+	})
+	return asks, nil
+}
+
+// addAsk adds an ask to the open asks of a miner, and indexes it by the
+// height at which it expires.
+func addAsk(ctx context.Context, storage exec.Storage, state *State, ask *Ask) error {
+	var err error
+
+	state.Asks, err = actor.WithTypedLookup(ctx, storage, state.Asks, Ask{}, func(asks exec.Lookup) error {
+		return asks.Set(ctx, ask.ID.String(), ask)
+	})
+	if err != nil {
+		return errors.FaultErrorWrap(err, "could not update asks")
+	}
+
+	key := ask.Expiry.String()
+	state.AskExpirations, err = actor.WithTypedLookup(ctx, storage, state.AskExpirations, []string{}, func(expirations exec.Lookup) error {
+		var askIDs []string
+		value, err := expirations.Find(ctx, key)
+		if err == nil {
+			askIDs = value.([]string)
+		} else if err != hamt.ErrNotFound {
+			return err
+		}
+		return expirations.Set(ctx, key, append(askIDs, ask.ID.String()))
+	})
+	if err != nil {
+		return errors.FaultErrorWrapf(err, "could not index ask %s by expiry", ask.ID)
+	}
+
+	i := sort.Search(len(state.AskExpiryHeights), func(i int) bool {
+		return state.AskExpiryHeights[i].GreaterEqual(ask.Expiry)
+	})
+	if i == len(state.AskExpiryHeights) || !state.AskExpiryHeights[i].Equal(ask.Expiry) {
+		state.AskExpiryHeights = append(state.AskExpiryHeights, nil)
+		copy(state.AskExpiryHeights[i+1:], state.AskExpiryHeights[i:])
+		state.AskExpiryHeights[i] = ask.Expiry
+	}
+	return nil
+}
+
+// pruneExpiredAsks deletes the asks that expire at or before the given
+// height. It only reads the ids of the asks expiring at heights that have
+// passed, so its cost does not grow with the number of open asks.
+func pruneExpiredAsks(ctx context.Context, storage exec.Storage, state *State, height *types.BlockHeight) error {
+	expired := 0
+	for expired < len(state.AskExpiryHeights) && state.AskExpiryHeights[expired].LessEqual(height) {
+		expired++
+	}
+	if expired == 0 {
+		return nil
+	}
+
+	asks, err := actor.LoadTypedLookup(ctx, storage, state.Asks, Ask{})
+	if err != nil {
+		return errors.FaultErrorWrap(err, "could not load asks")
+	}
+	expirations, err := actor.LoadTypedLookup(ctx, storage, state.AskExpirations, []string{})
+	if err != nil {
+		return errors.FaultErrorWrap(err, "could not load ask expirations")
+	}
+
+	for _, expiry := range state.AskExpiryHeights[:expired] {
+		key := expiry.String()
+		value, err := expirations.Find(ctx, key)
+		if err != nil {
+			return errors.FaultErrorWrapf(err, "could not find asks expiring at height %s", expiry)
+		}
+		for _, askID := range value.([]string) {
+			if err := asks.Delete(ctx, askID); err != nil {
+				return errors.FaultErrorWrapf(err, "could not delete ask %s", askID)
+			}
+		}
+		if err := expirations.Delete(ctx, key); err != nil {
+			return errors.FaultErrorWrapf(err, "could not delete asks expiring at height %s", expiry)
+		}
+	}
+	state.AskExpiryHeights = state.AskExpiryHeights[expired:]
+
+	state.Asks, err = asks.Commit(ctx)
+	if err != nil {
+		return errors.FaultErrorWrap(err, "could not commit asks")
+	}
+	state.AskExpirations, err = expirations.Commit(ctx)
+	if err != nil {
+		return errors.FaultErrorWrap(err, "could not commit ask expirations")
+	}
+	return nil
+}
+
+// loadSectorCommitments returns the commitments in the lookup with the given
+// cid, by sector id.
+func loadSectorCommitments(ctx context.Context, storage exec.Storage, id cid.Cid) (map[string]types.Commitments, error) {
+	commitments := map[string]types.Commitments{}
+	err := actor.WithTypedLookupForReading(ctx, storage, id, types.Commitments{}, func(lookup exec.Lookup) error {
+		kvs, err := lookup.Values(ctx)
+		if err != nil {
+			return err
+		}
+		for _, kv := range kvs {
+			commitments[kv.Key] = kv.Value.(types.Commitments)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return commitments, nil
+}
+
+// loadSectorExpirations returns the expirations in the lookup with the given
+// cid, by sector id.
+func loadSectorExpirations(ctx context.Context, storage exec.Storage, id cid.Cid) (map[string]*types.BlockHeight, error) {
+	expirations := map[string]*types.BlockHeight{}
+	err := actor.WithTypedLookupForReading(ctx, storage, id, types.BlockHeight{}, func(lookup exec.Lookup) error {
+		kvs, err := lookup.Values(ctx)
+		if err != nil {
+			return err
+		}
+		for _, kv := range kvs {
+			expiration := kv.Value.(types.BlockHeight)
+			expirations[kv.Key] = &expiration
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return expirations, nil
+}
+
+// removeExpiredSectors removes all sectors that expire at or before the given
+// height.
+func removeExpiredSectors(ctx exec.VMContext, state *State, height *types.BlockHeight) error {
+	expirations, err := loadSectorExpirations(context.Background(), ctx.Storage(), state.SectorExpirations)
+	if err != nil {
+		return errors.FaultErrorWrap(err, "could not load sector expirations")
+	}
+
+	var expired []string
+	for sectorID, expiration := range expirations {
+		if expiration.LessEqual(height) {
+			expired = append(expired, sectorID)
+		}
+	}
+	if len(expired) == 0 {
+		return nil
+	}
+	// remove sectors in a deterministic order, as each emits an event
+	sort.Strings(expired)
+
+	return removeSectors(ctx, state, expired, false)
+}
+
+// removeSectors deletes the sectors with the given ids, and removes their
+// power from the miner and the storage market. The storage market marks the
+// deals the sectors hold as terminated if terminated is set, or else as ended.
+func removeSectors(ctx exec.VMContext, state *State, sectorIDs []string, terminated bool) error {
+	if err := deleteSectors(ctx, state, sectorIDs); err != nil {
+		return err
+	}
+
+	ids := make([]uint64, len(sectorIDs))
+	for i, sectorID := range sectorIDs {
+		id, err := strconv.ParseUint(sectorID, 10, 64)
+		if err != nil {
+			return errors.FaultErrorWrapf(err, "invalid sector id %s", sectorID)
+		}
+		ids[i] = id
+	}
+	method := "endSectorDeals"
+	if terminated {
+		method = "terminateSectorDeals"
+	}
+	_, ret, err := ctx.Send(address.StorageMarketAddress, method, nil, []interface{}{ids})
+	if err != nil {
+		return err
+	}
+	if ret != 0 {
+		return Errors[ErrStoragemarketCallFailed]
+	}
+
+	// slashing removes all sectors along with the power, so the power of
+	// a miner is always that of its sectors
+	dec := state.SectorSize.Mul(types.NewBytesAmount(uint64(len(sectorIDs))))
+	if dec.GreaterThan(state.Power) {
+		return errors.NewFaultErrorf("miner power %s is less than the power of its removed sectors %s", state.Power, dec)
+	}
+	state.Power = state.Power.Sub(dec)
+
+	if dec.IsPositive() {
+		_, ret, err := ctx.Send(address.StorageMarketAddress, "updatePower", nil, []interface{}{types.ZeroBytes.Sub(dec)})
+		if err != nil {
+			return err
+		}
+		if ret != 0 {
+			return Errors[ErrStoragemarketCallFailed]
+		}
+	}
+
+	return nil
+}
+
+// deleteSectors deletes the commitments and expirations of the sectors with
+// the given ids, without changing the power of the miner.
+func deleteSectors(ctx exec.VMContext, state *State, sectorIDs []string) error {
+	lookupCtx := context.Background()
+
+	sectorCommitments, err := actor.LoadTypedLookup(lookupCtx, ctx.Storage(), state.SectorCommitments, types.Commitments{})
+	if err != nil {
+		return errors.FaultErrorWrap(err, "could not load sector commitments")
+	}
+	sectorExpirations, err := actor.LoadTypedLookup(lookupCtx, ctx.Storage(), state.SectorExpirations, types.BlockHeight{})
+	if err != nil {
+		return errors.FaultErrorWrap(err, "could not load sector expirations")
+	}
+
+	for _, sectorID := range sectorIDs {
+		if err := sectorCommitments.Delete(lookupCtx, sectorID); err != nil {
+			return errors.FaultErrorWrapf(err, "could not delete commitments of sector %s", sectorID)
+		}
+		if err := sectorExpirations.Delete(lookupCtx, sectorID); err != nil && err != hamt.ErrNotFound {
+			return errors.FaultErrorWrapf(err, "could not delete expiration of sector %s", sectorID)
+		}
+	}
+
+	state.SectorCommitments, err = sectorCommitments.Commit(lookupCtx)
+	if err != nil {
+		return errors.FaultErrorWrap(err, "could not commit sector commitments")
+	}
+	state.SectorExpirations, err = sectorExpirations.Commit(lookupCtx)
+	if err != nil {
+		return errors.FaultErrorWrap(err, "could not commit sector expirations")
+	}
+
+	for _, sectorID := range sectorIDs {
+		id, err := strconv.ParseUint(sectorID, 10, 64)
+		if err != nil {
+			return errors.FaultErrorWrapf(err, "invalid sector id %s", sectorID)
+		}
+		if err := ctx.EmitEvent(SectorRemovedTopic, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -3,16 +3,23 @@ package miner_test
 import (
 	"context"
 	"math/big"
+	"strconv"
 	"testing"
 
+	"gx/ipfs/QmRXf2uUSdGSunRJsM9wXSUNVwLUGCY3So5fAs7h2CBJVf/go-hamt-ipld"
+	"gx/ipfs/QmS2aqUZLJp8kF1ihE5rvDGE5LvmKDPnx32w9Z1BW9xLV5/go-ipfs-blockstore"
 	peer "gx/ipfs/QmY5Grm8pJdiSSVsYxx4uNRgweY72EmYwuSDbRnbFok3iY/go-libp2p-peer"
+	"gx/ipfs/Qmf4xQhNomPNhrtZc67qSnfJSjxjXs9LWvknJtSXwimPrM/go-datastore"
 
+	"github.com/filecoin-project/go-filecoin/abi"
 	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin"
 	. "github.com/filecoin-project/go-filecoin/actor/builtin/miner"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/consensus"
 	"github.com/filecoin-project/go-filecoin/core"
+	"github.com/filecoin-project/go-filecoin/exec"
 	"github.com/filecoin-project/go-filecoin/proofs"
 	"github.com/filecoin-project/go-filecoin/state"
 	th "github.com/filecoin-project/go-filecoin/testhelpers"
@@ -82,9 +89,12 @@ func TestAskFunctions(t *testing.T) {
 
 	var minerStorage State
 	builtin.RequireReadState(t, vms, minerAddr, miner, &minerStorage)
-	assert.Equal(1, len(minerStorage.Asks))
 	assert.Equal(uint64(1), minerStorage.NextAskID.Uint64())
 
+	storedAsks, err := LoadAsks(ctx, vms.NewStorage(minerAddr, miner))
+	require.NoError(err)
+	assert.Equal(1, len(storedAsks))
+
 	// Look for an ask that doesn't exist
 	pdata = actor.MustConvertParams(big.NewInt(3453))
 	msg = types.NewMessage(address.TestAddress, minerAddr, 2, types.NewZeroAttoFIL(), "getAsk", pdata)
@@ -115,9 +125,129 @@ func TestAskFunctions(t *testing.T) {
 	assert.NoError(err)
 	assert.NoError(result.ExecutionError)
 
-	var askids []uint64
-	require.NoError(actor.UnmarshalStorage(result.Receipt.Return[0], &askids))
-	assert.Len(askids, 2)
+	asks, err := abi.Deserialize(result.Receipt.Return[0], abi.ArrayOf(AskType))
+	require.NoError(err)
+	require.Len(asks.Val, 2)
+	assert.Equal(uint64(1), asks.Val.([]*Ask)[1].ID.Uint64())
+	assert.Equal(types.NewBlockHeight(203), asks.Val.([]*Ask)[1].Expiry)
+}
+
+func TestAskExpiration(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	ctx := context.Background()
+	st, vms := core.CreateStorages(ctx, t)
+
+	minerAddr := createTestMiner(assert.New(t), st, vms, address.TestAddress, []byte{}, th.RequireRandomPeerID())
+
+	addAsk := func(t *testing.T, height, expiry uint64) {
+		res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, height, "addAsk", types.NewAttoFILFromFIL(1), big.NewInt(int64(expiry)))
+		require.NoError(err)
+		require.NoError(res.ExecutionError)
+	}
+
+	loadMiner := func(t *testing.T) (*State, []*Ask) {
+		act, err := st.GetActor(ctx, minerAddr)
+		require.NoError(err)
+		storage := vms.NewStorage(minerAddr, act)
+		minerState, err := LoadState(storage)
+		require.NoError(err)
+		asks, err := LoadAsks(ctx, storage)
+		require.NoError(err)
+		return minerState, asks
+	}
+
+	// asks 0 and 2 expire at height 11, ask 1 at height 21
+	addAsk(t, 1, 10)
+	addAsk(t, 1, 20)
+	addAsk(t, 6, 5)
+
+	t.Run("asks are indexed by the height they expire at", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		minerState, asks := loadMiner(t)
+		require.Len(minerState.AskExpiryHeights, 2)
+		assert.True(types.NewBlockHeight(11).Equal(minerState.AskExpiryHeights[0]))
+		assert.True(types.NewBlockHeight(21).Equal(minerState.AskExpiryHeights[1]))
+		assert.Len(asks, 3)
+	})
+
+	t.Run("adding an ask prunes the asks that have expired", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		addAsk(t, 11, 100)
+
+		minerState, asks := loadMiner(t)
+		require.Len(minerState.AskExpiryHeights, 2)
+		assert.True(types.NewBlockHeight(21).Equal(minerState.AskExpiryHeights[0]))
+		assert.True(types.NewBlockHeight(111).Equal(minerState.AskExpiryHeights[1]))
+
+		require.Len(asks, 2)
+		assert.Equal(uint64(1), asks[0].ID.Uint64())
+		assert.Equal(uint64(3), asks[1].ID.Uint64())
+	})
+}
+
+func TestMigrateLegacyState(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	require := require.New(t)
+
+	ctx := context.Background()
+	st, vms := core.CreateStorages(ctx, t)
+
+	minerAddr := createTestMiner(assert, st, vms, address.TestAddress, []byte("my public key"), th.RequireRandomPeerID())
+	act, err := st.GetActor(ctx, minerAddr)
+	require.NoError(err)
+	storage := vms.NewStorage(minerAddr, act)
+
+	var comms types.Commitments
+	copy(comms.CommD[:], th.MakeCommitment())
+	copy(comms.CommR[:], th.MakeCommitment())
+	copy(comms.CommRStar[:], th.MakeCommitment())
+	old := &LegacyState{
+		Owner:         address.TestAddress,
+		PublicKey:     []byte("my public key"),
+		PledgeSectors: big.NewInt(100),
+		Collateral:    types.NewAttoFILFromFIL(100),
+		Asks: []*Ask{
+			{Price: types.NewAttoFILFromFIL(1), Expiry: types.NewBlockHeight(20), ID: big.NewInt(0)},
+			{Price: types.NewAttoFILFromFIL(2), Expiry: types.NewBlockHeight(10), ID: big.NewInt(1)},
+		},
+		NextAskID:         big.NewInt(2),
+		SectorCommitments: map[string]types.Commitments{"1": comms},
+		LastUsedSectorID:  1,
+		Power:             big.NewInt(3),
+	}
+	c, err := storage.Put(old)
+	require.NoError(err)
+	require.NoError(storage.Commit(c, storage.Head()))
+
+	require.NoError(MigrateLegacyState(storage, th.SectorSize()))
+
+	minerState, err := LoadState(storage)
+	require.NoError(err)
+	assert.Equal(address.TestAddress, minerState.Owner)
+	assert.Equal(uint64(2), minerState.NextAskID.Uint64())
+	assert.Equal(uint64(1), minerState.LastUsedSectorID)
+	assert.True(th.SectorSize().Mul(types.NewBytesAmount(3)).Equal(minerState.Power))
+	assert.True(th.SectorSize().Equal(minerState.SectorSize))
+	require.Len(minerState.AskExpiryHeights, 2)
+	assert.True(types.NewBlockHeight(10).Equal(minerState.AskExpiryHeights[0]))
+	assert.True(types.NewBlockHeight(20).Equal(minerState.AskExpiryHeights[1]))
+
+	asks, err := LoadAsks(ctx, storage)
+	require.NoError(err)
+	require.Len(asks, 2)
+	assert.True(types.NewAttoFILFromFIL(1).Equal(asks[0].Price))
+	assert.True(types.NewAttoFILFromFIL(2).Equal(asks[1].Price))
+
+	commitments, err := LoadSectorCommitments(ctx, storage)
+	require.NoError(err)
+	assert.Equal(map[string]types.Commitments{"1": comms}, commitments)
 }
 
 func TestGetKey(t *testing.T) {
@@ -137,13 +267,8 @@ func TestGetKey(t *testing.T) {
 
 func TestCBOREncodeState(t *testing.T) {
 	assert := assert.New(t)
-	state := NewState(address.TestAddress, []byte{}, big.NewInt(1), th.RequireRandomPeerID(), types.NewZeroAttoFIL())
-
-	state.SectorCommitments["1"] = types.Commitments{
-		CommD:     proofs.CommD{},
-		CommR:     proofs.CommR{},
-		CommRStar: proofs.CommRStar{},
-	}
+	state := NewState(address.TestAddress, []byte{}, big.NewInt(1), th.RequireRandomPeerID(), types.NewZeroAttoFIL(), th.SectorSize())
+	state.SectorCommitments = types.SomeCid()
 
 	_, err := actor.MarshalStorage(state)
 	assert.NoError(err)
@@ -230,7 +355,7 @@ func TestMinerGetPower(t *testing.T) {
 	t.Parallel()
 	require := require.New(t)
 
-	t.Run("GetPower returns proven sectors, 0, nil when successful", func(t *testing.T) {
+	t.Run("GetPower returns proven storage in bytes, 0, nil when successful", func(t *testing.T) {
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
 
@@ -241,7 +366,7 @@ func TestMinerGetPower(t *testing.T) {
 
 		// retrieve power (trivial result for no proven sectors)
 		result := callQueryMethodSuccess("getPower", ctx, t, st, vms, address.TestAddress, minerAddr)
-		require.Equal([]byte{}, result[0])
+		require.True(types.NewBytesAmountFromBytes(result[0]).IsZero())
 	})
 }
 
@@ -286,7 +411,7 @@ func TestMinerCommitSector(t *testing.T) {
 	commRStar := th.MakeCommitment()
 	commD := th.MakeCommitment()
 
-	res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 3, "commitSector", uint64(1), commD, commR, commRStar, th.MakeRandomBytes(int(proofs.SealBytesLen)))
+	res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 3, "commitSector", uint64(1), commD, commR, commRStar, th.MakeRandomBytes(int(proofs.SealBytesLen)), []uint64{})
 	require.NoError(err)
 	require.NoError(res.ExecutionError)
 	require.Equal(uint8(0), res.Receipt.ExitCode)
@@ -298,13 +423,56 @@ func TestMinerCommitSector(t *testing.T) {
 	// blockheight was 3
 	require.Equal(types.NewBlockHeight(3), types.NewBlockHeightFromBytes(res.Receipt.Return[0]))
 
+	// power grows by the size of the committed sector
+	res, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 3, "getPower")
+	require.NoError(err)
+	require.NoError(res.ExecutionError)
+	require.True(th.SectorSize().Equal(types.NewBytesAmountFromBytes(res.Receipt.Return[0])))
+
+	// the commitments are stored under the sector id
+	miner, err := st.GetActor(ctx, minerAddr)
+	require.NoError(err)
+	commitments, err := LoadSectorCommitments(ctx, vms.NewStorage(minerAddr, miner))
+	require.NoError(err)
+	require.Len(commitments, 1)
+	require.Equal(commR, commitments["1"].CommR[:])
+
 	// fail because commR already exists
-	res, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 4, "commitSector", uint64(1), commD, commR, commRStar, th.MakeRandomBytes(int(proofs.SealBytesLen)))
+	res, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 4, "commitSector", uint64(1), commD, commR, commRStar, th.MakeRandomBytes(int(proofs.SealBytesLen)), []uint64{})
 	require.NoError(err)
 	require.EqualError(res.ExecutionError, "sector already committed")
 	require.Equal(uint8(0x23), res.Receipt.ExitCode)
 }
 
+func TestMinerCommitSectorUsesRecordedSectorSize(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	ctx := context.Background()
+	cst := hamt.NewCborStore()
+	bs := blockstore.NewBlockstore(datastore.NewMapDatastore())
+
+	// the size recorded at genesis, not the environment, sets the power of sectors
+	sectorSize := types.NewBytesAmount(proofs.SectorSize(proofs.Test))
+	blk, err := consensus.MakeGenesisFunc(consensus.SectorSize(sectorSize))(cst, bs)
+	require.NoError(err)
+	st, err := state.LoadStateTree(ctx, cst, blk.StateRoot, builtin.Actors)
+	require.NoError(err)
+	vms := vm.NewStorageMap(bs)
+
+	minerAddr := createTestMiner(assert.New(t), st, vms, address.TestAddress, []byte("my public key"), th.RequireRandomPeerID())
+	res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 3, "commitSector", uint64(1), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(int(proofs.SealBytesLen)), []uint64{})
+	require.NoError(err)
+	require.NoError(res.ExecutionError)
+
+	act, err := st.GetActor(ctx, minerAddr)
+	require.NoError(err)
+	minerState, err := LoadState(vms.NewStorage(minerAddr, act))
+	require.NoError(err)
+	require.True(sectorSize.Equal(minerState.SectorSize))
+	require.True(sectorSize.Equal(minerState.Power))
+}
+
 func TestMinerSubmitPoSt(t *testing.T) {
 	require := require.New(t)
 	ctx := context.Background()
@@ -314,33 +482,326 @@ func TestMinerSubmitPoSt(t *testing.T) {
 	minerAddr := createTestMiner(assert.New(t), st, vms, address.TestAddress, []byte("my public key"), origPid)
 
 	// add a sector
-	res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 3, "commitSector", uint64(1), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(int(proofs.SealBytesLen)))
+	res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 3, "commitSector", uint64(1), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(int(proofs.SealBytesLen)), []uint64{})
 	require.NoError(err)
 	require.NoError(res.ExecutionError)
 	require.Equal(uint8(0), res.Receipt.ExitCode)
 
 	// add another sector
-	res, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 4, "commitSector", uint64(2), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(int(proofs.SealBytesLen)))
+	res, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 4, "commitSector", uint64(2), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(int(proofs.SealBytesLen)), []uint64{})
 	require.NoError(err)
 	require.NoError(res.ExecutionError)
 	require.Equal(uint8(0), res.Receipt.ExitCode)
 
-	// submit post
+	// fail to submit without the randomness of the proving period
 	proof := th.MakeRandomPoSTProofForTest()
-	res, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 8, "submitPoSt", proof[:])
+	res, err = submitPoSt(st, vms, minerAddr, 8, nil, proof[:], []uint64{})
+	require.NoError(err)
+	require.Error(res.ExecutionError)
+	require.Contains(res.ExecutionError.Error(), "could not sample PoSt challenge seed")
+
+	// fail to declare a sector that was never committed faulty
+	res, err = submitPoSt(st, vms, minerAddr, 8, tipSetsAtHeights(require, 7, 6, 5, 4, 3, 2, 1, 0), proof[:], []uint64{3})
+	require.NoError(err)
+	require.Equal(Errors[ErrInvalidSector], res.ExecutionError)
+
+	// fail to declare a sector faulty twice
+	res, err = submitPoSt(st, vms, minerAddr, 8, tipSetsAtHeights(require, 7, 6, 5, 4, 3, 2, 1, 0), proof[:], []uint64{2, 2})
+	require.NoError(err)
+	require.Equal(Errors[ErrInvalidSector], res.ExecutionError)
+
+	// submit post declaring a faulty sector
+	res, err = submitPoSt(st, vms, minerAddr, 8, tipSetsAtHeights(require, 7, 6, 5, 4, 3, 2, 1, 0), proof[:], []uint64{2})
 	require.NoError(err)
 	require.NoError(res.ExecutionError)
 	require.Equal(uint8(0), res.Receipt.ExitCode)
 
+	// the faulty sector is removed, and its share of the collateral is burnt
+	act, err := st.GetActor(ctx, minerAddr)
+	require.NoError(err)
+	commitments, err := LoadSectorCommitments(ctx, vms.NewStorage(minerAddr, act))
+	require.NoError(err)
+	require.Len(commitments, 1)
+	require.Contains(commitments, "1")
+	minerState, err := LoadState(vms.NewStorage(minerAddr, act))
+	require.NoError(err)
+	require.True(th.SectorSize().Equal(minerState.Power))
+	require.True(types.NewAttoFILFromFIL(99).Equal(minerState.Collateral))
+
 	// check that the proving period is now the next one
 	res, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 9, "getProvingPeriodStart")
 	require.NoError(err)
 	require.NoError(res.ExecutionError)
 	require.Equal(types.NewBlockHeightFromBytes(res.Receipt.Return[0]), types.NewBlockHeight(20003))
 
-	// fail to submit inside the proving period
+	// submit late, within the grace period, at half of the grace period
 	proof = th.MakeRandomPoSTProofForTest()
-	res, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 40008, "submitPoSt", proof[:])
+	lateHeight := uint64(40003) + GracePeriodBlocks.AsBigInt().Uint64()/2
+	res, err = submitPoSt(st, vms, minerAddr, lateHeight, tipSetsAtHeights(require, 20003, 20002, 20001, 20000), proof[:], []uint64{})
 	require.NoError(err)
-	require.EqualError(res.ExecutionError, "submitted PoSt late, need to pay a fee")
+	require.NoError(res.ExecutionError)
+
+	// half of the collateral is burnt as a fee
+	act, err = st.GetActor(ctx, minerAddr)
+	require.NoError(err)
+	minerState, err = LoadState(vms.NewStorage(minerAddr, act))
+	require.NoError(err)
+	halfLeft, ok := types.NewAttoFILFromFILString("49.5")
+	require.True(ok)
+	require.True(halfLeft.Equal(minerState.Collateral))
+	require.True(types.NewBlockHeight(40003).Equal(minerState.ProvingPeriodStart))
+
+	// fail to submit after the grace period
+	proof = th.MakeRandomPoSTProofForTest()
+	tooLateHeight := uint64(60003) + GracePeriodBlocks.AsBigInt().Uint64() + 1
+	res, err = submitPoSt(st, vms, minerAddr, tooLateHeight, tipSetsAtHeights(require, 40003, 40002, 40001, 40000), proof[:], []uint64{})
+	require.NoError(err)
+	require.Equal(Errors[ErrPoStTooLate], res.ExecutionError)
+}
+
+func TestLatePoStFee(t *testing.T) {
+	assert := assert.New(t)
+
+	collateral := types.NewAttoFILFromFIL(100)
+	end := types.NewBlockHeight(1000)
+
+	assert.True(LatePoStFee(collateral, end, end).IsZero())
+	assert.True(LatePoStFee(collateral, end, end.Add(GracePeriodBlocks)).Equal(collateral))
+	assert.True(LatePoStFee(collateral, end, end.Add(GracePeriodBlocks).Add(types.NewBlockHeight(1))).Equal(collateral))
+
+	quarter := types.NewBlockHeight(GracePeriodBlocks.AsBigInt().Uint64() / 4)
+	assert.True(LatePoStFee(collateral, end, end.Add(quarter)).Equal(types.NewAttoFILFromFIL(25)))
+}
+
+func TestMinerSectorExpiration(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	st, vms := core.CreateStorages(ctx, t)
+
+	// 100 sectors pledged with 100 FIL of collateral
+	minerAddr := createTestMiner(assert.New(t), st, vms, address.TestAddress, []byte("my public key"), th.RequireRandomPeerID())
+
+	loadMiner := func(t *testing.T) (*State, exec.Storage) {
+		act, err := st.GetActor(ctx, minerAddr)
+		require.NoError(err)
+		storage := vms.NewStorage(minerAddr, act)
+		minerState, err := LoadState(storage)
+		require.NoError(err)
+		return minerState, storage
+	}
+
+	totalStorage := func(t *testing.T) *types.BytesAmount {
+		res, err := th.CreateAndApplyTestMessage(t, st, vms, address.StorageMarketAddress, 0, 9, "getTotalStorage")
+		require.NoError(err)
+		require.NoError(res.ExecutionError)
+		return types.NewBytesAmountFromBytes(res.Receipt.Return[0])
+	}
+
+	// publish a deal lasting 100 blocks
+	signer := types.NewMockSigner(types.MustGenerateKeyInfo(1, types.GenerateKeyInfoSeed()))
+	sig, err := storagemarket.SignDeal(minerAddr, types.SomeCid(), types.NewBytesAmount(1000), types.NewBlockHeight(100), types.NewAttoFILFromFIL(5), signer.Addresses[0], signer)
+	require.NoError(err)
+	pdata := actor.MustConvertParams(minerAddr, signer.Addresses[0], types.SomeCid().Bytes(), types.NewBytesAmount(1000), types.NewBlockHeight(100), types.NewAttoFILFromFIL(5), []byte(sig))
+	msg := types.NewMessage(address.TestAddress, address.StorageMarketAddress, 0, types.NewAttoFILFromFIL(2), "publishDeal", pdata)
+	res, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(1))
+	require.NoError(err)
+	require.NoError(res.ExecutionError)
+
+	// commit a sector holding the deal, and one without deals
+	res, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 3, "commitSector", uint64(1), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(int(proofs.SealBytesLen)), []uint64{0})
+	require.NoError(err)
+	require.NoError(res.ExecutionError)
+	res, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 4, "commitSector", uint64(2), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(int(proofs.SealBytesLen)), []uint64{})
+	require.NoError(err)
+	require.NoError(res.ExecutionError)
+
+	t.Run("a sector expires when its longest deal ends", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		_, storage := loadMiner(t)
+		expirations, err := LoadSectorExpirations(ctx, storage)
+		require.NoError(err)
+		require.Len(expirations, 2)
+		assert.True(types.NewBlockHeight(103).Equal(expirations["1"]))
+		assert.True(types.NewBlockHeight(4).Add(SectorLifetimeWithoutDeals).Equal(expirations["2"]))
+	})
+
+	t.Run("expired sectors are removed once their proving period is proven", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		proof := th.MakeRandomPoSTProofForTest()
+		res, err := submitPoSt(st, vms, minerAddr, 8, tipSetsAtHeights(require, 7, 6, 5, 4, 3, 2, 1, 0), proof[:], []uint64{})
+		require.NoError(err)
+		require.NoError(res.ExecutionError)
+
+		minerState, storage := loadMiner(t)
+		assert.True(th.SectorSize().Equal(minerState.Power))
+		assert.True(th.SectorSize().Equal(totalStorage(t)))
+
+		commitments, err := LoadSectorCommitments(ctx, storage)
+		require.NoError(err)
+		require.Len(commitments, 1)
+		assert.Contains(commitments, "2")
+
+		expirations, err := LoadSectorExpirations(ctx, storage)
+		require.NoError(err)
+		assert.Len(expirations, 1)
+		assert.Contains(expirations, "2")
+
+		market, err := st.GetActor(ctx, address.StorageMarketAddress)
+		require.NoError(err)
+		deals, err := storagemarket.LoadDeals(ctx, vms.NewStorage(address.StorageMarketAddress, market))
+		require.NoError(err)
+		require.Len(deals, 1)
+		assert.True(deals[0].Ended)
+
+		// the collateral of the deal is returned to the owner
+		assert.True(market.Balance.IsZero())
+	})
+
+	t.Run("only the owner may terminate a committed sector", func(t *testing.T) {
+		require := require.New(t)
+
+		msg := types.NewMessage(address.TestAddress2, minerAddr, core.MustGetNonce(st, address.TestAddress2), types.NewZeroAttoFIL(), "terminateSector", actor.MustConvertParams(uint64(2)))
+		res, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(9))
+		require.NoError(err)
+		require.Equal(Errors[ErrCallerUnauthorized], res.ExecutionError)
+
+		res, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 9, "terminateSector", uint64(1))
+		require.NoError(err)
+		require.Equal(Errors[ErrSectorNotCommitted], res.ExecutionError)
+	})
+
+	t.Run("terminating a sector removes its power at a penalty", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 9, "terminateSector", uint64(2))
+		require.NoError(err)
+		require.NoError(res.ExecutionError)
+
+		minerState, storage := loadMiner(t)
+		assert.True(minerState.Power.IsZero())
+		assert.True(totalStorage(t).IsZero())
+		assert.True(types.NewAttoFILFromFIL(99).Equal(minerState.Collateral))
+
+		commitments, err := LoadSectorCommitments(ctx, storage)
+		require.NoError(err)
+		assert.Len(commitments, 0)
+	})
+}
+
+func TestSectorTerminationPenalty(t *testing.T) {
+	assert := assert.New(t)
+
+	collateral := types.NewAttoFILFromFIL(100)
+
+	assert.True(SectorTerminationPenalty(collateral, big.NewInt(100)).Equal(types.NewAttoFILFromFIL(1)))
+	assert.True(SectorTerminationPenalty(collateral, big.NewInt(1)).Equal(collateral))
+	assert.True(SectorTerminationPenalty(collateral, big.NewInt(0)).Equal(collateral))
+}
+
+// submitPoSt applies a submitPoSt message from the test address to the miner
+// at the given height, with the given ancestors to sample randomness from.
+func submitPoSt(st state.Tree, vms vm.StorageMap, minerAddr address.Address, height uint64, ancestors []types.TipSet, proof []byte, faults []uint64) (*consensus.ApplicationResult, error) {
+	msg := types.NewMessage(address.TestAddress, minerAddr, 0, types.NewZeroAttoFIL(), "submitPoSt", actor.MustConvertParams(proof, faults))
+	return th.ApplyTestMessageWithAncestors(st, vms, msg, types.NewBlockHeight(height), ancestors)
+}
+
+// tipSetsAtHeights returns a tipset for each of the given heights, each with a
+// ticket of its own.
+func tipSetsAtHeights(require *require.Assertions, heights ...uint64) []types.TipSet {
+	var tipSets []types.TipSet
+	for _, h := range heights {
+		blk := &types.Block{Height: types.Uint64(h), Ticket: []byte(strconv.FormatUint(h, 10))}
+		tipSets = append(tipSets, types.RequireNewTipSet(require, blk))
+	}
+	return tipSets
+}
+
+func TestMinerCollateralAndPledge(t *testing.T) {
+	ctx := context.Background()
+	st, vms := core.CreateStorages(ctx, t)
+
+	// 100 sectors pledged with 100 FIL of collateral
+	minerAddr := createTestMiner(assert.New(t), st, vms, address.TestAddress, []byte("my public key"), th.RequireRandomPeerID())
+
+	loadMiner := func(t *testing.T) (*State, *actor.Actor) {
+		act, err := st.GetActor(ctx, minerAddr)
+		require.NoError(t, err)
+		minerState, err := LoadState(vms.NewStorage(minerAddr, act))
+		require.NoError(t, err)
+		return minerState, act
+	}
+
+	t.Run("the owner can add collateral", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 10, 0, "addCollateral")
+		require.NoError(err)
+		require.NoError(res.ExecutionError)
+
+		minerState, act := loadMiner(t)
+		assert.True(types.NewAttoFILFromFIL(110).Equal(minerState.Collateral))
+		assert.True(types.NewAttoFILFromFIL(110).Equal(act.Balance))
+	})
+
+	t.Run("the owner can increase the pledge the collateral covers", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 0, "increasePledge", big.NewInt(1000))
+		require.NoError(err)
+		require.NoError(res.ExecutionError)
+
+		minerState, _ := loadMiner(t)
+		assert.Equal(uint64(1100), minerState.PledgeSectors.Uint64())
+
+		res, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 0, "increasePledge", big.NewInt(1000000))
+		require.NoError(err)
+		require.Error(res.ExecutionError)
+		assert.Equal(Errors[ErrInsufficientCollateral], res.ExecutionError)
+		assert.Equal(uint8(ErrInsufficientCollateral), res.Receipt.ExitCode)
+	})
+
+	t.Run("the owner can withdraw collateral the pledge does not need", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 0, "withdrawCollateral", types.NewAttoFILFromFIL(100))
+		require.NoError(err)
+		require.NoError(res.ExecutionError)
+
+		minerState, act := loadMiner(t)
+		assert.True(types.NewAttoFILFromFIL(10).Equal(minerState.Collateral))
+		assert.True(types.NewAttoFILFromFIL(10).Equal(act.Balance))
+
+		// 1100 sectors need 1.1 FIL of collateral
+		res, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 0, "withdrawCollateral", types.NewAttoFILFromFIL(9))
+		require.NoError(err)
+		require.Error(res.ExecutionError)
+		assert.Equal(Errors[ErrInsufficientCollateral], res.ExecutionError)
+	})
+
+	t.Run("only the owner may change collateral or pledge", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		for _, call := range []struct {
+			method string
+			params []interface{}
+		}{
+			{"addCollateral", nil},
+			{"increasePledge", []interface{}{big.NewInt(1)}},
+			{"withdrawCollateral", []interface{}{types.NewAttoFILFromFIL(1)}},
+		} {
+			msg := types.NewMessage(address.TestAddress2, minerAddr, core.MustGetNonce(st, address.TestAddress2), types.NewZeroAttoFIL(), call.method, actor.MustConvertParams(call.params...))
+			res, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(0))
+			require.NoError(err)
+			assert.Equal(Errors[ErrCallerUnauthorized], res.ExecutionError, call.method)
+		}
+	})
 }
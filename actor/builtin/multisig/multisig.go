@@ -0,0 +1,437 @@
+package multisig
+
+import (
+	"math/big"
+
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	cbor "gx/ipfs/QmRoARq3nkUb13HSKZGepCZSWe5GrVPwx7xURJGZ7KWv9V/go-ipld-cbor"
+	xerrors "gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm/errors"
+)
+
+const (
+	// ErrNotSigner indicates an address that is not a signer of the wallet.
+	ErrNotSigner = 33
+	// ErrUnknownTransaction indicates an invalid transaction id.
+	ErrUnknownTransaction = 34
+	// ErrAlreadyApproved indicates a signer approving the same transaction twice.
+	ErrAlreadyApproved = 35
+	// ErrNotProposer indicates an attempt to cancel a transaction by someone other than its proposer.
+	ErrNotProposer = 36
+	// ErrInvalidRequirement indicates a number of required approvals that cannot be met.
+	ErrInvalidRequirement = 37
+	// ErrDuplicateSigner indicates an attempt to add an existing signer.
+	ErrDuplicateSigner = 38
+	// ErrFundsLocked indicates a transaction that would spend funds which have not vested yet.
+	ErrFundsLocked = 39
+	// ErrUnknownMethod indicates a transaction to the wallet itself with a method it does not have.
+	ErrUnknownMethod = 40
+)
+
+// Errors map error codes to revert errors this actor may return.
+var Errors = map[uint8]error{
+	ErrNotSigner:          errors.NewCodedRevertError(ErrNotSigner, "address is not a signer"),
+	ErrUnknownTransaction: errors.NewCodedRevertError(ErrUnknownTransaction, "transaction is unknown"),
+	ErrAlreadyApproved:    errors.NewCodedRevertError(ErrAlreadyApproved, "transaction has already been approved by this signer"),
+	ErrNotProposer:        errors.NewCodedRevertError(ErrNotProposer, "only the proposer may cancel a transaction"),
+	ErrInvalidRequirement: errors.NewCodedRevertError(ErrInvalidRequirement, "required approvals must be between 1 and the number of signers"),
+	ErrDuplicateSigner:    errors.NewCodedRevertError(ErrDuplicateSigner, "address is already a signer"),
+	ErrFundsLocked:        errors.NewCodedRevertError(ErrFundsLocked, "transaction would spend funds that are still locked"),
+	ErrUnknownMethod:      errors.NewCodedRevertError(ErrUnknownMethod, "wallet has no such method"),
+}
+
+// TransactionExecutedTopic is the topic of the event emitted when a
+// transaction has been approved by enough signers and is executed. Its values
+// are the transaction id, the recipient and the value of the transaction.
+const TransactionExecutedTopic = "transactionExecuted"
+
+func init() {
+	cbor.RegisterCborType(State{})
+	cbor.RegisterCborType(Transaction{})
+}
+
+// Actor is a wallet whose funds can only be spent with the approval of a
+// number of its signers. Any signer may propose a transaction, which is
+// executed once the required number of signers approved it. Funds may
+// additionally vest linearly over a number of blocks after creation.
+type Actor struct{}
+
+// Transaction is a message the wallet will send once enough signers approve
+// it. Transactions to the wallet itself change its signers or the number of
+// required approvals.
+type Transaction struct {
+	Proposer address.Address `json:"proposer"`
+	To       address.Address `json:"to"`
+	Value    *types.AttoFIL  `json:"value"`
+	Method   string          `json:"method"`
+	// Params are the abi encoded parameters of the message.
+	Params   []byte            `json:"params"`
+	Approved []address.Address `json:"approved"`
+}
+
+// State is the multisig wallet's storage.
+type State struct {
+	Signers []address.Address
+	// Required is the number of signers that must approve a transaction
+	// before it is executed.
+	Required uint64
+
+	// Transactions maps the ids of pending transactions to the transaction.
+	// Due to a bug in refmt, the ids need to be stringified.
+	//
+	// See also: https://github.com/polydawn/refmt/issues/35
+	Transactions map[string]*Transaction
+	NextTxID     *big.Int
+
+	// InitialBalance is locked at StartHeight, and unlocks linearly over
+	// the following UnlockDuration blocks. A zero duration locks nothing.
+	InitialBalance *types.AttoFIL
+	StartHeight    *types.BlockHeight
+	UnlockDuration *types.BlockHeight
+}
+
+// NewState creates a multisig state struct.
+func NewState(signers []address.Address, required uint64, initialBalance *types.AttoFIL, start, unlockDuration *types.BlockHeight) *State {
+	return &State{
+		Signers:        signers,
+		Required:       required,
+		Transactions:   make(map[string]*Transaction),
+		NextTxID:       big.NewInt(0),
+		InitialBalance: initialBalance,
+		StartHeight:    start,
+		UnlockDuration: unlockDuration,
+	}
+}
+
+// LoadState decodes the state of a multisig actor from its storage, without
+// executing any actor code.
+func LoadState(storage exec.Storage) (*State, error) {
+	var state State
+	if err := actor.ReadState(storage, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// NewActor returns a new multisig actor.
+func NewActor() *actor.Actor {
+	return actor.NewActor(types.MultisigActorCodeCid, types.NewZeroAttoFIL())
+}
+
+// IsSigner returns true if addr is a signer of the wallet.
+func (st *State) IsSigner(addr address.Address) bool {
+	return indexOf(st.Signers, addr) >= 0
+}
+
+// LockedBalance returns the part of the initial balance that has not vested
+// at the given height.
+func (st *State) LockedBalance(height *types.BlockHeight) *types.AttoFIL {
+	if st.UnlockDuration == nil || st.UnlockDuration.Equal(types.NewBlockHeight(0)) {
+		return types.NewZeroAttoFIL()
+	}
+
+	elapsed := types.NewBlockHeight(0)
+	if height.GreaterThan(st.StartHeight) {
+		elapsed = height.Sub(st.StartHeight)
+	}
+	if elapsed.GreaterEqual(st.UnlockDuration) {
+		return types.NewZeroAttoFIL()
+	}
+
+	// round up, so that nothing unlocks early
+	remaining := st.UnlockDuration.Sub(elapsed)
+	return st.InitialBalance.MulBigInt(remaining.AsBigInt()).DivCeil(types.NewAttoFIL(st.UnlockDuration.AsBigInt()))
+}
+
+// InitializeState stores the wallet's initial data structure.
+func (ma *Actor) InitializeState(storage exec.Storage, initializerData interface{}) error {
+	msigState, ok := initializerData.(*State)
+	if !ok {
+		return errors.NewFaultError("Initial state to multisig actor is not a multisig.State struct")
+	}
+
+	for i, signer := range msigState.Signers {
+		if indexOf(msigState.Signers[:i], signer) >= 0 {
+			return Errors[ErrDuplicateSigner]
+		}
+	}
+	if msigState.Required == 0 || msigState.Required > uint64(len(msigState.Signers)) {
+		return Errors[ErrInvalidRequirement]
+	}
+
+	stateBytes, err := cbor.DumpObject(msigState)
+	if err != nil {
+		return xerrors.Wrap(err, "failed to cbor marshal object")
+	}
+
+	id, err := storage.Put(stateBytes)
+	if err != nil {
+		return err
+	}
+
+	return storage.Commit(id, cid.Undef)
+}
+
+var _ exec.ExecutableActor = (*Actor)(nil)
+
+// Exports returns the actor's exports.
+func (ma *Actor) Exports() exec.Exports {
+	return multisigExports
+}
+
+var multisigExports = exec.Exports{
+	"propose": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Address, abi.AttoFIL, abi.String, abi.Bytes},
+		Return: []abi.Type{abi.Integer},
+	},
+	"approve": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Integer},
+		Return: nil,
+	},
+	"cancel": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Integer},
+		Return: nil,
+	},
+}
+
+// SelfMethods describe the methods transactions to the wallet itself may
+// call. The VM does not support actors sending messages to themselves, so
+// these are applied to the wallet's state directly when such a transaction
+// is executed, and cannot be called by anyone else.
+var SelfMethods = exec.Exports{
+	"addSigner": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Address},
+		Return: nil,
+	},
+	"removeSigner": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Address},
+		Return: nil,
+	},
+	"changeRequirement": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Integer},
+		Return: nil,
+	},
+}
+
+// Propose proposes a transaction and approves it on behalf of the caller,
+// who must be a signer. The transaction is executed right away if that is
+// enough approvals. It returns the id of the transaction.
+func (ma *Actor) Propose(vmctx exec.VMContext, to address.Address, value *types.AttoFIL, method string, params []byte) (*big.Int, uint8, error) {
+	if err := vmctx.Charge(100); err != nil {
+		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	ret, err := actor.WithState(vmctx, &state, func() (interface{}, error) {
+		proposer := vmctx.Message().From
+		if !state.IsSigner(proposer) {
+			return nil, Errors[ErrNotSigner]
+		}
+		if to == vmctx.Message().To && !SelfMethods.Has(method) {
+			return nil, Errors[ErrUnknownMethod]
+		}
+
+		txID := state.NextTxID
+		state.NextTxID = big.NewInt(0).Add(txID, big.NewInt(1))
+
+		tx := &Transaction{
+			Proposer: proposer,
+			To:       to,
+			Value:    value,
+			Method:   method,
+			Params:   params,
+			Approved: []address.Address{proposer},
+		}
+		state.Transactions[txID.String()] = tx
+
+		if err := executeIfApproved(vmctx, &state, txID); err != nil {
+			return nil, err
+		}
+
+		return txID, nil
+	})
+	if err != nil {
+		return nil, errors.CodeError(err), err
+	}
+
+	return ret.(*big.Int), 0, nil
+}
+
+// Approve approves a pending transaction on behalf of the caller, who must be
+// a signer. The transaction is executed once the required number of signers
+// approved it. If it fails, the approval is reverted along with it.
+func (ma *Actor) Approve(vmctx exec.VMContext, txID *big.Int) (uint8, error) {
+	if err := vmctx.Charge(100); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	_, err := actor.WithState(vmctx, &state, func() (interface{}, error) {
+		signer := vmctx.Message().From
+		if !state.IsSigner(signer) {
+			return nil, Errors[ErrNotSigner]
+		}
+
+		tx, ok := state.Transactions[txID.String()]
+		if !ok {
+			return nil, Errors[ErrUnknownTransaction]
+		}
+		if indexOf(tx.Approved, signer) >= 0 {
+			return nil, Errors[ErrAlreadyApproved]
+		}
+		tx.Approved = append(tx.Approved, signer)
+
+		return nil, executeIfApproved(vmctx, &state, txID)
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
+// Cancel removes a pending transaction. Only its proposer may cancel it.
+func (ma *Actor) Cancel(vmctx exec.VMContext, txID *big.Int) (uint8, error) {
+	if err := vmctx.Charge(100); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	_, err := actor.WithState(vmctx, &state, func() (interface{}, error) {
+		tx, ok := state.Transactions[txID.String()]
+		if !ok {
+			return nil, Errors[ErrUnknownTransaction]
+		}
+		if tx.Proposer != vmctx.Message().From {
+			return nil, Errors[ErrNotProposer]
+		}
+
+		delete(state.Transactions, txID.String())
+		return nil, nil
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
+// executeIfApproved executes and removes the transaction with the given id if
+// enough signers approved it.
+func executeIfApproved(vmctx exec.VMContext, state *State, txID *big.Int) error {
+	tx := state.Transactions[txID.String()]
+	if uint64(len(tx.Approved)) < state.Required {
+		return nil
+	}
+	delete(state.Transactions, txID.String())
+
+	self := vmctx.Message().To
+	if tx.To == self {
+		if err := applySelfMethod(state, tx); err != nil {
+			return err
+		}
+	} else {
+		available := vmctx.MyBalance().Sub(state.LockedBalance(vmctx.BlockHeight()))
+		if tx.Value.GreaterThan(available) {
+			return Errors[ErrFundsLocked]
+		}
+
+		params, err := forwardParams(tx.Params)
+		if err != nil {
+			return errors.RevertErrorWrap(err, "could not decode transaction params")
+		}
+
+		_, code, err := vmctx.Send(tx.To, tx.Method, tx.Value, params)
+		if err != nil {
+			return err
+		}
+		if code != 0 {
+			return errors.NewRevertErrorf("transaction to %s failed with exit code %d", tx.To, code)
+		}
+	}
+
+	return vmctx.EmitEvent(TransactionExecutedTopic, txID, tx.To, tx.Value)
+}
+
+// applySelfMethod applies a transaction to the wallet itself to its state.
+func applySelfMethod(state *State, tx *Transaction) error {
+	sig, ok := SelfMethods[tx.Method]
+	if !ok {
+		return Errors[ErrUnknownMethod]
+	}
+	vals, err := abi.DecodeValues(tx.Params, sig.Params)
+	if err != nil {
+		return errors.RevertErrorWrap(err, "could not decode transaction params")
+	}
+	if len(vals) != len(sig.Params) {
+		return errors.NewRevertErrorf("expected %d parameters, but got %d", len(sig.Params), len(vals))
+	}
+
+	switch tx.Method {
+	case "addSigner":
+		signer := vals[0].Val.(address.Address)
+		if state.IsSigner(signer) {
+			return Errors[ErrDuplicateSigner]
+		}
+		state.Signers = append(state.Signers, signer)
+	case "removeSigner":
+		signer := vals[0].Val.(address.Address)
+		i := indexOf(state.Signers, signer)
+		if i < 0 {
+			return Errors[ErrNotSigner]
+		}
+		state.Signers = append(state.Signers[:i:i], state.Signers[i+1:]...)
+		if state.Required > uint64(len(state.Signers)) {
+			return Errors[ErrInvalidRequirement]
+		}
+		// approvals of a former signer no longer count
+		for _, pending := range state.Transactions {
+			if j := indexOf(pending.Approved, signer); j >= 0 {
+				pending.Approved = append(pending.Approved[:j:j], pending.Approved[j+1:]...)
+			}
+		}
+	case "changeRequirement":
+		required := vals[0].Val.(*big.Int)
+		if !required.IsUint64() || required.Uint64() == 0 || required.Uint64() > uint64(len(state.Signers)) {
+			return Errors[ErrInvalidRequirement]
+		}
+		state.Required = required.Uint64()
+	}
+	return nil
+}
+
+// forwardParams splits abi encoded params into their encoded values, which
+// the VM encodes back to the same bytes when sending them on as abi.Bytes.
+// This lets the wallet send messages without knowing the signature of the
+// method it calls.
+func forwardParams(data []byte) ([]interface{}, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var arr [][]byte
+	if err := cbor.DecodeInto(data, &arr); err != nil {
+		return nil, err
+	}
+
+	params := make([]interface{}, 0, len(arr))
+	for _, v := range arr {
+		params = append(params, v)
+	}
+	return params, nil
+}
+
+func indexOf(addrs []address.Address, addr address.Address) int {
+	for i, a := range addrs {
+		if a == addr {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,242 @@
+package multisig_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin"
+	. "github.com/filecoin-project/go-filecoin/actor/builtin/multisig"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/core"
+	"github.com/filecoin-project/go-filecoin/state"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultisigCreate(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	st, vms := core.CreateStorages(ctx, t)
+	signers := []address.Address{address.TestAddress, address.TestAddress2}
+	msigAddr := requireCreateMultisig(t, st, vms, signers, 2, types.NewAttoFILFromFIL(100), 0)
+
+	msigActor, err := st.GetActor(ctx, msigAddr)
+	require.NoError(err)
+	assert.Equal(types.MultisigActorCodeCid, msigActor.Code)
+	assert.Equal(types.NewAttoFILFromFIL(100), msigActor.Balance)
+
+	msigState := requireState(t, st, vms, msigAddr)
+	assert.Equal(signers, msigState.Signers)
+	assert.Equal(uint64(2), msigState.Required)
+	assert.Empty(msigState.Transactions)
+}
+
+func TestMultisigCreateInvalid(t *testing.T) {
+	ctx := context.Background()
+	signers := []address.Address{address.TestAddress, address.TestAddress2}
+
+	t.Run("more required approvals than signers", func(t *testing.T) {
+		st, vms := core.CreateStorages(ctx, t)
+		result := applyMessage(t, st, vms, address.TestAddress, address.StorageMarketAddress, types.NewAttoFILFromFIL(1), "createMultisig", signers, big.NewInt(3), types.NewBlockHeight(0))
+		requireError(t, result, Errors[ErrInvalidRequirement])
+	})
+
+	t.Run("duplicate signers", func(t *testing.T) {
+		st, vms := core.CreateStorages(ctx, t)
+		duplicates := []address.Address{address.TestAddress, address.TestAddress}
+		result := applyMessage(t, st, vms, address.TestAddress, address.StorageMarketAddress, types.NewAttoFILFromFIL(1), "createMultisig", duplicates, big.NewInt(1), types.NewBlockHeight(0))
+		requireError(t, result, Errors[ErrDuplicateSigner])
+	})
+}
+
+func TestMultisigProposeAndApprove(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	st, vms := core.CreateStorages(ctx, t)
+	signers := []address.Address{address.TestAddress, address.TestAddress2}
+	msigAddr := requireCreateMultisig(t, st, vms, signers, 2, types.NewAttoFILFromFIL(100), 0)
+	target := address.NewForTestGetter()()
+
+	result := applyMessage(t, st, vms, address.TestAddress, msigAddr, types.NewZeroAttoFIL(), "propose", target, types.NewAttoFILFromFIL(10), "", []byte{})
+	require.NoError(result.ExecutionError)
+	txID := big.NewInt(0).SetBytes(result.Receipt.Return[0])
+
+	msigState := requireState(t, st, vms, msigAddr)
+	require.Contains(msigState.Transactions, txID.String())
+	assert.Equal([]address.Address{address.TestAddress}, msigState.Transactions[txID.String()].Approved)
+
+	t.Run("only signers may approve", func(t *testing.T) {
+		result := applyMessage(t, st, vms, address.NetworkAddress, msigAddr, types.NewZeroAttoFIL(), "approve", txID)
+		requireError(t, result, Errors[ErrNotSigner])
+	})
+
+	t.Run("signers may not approve twice", func(t *testing.T) {
+		result := applyMessage(t, st, vms, address.TestAddress, msigAddr, types.NewZeroAttoFIL(), "approve", txID)
+		requireError(t, result, Errors[ErrAlreadyApproved])
+	})
+
+	t.Run("executes the transaction once approved", func(t *testing.T) {
+		result := applyMessage(t, st, vms, address.TestAddress2, msigAddr, types.NewZeroAttoFIL(), "approve", txID)
+		require.NoError(result.ExecutionError)
+
+		targetActor, err := st.GetActor(ctx, target)
+		require.NoError(err)
+		assert.Equal(types.NewAttoFILFromFIL(10), targetActor.Balance)
+
+		msigActor, err := st.GetActor(ctx, msigAddr)
+		require.NoError(err)
+		assert.Equal(types.NewAttoFILFromFIL(90), msigActor.Balance)
+
+		assert.Empty(requireState(t, st, vms, msigAddr).Transactions)
+
+		require.Len(result.Receipt.Events, 1)
+		assert.Equal(TransactionExecutedTopic, result.Receipt.Events[0].Topic)
+		vals, err := abi.DecodeValues(result.Receipt.Events[0].Data, []abi.Type{abi.Integer, abi.Address, abi.AttoFIL})
+		require.NoError(err)
+		assert.Equal(0, txID.Cmp(vals[0].Val.(*big.Int)))
+		assert.Equal(target, vals[1].Val)
+	})
+
+	t.Run("executed transactions are gone", func(t *testing.T) {
+		result := applyMessage(t, st, vms, address.TestAddress2, msigAddr, types.NewZeroAttoFIL(), "approve", txID)
+		requireError(t, result, Errors[ErrUnknownTransaction])
+	})
+}
+
+func TestMultisigCancel(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	st, vms := core.CreateStorages(ctx, t)
+	signers := []address.Address{address.TestAddress, address.TestAddress2}
+	msigAddr := requireCreateMultisig(t, st, vms, signers, 2, types.NewAttoFILFromFIL(100), 0)
+
+	result := applyMessage(t, st, vms, address.TestAddress, msigAddr, types.NewZeroAttoFIL(), "propose", address.TestAddress2, types.NewAttoFILFromFIL(10), "", []byte{})
+	require.NoError(result.ExecutionError)
+	txID := big.NewInt(0).SetBytes(result.Receipt.Return[0])
+
+	result = applyMessage(t, st, vms, address.TestAddress2, msigAddr, types.NewZeroAttoFIL(), "cancel", txID)
+	requireError(t, result, Errors[ErrNotProposer])
+
+	result = applyMessage(t, st, vms, address.TestAddress, msigAddr, types.NewZeroAttoFIL(), "cancel", txID)
+	require.NoError(result.ExecutionError)
+	assert.Empty(requireState(t, st, vms, msigAddr).Transactions)
+}
+
+func TestMultisigChangeSigners(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	st, vms := core.CreateStorages(ctx, t)
+	msigAddr := requireCreateMultisig(t, st, vms, []address.Address{address.TestAddress}, 1, types.NewAttoFILFromFIL(100), 0)
+
+	proposeToSelf := func(from address.Address, method string, params ...interface{}) *consensus.ApplicationResult {
+		encoded, err := abi.ToEncodedValues(params...)
+		require.NoError(err)
+		return applyMessage(t, st, vms, from, msigAddr, types.NewZeroAttoFIL(), "propose", msigAddr, types.NewZeroAttoFIL(), method, encoded)
+	}
+
+	// a single approval is enough to execute these right away
+	result := proposeToSelf(address.TestAddress, "addSigner", address.TestAddress2)
+	require.NoError(result.ExecutionError)
+	result = proposeToSelf(address.TestAddress, "changeRequirement", big.NewInt(2))
+	require.NoError(result.ExecutionError)
+
+	msigState := requireState(t, st, vms, msigAddr)
+	assert.Equal([]address.Address{address.TestAddress, address.TestAddress2}, msigState.Signers)
+	assert.Equal(uint64(2), msigState.Required)
+
+	t.Run("cannot require more approvals than signers", func(t *testing.T) {
+		result := proposeToSelf(address.TestAddress, "changeRequirement", big.NewInt(3))
+		require.NoError(result.ExecutionError)
+		txID := big.NewInt(0).SetBytes(result.Receipt.Return[0])
+
+		result = applyMessage(t, st, vms, address.TestAddress2, msigAddr, types.NewZeroAttoFIL(), "approve", txID)
+		requireError(t, result, Errors[ErrInvalidRequirement])
+	})
+
+	t.Run("rejects unknown methods", func(t *testing.T) {
+		result := proposeToSelf(address.TestAddress, "addOwner", address.TestAddress2)
+		requireError(t, result, Errors[ErrUnknownMethod])
+	})
+}
+
+func TestMultisigVesting(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	st, vms := core.CreateStorages(ctx, t)
+	msigAddr := requireCreateMultisig(t, st, vms, []address.Address{address.TestAddress}, 1, types.NewAttoFILFromFIL(100), 100)
+	target := address.NewForTestGetter()()
+
+	propose := func(value *types.AttoFIL, height uint64) *consensus.ApplicationResult {
+		msg := types.NewMessage(address.TestAddress, msigAddr, 0, types.NewZeroAttoFIL(), "propose", actor.MustConvertParams(target, value, "", []byte{}))
+		result, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(height))
+		require.NoError(err)
+		return result
+	}
+
+	// half of the funds unlocked after half of the duration
+	requireError(t, propose(types.NewAttoFILFromFIL(60), 50), Errors[ErrFundsLocked])
+	require.NoError(propose(types.NewAttoFILFromFIL(50), 50).ExecutionError)
+	requireError(t, propose(types.NewAttoFILFromFIL(1), 50), Errors[ErrFundsLocked])
+
+	// everything unlocked after the duration
+	require.NoError(propose(types.NewAttoFILFromFIL(50), 100).ExecutionError)
+}
+
+func TestLockedBalance(t *testing.T) {
+	assert := assert.New(t)
+
+	msigState := NewState(nil, 1, types.NewAttoFILFromFIL(10), types.NewBlockHeight(100), types.NewBlockHeight(4))
+	assert.Equal(types.NewAttoFILFromFIL(10), msigState.LockedBalance(types.NewBlockHeight(99)))
+	assert.Equal(types.NewAttoFILFromFIL(10), msigState.LockedBalance(types.NewBlockHeight(100)))
+	assert.Equal(types.NewAttoFILFromFIL(5), msigState.LockedBalance(types.NewBlockHeight(102)))
+	assert.True(msigState.LockedBalance(types.NewBlockHeight(104)).IsZero())
+
+	unlocked := NewState(nil, 1, types.NewAttoFILFromFIL(10), types.NewBlockHeight(100), types.NewBlockHeight(0))
+	assert.True(unlocked.LockedBalance(types.NewBlockHeight(100)).IsZero())
+}
+
+func requireCreateMultisig(t *testing.T, st state.Tree, vms vm.StorageMap, signers []address.Address, required int64, value *types.AttoFIL, unlockDuration uint64) address.Address {
+	result := applyMessage(t, st, vms, address.TestAddress, address.StorageMarketAddress, value, "createMultisig", signers, big.NewInt(required), types.NewBlockHeight(unlockDuration))
+	require.NoError(t, result.ExecutionError)
+
+	msigAddr, err := address.NewFromBytes(result.Receipt.Return[0])
+	require.NoError(t, err)
+	return msigAddr
+}
+
+func requireState(t *testing.T, st state.Tree, vms vm.StorageMap, msigAddr address.Address) *State {
+	msigActor, err := st.GetActor(context.Background(), msigAddr)
+	require.NoError(t, err)
+
+	var msigState State
+	builtin.RequireReadState(t, vms, msigAddr, msigActor, &msigState)
+	return &msigState
+}
+
+func applyMessage(t *testing.T, st state.Tree, vms vm.StorageMap, from, to address.Address, value *types.AttoFIL, method string, params ...interface{}) *consensus.ApplicationResult {
+	msg := types.NewMessage(from, to, 0, value, method, actor.MustConvertParams(params...))
+	result, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(0))
+	require.NoError(t, err)
+	return result
+}
+
+func requireError(t *testing.T, result *consensus.ApplicationResult, expected error) {
+	require.NotNil(t, result.ExecutionError)
+	assert.Contains(t, result.ExecutionError.Error(), expected.Error())
+}
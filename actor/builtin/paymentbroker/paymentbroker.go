@@ -2,6 +2,7 @@ package paymentbroker
 
 import (
 	"context"
+	"sort"
 
 	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
 	"gx/ipfs/QmRXf2uUSdGSunRJsM9wXSUNVwLUGCY3So5fAs7h2CBJVf/go-hamt-ipld"
@@ -40,6 +41,17 @@ const (
 	ErrTooEarly = 43
 )
 
+const (
+	// ChannelRedeemedTopic is the topic of the event emitted when a voucher
+	// is redeemed. Its values are the payer, the channel id and the amount
+	// of the voucher.
+	ChannelRedeemedTopic = "channelRedeemed"
+	// ChannelClosedTopic is the topic of the event emitted when a channel is
+	// closed. Its values are the payer, the channel id and the amount of the
+	// final voucher.
+	ChannelClosedTopic = "channelClosed"
+)
+
 // Errors map error codes to revert errors this actor may return.
 var Errors = map[uint8]error{
 	ErrTooEarly:                 errors.NewCodedRevertError(ErrTooEarly, "block height too low to redeem voucher"),
@@ -57,8 +69,19 @@ var Errors = map[uint8]error{
 
 func init() {
 	cbor.RegisterCborType(PaymentChannel{})
+	cbor.RegisterCborType(ChannelListing{})
+}
+
+// ChannelListing is a payment channel of a payer along with its id, as
+// returned by ls.
+type ChannelListing struct {
+	ID      *types.ChannelID `json:"id"`
+	Channel *PaymentChannel  `json:"channel"`
 }
 
+// ChannelListingType is the ABI type of a *ChannelListing.
+var ChannelListingType = abi.RegisterStruct(3, &ChannelListing{})
+
 // PaymentChannel records the intent to pay funds to a target account.
 type PaymentChannel struct {
 	Target         address.Address    `json:"target"`
@@ -101,7 +124,7 @@ var paymentBrokerExports = exec.Exports{
 	},
 	"ls": &exec.FunctionSignature{
 		Params: []abi.Type{abi.Address},
-		Return: []abi.Type{abi.Bytes},
+		Return: []abi.Type{abi.ArrayOf(ChannelListingType)},
 	},
 	"reclaim": &exec.FunctionSignature{
 		Params: []abi.Type{abi.ChannelID},
@@ -187,14 +210,18 @@ func (pb *Actor) Redeem(vmctx exec.VMContext, payer address.Address, chid *types
 		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
-	if !VerifyVoucherSignature(payer, chid, amt, validAt, sig) {
+	valid, err := vmctx.VerifySignature(createVoucherSignatureData(chid, amt, validAt), payer, sig)
+	if err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+	if !valid {
 		return errors.CodeError(Errors[ErrInvalidSignature]), Errors[ErrInvalidSignature]
 	}
 
 	ctx := context.Background()
 	storage := vmctx.Storage()
 
-	err := withPayerChannels(ctx, storage, payer, func(byChannelID exec.Lookup) error {
+	err = withPayerChannels(ctx, storage, payer, func(byChannelID exec.Lookup) error {
 		var channel *PaymentChannel
 
 		chInt, err := byChannelID.Find(ctx, chid.KeyString())
@@ -227,6 +254,10 @@ func (pb *Actor) Redeem(vmctx exec.VMContext, payer address.Address, chid *types
 		return errors.CodeError(err), err
 	}
 
+	if err := vmctx.EmitEvent(ChannelRedeemedTopic, payer, chid, amt); err != nil {
+		return errors.CodeError(err), err
+	}
+
 	return 0, nil
 }
 
@@ -237,14 +268,18 @@ func (pb *Actor) Close(vmctx exec.VMContext, payer address.Address, chid *types.
 		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
-	if !VerifyVoucherSignature(payer, chid, amt, validAt, sig) {
+	valid, err := vmctx.VerifySignature(createVoucherSignatureData(chid, amt, validAt), payer, sig)
+	if err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+	if !valid {
 		return errors.CodeError(Errors[ErrInvalidSignature]), Errors[ErrInvalidSignature]
 	}
 
 	ctx := context.Background()
 	storage := vmctx.Storage()
 
-	err := withPayerChannels(ctx, storage, payer, func(byChannelID exec.Lookup) error {
+	err = withPayerChannels(ctx, storage, payer, func(byChannelID exec.Lookup) error {
 		chInt, err := byChannelID.Find(ctx, chid.KeyString())
 		if err != nil {
 			if err == hamt.ErrNotFound {
@@ -281,6 +316,10 @@ func (pb *Actor) Close(vmctx exec.VMContext, payer address.Address, chid *types.
 		return errors.CodeError(err), err
 	}
 
+	if err := vmctx.EmitEvent(ChannelClosedTopic, payer, chid, amt); err != nil {
+		return errors.CodeError(err), err
+	}
+
 	return 0, nil
 }
 
@@ -443,48 +482,103 @@ func (pb *Actor) Voucher(vmctx exec.VMContext, chid *types.ChannelID, amount *ty
 	return voucherBytes, 0, nil
 }
 
-// Ls returns all payment channels for a given payer address.
-// The slice of channels will be returned as cbor encoded map from string channelId to PaymentChannel.
-func (pb *Actor) Ls(vmctx exec.VMContext, payer address.Address) ([]byte, uint8, error) {
+// Ls returns all payment channels for a given payer address, in order of
+// their ids.
+func (pb *Actor) Ls(vmctx exec.VMContext, payer address.Address) ([]*ChannelListing, uint8, error) {
 	if err := vmctx.Charge(100); err != nil {
-		return []byte{}, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
-	ctx := context.Background()
-	storage := vmctx.Storage()
-	channels := map[string]*PaymentChannel{}
+	channels, err := LoadChannels(context.Background(), vmctx.Storage(), payer)
+	if err != nil {
+		// ensure error is properly wrapped
+		if !errors.IsFault(err) && !errors.ShouldRevert(err) {
+			return nil, 1, errors.FaultErrorWrap(err, "Error reclaiming channel")
+		}
+		return nil, errors.CodeError(err), err
+	}
 
+	listings := make([]*ChannelListing, 0, len(channels))
+	for key, channel := range channels {
+		id, ok := types.NewChannelIDFromString(key, 10)
+		if !ok {
+			return nil, 1, errors.NewFaultErrorf("invalid channel id %s", key)
+		}
+		listings = append(listings, &ChannelListing{ID: id, Channel: channel})
+	}
+	// channel ids are decimal, so shorter ids are lower
+	sort.Slice(listings, func(i, j int) bool {
+		a, b := listings[i].ID.String(), listings[j].ID.String()
+		return len(a) < len(b) || (len(a) == len(b) && a < b)
+	})
+
+	return listings, 0, nil
+}
+
+// LoadChannels returns the payment channels of the payer by channel id from
+// the storage of the payment broker, without executing any actor code.
+func LoadChannels(ctx context.Context, storage exec.Storage, payer address.Address) (map[string]*PaymentChannel, error) {
+	channels := map[string]*PaymentChannel{}
 	err := withPayerChannelsForReading(ctx, storage, payer, func(byChannelID exec.Lookup) error {
-		kvs, err := byChannelID.Values(ctx)
+		return collectChannels(ctx, byChannelID, channels)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
+// LoadState returns the payment channels of every payer, by payer address and
+// channel id, from the storage of the payment broker, without executing any
+// actor code.
+func LoadState(ctx context.Context, storage exec.Storage) (map[string]map[string]*PaymentChannel, error) {
+	state := map[string]map[string]*PaymentChannel{}
+	err := actor.WithLookupForReading(ctx, storage, storage.Head(), func(byPayer exec.Lookup) error {
+		kvs, err := byPayer.Values(ctx)
 		if err != nil {
 			return err
 		}
 
 		for _, kv := range kvs {
-			pc, ok := kv.Value.(*PaymentChannel)
-			if !ok {
-				return errors.NewFaultError("Expected PaymentChannel from channel lookup")
+			payer, err := address.NewFromString(kv.Key)
+			if err != nil {
+				return errors.FaultErrorWrap(err, "Paymentbroker payer is not an address")
+			}
+			byChannelID, err := findByChannelLookup(ctx, storage, byPayer, payer)
+			if err != nil {
+				return err
+			}
+
+			channels := map[string]*PaymentChannel{}
+			if err := collectChannels(ctx, byChannelID, channels); err != nil {
+				return err
 			}
-			channels[kv.Key] = pc
+			state[kv.Key] = channels
 		}
 
 		return nil
 	})
-
 	if err != nil {
-		// ensure error is properly wrapped
-		if !errors.IsFault(err) && !errors.ShouldRevert(err) {
-			return nil, 1, errors.FaultErrorWrap(err, "Error reclaiming channel")
-		}
-		return nil, errors.CodeError(err), err
+		return nil, err
 	}
+	return state, nil
+}
 
-	channelsBytes, err := actor.MarshalStorage(channels)
+func collectChannels(ctx context.Context, byChannelID exec.Lookup, channels map[string]*PaymentChannel) error {
+	kvs, err := byChannelID.Values(ctx)
 	if err != nil {
-		return nil, 1, errors.FaultErrorWrap(err, "Error marshalling voucher")
+		return err
 	}
 
-	return channelsBytes, 0, nil
+	for _, kv := range kvs {
+		pc, ok := kv.Value.(*PaymentChannel)
+		if !ok {
+			return errors.NewFaultError("Expected PaymentChannel from channel lookup")
+		}
+		channels[kv.Key] = pc
+	}
+
+	return nil
 }
 
 func updateChannel(ctx exec.VMContext, target address.Address, channel *PaymentChannel, amt *types.AttoFIL, validAt *types.BlockHeight) error {
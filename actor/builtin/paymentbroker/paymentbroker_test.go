@@ -438,11 +438,16 @@ func TestPaymentBrokerLs(t *testing.T) {
 		require.NoError(err)
 		assert.Equal(uint8(0), exitCode)
 
-		channels := make(map[string]*PaymentChannel)
-		err = cbor.DecodeInto(returnValue[0], &channels)
+		listed, err := abi.Deserialize(returnValue[0], abi.ArrayOf(ChannelListingType))
 		require.NoError(err)
+		listings := listed.Val.([]*ChannelListing)
+		require.Len(listings, 2)
 
-		assert.Equal(2, len(channels))
+		// channels are listed in order of their ids
+		assert.True(channelID1.Equal(listings[0].ID))
+		assert.True(channelID2.Equal(listings[1].ID))
+
+		channels := requireListedChannels(require, returnValue[0])
 
 		pc1, found := channels[channelID1.String()]
 		require.True(found)
@@ -475,14 +480,43 @@ func TestPaymentBrokerLs(t *testing.T) {
 		require.NoError(err)
 		assert.Equal(uint8(0), exitCode)
 
-		channels := make(map[string]*PaymentChannel)
-		err = cbor.DecodeInto(returnValue[0], &channels)
-		require.NoError(err)
-
+		channels := requireListedChannels(require, returnValue[0])
 		assert.Equal(0, len(channels))
 	})
 }
 
+func TestPaymentBrokerLoadState(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	payer := address.TestAddress
+	target := address.NewForTestGetter()()
+	_, st, vms := requireGenesis(ctx, t, target)
+
+	channelID := establishChannel(ctx, st, vms, payer, target, 0, types.NewAttoFILFromFIL(1000), types.NewBlockHeight(10))
+
+	paymentBroker, err := st.GetActor(ctx, address.PaymentBrokerAddress)
+	require.NoError(err)
+	storage := vms.NewStorage(address.PaymentBrokerAddress, paymentBroker)
+
+	channels, err := LoadChannels(ctx, storage, payer)
+	require.NoError(err)
+	require.Len(channels, 1)
+	assert.Equal(target, channels[channelID.KeyString()].Target)
+	assert.Equal(types.NewAttoFILFromFIL(1000), channels[channelID.KeyString()].Amount)
+
+	channels, err = LoadChannels(ctx, storage, target)
+	require.NoError(err)
+	assert.Empty(channels)
+
+	byPayer, err := LoadState(ctx, storage)
+	require.NoError(err)
+	require.Len(byPayer, 1)
+	assert.Equal(target, byPayer[payer.String()][channelID.KeyString()].Target)
+}
+
 func TestNewPaymentBrokerVoucher(t *testing.T) {
 	require := require.New(t)
 	assert := assert.New(t)
@@ -671,10 +705,7 @@ func (sys *system) retrieveChannel(paymentBroker *actor.Actor) *PaymentChannel {
 	require.NoError(err)
 	assert.Equal(uint8(0), exitCode)
 
-	channels := make(map[string]*PaymentChannel)
-	err = cbor.DecodeInto(returnValue[0], &channels)
-	require.NoError(err)
-
+	channels := requireListedChannels(require, returnValue[0])
 	channel := channels[sys.channelID.KeyString()]
 	require.NotNil(channel)
 	return channel
@@ -701,17 +732,28 @@ func (sys *system) ApplyMessage(msg *types.Message, height uint64) (*consensus.A
 
 func requireGetPaymentChannel(t *testing.T, ctx context.Context, st state.Tree, vms vm.StorageMap, payer address.Address, channelId *types.ChannelID) *PaymentChannel {
 	require := require.New(t)
-	var paymentMap map[string]*PaymentChannel
 
 	pdata := core.MustConvertParams(payer)
 	values, ec, err := consensus.CallQueryMethod(ctx, st, vms, address.PaymentBrokerAddress, "ls", pdata, payer, types.NewBlockHeight(0))
 	require.Zero(ec)
 	require.NoError(err)
 
-	actor.UnmarshalStorage(values[0], &paymentMap)
+	paymentMap := requireListedChannels(require, values[0])
 
 	result, ok := paymentMap[channelId.KeyString()]
 	require.True(ok)
 
 	return result
 }
+
+// requireListedChannels decodes the channels returned by ls by channel id.
+func requireListedChannels(require *require.Assertions, data []byte) map[string]*PaymentChannel {
+	listed, err := abi.Deserialize(data, abi.ArrayOf(ChannelListingType))
+	require.NoError(err)
+
+	channels := map[string]*PaymentChannel{}
+	for _, listing := range listed.Val.([]*ChannelListing) {
+		channels[listing.ID.KeyString()] = listing.Channel
+	}
+	return channels
+}
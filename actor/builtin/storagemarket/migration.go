@@ -0,0 +1,110 @@
+package storagemarket
+
+import (
+	"context"
+	"math/big"
+
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	cbor "gx/ipfs/QmRoARq3nkUb13HSKZGepCZSWe5GrVPwx7xURJGZ7KWv9V/go-ipld-cbor"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm/errors"
+)
+
+func init() {
+	cbor.RegisterCborType(LegacyState{})
+}
+
+// LegacyState is the layout of the storage market state on chains created
+// before the market kept the power of every miner, in bytes of sectors of a
+// size recorded at genesis.
+type LegacyState struct {
+	// Miners is a lookup of true, by the address of every miner.
+	Miners cid.Cid `refmt:",omitempty"`
+
+	// TotalCommittedStorage is the number of sectors committed in the
+	// whole network.
+	TotalCommittedStorage *big.Int
+}
+
+// LoadLegacyState decodes the state of the storage market in the legacy
+// layout from its storage.
+func LoadLegacyState(storage exec.Storage) (*LegacyState, error) {
+	var state LegacyState
+	if err := actor.ReadState(storage, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// MigrateLegacyState converts the state of the storage market in the legacy
+// layout to the current one, for a network whose sectors have the given size
+// in bytes, and commits it as the new head. It reads the power of every miner
+// from the miner's own state in the legacy layout, so the market must be
+// migrated before its miners are.
+func MigrateLegacyState(storage exec.Storage, storageOf func(address.Address) (exec.Storage, error), sectorSize *types.BytesAmount) error {
+	old, err := LoadLegacyState(storage)
+	if err != nil {
+		return err
+	}
+	if old.TotalCommittedStorage == nil || !old.TotalCommittedStorage.IsUint64() {
+		return errors.NewFaultErrorf("invalid total committed storage %s", old.TotalCommittedStorage)
+	}
+
+	ctx := context.Background()
+	var minerKeys []string
+	err = actor.WithLookupForReading(ctx, storage, old.Miners, func(miners exec.Lookup) error {
+		kvs, err := miners.Values(ctx)
+		if err != nil {
+			return err
+		}
+		for _, kv := range kvs {
+			minerKeys = append(minerKeys, kv.Key)
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.FaultErrorWrap(err, "could not read legacy miners")
+	}
+
+	state := NewState(sectorSize)
+	state.TotalCommittedStorage = sectorSize.Mul(types.NewBytesAmount(old.TotalCommittedStorage.Uint64()))
+	state.Miners, err = actor.WithTypedLookup(ctx, storage, cid.Undef, types.BytesAmount{}, func(miners exec.Lookup) error {
+		for _, key := range minerKeys {
+			addr, err := address.NewFromString(key)
+			if err != nil {
+				return err
+			}
+			minerStorage, err := storageOf(addr)
+			if err != nil {
+				return err
+			}
+			minerState, err := miner.LoadLegacyState(minerStorage)
+			if err != nil {
+				return errors.FaultErrorWrapf(err, "could not load legacy state of miner %s", addr)
+			}
+			if minerState.Power == nil || !minerState.Power.IsUint64() {
+				return errors.NewFaultErrorf("invalid power %s of miner %s", minerState.Power, addr)
+			}
+
+			power := sectorSize.Mul(types.NewBytesAmount(minerState.Power.Uint64()))
+			if err := miners.Set(ctx, key, power); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.FaultErrorWrap(err, "could not migrate miners")
+	}
+
+	id, err := storage.Put(state)
+	if err != nil {
+		return errors.FaultErrorWrap(err, "could not write migrated state")
+	}
+	return storage.Commit(id, storage.Head())
+}
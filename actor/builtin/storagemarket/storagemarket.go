@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"math/big"
+	"sort"
+	"strconv"
 
 	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
 	"gx/ipfs/QmRXf2uUSdGSunRJsM9wXSUNVwLUGCY3So5fAs7h2CBJVf/go-hamt-ipld"
@@ -13,6 +15,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/abi"
 	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/multisig"
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/exec"
 	"github.com/filecoin-project/go-filecoin/types"
@@ -23,26 +26,60 @@ import (
 var MinimumPledge = big.NewInt(10)
 
 // MinimumCollateralPerSector is the minimum amount of collateral required per sector
-var MinimumCollateralPerSector, _ = types.NewAttoFILFromFILString("0.001")
+var MinimumCollateralPerSector = miner.MinimumCollateralPerSector
 
 const (
 	// ErrPledgeTooLow is the error code for a pledge under the MinimumPledge.
 	ErrPledgeTooLow = 33
 	// ErrUnknownMiner indicates a pledge under the MinimumPledge.
 	ErrUnknownMiner = 34
+	// ErrInvalidConsensusFault indicates the submitted blocks are not evidence of a consensus fault.
+	ErrInvalidConsensusFault = 35
+	// ErrNotMinerOwner indicates a deal published by someone other than the owner of its miner.
+	ErrNotMinerOwner = 36
+	// ErrUnknownDeal indicates a deal id that was never published.
+	ErrUnknownDeal = 37
+	// ErrDealNotForMiner indicates an attempt to commit a deal of another miner.
+	ErrDealNotForMiner = 38
+	// ErrDealCommitted indicates the deal has already been committed to a sector.
+	ErrDealCommitted = 39
+	// ErrInvalidDealSignature indicates a deal not signed by its client.
+	ErrInvalidDealSignature = 40
 	// ErrInsufficientCollateral indicates the collateral is too low.
 	ErrInsufficientCollateral = 43
+	// ErrDealCancelled indicates the deal has been cancelled.
+	ErrDealCancelled = 44
+	// ErrNotDealParty indicates an attempt to cancel a deal by someone other than its client or the owner of its miner.
+	ErrNotDealParty = 45
 )
 
+// MinerCreatedTopic is the topic of the event emitted when a miner is
+// created. Its values are the address of the miner and of its owner.
+const MinerCreatedTopic = "minerCreated"
+
+// DealPublishedTopic is the topic of the event emitted when a deal is
+// published. Its values are the deal id, and the addresses of the client and
+// of the miner.
+const DealPublishedTopic = "dealPublished"
+
 // Errors map error codes to revert errors this actor may return.
 var Errors = map[uint8]error{
 	ErrPledgeTooLow:           errors.NewCodedRevertErrorf(ErrPledgeTooLow, "pledge must be at least %s sectors", MinimumPledge),
 	ErrUnknownMiner:           errors.NewCodedRevertErrorf(ErrUnknownMiner, "unknown miner"),
+	ErrInvalidConsensusFault:  errors.NewCodedRevertErrorf(ErrInvalidConsensusFault, "blocks are not evidence of a consensus fault"),
+	ErrNotMinerOwner:          errors.NewCodedRevertErrorf(ErrNotMinerOwner, "deals may only be published by the owner of their miner"),
+	ErrUnknownDeal:            errors.NewCodedRevertErrorf(ErrUnknownDeal, "unknown deal"),
+	ErrDealNotForMiner:        errors.NewCodedRevertErrorf(ErrDealNotForMiner, "deal belongs to another miner"),
+	ErrDealCommitted:          errors.NewCodedRevertErrorf(ErrDealCommitted, "deal already committed"),
+	ErrInvalidDealSignature:   errors.NewCodedRevertErrorf(ErrInvalidDealSignature, "deal is not signed by its client"),
 	ErrInsufficientCollateral: errors.NewCodedRevertErrorf(ErrInsufficientCollateral, "collateral must be more than %s FIL per sector", MinimumCollateralPerSector),
+	ErrDealCancelled:          errors.NewCodedRevertErrorf(ErrDealCancelled, "deal has been cancelled"),
+	ErrNotDealParty:           errors.NewCodedRevertErrorf(ErrNotDealParty, "deals may only be cancelled by their client or the owner of their miner"),
 }
 
 func init() {
 	cbor.RegisterCborType(State{})
+	cbor.RegisterCborType(Deal{})
 	cbor.RegisterCborType(struct{}{})
 }
 
@@ -52,11 +89,116 @@ type Actor struct{}
 
 // State is the storage market's storage.
 type State struct {
+	// Miners maps the address of every miner created by the storage market
+	// to its power in bytes. Together with TotalCommittedStorage it forms the
+	// power table, and is kept up to date by updatePower.
 	Miners cid.Cid `refmt:",omitempty"`
 
-	// TotalCommitedStorage is the number of sectors that are currently committed
-	// in the whole network.
-	TotalCommittedStorage *big.Int
+	// TotalCommitedStorage is the number of bytes of storage that are
+	// currently committed in the whole network.
+	TotalCommittedStorage *types.BytesAmount
+
+	// Deals is a lookup of all published deals, by their id.
+	Deals      cid.Cid `refmt:",omitempty"`
+	NextDealID uint64
+
+	// SectorDeals is a lookup of the ids of the deals committed to every
+	// sector still stored, by the miner address and sector id returned by
+	// sectorDealsKey.
+	SectorDeals cid.Cid `refmt:",omitempty"`
+
+	// MinerSectors is a lookup of the ids of the sectors in SectorDeals of
+	// every miner, by miner address, so the deals of a single miner can be
+	// found without reading the deals of all others.
+	MinerSectors cid.Cid `refmt:",omitempty"`
+
+	// SectorSize is the size in bytes of the sectors of every miner in the
+	// network. It is fixed at genesis, so the power miners gain does not
+	// depend on the environment of the nodes computing it.
+	SectorSize *types.BytesAmount
+}
+
+// Deal is the record of a storage deal between a client and a miner, which
+// the miner publishes once it accepts the deal.
+type Deal struct {
+	ID     uint64
+	Client address.Address
+	Miner  address.Address
+
+	// PieceRef is the cid of the piece being stored.
+	PieceRef cid.Cid
+	Size     *types.BytesAmount
+
+	// Duration is the number of blocks the piece is stored for.
+	Duration *types.BlockHeight
+
+	// Price is the total price the client pays for the deal.
+	Price *types.AttoFIL
+
+	// Collateral is the value of the message that published the deal. It is
+	// held by the storage market until the deal ends or is cancelled, when it
+	// is returned to the owner of the miner, or is terminated, when it is paid
+	// to the client.
+	Collateral *types.AttoFIL
+
+	// Signature is the client's signature of the deal, see SignDeal.
+	Signature types.Signature
+
+	// Committed is set once the miner commits the sector with id SectorID,
+	// which holds the piece.
+	Committed bool
+	SectorID  uint64
+
+	// Ended is set once the sector holding the piece expires, and Terminated
+	// once the miner removes it before it expires.
+	Ended      bool
+	Terminated bool
+
+	// Cancelled is set if the deal is cancelled before it is committed.
+	Cancelled bool
+}
+
+// DealType is the ABI type of a *Deal.
+var DealType = abi.RegisterStruct(2, &Deal{})
+
+// LoadState decodes the state of the storage market actor from its storage,
+// without executing any actor code.
+func LoadState(storage exec.Storage) (*State, error) {
+	var state State
+	if err := actor.ReadState(storage, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// LoadDeals returns all published deals, ordered by id, from the storage of
+// the storage market, without executing any actor code.
+func LoadDeals(ctx context.Context, storage exec.Storage) ([]*Deal, error) {
+	state, err := LoadState(storage)
+	if err != nil {
+		return nil, err
+	}
+
+	var deals []*Deal
+	err = actor.WithTypedLookupForReading(ctx, storage, state.Deals, Deal{}, func(lookup exec.Lookup) error {
+		kvs, err := lookup.Values(ctx)
+		if err != nil {
+			return err
+		}
+		for _, kv := range kvs {
+			deal := kv.Value.(Deal)
+			deals = append(deals, &deal)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(deals, func(i, j int) bool {
+		return deals[i].ID < deals[j].ID
+	})
+	return deals, nil
 }
 
 // NewActor returns a new storage market actor.
@@ -64,11 +206,22 @@ func NewActor() (*actor.Actor, error) {
 	return actor.NewActor(types.StorageMarketActorCodeCid, types.NewZeroAttoFIL()), nil
 }
 
+// NewState creates a storage market state for a network whose miners commit
+// sectors of the given size in bytes.
+func NewState(sectorSize *types.BytesAmount) *State {
+	return &State{
+		TotalCommittedStorage: types.NewBytesAmount(0),
+		SectorSize:            sectorSize,
+	}
+}
+
 // InitializeState stores the actor's initial data structure.
-func (sma *Actor) InitializeState(storage exec.Storage, _ interface{}) error {
-	initStorage := &State{
-		TotalCommittedStorage: big.NewInt(0),
+func (sma *Actor) InitializeState(storage exec.Storage, initializerData interface{}) error {
+	initStorage, ok := initializerData.(*State)
+	if !ok {
+		return errors.NewFaultError("Initial state to storage market actor is not a storagemarket.State struct")
 	}
+
 	stateBytes, err := cbor.DumpObject(initStorage)
 	if err != nil {
 		return err
@@ -94,14 +247,50 @@ var storageMarketExports = exec.Exports{
 		Params: []abi.Type{abi.Integer, abi.Bytes, abi.PeerID},
 		Return: []abi.Type{abi.Address},
 	},
+	"createMultisig": &exec.FunctionSignature{
+		Params: []abi.Type{abi.ArrayOf(abi.Address), abi.Integer, abi.BlockHeight},
+		Return: []abi.Type{abi.Address},
+	},
 	"updatePower": &exec.FunctionSignature{
-		Params: []abi.Type{abi.Integer},
+		Params: []abi.Type{abi.BytesAmount},
 		Return: nil,
 	},
 	"getTotalStorage": &exec.FunctionSignature{
 		Params: []abi.Type{},
+		Return: []abi.Type{abi.BytesAmount},
+	},
+	"slashConsensusFault": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Bytes, abi.Bytes},
+		Return: nil,
+	},
+	"slashStorageFault": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Address},
+		Return: nil,
+	},
+	"publishDeal": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Address, abi.Address, abi.Bytes, abi.BytesAmount, abi.BlockHeight, abi.AttoFIL, abi.Bytes},
 		Return: []abi.Type{abi.Integer},
 	},
+	"commitDeals": &exec.FunctionSignature{
+		Params: []abi.Type{abi.SectorID, abi.UintArray},
+		Return: []abi.Type{abi.BlockHeight},
+	},
+	"cancelDeal": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Integer},
+		Return: nil,
+	},
+	"getDeal": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Integer},
+		Return: []abi.Type{DealType},
+	},
+	"endSectorDeals": &exec.FunctionSignature{
+		Params: []abi.Type{abi.UintArray},
+		Return: nil,
+	},
+	"terminateSectorDeals": &exec.FunctionSignature{
+		Params: []abi.Type{abi.UintArray},
+		Return: nil,
+	},
 }
 
 // CreateMiner creates a new miner with the a pledge of the given amount of sectors. The
@@ -127,7 +316,7 @@ func (sma *Actor) CreateMiner(vmctx exec.VMContext, pledge *big.Int, publicKey [
 			return nil, Errors[ErrInsufficientCollateral]
 		}
 
-		minerInitializationParams := miner.NewState(vmctx.Message().From, publicKey, pledge, pid, vmctx.Message().Value)
+		minerInitializationParams := miner.NewState(vmctx.Message().From, publicKey, pledge, pid, vmctx.Message().Value, state.SectorSize)
 
 		actorCodeCid := types.MinerActorCodeCid
 		if vmctx.BlockHeight().Equal(types.NewBlockHeight(0)) {
@@ -145,11 +334,15 @@ func (sma *Actor) CreateMiner(vmctx exec.VMContext, pledge *big.Int, publicKey [
 
 		ctx := context.Background()
 
-		state.Miners, err = actor.SetKeyValue(ctx, vmctx.Storage(), state.Miners, addr.String(), true)
+		state.Miners, err = actor.SetKeyValue(ctx, vmctx.Storage(), state.Miners, addr.String(), types.NewBytesAmount(0))
 		if err != nil {
 			return nil, errors.FaultErrorWrapf(err, "could not set miner key value for lookup with CID: %s", state.Miners)
 		}
 
+		if err := vmctx.EmitEvent(MinerCreatedTopic, addr, vmctx.Message().From); err != nil {
+			return nil, err
+		}
+
 		return addr, nil
 	})
 	if err != nil {
@@ -159,10 +352,43 @@ func (sma *Actor) CreateMiner(vmctx exec.VMContext, pledge *big.Int, publicKey [
 	return ret.(address.Address), 0, nil
 }
 
+// CreateMultisig creates a new multisig wallet with the given signers, of
+// which required must approve every transaction. The value in the message
+// funds the wallet, and unlocks linearly over unlockDuration blocks.
+// TODO: the storage market is the only actor that can create other actors.
+// Move this to a dedicated actor once there is one.
+func (sma *Actor) CreateMultisig(vmctx exec.VMContext, signers []address.Address, required *big.Int, unlockDuration *types.BlockHeight) (address.Address, uint8, error) {
+	if err := vmctx.Charge(100); err != nil {
+		return address.Address{}, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	if !required.IsUint64() {
+		return address.Address{}, errors.CodeError(multisig.Errors[multisig.ErrInvalidRequirement]), multisig.Errors[multisig.ErrInvalidRequirement]
+	}
+
+	addr, err := vmctx.AddressForNewActor()
+	if err != nil {
+		err = errors.FaultErrorWrap(err, "could not get address for new actor")
+		return address.Address{}, errors.CodeError(err), err
+	}
+
+	value := vmctx.Message().Value
+	msigState := multisig.NewState(signers, required.Uint64(), value, vmctx.BlockHeight(), unlockDuration)
+	if err := vmctx.CreateNewActor(addr, types.MultisigActorCodeCid, msigState); err != nil {
+		return address.Address{}, errors.CodeError(err), err
+	}
+
+	if _, _, err := vmctx.Send(addr, "", value, nil); err != nil {
+		return address.Address{}, errors.CodeError(err), err
+	}
+
+	return addr, 0, nil
+}
+
 // UpdatePower is called to reflect a change in the overall power of the network.
 // This occurs either when a miner adds a new commitment, or when one is removed
-// (via slashing or willful removal). The delta is in number of sectors.
-func (sma *Actor) UpdatePower(vmctx exec.VMContext, delta *big.Int) (uint8, error) {
+// (via slashing or willful removal). The delta is in bytes.
+func (sma *Actor) UpdatePower(vmctx exec.VMContext, delta *types.BytesAmount) (uint8, error) {
 	if err := vmctx.Charge(100); err != nil {
 		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
@@ -172,12 +398,12 @@ func (sma *Actor) UpdatePower(vmctx exec.VMContext, delta *big.Int) (uint8, erro
 		miner := vmctx.Message().From
 		ctx := context.Background()
 
-		miners, err := actor.LoadLookup(ctx, vmctx.Storage(), state.Miners)
+		miners, err := actor.LoadTypedLookup(ctx, vmctx.Storage(), state.Miners, types.BytesAmount{})
 		if err != nil {
 			return nil, errors.FaultErrorWrapf(err, "could not load lookup for miner with CID: %s", state.Miners)
 		}
 
-		_, err = miners.Find(ctx, miner.String())
+		value, err := miners.Find(ctx, miner.String())
 		if err != nil {
 			if err == hamt.ErrNotFound {
 				return nil, Errors[ErrUnknownMiner]
@@ -185,7 +411,17 @@ func (sma *Actor) UpdatePower(vmctx exec.VMContext, delta *big.Int) (uint8, erro
 			return nil, errors.FaultErrorWrapf(err, "could not load lookup for miner with address: %s", miner)
 		}
 
-		state.TotalCommittedStorage = state.TotalCommittedStorage.Add(state.TotalCommittedStorage, delta)
+		power := value.(types.BytesAmount)
+		if err := miners.Set(ctx, miner.String(), power.Add(delta)); err != nil {
+			return nil, errors.FaultErrorWrapf(err, "could not set power for miner with address: %s", miner)
+		}
+
+		state.Miners, err = miners.Commit(ctx)
+		if err != nil {
+			return nil, errors.FaultErrorWrap(err, "could not commit miners lookup")
+		}
+
+		state.TotalCommittedStorage = state.TotalCommittedStorage.Add(delta)
 
 		return nil, nil
 	})
@@ -197,7 +433,7 @@ func (sma *Actor) UpdatePower(vmctx exec.VMContext, delta *big.Int) (uint8, erro
 }
 
 // GetTotalStorage returns the total amount of proven storage in the system.
-func (sma *Actor) GetTotalStorage(vmctx exec.VMContext) (*big.Int, uint8, error) {
+func (sma *Actor) GetTotalStorage(vmctx exec.VMContext) (*types.BytesAmount, uint8, error) {
 	if err := vmctx.Charge(100); err != nil {
 		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
@@ -210,15 +446,667 @@ func (sma *Actor) GetTotalStorage(vmctx exec.VMContext) (*big.Int, uint8, error)
 		return nil, errors.CodeError(err), err
 	}
 
-	count, ok := ret.(*big.Int)
+	count, ok := ret.(*types.BytesAmount)
 	if !ok {
-		return nil, 1, fmt.Errorf("expected *big.Int to be returned, but got %T instead", ret)
+		return nil, 1, fmt.Errorf("expected *types.BytesAmount to be returned, but got %T instead", ret)
 	}
 
 	return count, 0, nil
 }
 
+// SlashConsensusFault accepts two serialized block headers as evidence that a
+// miner produced conflicting blocks. If the evidence holds up, the miner's
+// collateral is burnt and its power is removed from the network total. Anyone
+// may submit evidence.
+func (sma *Actor) SlashConsensusFault(vmctx exec.VMContext, block1, block2 []byte) (uint8, error) {
+	if err := vmctx.Charge(100); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	first, err := types.DecodeBlock(block1)
+	if err != nil {
+		return ErrInvalidConsensusFault, errors.RevertErrorWrap(err, "could not decode first block")
+	}
+	second, err := types.DecodeBlock(block2)
+	if err != nil {
+		return ErrInvalidConsensusFault, errors.RevertErrorWrap(err, "could not decode second block")
+	}
+
+	if err := VerifyConsensusFault(first, second); err != nil {
+		return ErrInvalidConsensusFault, err
+	}
+
+	owner, key, err := minerBlockKey(vmctx, first.Miner)
+	if err != nil {
+		return ErrInvalidConsensusFault, err
+	}
+	if !first.VerifySignature(owner, key) || !second.VerifySignature(owner, key) {
+		return ErrInvalidConsensusFault, errors.NewRevertErrorf("blocks are not signed by the owner of miner %s", first.Miner)
+	}
+
+	var state State
+	_, err = actor.WithState(vmctx, &state, func() (interface{}, error) {
+		return nil, slashMiner(vmctx, &state, first.Miner, "slashConsensusFault")
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
+// SlashStorageFault slashes the miner at minerAddr if it let the grace period
+// of its proving period pass without submitting a PoSt. Anyone may report the
+// fault; the miner verifies it. The miner's collateral is burnt and its power
+// removed from the network total.
+func (sma *Actor) SlashStorageFault(vmctx exec.VMContext, minerAddr address.Address) (uint8, error) {
+	if err := vmctx.Charge(100); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	_, err := actor.WithState(vmctx, &state, func() (interface{}, error) {
+		return nil, slashMiner(vmctx, &state, minerAddr, "slashStorageFault")
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
+// slashMiner sends method to the known miner at minerAddr to slash it, and
+// removes the power it returns from the miner's power and the network total.
+func slashMiner(vmctx exec.VMContext, state *State, minerAddr address.Address, method string) error {
+	ctx := context.Background()
+
+	miners, err := actor.LoadLookup(ctx, vmctx.Storage(), state.Miners)
+	if err != nil {
+		return errors.FaultErrorWrapf(err, "could not load lookup for miner with CID: %s", state.Miners)
+	}
+
+	_, err = miners.Find(ctx, minerAddr.String())
+	if err != nil {
+		if err == hamt.ErrNotFound {
+			return Errors[ErrUnknownMiner]
+		}
+		return errors.FaultErrorWrapf(err, "could not load lookup for miner with address: %s", minerAddr)
+	}
+
+	ret, code, err := vmctx.Send(minerAddr, method, nil, nil)
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		return errors.NewRevertErrorf("slashing miner %s failed with exit code %d", minerAddr, code)
+	}
+
+	slashedPower, err := abi.Deserialize(ret[0], abi.BytesAmount)
+	if err != nil {
+		return errors.FaultErrorWrap(err, "could not deserialize slashed power")
+	}
+
+	if err := miners.Set(ctx, minerAddr.String(), types.NewBytesAmount(0)); err != nil {
+		return errors.FaultErrorWrapf(err, "could not set power for miner with address: %s", minerAddr)
+	}
+	state.Miners, err = miners.Commit(ctx)
+	if err != nil {
+		return errors.FaultErrorWrap(err, "could not commit miners lookup")
+	}
+
+	state.TotalCommittedStorage = state.TotalCommittedStorage.Sub(slashedPower.Val.(*types.BytesAmount))
+
+	// slashing removes all sectors of the miner
+	return terminateMinerDeals(vmctx, state, minerAddr)
+}
+
+// PublishDeal records a deal in which the miner at minerAddr stores the piece
+// with the given cid for client, and returns the id of the deal. Only the
+// owner of the miner may publish its deals, and the deal must be signed by
+// client. The value of the message is held as the miner's collateral for the
+// deal.
+func (sma *Actor) PublishDeal(vmctx exec.VMContext, minerAddr, client address.Address, pieceRef []byte, size *types.BytesAmount, duration *types.BlockHeight, price *types.AttoFIL, sig []byte) (*big.Int, uint8, error) {
+	if err := vmctx.Charge(100); err != nil {
+		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	ref, err := cid.Cast(pieceRef)
+	if err != nil {
+		return nil, 1, errors.RevertErrorWrap(err, "invalid piece ref")
+	}
+
+	valid, err := vmctx.VerifySignature(createDealSignatureData(minerAddr, ref, size, duration, price), client, sig)
+	if err != nil {
+		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+	if !valid {
+		return nil, errors.CodeError(Errors[ErrInvalidDealSignature]), Errors[ErrInvalidDealSignature]
+	}
+
+	var state State
+	ret, err := actor.WithState(vmctx, &state, func() (interface{}, error) {
+		ctx := context.Background()
+
+		miners, err := actor.LoadLookup(ctx, vmctx.Storage(), state.Miners)
+		if err != nil {
+			return nil, errors.FaultErrorWrapf(err, "could not load lookup for miner with CID: %s", state.Miners)
+		}
+		_, err = miners.Find(ctx, minerAddr.String())
+		if err != nil {
+			if err == hamt.ErrNotFound {
+				return nil, Errors[ErrUnknownMiner]
+			}
+			return nil, errors.FaultErrorWrapf(err, "could not load lookup for miner with address: %s", minerAddr)
+		}
+
+		ret, code, err := vmctx.Send(minerAddr, "getOwner", nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		if code != 0 {
+			return nil, errors.NewRevertErrorf("getting owner of miner %s failed with exit code %d", minerAddr, code)
+		}
+		owner, err := address.NewFromBytes(ret[0])
+		if err != nil {
+			return nil, errors.FaultErrorWrap(err, "could not decode owner of miner")
+		}
+		if vmctx.Message().From != owner {
+			return nil, Errors[ErrNotMinerOwner]
+		}
+
+		collateral := vmctx.Message().Value
+		if collateral == nil {
+			collateral = types.NewZeroAttoFIL()
+		}
+
+		id := state.NextDealID
+		state.NextDealID++
+
+		state.Deals, err = actor.WithTypedLookup(ctx, vmctx.Storage(), state.Deals, Deal{}, func(deals exec.Lookup) error {
+			return deals.Set(ctx, strconv.FormatUint(id, 10), &Deal{
+				ID:         id,
+				Client:     client,
+				Miner:      minerAddr,
+				PieceRef:   ref,
+				Size:       size,
+				Duration:   duration,
+				Price:      price,
+				Collateral: collateral,
+				Signature:  sig,
+			})
+		})
+		if err != nil {
+			return nil, errors.FaultErrorWrapf(err, "could not store deal %d", id)
+		}
+
+		if err := vmctx.EmitEvent(DealPublishedTopic, id, client, minerAddr); err != nil {
+			return nil, err
+		}
+
+		return big.NewInt(0).SetUint64(id), nil
+	})
+	if err != nil {
+		return nil, errors.CodeError(err), err
+	}
+
+	return ret.(*big.Int), 0, nil
+}
+
+// CommitDeals records that the deals with the given ids are stored in the
+// sector with the given id. It is called by miners when they commit the
+// sector, and every deal must belong to the calling miner and not have been
+// committed before. The longest duration of the deals is returned, which is
+// how long the sector must be stored for.
+func (sma *Actor) CommitDeals(vmctx exec.VMContext, sectorID uint64, dealIDs []uint64) (*types.BlockHeight, uint8, error) {
+	if err := vmctx.Charge(100); err != nil {
+		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	ret, err := actor.WithState(vmctx, &state, func() (interface{}, error) {
+		ctx := context.Background()
+		duration := types.NewBlockHeight(0)
+
+		deals, err := actor.LoadTypedLookup(ctx, vmctx.Storage(), state.Deals, Deal{})
+		if err != nil {
+			return nil, errors.FaultErrorWrapf(err, "could not load lookup for deals with CID: %s", state.Deals)
+		}
+
+		for _, id := range dealIDs {
+			key := strconv.FormatUint(id, 10)
+			value, err := deals.Find(ctx, key)
+			if err != nil {
+				if err == hamt.ErrNotFound {
+					return nil, Errors[ErrUnknownDeal]
+				}
+				return nil, errors.FaultErrorWrapf(err, "could not find deal %d", id)
+			}
+
+			deal := value.(Deal)
+			if deal.Miner != vmctx.Message().From {
+				return nil, Errors[ErrDealNotForMiner]
+			}
+			if deal.Committed {
+				return nil, Errors[ErrDealCommitted]
+			}
+			if deal.Cancelled {
+				return nil, Errors[ErrDealCancelled]
+			}
+
+			deal.Committed = true
+			deal.SectorID = sectorID
+			if err := deals.Set(ctx, key, &deal); err != nil {
+				return nil, errors.FaultErrorWrapf(err, "could not set deal %d", id)
+			}
+
+			if deal.Duration.GreaterThan(duration) {
+				duration = deal.Duration
+			}
+		}
+
+		state.Deals, err = deals.Commit(ctx)
+		if err != nil {
+			return nil, errors.FaultErrorWrap(err, "could not commit deals lookup")
+		}
+
+		state.SectorDeals, err = actor.WithTypedLookup(ctx, vmctx.Storage(), state.SectorDeals, []uint64{}, func(sectorDeals exec.Lookup) error {
+			return sectorDeals.Set(ctx, sectorDealsKey(vmctx.Message().From, sectorID), dealIDs)
+		})
+		if err != nil {
+			return nil, errors.FaultErrorWrapf(err, "could not store deals of sector %d", sectorID)
+		}
+
+		state.MinerSectors, err = actor.WithTypedLookup(ctx, vmctx.Storage(), state.MinerSectors, []uint64{}, func(minerSectors exec.Lookup) error {
+			sectorIDs, err := findMinerSectors(ctx, minerSectors, vmctx.Message().From)
+			if err != nil {
+				return err
+			}
+			for _, id := range sectorIDs {
+				if id == sectorID {
+					return nil
+				}
+			}
+			return minerSectors.Set(ctx, vmctx.Message().From.String(), append(sectorIDs, sectorID))
+		})
+		if err != nil {
+			return nil, errors.FaultErrorWrapf(err, "could not index sector %d", sectorID)
+		}
+
+		return duration, nil
+	})
+	if err != nil {
+		return nil, errors.CodeError(err), err
+	}
+
+	duration, ok := ret.(*types.BlockHeight)
+	if !ok {
+		return nil, 1, errors.NewFaultErrorf("expected *types.BlockHeight to be returned, but got %T instead", ret)
+	}
+
+	return duration, 0, nil
+}
+
+// CancelDeal cancels the deal with the given id, which has not been
+// committed to a sector, and returns its collateral to the owner of its miner.
+// It may be called by the client of the deal or the owner of its miner, so
+// the collateral of a deal the miner never stores is not held forever.
+func (sma *Actor) CancelDeal(vmctx exec.VMContext, id *big.Int) (uint8, error) {
+	if err := vmctx.Charge(100); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	_, err := actor.WithState(vmctx, &state, func() (interface{}, error) {
+		ctx := context.Background()
+
+		deals, err := actor.LoadTypedLookup(ctx, vmctx.Storage(), state.Deals, Deal{})
+		if err != nil {
+			return nil, errors.FaultErrorWrapf(err, "could not load lookup for deals with CID: %s", state.Deals)
+		}
+
+		value, err := deals.Find(ctx, id.String())
+		if err != nil {
+			if err == hamt.ErrNotFound {
+				return nil, Errors[ErrUnknownDeal]
+			}
+			return nil, errors.FaultErrorWrapf(err, "could not find deal %s", id)
+		}
+
+		deal := value.(Deal)
+		if deal.Committed {
+			return nil, Errors[ErrDealCommitted]
+		}
+		if deal.Cancelled {
+			return nil, Errors[ErrDealCancelled]
+		}
+
+		if vmctx.Message().From != deal.Client {
+			ret, code, err := vmctx.Send(deal.Miner, "getOwner", nil, nil)
+			if err != nil {
+				return nil, err
+			}
+			if code != 0 {
+				return nil, errors.NewRevertErrorf("getting owner of miner %s failed with exit code %d", deal.Miner, code)
+			}
+			owner, err := address.NewFromBytes(ret[0])
+			if err != nil {
+				return nil, errors.FaultErrorWrap(err, "could not decode owner of miner")
+			}
+			if vmctx.Message().From != owner {
+				return nil, Errors[ErrNotDealParty]
+			}
+		}
+
+		deal.Cancelled = true
+		if err := deals.Set(ctx, id.String(), &deal); err != nil {
+			return nil, errors.FaultErrorWrapf(err, "could not set deal %s", id)
+		}
+		state.Deals, err = deals.Commit(ctx)
+		if err != nil {
+			return nil, errors.FaultErrorWrap(err, "could not commit deals lookup")
+		}
+
+		return nil, settleCollateral(vmctx, &deal)
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
+// EndSectorDeals marks the deals committed to the sectors with the given ids
+// as ended. It is called by miners when the sectors expire.
+func (sma *Actor) EndSectorDeals(vmctx exec.VMContext, sectorIDs []uint64) (uint8, error) {
+	return removeSectorDeals(vmctx, sectorIDs, false)
+}
+
+// TerminateSectorDeals marks the deals committed to the sectors with the
+// given ids as terminated. It is called by miners when they remove the
+// sectors before they expire.
+func (sma *Actor) TerminateSectorDeals(vmctx exec.VMContext, sectorIDs []uint64) (uint8, error) {
+	return removeSectorDeals(vmctx, sectorIDs, true)
+}
+
+// removeSectorDeals marks the deals committed to the calling miner's sectors
+// with the given ids as ended, or terminated, and forgets the sectors. Sectors
+// without deals are ignored.
+func removeSectorDeals(vmctx exec.VMContext, sectorIDs []uint64, terminated bool) (uint8, error) {
+	if err := vmctx.Charge(100); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	_, err := actor.WithState(vmctx, &state, func() (interface{}, error) {
+		return nil, markSectorDeals(vmctx, &state, vmctx.Message().From, sectorIDs, terminated)
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
+// markSectorDeals marks the deals committed to the sectors of the miner at
+// minerAddr with the given ids as ended, or terminated, settles their
+// collateral, and forgets the sectors. Sectors without deals are ignored.
+func markSectorDeals(vmctx exec.VMContext, state *State, minerAddr address.Address, sectorIDs []uint64, terminated bool) error {
+	ctx := context.Background()
+
+	deals, err := actor.LoadTypedLookup(ctx, vmctx.Storage(), state.Deals, Deal{})
+	if err != nil {
+		return errors.FaultErrorWrapf(err, "could not load lookup for deals with CID: %s", state.Deals)
+	}
+	sectorDeals, err := actor.LoadTypedLookup(ctx, vmctx.Storage(), state.SectorDeals, []uint64{})
+	if err != nil {
+		return errors.FaultErrorWrapf(err, "could not load lookup for sector deals with CID: %s", state.SectorDeals)
+	}
+
+	removed := make(map[uint64]bool)
+	for _, sectorID := range sectorIDs {
+		sectorKey := sectorDealsKey(minerAddr, sectorID)
+		value, err := sectorDeals.Find(ctx, sectorKey)
+		if err == hamt.ErrNotFound {
+			continue
+		} else if err != nil {
+			return errors.FaultErrorWrapf(err, "could not find deals of sector %s", sectorKey)
+		}
+
+		for _, id := range value.([]uint64) {
+			key := strconv.FormatUint(id, 10)
+			value, err := deals.Find(ctx, key)
+			if err != nil {
+				return errors.FaultErrorWrapf(err, "could not find deal %d", id)
+			}
+
+			deal := value.(Deal)
+			if terminated {
+				deal.Terminated = true
+			} else {
+				deal.Ended = true
+			}
+			if err := deals.Set(ctx, key, &deal); err != nil {
+				return errors.FaultErrorWrapf(err, "could not set deal %d", id)
+			}
+
+			if err := settleCollateral(vmctx, &deal); err != nil {
+				return err
+			}
+		}
+
+		if err := sectorDeals.Delete(ctx, sectorKey); err != nil {
+			return errors.FaultErrorWrapf(err, "could not delete deals of sector %s", sectorKey)
+		}
+		removed[sectorID] = true
+	}
+
+	state.Deals, err = deals.Commit(ctx)
+	if err != nil {
+		return errors.FaultErrorWrap(err, "could not commit deals lookup")
+	}
+	state.SectorDeals, err = sectorDeals.Commit(ctx)
+	if err != nil {
+		return errors.FaultErrorWrap(err, "could not commit sector deals lookup")
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	state.MinerSectors, err = actor.WithTypedLookup(ctx, vmctx.Storage(), state.MinerSectors, []uint64{}, func(minerSectors exec.Lookup) error {
+		sectorIDs, err := findMinerSectors(ctx, minerSectors, minerAddr)
+		if err != nil {
+			return err
+		}
+		var remaining []uint64
+		for _, id := range sectorIDs {
+			if !removed[id] {
+				remaining = append(remaining, id)
+			}
+		}
+		if len(remaining) == 0 {
+			return minerSectors.Delete(ctx, minerAddr.String())
+		}
+		return minerSectors.Set(ctx, minerAddr.String(), remaining)
+	})
+	if err != nil {
+		return errors.FaultErrorWrapf(err, "could not update sectors of miner %s", minerAddr)
+	}
+
+	return nil
+}
+
+// findMinerSectors returns the ids of the sectors with deals of the miner at
+// minerAddr in the MinerSectors lookup.
+func findMinerSectors(ctx context.Context, minerSectors exec.Lookup, minerAddr address.Address) ([]uint64, error) {
+	value, err := minerSectors.Find(ctx, minerAddr.String())
+	if err == hamt.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return value.([]uint64), nil
+}
+
+// settleCollateral pays the collateral of a deal that has ended or been
+// cancelled back to the owner of its miner, or the collateral of a terminated
+// deal to its client.
+func settleCollateral(vmctx exec.VMContext, deal *Deal) error {
+	if !deal.Collateral.IsPositive() {
+		return nil
+	}
+
+	to := deal.Client
+	if !deal.Terminated {
+		ret, code, err := vmctx.Send(deal.Miner, "getOwner", nil, nil)
+		if err != nil {
+			return err
+		}
+		if code != 0 {
+			return errors.NewRevertErrorf("getting owner of miner %s failed with exit code %d", deal.Miner, code)
+		}
+		to, err = address.NewFromBytes(ret[0])
+		if err != nil {
+			return errors.FaultErrorWrap(err, "could not decode owner of miner")
+		}
+	}
+
+	_, _, err := vmctx.Send(to, "", deal.Collateral, nil)
+	return err
+}
+
+// terminateMinerDeals marks the deals committed to all sectors of the miner at
+// minerAddr as terminated.
+func terminateMinerDeals(vmctx exec.VMContext, state *State, minerAddr address.Address) error {
+	ctx := context.Background()
+
+	var sectorIDs []uint64
+	err := actor.WithTypedLookupForReading(ctx, vmctx.Storage(), state.MinerSectors, []uint64{}, func(minerSectors exec.Lookup) error {
+		var err error
+		sectorIDs, err = findMinerSectors(ctx, minerSectors, minerAddr)
+		return err
+	})
+	if err != nil {
+		return errors.FaultErrorWrapf(err, "could not read sectors of miner %s", minerAddr)
+	}
+
+	return markSectorDeals(vmctx, state, minerAddr, sectorIDs, true)
+}
+
+// SignDeal returns the signature of client of a deal in which the miner at
+// minerAddr stores the piece with the given cid, which PublishDeal requires.
+func SignDeal(minerAddr address.Address, pieceRef cid.Cid, size *types.BytesAmount, duration *types.BlockHeight, price *types.AttoFIL, client address.Address, signer types.Signer) (types.Signature, error) {
+	data := createDealSignatureData(minerAddr, pieceRef, size, duration, price)
+	return signer.SignBytes(data, client)
+}
+
+// VerifyDealSignature returns whether sig is the signature of client of the
+// deal with the given terms.
+func VerifyDealSignature(minerAddr address.Address, pieceRef cid.Cid, size *types.BytesAmount, duration *types.BlockHeight, price *types.AttoFIL, client address.Address, sig types.Signature) bool {
+	data := createDealSignatureData(minerAddr, pieceRef, size, duration, price)
+	return types.IsValidSignature(data, client, sig)
+}
+
+// separator is the separator used when concatenating the terms of a deal in
+// the data a client signs.
+const separator = 0x0
+
+func createDealSignatureData(minerAddr address.Address, pieceRef cid.Cid, size *types.BytesAmount, duration *types.BlockHeight, price *types.AttoFIL) []byte {
+	data := append(minerAddr.Bytes(), separator)
+	data = append(data, pieceRef.Bytes()...)
+	data = append(data, separator)
+	data = append(data, size.Bytes()...)
+	data = append(data, separator)
+	data = append(data, duration.Bytes()...)
+	data = append(data, separator)
+	return append(data, price.Bytes()...)
+}
+
+// sectorDealsKey returns the key of the deals committed to the sector with
+// the given id of a miner in the SectorDeals lookup.
+func sectorDealsKey(minerAddr address.Address, sectorID uint64) string {
+	return minerAddr.String() + "/" + strconv.FormatUint(sectorID, 10)
+}
+
+// GetDeal returns the deal with the given id.
+func (sma *Actor) GetDeal(vmctx exec.VMContext, id *big.Int) (*Deal, uint8, error) {
+	if err := vmctx.Charge(100); err != nil {
+		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	ret, err := actor.WithState(vmctx, &state, func() (interface{}, error) {
+		ctx := context.Background()
+
+		deals, err := actor.LoadTypedLookup(ctx, vmctx.Storage(), state.Deals, Deal{})
+		if err != nil {
+			return nil, errors.FaultErrorWrapf(err, "could not load lookup for deals with CID: %s", state.Deals)
+		}
+
+		value, err := deals.Find(ctx, id.String())
+		if err != nil {
+			if err == hamt.ErrNotFound {
+				return nil, Errors[ErrUnknownDeal]
+			}
+			return nil, errors.FaultErrorWrapf(err, "could not find deal %s", id)
+		}
+
+		deal := value.(Deal)
+		return &deal, nil
+	})
+	if err != nil {
+		return nil, errors.CodeError(err), err
+	}
+
+	return ret.(*Deal), 0, nil
+}
+
+// VerifyConsensusFault returns nil if the two blocks are proof that their
+// miner committed a consensus fault, i.e. produced two different blocks at the
+// same height on top of the same parents. It does not check the block
+// signatures, which SlashConsensusFault verifies against the miner's key.
+func VerifyConsensusFault(first, second *types.Block) error {
+	if first.Cid().Equals(second.Cid()) {
+		return errors.NewRevertError("blocks are identical")
+	}
+	if first.Miner != second.Miner {
+		return errors.NewRevertErrorf("blocks were mined by different miners: %s and %s", first.Miner, second.Miner)
+	}
+	if first.Height != second.Height {
+		return errors.NewRevertErrorf("blocks are at different heights: %d and %d", first.Height, second.Height)
+	}
+	if !first.Parents.Equals(second.Parents) {
+		return errors.NewRevertError("blocks have different parents")
+	}
+	return nil
+}
+
 // MinimumCollateral returns the minimum required amount of collateral for a given pledge
 func MinimumCollateral(sectors *big.Int) *types.AttoFIL {
-	return MinimumCollateralPerSector.MulBigInt(sectors)
+	return miner.MinimumCollateral(sectors)
+}
+
+// minerBlockKey returns the owner of the given miner and the public key its
+// blocks are signed with.
+func minerBlockKey(vmctx exec.VMContext, minerAddr address.Address) (address.Address, []byte, error) {
+	ret, code, err := vmctx.Send(minerAddr, "getOwner", nil, nil)
+	if err != nil {
+		return address.Address{}, nil, err
+	}
+	if code != 0 {
+		return address.Address{}, nil, errors.NewRevertErrorf("getting owner of miner %s failed with exit code %d", minerAddr, code)
+	}
+	owner, err := address.NewFromBytes(ret[0])
+	if err != nil {
+		return address.Address{}, nil, errors.FaultErrorWrap(err, "could not decode owner of miner")
+	}
+
+	ret, code, err = vmctx.Send(minerAddr, "getKey", nil, nil)
+	if err != nil {
+		return address.Address{}, nil, err
+	}
+	if code != 0 {
+		return address.Address{}, nil, errors.NewRevertErrorf("getting key of miner %s failed with exit code %d", minerAddr, code)
+	}
+
+	return owner, ret[0], nil
 }
@@ -7,14 +7,23 @@ import (
 	"math/big"
 	"testing"
 
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	"gx/ipfs/QmRXf2uUSdGSunRJsM9wXSUNVwLUGCY3So5fAs7h2CBJVf/go-hamt-ipld"
+
+	"github.com/filecoin-project/go-filecoin/abi"
 	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
 	. "github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/consensus"
 	"github.com/filecoin-project/go-filecoin/core"
+	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/proofs"
+	"github.com/filecoin-project/go-filecoin/state"
 	th "github.com/filecoin-project/go-filecoin/testhelpers"
 	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -51,6 +60,16 @@ func TestStorageMarketCreateMiner(t *testing.T) {
 	assert.Equal(mstor.Collateral, types.NewAttoFILFromFIL(100))
 	assert.Equal(mstor.PledgeSectors, big.NewInt(10))
 	assert.Equal(mstor.PeerID, pid)
+	assert.True(th.SectorSize().Equal(mstor.SectorSize))
+
+	require.Len(result.Receipt.Events, 1)
+	event := result.Receipt.Events[0]
+	assert.Equal(address.StorageMarketAddress, event.Actor)
+	assert.Equal(MinerCreatedTopic, event.Topic)
+	vals, err := abi.DecodeValues(event.Data, []abi.Type{abi.Address, abi.Address})
+	require.NoError(err)
+	assert.Equal(outAddr, vals[0].Val)
+	assert.Equal(address.TestAddress, vals[1].Val)
 }
 
 func TestStorageMarketCreateMinerPledgeTooLow(t *testing.T) {
@@ -94,8 +113,11 @@ func TestStorageMarkeCreateMinerDoesNotOverwriteActorBalance(t *testing.T) {
 
 	st, vms := core.CreateStorages(ctx, t)
 
+	pdata := actor.MustConvertParams(big.NewInt(15), []byte{}, th.RequireRandomPeerID())
+	createMsg := types.NewMessage(address.TestAddress, address.StorageMarketAddress, 0, types.NewAttoFILFromFIL(200), "createMiner", pdata)
+
 	// create account of future miner actor by sending FIL to the predicted address
-	minerAddr, err := deriveMinerAddress(address.TestAddress, 0)
+	minerAddr, err := deriveMinerAddress(createMsg)
 	require.NoError(err)
 
 	msg := types.NewMessage(address.TestAddress2, minerAddr, 0, types.NewAttoFILFromFIL(100), "", []byte{})
@@ -103,9 +125,7 @@ func TestStorageMarkeCreateMinerDoesNotOverwriteActorBalance(t *testing.T) {
 	require.NoError(err)
 	require.Equal(uint8(0), result.Receipt.ExitCode)
 
-	pdata := actor.MustConvertParams(big.NewInt(15), []byte{}, th.RequireRandomPeerID())
-	msg = types.NewMessage(address.TestAddress, address.StorageMarketAddress, 0, types.NewAttoFILFromFIL(200), "createMiner", pdata)
-	result, err = th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(0))
+	result, err = th.ApplyTestMessage(st, vms, createMsg, types.NewBlockHeight(0))
 	require.NoError(err)
 	require.Equal(uint8(0), result.Receipt.ExitCode)
 	require.NoError(result.ExecutionError)
@@ -139,6 +159,590 @@ func TestStorageMarkeCreateMinerErrorsOnInvalidKey(t *testing.T) {
 	assert.Contains(result.ExecutionError.Error(), miner.Errors[miner.ErrPublicKeyTooBig].Error())
 }
 
+func TestStorageMarketSlashConsensusFault(t *testing.T) {
+	ctx := context.Background()
+
+	kis := types.MustGenerateKeyInfo(2, types.GenerateKeyInfoSeed())
+	signer := types.NewMockSigner(kis)
+	owner := signer.Addresses[0]
+
+	setup := func(t *testing.T) (state.Tree, vm.StorageMap, address.Address) {
+		require := require.New(t)
+		st, vms := core.CreateStorages(ctx, t)
+		state.MustSetActor(st, owner, th.RequireNewAccountActor(require, types.NewAttoFILFromFIL(1000)))
+
+		pubKey, err := kis[0].PublicKey()
+		require.NoError(err)
+		pdata := actor.MustConvertParams(big.NewInt(10), pubKey, th.RequireRandomPeerID())
+		msg := types.NewMessage(owner, address.StorageMarketAddress, 0, types.NewAttoFILFromFIL(100), "createMiner", pdata)
+		result, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(0))
+		require.NoError(err)
+		require.NoError(result.ExecutionError)
+
+		minerAddr, err := address.NewFromBytes(result.Receipt.Return[0])
+		require.NoError(err)
+
+		pdata = actor.MustConvertParams(uint64(1), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(int(proofs.SealBytesLen)), []uint64{})
+		msg = types.NewMessage(owner, minerAddr, 1, types.NewZeroAttoFIL(), "commitSector", pdata)
+		result, err = th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(3))
+		require.NoError(err)
+		require.NoError(result.ExecutionError)
+
+		return st, vms, minerAddr
+	}
+
+	conflictingBlocks := func(t *testing.T, minerAddr address.Address) (*types.Block, *types.Block) {
+		require := require.New(t)
+		parents := types.NewSortedCidSet(types.SomeCid())
+		first := &types.Block{Miner: minerAddr, Height: 5, Parents: parents, Nonce: 1}
+		second := &types.Block{Miner: minerAddr, Height: 5, Parents: parents, Nonce: 2}
+		require.NoError(first.Sign(signer, owner))
+		require.NoError(second.Sign(signer, owner))
+		return first, second
+	}
+
+	t.Run("valid evidence burns collateral and removes power", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+		st, vms, minerAddr := setup(t)
+
+		first, second := conflictingBlocks(t, minerAddr)
+		pdata := actor.MustConvertParams(first.ToNode().RawData(), second.ToNode().RawData())
+		msg := types.NewMessage(address.TestAddress2, address.StorageMarketAddress, 0, types.NewZeroAttoFIL(), "slashConsensusFault", pdata)
+		result, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(6))
+		require.NoError(err)
+		require.NoError(result.ExecutionError)
+		require.Equal(uint8(0), result.Receipt.ExitCode)
+
+		minerActor, err := st.GetActor(ctx, minerAddr)
+		require.NoError(err)
+		var mstor miner.State
+		builtin.RequireReadState(t, vms, minerAddr, minerActor, &mstor)
+		assert.True(mstor.Collateral.IsZero())
+		assert.True(mstor.Power.IsZero())
+		assert.True(minerActor.Balance.IsZero())
+
+		// the slashed sectors are removed along with the power
+		commitments, err := miner.LoadSectorCommitments(ctx, vms.NewStorage(minerAddr, minerActor))
+		require.NoError(err)
+		assert.Len(commitments, 0)
+		assert.Nil(mstor.ProvingPeriodStart)
+
+		burnt, err := st.GetActor(ctx, address.BurntFundsAddress)
+		require.NoError(err)
+		assert.Equal(types.NewAttoFILFromFIL(100), burnt.Balance)
+
+		storageMkt, err := st.GetActor(ctx, address.StorageMarketAddress)
+		require.NoError(err)
+		var smstor State
+		builtin.RequireReadState(t, vms, address.StorageMarketAddress, storageMkt, &smstor)
+		assert.True(smstor.TotalCommittedStorage.IsZero())
+	})
+
+	t.Run("blocks at different heights are not evidence", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+		st, vms, minerAddr := setup(t)
+
+		first, second := conflictingBlocks(t, minerAddr)
+		second.Height = 6
+		pdata := actor.MustConvertParams(first.ToNode().RawData(), second.ToNode().RawData())
+		msg := types.NewMessage(address.TestAddress2, address.StorageMarketAddress, 0, types.NewZeroAttoFIL(), "slashConsensusFault", pdata)
+		result, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(7))
+		require.NoError(err)
+		require.Error(result.ExecutionError)
+		assert.Equal(uint8(ErrInvalidConsensusFault), result.Receipt.ExitCode)
+	})
+
+	t.Run("blocks not signed by the miner owner are not evidence", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+		st, vms, minerAddr := setup(t)
+
+		first, second := conflictingBlocks(t, minerAddr)
+		require.NoError(second.Sign(signer, signer.Addresses[1]))
+		pdata := actor.MustConvertParams(first.ToNode().RawData(), second.ToNode().RawData())
+		msg := types.NewMessage(address.TestAddress2, address.StorageMarketAddress, 0, types.NewZeroAttoFIL(), "slashConsensusFault", pdata)
+		result, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(7))
+		require.NoError(err)
+		require.Error(result.ExecutionError)
+		assert.Equal(uint8(ErrInvalidConsensusFault), result.Receipt.ExitCode)
+	})
+
+	t.Run("identical blocks are not evidence", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+		st, vms, minerAddr := setup(t)
+
+		first, _ := conflictingBlocks(t, minerAddr)
+		pdata := actor.MustConvertParams(first.ToNode().RawData(), first.ToNode().RawData())
+		msg := types.NewMessage(address.TestAddress2, address.StorageMarketAddress, 0, types.NewZeroAttoFIL(), "slashConsensusFault", pdata)
+		result, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(7))
+		require.NoError(err)
+		assert.Equal(uint8(ErrInvalidConsensusFault), result.Receipt.ExitCode)
+	})
+
+	t.Run("only the storage market can slash a miner", func(t *testing.T) {
+		require := require.New(t)
+		st, vms, minerAddr := setup(t)
+
+		result, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 7, "slashConsensusFault")
+		require.NoError(err)
+		require.Error(result.ExecutionError)
+		require.Equal(uint8(miner.ErrCallerUnauthorized), result.Receipt.ExitCode)
+	})
+}
+
+func TestStorageMarketSlashStorageFault(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T) (state.Tree, vm.StorageMap, address.Address) {
+		require := require.New(t)
+		st, vms := core.CreateStorages(ctx, t)
+
+		pdata := actor.MustConvertParams(big.NewInt(10), []byte{}, th.RequireRandomPeerID())
+		msg := types.NewMessage(address.TestAddress, address.StorageMarketAddress, 0, types.NewAttoFILFromFIL(100), "createMiner", pdata)
+		result, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(0))
+		require.NoError(err)
+		require.NoError(result.ExecutionError)
+
+		minerAddr, err := address.NewFromBytes(result.Receipt.Return[0])
+		require.NoError(err)
+
+		// the proving period starts at height 3
+		result, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 3, "commitSector", uint64(1), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(int(proofs.SealBytesLen)), []uint64{})
+		require.NoError(err)
+		require.NoError(result.ExecutionError)
+
+		return st, vms, minerAddr
+	}
+
+	graceEnd := types.NewBlockHeight(3).Add(miner.ProvingPeriodBlocks).Add(miner.GracePeriodBlocks)
+
+	t.Run("missing the grace period burns collateral and removes power", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+		st, vms, minerAddr := setup(t)
+
+		msg := types.NewMessage(address.TestAddress2, address.StorageMarketAddress, 0, types.NewZeroAttoFIL(), "slashStorageFault", actor.MustConvertParams(minerAddr))
+		result, err := th.ApplyTestMessage(st, vms, msg, graceEnd.Add(types.NewBlockHeight(1)))
+		require.NoError(err)
+		require.NoError(result.ExecutionError)
+
+		minerActor, err := st.GetActor(ctx, minerAddr)
+		require.NoError(err)
+		var mstor miner.State
+		builtin.RequireReadState(t, vms, minerAddr, minerActor, &mstor)
+		assert.True(mstor.Collateral.IsZero())
+		assert.True(mstor.Power.IsZero())
+		assert.True(minerActor.Balance.IsZero())
+
+		// the slashed sectors are removed along with the power
+		commitments, err := miner.LoadSectorCommitments(ctx, vms.NewStorage(minerAddr, minerActor))
+		require.NoError(err)
+		assert.Len(commitments, 0)
+		assert.Nil(mstor.ProvingPeriodStart)
+
+		storageMkt, err := st.GetActor(ctx, address.StorageMarketAddress)
+		require.NoError(err)
+		var smstor State
+		builtin.RequireReadState(t, vms, address.StorageMarketAddress, storageMkt, &smstor)
+		assert.True(smstor.TotalCommittedStorage.IsZero())
+	})
+
+	t.Run("miners within the grace period are not slashed", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+		st, vms, minerAddr := setup(t)
+
+		msg := types.NewMessage(address.TestAddress2, address.StorageMarketAddress, 0, types.NewZeroAttoFIL(), "slashStorageFault", actor.MustConvertParams(minerAddr))
+		result, err := th.ApplyTestMessage(st, vms, msg, graceEnd)
+		require.NoError(err)
+		require.Error(result.ExecutionError)
+
+		minerActor, err := st.GetActor(ctx, minerAddr)
+		require.NoError(err)
+		var mstor miner.State
+		builtin.RequireReadState(t, vms, minerAddr, minerActor, &mstor)
+		assert.False(mstor.Power.IsZero())
+	})
+
+	t.Run("only the storage market can slash a miner", func(t *testing.T) {
+		require := require.New(t)
+		st, vms, minerAddr := setup(t)
+
+		result, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, graceEnd.AsBigInt().Uint64()+1, "slashStorageFault")
+		require.NoError(err)
+		require.Error(result.ExecutionError)
+		require.Equal(uint8(miner.ErrCallerUnauthorized), result.Receipt.ExitCode)
+	})
+}
+
+func TestStorageMarketDeals(t *testing.T) {
+	ctx := context.Background()
+
+	signer := types.NewMockSigner(types.MustGenerateKeyInfo(1, types.GenerateKeyInfoSeed()))
+	client := signer.Addresses[0]
+
+	setup := func(t *testing.T) (state.Tree, vm.StorageMap, address.Address) {
+		require := require.New(t)
+		st, vms := core.CreateStorages(ctx, t)
+
+		pdata := actor.MustConvertParams(big.NewInt(10), []byte{}, th.RequireRandomPeerID())
+		msg := types.NewMessage(address.TestAddress, address.StorageMarketAddress, 0, types.NewAttoFILFromFIL(100), "createMiner", pdata)
+		result, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(0))
+		require.NoError(err)
+		require.NoError(result.ExecutionError)
+
+		minerAddr, err := address.NewFromBytes(result.Receipt.Return[0])
+		require.NoError(err)
+		return st, vms, minerAddr
+	}
+
+	signDeal := func(t *testing.T, minerAddr address.Address) types.Signature {
+		sig, err := SignDeal(minerAddr, types.SomeCid(), types.NewBytesAmount(1000), types.NewBlockHeight(100), types.NewAttoFILFromFIL(5), client, signer)
+		require.NoError(t, err)
+		return sig
+	}
+
+	publishSignedDeal := func(t *testing.T, st state.Tree, vms vm.StorageMap, from, minerAddr address.Address, sig types.Signature) *consensus.ApplicationResult {
+		pdata := actor.MustConvertParams(minerAddr, client, types.SomeCid().Bytes(), types.NewBytesAmount(1000), types.NewBlockHeight(100), types.NewAttoFILFromFIL(5), []byte(sig))
+		msg := types.NewMessage(from, address.StorageMarketAddress, 0, types.NewAttoFILFromFIL(2), "publishDeal", pdata)
+		result, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(1))
+		require.NoError(t, err)
+		return result
+	}
+
+	publishDeal := func(t *testing.T, st state.Tree, vms vm.StorageMap, from, minerAddr address.Address) *consensus.ApplicationResult {
+		return publishSignedDeal(t, st, vms, from, minerAddr, signDeal(t, minerAddr))
+	}
+
+	balance := func(t *testing.T, st state.Tree, addr address.Address) *types.AttoFIL {
+		act, err := st.GetActor(ctx, addr)
+		require.NoError(t, err)
+		return act.Balance
+	}
+
+	loadDeals := func(t *testing.T, st state.Tree, vms vm.StorageMap) []*Deal {
+		storageMkt, err := st.GetActor(ctx, address.StorageMarketAddress)
+		require.NoError(t, err)
+		deals, err := LoadDeals(ctx, vms.NewStorage(address.StorageMarketAddress, storageMkt))
+		require.NoError(t, err)
+		return deals
+	}
+
+	t.Run("the miner owner publishes deals", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+		st, vms, minerAddr := setup(t)
+
+		result := publishDeal(t, st, vms, address.TestAddress, minerAddr)
+		require.NoError(result.ExecutionError)
+		assert.Equal(uint64(0), big.NewInt(0).SetBytes(result.Receipt.Return[0]).Uint64())
+		require.Len(result.Receipt.Events, 1)
+		assert.Equal(DealPublishedTopic, result.Receipt.Events[0].Topic)
+
+		result = publishDeal(t, st, vms, address.TestAddress, minerAddr)
+		require.NoError(result.ExecutionError)
+		assert.Equal(uint64(1), big.NewInt(0).SetBytes(result.Receipt.Return[0]).Uint64())
+
+		deals := loadDeals(t, st, vms)
+		require.Len(deals, 2)
+		assert.Equal(uint64(0), deals[0].ID)
+		assert.Equal(client, deals[0].Client)
+		assert.Equal(minerAddr, deals[0].Miner)
+		assert.Equal(types.SomeCid(), deals[0].PieceRef)
+		assert.Equal(types.NewBytesAmount(1000), deals[0].Size)
+		assert.Equal(types.NewBlockHeight(100), deals[0].Duration)
+		assert.Equal(types.NewAttoFILFromFIL(5), deals[0].Price)
+		assert.Equal(types.NewAttoFILFromFIL(2), deals[0].Collateral)
+		assert.True(VerifyDealSignature(minerAddr, deals[0].PieceRef, deals[0].Size, deals[0].Duration, deals[0].Price, client, deals[0].Signature))
+		assert.False(deals[0].Committed)
+	})
+
+	t.Run("deals must be signed by their client", func(t *testing.T) {
+		require := require.New(t)
+		st, vms, minerAddr := setup(t)
+
+		result := publishSignedDeal(t, st, vms, address.TestAddress, minerAddr, signDeal(t, address.TestAddress2))
+		require.Error(result.ExecutionError)
+		require.Equal(uint8(ErrInvalidDealSignature), result.Receipt.ExitCode)
+	})
+
+	t.Run("only the miner owner may publish deals", func(t *testing.T) {
+		require := require.New(t)
+		st, vms, minerAddr := setup(t)
+
+		result := publishDeal(t, st, vms, address.TestAddress2, minerAddr)
+		require.Error(result.ExecutionError)
+		require.Equal(uint8(ErrNotMinerOwner), result.Receipt.ExitCode)
+	})
+
+	t.Run("deals are only published for known miners", func(t *testing.T) {
+		require := require.New(t)
+		st, vms, _ := setup(t)
+
+		result := publishDeal(t, st, vms, address.TestAddress, address.TestAddress2)
+		require.Error(result.ExecutionError)
+		require.Equal(uint8(ErrUnknownMiner), result.Receipt.ExitCode)
+	})
+
+	t.Run("committing a sector commits its deals", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+		st, vms, minerAddr := setup(t)
+
+		result := publishDeal(t, st, vms, address.TestAddress, minerAddr)
+		require.NoError(result.ExecutionError)
+
+		result, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 3, "commitSector", uint64(1), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(int(proofs.SealBytesLen)), []uint64{0})
+		require.NoError(err)
+		require.NoError(result.ExecutionError)
+
+		deals := loadDeals(t, st, vms)
+		require.Len(deals, 1)
+		assert.True(deals[0].Committed)
+		assert.Equal(uint64(1), deals[0].SectorID)
+
+		result, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 4, "commitSector", uint64(2), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(int(proofs.SealBytesLen)), []uint64{0})
+		require.NoError(err)
+		require.Error(result.ExecutionError)
+		assert.Contains(result.ExecutionError.Error(), Errors[ErrDealCommitted].Error())
+
+		result, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 4, "commitSector", uint64(2), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(int(proofs.SealBytesLen)), []uint64{7})
+		require.NoError(err)
+		require.Error(result.ExecutionError)
+		assert.Contains(result.ExecutionError.Error(), Errors[ErrUnknownDeal].Error())
+	})
+
+	t.Run("removing sectors terminates their deals and pays their collateral to the client", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+		st, vms, minerAddr := setup(t)
+
+		require.NoError(publishDeal(t, st, vms, address.TestAddress, minerAddr).ExecutionError)
+		require.NoError(publishDeal(t, st, vms, address.TestAddress, minerAddr).ExecutionError)
+
+		result, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 3, "commitSector", uint64(1), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(int(proofs.SealBytesLen)), []uint64{0})
+		require.NoError(err)
+		require.NoError(result.ExecutionError)
+		result, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 4, "commitSector", uint64(2), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(int(proofs.SealBytesLen)), []uint64{1})
+		require.NoError(err)
+		require.NoError(result.ExecutionError)
+
+		result, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 5, "terminateSector", uint64(2))
+		require.NoError(err)
+		require.NoError(result.ExecutionError)
+
+		deals := loadDeals(t, st, vms)
+		require.Len(deals, 2)
+		assert.False(deals[0].Terminated)
+		assert.True(deals[1].Terminated)
+		assert.False(deals[1].Ended)
+		assert.True(types.NewAttoFILFromFIL(2).Equal(balance(t, st, client)))
+
+		// slashing the miner removes its remaining sectors
+		graceEnd := types.NewBlockHeight(3).Add(miner.ProvingPeriodBlocks).Add(miner.GracePeriodBlocks)
+		msg := types.NewMessage(address.TestAddress2, address.StorageMarketAddress, 0, types.NewZeroAttoFIL(), "slashStorageFault", actor.MustConvertParams(minerAddr))
+		result, err = th.ApplyTestMessage(st, vms, msg, graceEnd.Add(types.NewBlockHeight(1)))
+		require.NoError(err)
+		require.NoError(result.ExecutionError)
+
+		deals = loadDeals(t, st, vms)
+		assert.True(deals[0].Terminated)
+		assert.False(deals[0].Ended)
+		assert.True(types.NewAttoFILFromFIL(4).Equal(balance(t, st, client)))
+	})
+
+	t.Run("slashing a miner only terminates its own deals", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+		st, vms, minerAddr := setup(t)
+
+		pdata := actor.MustConvertParams(big.NewInt(10), []byte{}, th.RequireRandomPeerID())
+		msg := types.NewMessage(address.TestAddress2, address.StorageMarketAddress, 0, types.NewAttoFILFromFIL(100), "createMiner", pdata)
+		result, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(0))
+		require.NoError(err)
+		require.NoError(result.ExecutionError)
+		otherMiner, err := address.NewFromBytes(result.Receipt.Return[0])
+		require.NoError(err)
+
+		require.NoError(publishDeal(t, st, vms, address.TestAddress, minerAddr).ExecutionError)
+		require.NoError(publishDeal(t, st, vms, address.TestAddress2, otherMiner).ExecutionError)
+
+		result, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 3, "commitSector", uint64(1), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(int(proofs.SealBytesLen)), []uint64{0})
+		require.NoError(err)
+		require.NoError(result.ExecutionError)
+		result, err = th.CreateAndApplyTestMessage(t, st, vms, otherMiner, 0, 3, "commitSector", uint64(1), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(int(proofs.SealBytesLen)), []uint64{1})
+		require.NoError(err)
+		require.NoError(result.ExecutionError)
+
+		graceEnd := types.NewBlockHeight(3).Add(miner.ProvingPeriodBlocks).Add(miner.GracePeriodBlocks)
+		msg = types.NewMessage(address.TestAddress2, address.StorageMarketAddress, 0, types.NewZeroAttoFIL(), "slashStorageFault", actor.MustConvertParams(minerAddr))
+		result, err = th.ApplyTestMessage(st, vms, msg, graceEnd.Add(types.NewBlockHeight(1)))
+		require.NoError(err)
+		require.NoError(result.ExecutionError)
+
+		deals := loadDeals(t, st, vms)
+		require.Len(deals, 2)
+		assert.True(deals[0].Terminated)
+		assert.False(deals[1].Terminated)
+		assert.True(deals[1].Committed)
+
+		storageMkt, err := st.GetActor(ctx, address.StorageMarketAddress)
+		require.NoError(err)
+		storage := vms.NewStorage(address.StorageMarketAddress, storageMkt)
+		mktState, err := LoadState(storage)
+		require.NoError(err)
+		err = actor.WithTypedLookupForReading(ctx, storage, mktState.MinerSectors, []uint64{}, func(minerSectors exec.Lookup) error {
+			_, err := minerSectors.Find(ctx, minerAddr.String())
+			assert.Equal(hamt.ErrNotFound, err)
+
+			sectorIDs, err := minerSectors.Find(ctx, otherMiner.String())
+			require.NoError(err)
+			assert.Equal([]uint64{1}, sectorIDs)
+			return nil
+		})
+		require.NoError(err)
+	})
+
+	t.Run("the client or miner owner may cancel an uncommitted deal to refund its collateral", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+		st, vms, minerAddr := setup(t)
+
+		require.NoError(publishDeal(t, st, vms, address.TestAddress, minerAddr).ExecutionError)
+		require.NoError(publishDeal(t, st, vms, address.TestAddress, minerAddr).ExecutionError)
+
+		cancelDeal := func(from address.Address, id int64) *consensus.ApplicationResult {
+			msg := types.NewMessage(from, address.StorageMarketAddress, 0, types.NewZeroAttoFIL(), "cancelDeal", actor.MustConvertParams(big.NewInt(id)))
+			result, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(2))
+			require.NoError(err)
+			return result
+		}
+
+		result := cancelDeal(address.TestAddress2, 0)
+		require.Error(result.ExecutionError)
+		assert.Equal(uint8(ErrNotDealParty), result.Receipt.ExitCode)
+
+		before := balance(t, st, address.TestAddress)
+		require.NoError(cancelDeal(address.TestAddress, 0).ExecutionError)
+		assert.True(before.Add(types.NewAttoFILFromFIL(2)).Equal(balance(t, st, address.TestAddress)))
+
+		result = cancelDeal(address.TestAddress, 0)
+		require.Error(result.ExecutionError)
+		assert.Equal(uint8(ErrDealCancelled), result.Receipt.ExitCode)
+
+		// the client needs an account to send messages
+		msg := types.NewMessage(address.TestAddress, client, 0, types.NewAttoFILFromFIL(1), "", nil)
+		result, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(2))
+		require.NoError(err)
+		require.NoError(result.ExecutionError)
+
+		before = balance(t, st, address.TestAddress)
+		require.NoError(cancelDeal(client, 1).ExecutionError)
+		assert.True(before.Add(types.NewAttoFILFromFIL(2)).Equal(balance(t, st, address.TestAddress)))
+
+		deals := loadDeals(t, st, vms)
+		require.Len(deals, 2)
+		assert.True(deals[0].Cancelled)
+		assert.True(deals[1].Cancelled)
+
+		result, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 3, "commitSector", uint64(1), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(int(proofs.SealBytesLen)), []uint64{0})
+		require.NoError(err)
+		require.Error(result.ExecutionError)
+		assert.Contains(result.ExecutionError.Error(), Errors[ErrDealCancelled].Error())
+	})
+
+	t.Run("committed deals cannot be cancelled", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+		st, vms, minerAddr := setup(t)
+
+		require.NoError(publishDeal(t, st, vms, address.TestAddress, minerAddr).ExecutionError)
+		result, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 3, "commitSector", uint64(1), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(int(proofs.SealBytesLen)), []uint64{0})
+		require.NoError(err)
+		require.NoError(result.ExecutionError)
+
+		msg := types.NewMessage(address.TestAddress, address.StorageMarketAddress, 0, types.NewZeroAttoFIL(), "cancelDeal", actor.MustConvertParams(big.NewInt(0)))
+		result, err = th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(4))
+		require.NoError(err)
+		require.Error(result.ExecutionError)
+		assert.Equal(uint8(ErrDealCommitted), result.Receipt.ExitCode)
+	})
+
+	t.Run("only the miner of a deal may commit it", func(t *testing.T) {
+		require := require.New(t)
+		st, vms, minerAddr := setup(t)
+
+		result := publishDeal(t, st, vms, address.TestAddress, minerAddr)
+		require.NoError(result.ExecutionError)
+
+		result, err := th.CreateAndApplyTestMessage(t, st, vms, address.StorageMarketAddress, 0, 3, "commitDeals", uint64(1), []uint64{0})
+		require.NoError(err)
+		require.Error(result.ExecutionError)
+		require.Equal(uint8(ErrDealNotForMiner), result.Receipt.ExitCode)
+	})
+}
+
+func TestMigrateLegacyState(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	st, vms := core.CreateStorages(ctx, t)
+
+	pdata := actor.MustConvertParams(big.NewInt(10), []byte{}, th.RequireRandomPeerID())
+	msg := types.NewMessage(address.TestAddress, address.StorageMarketAddress, 0, types.NewAttoFILFromFIL(100), "createMiner", pdata)
+	result, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(0))
+	require.NoError(err)
+	require.NoError(result.ExecutionError)
+	minerAddr, err := address.NewFromBytes(result.Receipt.Return[0])
+	require.NoError(err)
+
+	// put the miner and the market back into the legacy layout, with 2 sectors committed
+	minerAct, err := st.GetActor(ctx, minerAddr)
+	require.NoError(err)
+	minerStorage := vms.NewStorage(minerAddr, minerAct)
+	c, err := minerStorage.Put(&miner.LegacyState{
+		Owner:         address.TestAddress,
+		PledgeSectors: big.NewInt(10),
+		Collateral:    types.NewAttoFILFromFIL(100),
+		NextAskID:     big.NewInt(0),
+		Power:         big.NewInt(2),
+	})
+	require.NoError(err)
+	require.NoError(minerStorage.Commit(c, minerStorage.Head()))
+
+	market, err := st.GetActor(ctx, address.StorageMarketAddress)
+	require.NoError(err)
+	storage := vms.NewStorage(address.StorageMarketAddress, market)
+	miners, err := actor.SetKeyValue(ctx, storage, cid.Undef, minerAddr.String(), true)
+	require.NoError(err)
+	c, err = storage.Put(&LegacyState{Miners: miners, TotalCommittedStorage: big.NewInt(2)})
+	require.NoError(err)
+	require.NoError(storage.Commit(c, storage.Head()))
+
+	storageOf := func(addr address.Address) (exec.Storage, error) {
+		require.Equal(minerAddr, addr)
+		return minerStorage, nil
+	}
+	require.NoError(MigrateLegacyState(storage, storageOf, th.SectorSize()))
+
+	marketState, err := LoadState(storage)
+	require.NoError(err)
+	power := th.SectorSize().Mul(types.NewBytesAmount(2))
+	assert.True(power.Equal(marketState.TotalCommittedStorage))
+	assert.True(th.SectorSize().Equal(marketState.SectorSize))
+
+	lookup, err := actor.LoadTypedLookup(ctx, storage, marketState.Miners, types.BytesAmount{})
+	require.NoError(err)
+	value, err := lookup.Find(ctx, minerAddr.String())
+	require.NoError(err)
+	minerPower := value.(types.BytesAmount)
+	assert.True(power.Equal(&minerPower))
+}
+
 func TestMinimumCollateral(t *testing.T) {
 	assert := assert.New(t)
 	numSectors := big.NewInt(25000)
@@ -150,14 +754,19 @@ func TestMinimumCollateral(t *testing.T) {
 // minerActor and sends some FIL. If that FIL creates an actor tha cannot be upgraded to a miner
 // actor, this action will block the other user. Another possibility is that the miner actor will
 // overwrite the account with the balance thereby obliterating the FIL.
-func deriveMinerAddress(creator address.Address, nonce uint64) (address.Address, error) {
+func deriveMinerAddress(createMsg *types.Message) (address.Address, error) {
 	buf := new(bytes.Buffer)
 
-	if _, err := buf.Write(creator.Bytes()); err != nil {
+	c, err := createMsg.Cid()
+	if err != nil {
+		return address.Address{}, err
+	}
+	if _, err := buf.Write(c.Bytes()); err != nil {
 		return address.Address{}, err
 	}
 
-	if err := binary.Write(buf, binary.BigEndian, nonce); err != nil {
+	// the miner is the first actor the message creates
+	if err := binary.Write(buf, binary.BigEndian, uint64(0)); err != nil {
 		return address.Address{}, err
 	}
 
@@ -87,7 +87,12 @@ func MakeTypedExport(actor exec.ExecutableActor, method string) exec.ExportedFun
 			reflect.ValueOf(ctx),
 		}
 
-		for _, param := range params {
+		for i, param := range params {
+			// optional values may be nil, which has no reflect value of its own
+			if param.Val == nil {
+				args = append(args, reflect.Zero(t.In(i+2)))
+				continue
+			}
 			args = append(args, reflect.ValueOf(param.Val))
 		}
 
@@ -108,11 +113,13 @@ func MakeTypedExport(actor exec.ExecutableActor, method string) exec.ExportedFun
 			return nil, exitCode, outErr
 		}
 
-		vals := make([]interface{}, 0, len(out)-2)
-		for _, vv := range out[:len(out)-2] {
-			vals = append(vals, vv.Interface())
+		// encode with the declared types, since optional values cannot be
+		// told apart from their go values.
+		vals := make([]*abi.Value, 0, len(out)-2)
+		for i, vv := range out[:len(out)-2] {
+			vals = append(vals, &abi.Value{Type: signature.Return[i], Val: vv.Interface()})
 		}
-		retVal, err := abi.ToEncodedValues(vals...)
+		retVal, err := abi.EncodeValues(vals)
 		if err != nil {
 			return nil, 1, errors.FaultErrorWrap(err, "failed to marshal output value")
 		}
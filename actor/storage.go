@@ -7,6 +7,7 @@ import (
 	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
 	"gx/ipfs/QmRXf2uUSdGSunRJsM9wXSUNVwLUGCY3So5fAs7h2CBJVf/go-hamt-ipld"
 	cbor "gx/ipfs/QmRoARq3nkUb13HSKZGepCZSWe5GrVPwx7xURJGZ7KWv9V/go-ipld-cbor"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
 	block "gx/ipfs/QmWoXtvgC8inqFkAATB7cp2Dax7XBi9VDvSg9RCCZufmRk/go-block-format"
 	"gx/ipfs/QmfWqohMtbivn5NRJvtrLzCW3EU4QmoLvVNtmvo9vbdtVA/refmt/obj"
 	"gx/ipfs/QmfWqohMtbivn5NRJvtrLzCW3EU4QmoLvVNtmvo9vbdtVA/refmt/shared"
@@ -37,14 +38,19 @@ func UnmarshalStorage(raw []byte, to interface{}) error {
 //
 // Note that if 'f' returns an error, modifications to the storage are not
 // saved.
+//
+// The state is committed over the head it was read from. If 'f' changed the
+// head, for example by sending a message that called back into the actor,
+// nothing is saved and an error is returned.
 func WithState(ctx exec.VMContext, st interface{}, f func() (interface{}, error)) (interface{}, error) {
-	chunk, err := ctx.ReadStorage()
-	if err != nil {
-		return nil, vmerrors.FaultErrorWrap(err, "Could not read actor storage")
+	storage := ctx.Storage()
+	head := storage.Head()
+	if !head.Defined() {
+		return nil, vmerrors.NewRevertError("actor has no state")
 	}
 
-	if err := UnmarshalStorage(chunk, st); err != nil {
-		return nil, vmerrors.FaultErrorWrap(err, "Could not unmarshall actor storage")
+	if err := storage.GetObject(head, st); err != nil {
+		return nil, vmerrors.FaultErrorWrap(err, "Could not read actor storage")
 	}
 
 	ret, err := f()
@@ -52,13 +58,28 @@ func WithState(ctx exec.VMContext, st interface{}, f func() (interface{}, error)
 		return nil, err
 	}
 
-	if err := ctx.WriteStorage(st); err != nil {
+	id, err := storage.Put(st)
+	if err != nil {
 		return nil, vmerrors.FaultErrorWrap(err, "Could not write actor storage")
 	}
 
+	if err := storage.Commit(id, head); err != nil {
+		return nil, vmerrors.RevertErrorWrap(err, "Could not commit actor storage")
+	}
+
 	return ret, nil
 }
 
+// ReadState decodes the state at the head of an actor's storage into st.
+// Unlike WithState it needs no vm context, so state can be read without
+// executing actor code.
+func ReadState(storage exec.Storage, st interface{}) error {
+	if err := storage.GetObject(storage.Head(), st); err != nil {
+		return errors.Wrap(err, "Could not read actor storage")
+	}
+	return nil
+}
+
 // SetKeyValue convenience method to load a lookup, set one key value pair and commit.
 // This function is inefficient when multiple values need to be set into the lookup.
 func SetKeyValue(ctx context.Context, storage exec.Storage, id cid.Cid, key string, value interface{}) (cid.Cid, error) {
@@ -78,7 +99,19 @@ func SetKeyValue(ctx context.Context, storage exec.Storage, id cid.Cid, key stri
 // WithLookup allows one to read and write to a hamt-ipld node from storage via a callback function.
 // This function commits the lookup before returning.
 func WithLookup(ctx context.Context, storage exec.Storage, id cid.Cid, f func(exec.Lookup) error) (cid.Cid, error) {
-	lookup, err := LoadLookup(ctx, storage, id)
+	return WithTypedLookup(ctx, storage, id, nil, f)
+}
+
+// WithTypedLookup is like WithLookup, but unmarshals the values of the lookup
+// into the type of valueType. Actors use it to keep large collections, such as
+// the sectors of a miner, in a lookup referenced by a cid field of their state,
+// so that changing one element does not rewrite the whole collection:
+//
+// state.Sectors, err = WithTypedLookup(ctx, storage, state.Sectors, Sector{}, func(sectors exec.Lookup) error {
+//   return sectors.Set(ctx, id, sector)
+// })
+func WithTypedLookup(ctx context.Context, storage exec.Storage, id cid.Cid, valueType interface{}, f func(exec.Lookup) error) (cid.Cid, error) {
+	lookup, err := LoadTypedLookup(ctx, storage, id, valueType)
 	if err != nil {
 		return cid.Undef, err
 	}
@@ -93,7 +126,13 @@ func WithLookup(ctx context.Context, storage exec.Storage, id cid.Cid, f func(ex
 // WithLookupForReading allows one to read from a hamt-ipld node from storage via a callback function.
 // Unlike WithLookup, this function will not attempt to commit.
 func WithLookupForReading(ctx context.Context, storage exec.Storage, id cid.Cid, f func(exec.Lookup) error) error {
-	lookup, err := LoadLookup(ctx, storage, id)
+	return WithTypedLookupForReading(ctx, storage, id, nil, f)
+}
+
+// WithTypedLookupForReading is like WithLookupForReading, but unmarshals the
+// values of the lookup into the type of valueType.
+func WithTypedLookupForReading(ctx context.Context, storage exec.Storage, id cid.Cid, valueType interface{}, f func(exec.Lookup) error) error {
+	lookup, err := LoadTypedLookup(ctx, storage, id, valueType)
 	if err != nil {
 		return err
 	}
@@ -1,6 +1,7 @@
 package address
 
 import (
+	"encoding/binary"
 	"fmt"
 	"strings"
 
@@ -54,6 +55,8 @@ var (
 	ErrUnknownVersion = errors.New("unknown version")
 	// ErrInvalidBytes is returned when encountering an invalid byte format.
 	ErrInvalidBytes = errors.New("invalid bytes")
+	// ErrInvalidID is returned when encountering a malformed ID address.
+	ErrInvalidID = errors.New("invalid id")
 )
 
 // NetworkFromString tries to convert the string representation of a network to
@@ -103,9 +106,27 @@ func New(network Network, hash []byte) Address {
 	return addr
 }
 
+// NewBLSAddress constructs the address of the BLS public key with the given
+// hash for the given network.
+func NewBLSAddress(network Network, hash []byte) Address {
+	addr := New(network, hash)
+	addr[1] = BLSVersion
+	return addr
+}
+
+// NewIDAddress constructs the ID address with the given id for the given
+// network. IDs are assigned to actors by the init actor.
+func NewIDAddress(network Network, id uint64) Address {
+	var addr [Length]byte
+	addr[0] = network
+	addr[1] = IDVersion
+	binary.BigEndian.PutUint64(addr[Length-8:], id)
+	return addr
+}
+
 // NewFromString tries to parse a given string into a filecoin address.
 func NewFromString(s string) (Address, error) {
-	networkString, version, hash, err := decode(s)
+	networkString, version, data, err := decode(s)
 	if err != nil {
 		return Address{}, err
 	}
@@ -115,16 +136,31 @@ func NewFromString(s string) (Address, error) {
 		return Address{}, err
 	}
 
-	if version != Version {
+	switch version {
+	case Version:
+		if len(data) != HashLength {
+			return Address{}, fmt.Errorf("invalid data size: len=%d", len(data))
+		}
+		return New(network, data), nil
+	case BLSVersion:
+		if len(data) != HashLength {
+			return Address{}, fmt.Errorf("invalid data size: len=%d", len(data))
+		}
+		return NewBLSAddress(network, data), nil
+	case IDVersion:
+		id, err := decodeID(data)
+		if err != nil {
+			return Address{}, err
+		}
+		return NewIDAddress(network, id), nil
+	default:
 		return Address{}, ErrUnknownVersion
 	}
-
-	return New(network, hash), nil
 }
 
 // NewFromBytes tries to create an address from the given bytes.
 func NewFromBytes(raw []byte) (Address, error) {
-	if len(raw) != 22 {
+	if len(raw) < 3 || len(raw) != encodedLength(raw) {
 		return Address{}, ErrInvalidBytes
 	}
 
@@ -133,12 +169,20 @@ func NewFromBytes(raw []byte) (Address, error) {
 		return Address{}, ErrUnknownNetwork
 	}
 
-	version := raw[1]
-	if version != Version {
+	switch raw[1] {
+	case Version:
+		return New(network, raw[2:]), nil
+	case BLSVersion:
+		return NewBLSAddress(network, raw[2:]), nil
+	case IDVersion:
+		id, err := decodeID(raw[2:])
+		if err != nil {
+			return Address{}, err
+		}
+		return NewIDAddress(network, id), nil
+	default:
 		return Address{}, ErrUnknownVersion
 	}
-
-	return New(network, raw[2:]), nil
 }
 
 // ParseError checks if the given address parses as a valid filecoin address.
@@ -152,14 +196,19 @@ func ParseError(addr string) error {
 		return errors.Wrap(err, "invalid network")
 	}
 
-	if version != Version {
+	switch version {
+	case Version, BLSVersion:
+		if len(data) != HashLength {
+			return fmt.Errorf("invalid data length: len=%d", len(data))
+		}
+	case IDVersion:
+		if _, err := decodeID(data); err != nil {
+			return err
+		}
+	default:
 		return fmt.Errorf("invalid version: version=%d", version)
 	}
 
-	if len(data) != HashLength {
-		return fmt.Errorf("invalid data length: len=%d", len(data))
-	}
-
 	return nil
 }
 
@@ -183,6 +232,25 @@ func (a Address) Hash() []byte {
 	return a[2:]
 }
 
+// IsID returns true if the address is an ID address.
+func (a Address) IsID() bool {
+	return a.Version() == IDVersion
+}
+
+// IsBLS returns true if the address is the address of a BLS public key.
+func (a Address) IsBLS() bool {
+	return a.Version() == BLSVersion
+}
+
+// ID returns the id of an ID address. It returns ErrInvalidID if the address
+// is not an ID address.
+func (a Address) ID() (uint64, error) {
+	if !a.IsID() {
+		return 0, ErrInvalidID
+	}
+	return binary.BigEndian.Uint64(a[Length-8:]), nil
+}
+
 // Format implements the Formatter interface.
 func (a Address) Format(f fmt.State, c rune) {
 	switch c {
@@ -221,7 +289,12 @@ func (a *Address) UnmarshalText(in []byte) error {
 }
 
 func (a Address) String() string {
-	out, err := encode(NetworkToString(a.Network()), a.Version(), a.Hash())
+	data := a.Hash()
+	if id, err := a.ID(); err == nil {
+		data = encodeID(id)
+	}
+
+	out, err := encode(NetworkToString(a.Network()), a.Version(), data)
 	if err != nil {
 		// should really not happen
 		panic(err)
@@ -230,11 +303,45 @@ func (a Address) String() string {
 	return out
 }
 
-// Bytes returns the byte representation of the address.
+// Bytes returns the byte representation of the address. The id of an ID
+// address is encoded as a uvarint, so ID addresses take fewer bytes than the
+// hash addresses they stand for.
 func (a Address) Bytes() []byte {
+	if id, err := a.ID(); err == nil {
+		return append([]byte{a.Network(), IDVersion}, encodeID(id)...)
+	}
 	return a[:]
 }
 
+// encodedLength returns the length of the byte representation of the address
+// raw starts with.
+func encodedLength(raw []byte) int {
+	if len(raw) > 2 && raw[1] == IDVersion {
+		if _, n := binary.Uvarint(raw[2:]); n > 0 {
+			return 2 + n
+		}
+	}
+	return Length
+}
+
+// encodeID encodes an id as the uvarint that forms the data of the string and
+// byte forms of ID addresses, which keeps them short.
+func encodeID(id uint64) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, id)
+	return buf[:n]
+}
+
+// decodeID decodes the data of the string or byte form of an ID address, rejecting
+// anything but the canonical encoding of the id.
+func decodeID(data []byte) (uint64, error) {
+	id, n := binary.Uvarint(data)
+	if n <= 0 || n != len(data) || n != len(encodeID(id)) {
+		return 0, ErrInvalidID
+	}
+	return id, nil
+}
+
 // --
 // TODO: find a better place for the things below
 
@@ -243,7 +350,7 @@ func encode(hrp string, version byte, data []byte) (string, error) {
 	if len(hrp) != 2 {
 		return "", fmt.Errorf("hrp has invalid length: hrp=%d", len(hrp))
 	}
-	if len(data) != HashLength {
+	if len(data) == 0 || len(data) > HashLength {
 		return "", fmt.Errorf("data is malformed: data length=%d", len(data))
 	}
 	for p, c := range hrp {
@@ -318,7 +425,7 @@ func decode(addr string) (string, byte, []byte, error) {
 	if err != nil {
 		return "", 0, nil, err
 	}
-	if len(decodedBytes) != HashLength {
+	if len(decodedBytes) == 0 || len(decodedBytes) > HashLength {
 		return "", 0, nil, fmt.Errorf("invalid data size: len=%d", len(decodedBytes))
 	}
 	return hrp, dataBytes[0], decodedBytes, nil
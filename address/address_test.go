@@ -113,15 +113,91 @@ func TestInvalidAddressCreation(t *testing.T) {
 		assert.Equal(ErrUnknownNetwork, err)
 	})
 
-	t.Run(fmt.Sprintf("NewFromBytes supports only AddressVersion %d", Version), func(t *testing.T) {
+	t.Run(fmt.Sprintf("NewFromBytes supports only AddressVersion %d, IDVersion %d and BLSVersion %d", Version, IDVersion, BLSVersion), func(t *testing.T) {
 		assert := assert.New(t)
 
-		_, err := NewFromBytes([]byte{Testnet, Version + 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0})
+		_, err := NewFromBytes([]byte{Testnet, BLSVersion + 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0})
 		assert.Error(err)
 		assert.Equal(ErrUnknownVersion, err)
 	})
 }
 
+func TestIDAddress(t *testing.T) {
+	t.Run("roundtrips", func(t *testing.T) {
+		for _, id := range []uint64{0, 1, 127, 128, 1 << 40, ^uint64(0)} {
+			assert := assert.New(t)
+
+			a := NewIDAddress(Mainnet, id)
+			assert.True(a.IsID())
+			assert.Equal(IDVersion, a.Version())
+			actual, err := a.ID()
+			assert.NoError(err)
+			assert.Equal(id, actual)
+
+			fromString, err := NewFromString(a.String())
+			assert.NoError(err)
+			assert.Equal(a, fromString)
+			assert.NoError(ParseError(a.String()))
+
+			fromBytes, err := NewFromBytes(a.Bytes())
+			assert.NoError(err)
+			assert.Equal(a, fromBytes)
+		}
+	})
+
+	t.Run("string form is shorter than hash addresses", func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.True(len(NewIDAddress(Testnet, 1000).String()) < len(NewTestnet(hashes[0]).String()))
+	})
+
+	t.Run("hash addresses have no id", func(t *testing.T) {
+		assert := assert.New(t)
+
+		a := NewMainnet(hashes[0])
+		assert.False(a.IsID())
+		_, err := a.ID()
+		assert.Equal(ErrInvalidID, err)
+	})
+
+	t.Run("byte form is shorter than hash addresses", func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.Equal([]byte{Testnet, IDVersion, 0xe8, 0x07}, NewIDAddress(Testnet, 1000).Bytes())
+	})
+
+	t.Run("NewFromBytes rejects ids that are not canonically encoded", func(t *testing.T) {
+		assert := assert.New(t)
+
+		_, err := NewFromBytes([]byte{Mainnet, IDVersion, 0x81, 0x00})
+		assert.Equal(ErrInvalidID, err)
+
+		_, err = NewFromBytes([]byte{Mainnet, IDVersion, 0x01, 0x00})
+		assert.Equal(ErrInvalidBytes, err)
+	})
+}
+
+func TestBLSAddress(t *testing.T) {
+	assert := assert.New(t)
+
+	a := NewBLSAddress(Testnet, hashes[0])
+	assert.True(a.IsBLS())
+	assert.False(a.IsID())
+	assert.Equal(BLSVersion, a.Version())
+	assert.Equal(hashes[0], a.Hash())
+	assert.NotEqual(NewTestnet(hashes[0]), a)
+	assert.False(NewTestnet(hashes[0]).IsBLS())
+
+	fromString, err := NewFromString(a.String())
+	assert.NoError(err)
+	assert.Equal(a, fromString)
+	assert.NoError(ParseError(a.String()))
+
+	fromBytes, err := NewFromBytes(a.Bytes())
+	assert.NoError(err)
+	assert.Equal(a, fromBytes)
+}
+
 func TestAddressFormat(t *testing.T) {
 	assert := assert.New(t)
 
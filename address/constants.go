@@ -13,6 +13,15 @@ const Length = 1 + 1 + HashLength
 // Version is the current version of the address format.
 const Version byte = 0
 
+// IDVersion is the version of ID addresses, which hold the id the init actor
+// assigned to an actor instead of a hash.
+const IDVersion byte = 1
+
+// BLSVersion is the version of addresses that hold the hash of a BLS public
+// key. Messages from them are signed with BLS, whose signatures blocks
+// aggregate.
+const BLSVersion byte = 2
+
 // Base32Charset is the character set used for base32 encoding in addresses.
 const Base32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
 
@@ -42,6 +51,18 @@ var (
 	StorageMarketAddress Address
 	// PaymentBrokerAddress is the hard-coded address of the filecoin storage market
 	PaymentBrokerAddress Address
+	// BurntFundsAddress is the hard-coded address that slashed funds are sent
+	// to. Nothing can spend from it.
+	BurntFundsAddress Address
+	// InitAddress is the hard-coded address of the init actor, which assigns
+	// ID addresses to actors.
+	InitAddress Address
+	// GovernanceAddress is the hard-coded address of the governance actor,
+	// which schedules upgrades of actor code.
+	GovernanceAddress Address
+	// SystemAddress is the sender of the messages the protocol itself sends
+	// to actors. No actor lives at it, and nothing can sign for it.
+	SystemAddress Address
 )
 
 func init() {
@@ -59,4 +80,16 @@ func init() {
 
 	p := Hash([]byte("payments"))
 	PaymentBrokerAddress = NewMainnet(p)
+
+	b := Hash([]byte("burntfunds"))
+	BurntFundsAddress = NewMainnet(b)
+
+	i := Hash([]byte("init"))
+	InitAddress = NewMainnet(i)
+
+	g := Hash([]byte("governance"))
+	GovernanceAddress = NewMainnet(g)
+
+	sys := Hash([]byte("system"))
+	SystemAddress = NewMainnet(sys)
 }
@@ -33,8 +33,8 @@ var addrSetEntry = atlas.BuildEntry(Set{}).Transform().
 	TransformUnmarshal(atlas.MakeUnmarshalTransformFunc(
 		func(vals []byte) (Set, error) {
 			out := make(Set)
-			for i := 0; i < len(vals); i += Length {
-				end := i + Length
+			for i := 0; i < len(vals); {
+				end := i + encodedLength(vals[i:])
 				if end > len(vals) {
 					end = len(vals)
 				}
@@ -43,6 +43,7 @@ var addrSetEntry = atlas.BuildEntry(Set{}).Transform().
 					return nil, err
 				}
 				out[s] = struct{}{}
+				i = end
 			}
 			return out, nil
 		})).
@@ -16,6 +16,8 @@ func TestSetRoundtrip(t *testing.T) {
 	for i := range addrs {
 		addrs[i] = addrGetter()
 	}
+	// ID addresses take fewer bytes
+	addrs = append(addrs, NewIDAddress(Mainnet, 1), NewIDAddress(Mainnet, 1000))
 
 	set := Set{}
 
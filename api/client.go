@@ -30,6 +30,7 @@ type Client interface {
 	ImportData(ctx context.Context, data io.Reader) (ipld.Node, error)
 	ProposeStorageDeal(ctx context.Context, data cid.Cid, miner address.Address, ask uint64, duration uint64, allowDuplicates bool) (*storage.DealResponse, error)
 	QueryStorageDeal(ctx context.Context, prop cid.Cid) (*storage.DealResponse, error)
+	ListDeals(ctx context.Context) ([]*storage.ClientDeal, error)
 	ListAsks(ctx context.Context) (<-chan Ask, error)
 	Payments(ctx context.Context, dealCid cid.Cid) ([]*paymentbroker.PaymentVoucher, error)
 }
@@ -35,6 +35,9 @@ type DaemonInitConfig struct {
 	// AutoSealIntervalSeconds, when set, configures the daemon to check for and seal any staged sectors on an interval
 	AutoSealIntervalSeconds uint
 	DefaultAddress          address.Address
+	// Authorities, if set, are the block producers of a round-robin
+	// proof-of-authority chain created with a new genesis block.
+	Authorities []address.Address
 }
 
 // DaemonInitOpt is the signature a daemon init option has to fulfill.
@@ -102,3 +105,11 @@ func DefaultAddress(address address.Address) DaemonInitOpt {
 		dc.DefaultAddress = address
 	}
 }
+
+// Authorities selects round-robin proof-of-authority consensus with the given
+// block producers.
+func Authorities(authorities []address.Address) DaemonInitOpt {
+	return func(dc *DaemonInitConfig) {
+		dc.Authorities = authorities
+	}
+}
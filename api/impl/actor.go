@@ -7,7 +7,10 @@ import (
 
 	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/account"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/governance"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/initactor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/multisig"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
 	"github.com/filecoin-project/go-filecoin/api"
@@ -53,6 +56,12 @@ func ls(ctx context.Context, fcn *node.Node, actorGetter state.GetAllActorsFunc)
 			res[i] = makeActorView(a, addrs[i], &miner.Actor{})
 		case a.Code.Equals(types.BootstrapMinerActorCodeCid):
 			res[i] = makeActorView(a, addrs[i], &miner.Actor{})
+		case a.Code.Equals(types.MultisigActorCodeCid):
+			res[i] = makeActorView(a, addrs[i], &multisig.Actor{})
+		case a.Code.Equals(types.InitActorCodeCid):
+			res[i] = makeActorView(a, addrs[i], &initactor.Actor{})
+		case a.Code.Equals(types.GovernanceActorCodeCid):
+			res[i] = makeActorView(a, addrs[i], governance.NewCode())
 		default:
 			res[i] = makeActorView(a, addrs[i], nil)
 		}
@@ -3,7 +3,6 @@ package impl
 import (
 	"context"
 	"io"
-	"math/big"
 
 	"github.com/filecoin-project/go-filecoin/api"
 	"github.com/filecoin-project/go-filecoin/plumbing/msg"
@@ -12,10 +11,10 @@ import (
 	uio "gx/ipfs/QmQXze9tG878pa4Euya4rrDpyTNX3kQe4dhCaBzBozGgpe/go-unixfs/io"
 	chunk "gx/ipfs/QmR4QQVkBZsZENRjYFVi8dEtPL3daZRNKk24m4r6WKJHNm/go-ipfs-chunker"
 	cid "gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
-	cbor "gx/ipfs/QmRoARq3nkUb13HSKZGepCZSWe5GrVPwx7xURJGZ7KWv9V/go-ipld-cbor"
 	dag "gx/ipfs/QmTQdH4848iTVCJmKXYyRiK72HufWTLYQQ8iN3JaQ8K1Hq/go-merkledag"
 	ipld "gx/ipfs/QmcKKBwfz6FyQdHR2jsXrrF6XeSBXYL86anmWNewpFpoF5/go-ipld-format"
 
+	"github.com/filecoin-project/go-filecoin/abi"
 	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
@@ -65,6 +64,10 @@ func (api *nodeClient) QueryStorageDeal(ctx context.Context, prop cid.Cid) (*sto
 	return api.api.node.StorageMinerClient.QueryDeal(ctx, prop)
 }
 
+func (api *nodeClient) ListDeals(ctx context.Context) ([]*storage.ClientDeal, error) {
+	return api.api.node.StorageMinerClient.ListDeals(), nil
+}
+
 func (api *nodeClient) ListAsks(ctx context.Context) (<-chan mapi.Ask, error) {
 	nd := api.api.node
 
@@ -90,22 +93,12 @@ func (api *nodeClient) ListAsks(ctx context.Context) (<-chan mapi.Ask, error) {
 				return err
 			}
 
-			var asksIds []uint64
-			if err := cbor.DecodeInto(ret[0], &asksIds); err != nil {
+			asks, err := abi.Deserialize(ret[0], abi.ArrayOf(miner.AskType))
+			if err != nil {
 				return err
 			}
 
-			for _, id := range asksIds {
-				ret, _, err := queryer.Query(ctx, (address.Address{}), addr, "getAsk", big.NewInt(int64(id)))
-				if err != nil {
-					return err
-				}
-
-				var ask miner.Ask
-				if err := cbor.DecodeInto(ret[0], &ask); err != nil {
-					return err
-				}
-
+			for _, ask := range asks.Val.([]*miner.Ask) {
 				out <- mapi.Ask{
 					Expiry: ask.Expiry,
 					ID:     ask.ID.Uint64(),
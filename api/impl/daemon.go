@@ -145,6 +145,21 @@ func (nd *nodeDaemon) Init(ctx context.Context, opts ...api.DaemonInitOpt) error
 	}
 
 	switch {
+	case cfg.GenesisFile != "" && len(cfg.Authorities) > 0:
+		return fmt.Errorf("authorities are read from the genesis file and cannot be set when one is given")
+	case len(cfg.Authorities) > 0:
+		gif = consensus.MakeGenesisFunc(consensus.Authorities(cfg.Authorities...))
+
+		// a node whose default address is an authority mines with its miner
+		for _, authority := range cfg.Authorities {
+			if authority == cfg.DefaultAddress && cfg.WithMiner == (address.Address{}) {
+				newConfig := rep.Config()
+				newConfig.Mining.MinerAddress = consensus.AuthorityMinerAddress(authority)
+				if err := rep.ReplaceConfig(newConfig); err != nil {
+					return err
+				}
+			}
+		}
 	case cfg.GenesisFile != "":
 		// TODO: this feels a little wonky, I think the InitGenesis interface might need some tweaking
 		genCid, err := LoadGenesis(rep, cfg.GenesisFile)
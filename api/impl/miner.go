@@ -69,63 +69,37 @@ func (nm *nodeMiner) AddAsk(ctx context.Context, fromAddr, minerAddr address.Add
 }
 
 func (nm *nodeMiner) GetOwner(ctx context.Context, minerAddr address.Address) (address.Address, error) {
-	bytes, _, err := nm.porcelainAPI.MessageQuery(
-		ctx,
-		address.Address{},
-		minerAddr,
-		"getOwner",
-	)
+	state, err := nm.porcelainAPI.MinerState(ctx, types.SortedCidSet{}, minerAddr)
 	if err != nil {
 		return address.Address{}, err
 	}
 
-	return address.NewFromBytes(bytes[0])
+	return state.Owner, nil
 }
 
-func (nm *nodeMiner) GetPower(ctx context.Context, minerAddr address.Address) (*big.Int, error) {
-	bytes, _, err := nm.porcelainAPI.MessageQuery(
-		ctx,
-		address.Address{},
-		minerAddr,
-		"getPower",
-	)
+func (nm *nodeMiner) GetPower(ctx context.Context, minerAddr address.Address) (*types.BytesAmount, error) {
+	state, err := nm.porcelainAPI.MinerState(ctx, types.SortedCidSet{}, minerAddr)
 	if err != nil {
 		return nil, err
 	}
 
-	power := big.NewInt(0).SetBytes(bytes[0])
-
-	return power, nil
+	return state.Power, nil
 }
 
 func (nm *nodeMiner) GetPledge(ctx context.Context, minerAddr address.Address) (*big.Int, error) {
-	bytes, _, err := nm.porcelainAPI.MessageQuery(
-		ctx,
-		address.Address{},
-		minerAddr,
-		"getPledge",
-	)
+	state, err := nm.porcelainAPI.MinerState(ctx, types.SortedCidSet{}, minerAddr)
 	if err != nil {
 		return nil, err
 	}
 
-	power := big.NewInt(0).SetBytes(bytes[0])
-
-	return power, nil
+	return state.PledgeSectors, nil
 }
 
-func (nm *nodeMiner) GetTotalPower(ctx context.Context) (*big.Int, error) {
-	bytes, _, err := nm.porcelainAPI.MessageQuery(
-		ctx,
-		address.Address{},
-		address.StorageMarketAddress,
-		"getTotalStorage",
-	)
+func (nm *nodeMiner) GetTotalPower(ctx context.Context) (*types.BytesAmount, error) {
+	state, err := nm.porcelainAPI.StorageMarketState(ctx, types.SortedCidSet{})
 	if err != nil {
 		return nil, err
 	}
 
-	power := big.NewInt(0).SetBytes(bytes[0])
-
-	return power, nil
+	return state.TotalCommittedStorage, nil
 }
@@ -27,6 +27,10 @@ func (api *nodeMining) Once(ctx context.Context) (*types.Block, error) {
 	if err != nil {
 		return nil, err
 	}
+	miningOwnerAddr, err := nd.MiningOwnerAddress(ctx, miningAddr)
+	if err != nil {
+		return nil, err
+	}
 	blockTime, mineDelay := nd.MiningTimes()
 
 	getStateByKey := func(ctx context.Context, tsKey string) (state.Tree, error) {
@@ -39,10 +43,10 @@ func (api *nodeMining) Once(ctx context.Context) (*types.Block, error) {
 	getState := func(ctx context.Context, ts types.TipSet) (state.Tree, error) {
 		return getStateByKey(ctx, ts.String())
 	}
-	getWeight := func(ctx context.Context, ts types.TipSet) (uint64, error) {
+	getWeight := func(ctx context.Context, ts types.TipSet) (*types.ChainWeight, error) {
 		parent, err := ts.Parents()
 		if err != nil {
-			return uint64(0), err
+			return nil, err
 		}
 		// TODO handle genesis cid more gracefully
 		if parent.Len() == 0 {
@@ -50,14 +54,17 @@ func (api *nodeMining) Once(ctx context.Context) (*types.Block, error) {
 		}
 		pSt, err := getStateByKey(ctx, parent.String())
 		if err != nil {
-			return uint64(0), err
+			return nil, err
 		}
 		return nd.Consensus.Weight(ctx, ts, pSt)
 	}
 	getAncestors := func(ctx context.Context, ts types.TipSet, newBlockHeight *types.BlockHeight) ([]types.TipSet, error) {
 		return chain.GetRecentAncestors(ctx, ts, nd.ChainReader, newBlockHeight, consensus.AncestorRoundsNeeded, consensus.LookBackParameter)
 	}
-	worker := mining.NewDefaultWorker(nd.MsgPool, getState, getWeight, getAncestors, consensus.NewDefaultProcessor(), nd.PowerTable, nd.Blockstore, nd.CborStore(), miningAddr, blockTime)
+	worker := mining.NewDefaultWorker(nd.MsgPool, getState, getWeight, getAncestors, consensus.NewDefaultProcessor(), nd.PowerTable, nd.Blockstore, nd.CborStore(), miningAddr, miningOwnerAddr, nd.Wallet, blockTime)
+	if len(nd.Authorities) > 0 {
+		worker.SetAuthorities(nd.Authorities)
+	}
 
 	res, err := mining.MineOnce(ctx, worker, mineDelay, ts)
 	if err != nil {
@@ -45,24 +45,7 @@ func (np *nodePaych) Ls(ctx context.Context, fromAddr, payerAddr address.Address
 		payerAddr = fromAddr
 	}
 
-	values, _, err := np.porcelainAPI.MessageQuery(
-		ctx,
-		fromAddr,
-		address.PaymentBrokerAddress,
-		"ls",
-		payerAddr,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	var channels map[string]*paymentbroker.PaymentChannel
-
-	if err := cbor.DecodeInto(values[0], &channels); err != nil {
-		return nil, err
-	}
-
-	return channels, nil
+	return np.porcelainAPI.PaymentChannels(ctx, types.SortedCidSet{}, payerAddr)
 }
 
 func (np *nodePaych) Voucher(ctx context.Context, fromAddr address.Address, channel *types.ChannelID, amount *types.AttoFIL, validAt *types.BlockHeight) (string, error) {
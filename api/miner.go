@@ -18,6 +18,6 @@ type Miner interface {
 	AddAsk(ctx context.Context, fromAddr, minerAddr address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits, price *types.AttoFIL, expiry *big.Int) (cid.Cid, error)
 	GetOwner(ctx context.Context, minerAddr address.Address) (address.Address, error)
 	GetPledge(ctx context.Context, minerAddr address.Address) (*big.Int, error)
-	GetPower(ctx context.Context, minerAddr address.Address) (*big.Int, error)
-	GetTotalPower(ctx context.Context) (*big.Int, error)
+	GetPower(ctx context.Context, minerAddr address.Address) (*types.BytesAmount, error)
+	GetTotalPower(ctx context.Context) (*types.BytesAmount, error)
 }
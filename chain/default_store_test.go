@@ -25,7 +25,7 @@ func initStoreTest(ctx context.Context, require *require.Assertions) {
 	r := repo.NewInMemoryRepo()
 	bs := bstore.NewBlockstore(r.Datastore())
 	cst := hamt.NewCborStore()
-	con := consensus.NewExpected(cst, bs, testhelpers.NewTestProcessor(), powerTable, genCid, proofs.NewFakeVerifier(true, nil))
+	con := consensus.NewExpected(cst, bs, testhelpers.NewTestProcessor(), powerTable, genCid, proofs.NewFakeVerifier(true, nil), &testhelpers.TestBlockSignatureValidator{})
 	initSyncTest(require, con, consensus.InitGenesis, cst, bs, r)
 	requireSetTestChain(require, con, true)
 }
@@ -61,20 +61,25 @@ type DefaultSyncer struct {
 	badTipSets *badTipSetCache
 	consensus  consensus.Protocol
 	chainStore Store
+	// faultDetector, if set, is shown every block of each tipset that passes
+	// the state transition so it can spot miners producing conflicting blocks.
+	faultDetector *ConsensusFaultDetector
 }
 
 var _ Syncer = (*DefaultSyncer)(nil)
 
-// NewDefaultSyncer constructs a DefaultSyncer ready for use.
-func NewDefaultSyncer(online, offline *hamt.CborIpldStore, c consensus.Protocol, s Store) Syncer {
+// NewDefaultSyncer constructs a DefaultSyncer ready for use. The fault
+// detector is optional and may be nil.
+func NewDefaultSyncer(online, offline *hamt.CborIpldStore, c consensus.Protocol, s Store, fd *ConsensusFaultDetector) Syncer {
 	return &DefaultSyncer{
 		cstOnline:  online,
 		cstOffline: offline,
 		badTipSets: &badTipSetCache{
 			bad: make(map[string]struct{}),
 		},
-		consensus:  c,
-		chainStore: s,
+		consensus:     c,
+		chainStore:    s,
+		faultDetector: fd,
 	}
 }
 
@@ -218,6 +223,14 @@ func (syncer *DefaultSyncer) syncOne(ctx context.Context, parent, next types.Tip
 	if err != nil {
 		return err
 	}
+
+	// Only blocks that passed full validation, including their signatures,
+	// are evidence of a consensus fault.
+	if syncer.faultDetector != nil {
+		for _, blk := range next.ToSlice() {
+			syncer.faultDetector.Observe(ctx, blk)
+		}
+	}
 	root, err := st.Flush(ctx)
 	if err != nil {
 		return err
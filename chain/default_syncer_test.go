@@ -155,7 +155,7 @@ func loadSyncerFromRepo(require *require.Assertions, r repo.Repo) (Syncer, *hamt
 	bs := bstore.NewBlockstore(r.Datastore())
 	cst := hamt.NewCborStore()
 	verifier := proofs.NewFakeVerifier(true, nil)
-	con := consensus.NewExpected(cst, bs, testhelpers.NewTestProcessor(), powerTable, genCid, verifier)
+	con := consensus.NewExpected(cst, bs, testhelpers.NewTestProcessor(), powerTable, genCid, verifier, &testhelpers.TestBlockSignatureValidator{})
 	syncer, chain, cst, _ := initSyncTest(require, con, consensus.InitGenesis, cst, bs, r)
 	ctx := context.Background()
 	err := chain.Load(ctx)
@@ -172,7 +172,7 @@ func initSyncTestDefault(require *require.Assertions) (Syncer, Store, *hamt.Cbor
 	bs := bstore.NewBlockstore(r.Datastore())
 	cst := hamt.NewCborStore()
 	verifier := proofs.NewFakeVerifier(true, nil)
-	con := consensus.NewExpected(cst, bs, processor, powerTable, genCid, verifier)
+	con := consensus.NewExpected(cst, bs, processor, powerTable, genCid, verifier, &testhelpers.TestBlockSignatureValidator{})
 	requireSetTestChain(require, con, false)
 	return initSyncTest(require, con, consensus.InitGenesis, cst, bs, r)
 }
@@ -185,7 +185,7 @@ func initSyncTestWithPowerTable(require *require.Assertions, powerTable consensu
 	bs := bstore.NewBlockstore(r.Datastore())
 	cst := hamt.NewCborStore()
 	verifier := proofs.NewFakeVerifier(true, nil)
-	con := consensus.NewExpected(cst, bs, processor, powerTable, genCid, verifier)
+	con := consensus.NewExpected(cst, bs, processor, powerTable, genCid, verifier, &testhelpers.TestBlockSignatureValidator{})
 	requireSetTestChain(require, con, false)
 	sync, chain, cst, _ := initSyncTest(require, con, consensus.InitGenesis, cst, bs, r)
 	return sync, chain, cst, con
@@ -204,7 +204,7 @@ func initSyncTest(require *require.Assertions, con consensus.Protocol, genFunc f
 	chain := NewDefaultStore(chainDS, cst, calcGenBlk.Cid())
 
 	// chain.Syncer
-	syncer := NewDefaultSyncer(cst, cst, con, chain) // note we use same cst for on and offline for tests
+	syncer := NewDefaultSyncer(cst, cst, con, chain, nil) // note we use same cst for on and offline for tests
 
 	// Initialize stores to contain genesis block and state
 	calcGenTS := testhelpers.RequireNewTipSet(require, calcGenBlk)
@@ -653,16 +653,16 @@ func TestWidenChainAncestor(t *testing.T) {
 
 type powerTableForWidenTest struct{}
 
-func (pt *powerTableForWidenTest) Total(ctx context.Context, st state.Tree, bs bstore.Blockstore) (uint64, error) {
-	return uint64(100), nil
+func (pt *powerTableForWidenTest) Total(ctx context.Context, st state.Tree, bs bstore.Blockstore) (*types.BytesAmount, error) {
+	return types.NewBytesAmount(100), nil
 }
 
-func (pt *powerTableForWidenTest) Miner(ctx context.Context, st state.Tree, bs bstore.Blockstore, mAddr address.Address) (uint64, error) {
-	return uint64(25), nil
+func (pt *powerTableForWidenTest) Miner(ctx context.Context, st state.Tree, bs bstore.Blockstore, mAddr address.Address) (*types.BytesAmount, error) {
+	return types.NewBytesAmount(25), nil
 }
 
-func (pt *powerTableForWidenTest) HasPower(ctx context.Context, st state.Tree, bs bstore.Blockstore, mAddr address.Address) bool {
-	return true
+func (pt *powerTableForWidenTest) HasPower(ctx context.Context, st state.Tree, bs bstore.Blockstore, mAddr address.Address) (bool, error) {
+	return true, nil
 }
 
 // Syncer finds a heaviest tipset by combining blocks from the ancestors of a
@@ -795,7 +795,7 @@ func TestTipSetWeightDeep(t *testing.T) {
 
 	// chain.Syncer
 	verifier := proofs.NewFakeVerifier(true, nil)
-	con := consensus.NewExpected(cst, bs, testhelpers.NewTestProcessor(), &testhelpers.TestView{}, calcGenBlk.Cid(), verifier)
+	con := consensus.NewExpected(cst, bs, testhelpers.NewTestProcessor(), &testhelpers.TestView{}, calcGenBlk.Cid(), verifier, &testhelpers.TestBlockSignatureValidator{})
 
 	// Initialize stores to contain genesis block and state
 	calcGenTS := testhelpers.RequireNewTipSet(require, &calcGenBlk)
@@ -811,8 +811,8 @@ func TestTipSetWeightDeep(t *testing.T) {
 
 	// Now sync the chain with consensus using a MarketView.
 	verifier = proofs.NewFakeVerifier(true, nil)
-	con = consensus.NewExpected(cst, bs, testhelpers.NewTestProcessor(), &consensus.MarketView{}, calcGenBlk.Cid(), verifier)
-	syncer := NewDefaultSyncer(cst, cst, con, chain)
+	con = consensus.NewExpected(cst, bs, testhelpers.NewTestProcessor(), &consensus.MarketView{}, calcGenBlk.Cid(), verifier, &testhelpers.TestBlockSignatureValidator{})
+	syncer := NewDefaultSyncer(cst, cst, con, chain, nil)
 	baseTS := chain.Head() // this is the last block of the bootstrapping chain creating miners
 	require.Equal(1, len(baseTS))
 	bootstrapStateRoot := baseTS.ToSlice()[0].StateRoot
@@ -834,7 +834,7 @@ func TestTipSetWeightDeep(t *testing.T) {
 	startingWeight, err := con.Weight(ctx, baseTS, pSt)
 	require.NoError(err)
 
-	wFun := func(ts types.TipSet) (uint64, error) {
+	wFun := func(ts types.TipSet) (*types.ChainWeight, error) {
 		// No power-altering messages processed from here on out.
 		// And so bootstrapSt correctly retrives power table for all
 		// test blocks.
@@ -861,8 +861,8 @@ func TestTipSetWeightDeep(t *testing.T) {
 	assertHead(assert, chain, tsShared)
 	measuredWeight, err := wFun(chain.Head())
 	require.NoError(err)
-	expectedWeight := startingWeight + uint64(22000)
-	assert.Equal(expectedWeight, measuredWeight)
+	expectedWeight := startingWeight.Add(types.NewChainWeight(22))
+	assert.True(expectedWeight.Equal(measuredWeight))
 
 	// fork 1 is heavier than the old head.
 	f1b2a := RequireMkFakeChildCore(require,
@@ -884,8 +884,8 @@ func TestTipSetWeightDeep(t *testing.T) {
 	assertHead(assert, chain, f1)
 	measuredWeight, err = wFun(chain.Head())
 	require.NoError(err)
-	expectedWeight = startingWeight + uint64(33000)
-	assert.Equal(expectedWeight, measuredWeight)
+	expectedWeight = startingWeight.Add(types.NewChainWeight(33))
+	assert.True(expectedWeight.Equal(measuredWeight))
 
 	// fork 2 has heavier weight because of addr3's power even though there
 	// are fewer blocks in the tipset than fork 1.
@@ -900,6 +900,6 @@ func TestTipSetWeightDeep(t *testing.T) {
 	assertHead(assert, chain, f2)
 	measuredWeight, err = wFun(chain.Head())
 	require.NoError(err)
-	expectedWeight = startingWeight + uint64(119000)
-	assert.Equal(expectedWeight, measuredWeight)
+	expectedWeight = startingWeight.Add(types.NewChainWeight(119))
+	assert.True(expectedWeight.Equal(measuredWeight))
 }
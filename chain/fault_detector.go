@@ -0,0 +1,127 @@
+package chain
+
+import (
+	"context"
+	"sync"
+
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// FaultDetectionWindow is the number of heights below the highest observed
+// block for which the ConsensusFaultDetector remembers blocks.
+var FaultDetectionWindow = uint64(100)
+
+// FaultReportQueueSize is the number of faults the ConsensusFaultDetector
+// holds for its reporter before it drops further faults.
+var FaultReportQueueSize = 16
+
+// FaultReporter is called with two conflicting blocks produced by the same
+// miner.
+type FaultReporter func(ctx context.Context, first, second *types.Block)
+
+// ConsensusFaultDetector watches blocks as they arrive and reports any miner
+// that produces two different blocks at the same height on the same parents.
+// Each fault is reported at most once. Faults are queued by Observe and
+// reported by Run, so a slow reporter never holds up syncing.
+type ConsensusFaultDetector struct {
+	lk sync.Mutex
+	// seen maps a (miner, height, parents) key to the first block observed
+	// for it.
+	seen map[faultKey]*types.Block
+	// reported records the keys for which a fault has been reported.
+	reported  map[faultKey]struct{}
+	maxHeight uint64
+	report    FaultReporter
+	// faults holds the faults waiting to be reported.
+	faults chan [2]*types.Block
+}
+
+type faultKey struct {
+	miner   string
+	height  uint64
+	parents string
+}
+
+// NewConsensusFaultDetector returns a detector that calls report for each
+// consensus fault it observes.
+func NewConsensusFaultDetector(report FaultReporter) *ConsensusFaultDetector {
+	return &ConsensusFaultDetector{
+		seen:     make(map[faultKey]*types.Block),
+		reported: make(map[faultKey]struct{}),
+		report:   report,
+		faults:   make(chan [2]*types.Block, FaultReportQueueSize),
+	}
+}
+
+// Run reports the faults queued by Observe until ctx is done.
+func (d *ConsensusFaultDetector) Run(ctx context.Context) {
+	for {
+		select {
+		case fault := <-d.faults:
+			d.report(ctx, fault[0], fault[1])
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Observe records blk and queues a fault for reporting if its miner has
+// already produced a different block at the same height on the same parents.
+// It never blocks on the reporter: a fault is dropped if the queue is full.
+func (d *ConsensusFaultDetector) Observe(ctx context.Context, blk *types.Block) {
+	height := uint64(blk.Height)
+	key := faultKey{
+		miner:   blk.Miner.String(),
+		height:  height,
+		parents: blk.Parents.String(),
+	}
+
+	d.lk.Lock()
+	if height+FaultDetectionWindow < d.maxHeight {
+		d.lk.Unlock()
+		return
+	}
+	if height > d.maxHeight {
+		d.maxHeight = height
+		d.prune()
+	}
+
+	first, ok := d.seen[key]
+	if !ok {
+		d.seen[key] = blk
+		d.lk.Unlock()
+		return
+	}
+	_, reported := d.reported[key]
+	if reported || first.Cid().Equals(blk.Cid()) {
+		d.lk.Unlock()
+		return
+	}
+	d.reported[key] = struct{}{}
+	d.lk.Unlock()
+
+	logSyncer.Warningf("miner %s produced conflicting blocks %s and %s at height %d", blk.Miner, first.Cid(), blk.Cid(), height)
+	if d.report == nil {
+		return
+	}
+	select {
+	case d.faults <- [2]*types.Block{first, blk}:
+	default:
+		logSyncer.Errorf("dropped consensus fault by miner %s at height %d: report queue is full", blk.Miner, height)
+	}
+}
+
+// prune forgets blocks that have fallen out of the detection window. The
+// caller must hold d.lk.
+func (d *ConsensusFaultDetector) prune() {
+	if d.maxHeight < FaultDetectionWindow {
+		return
+	}
+	floor := d.maxHeight - FaultDetectionWindow
+	for key := range d.seen {
+		if key.height < floor {
+			delete(d.seen, key)
+			delete(d.reported, key)
+		}
+	}
+}
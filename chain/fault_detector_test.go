@@ -0,0 +1,119 @@
+package chain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsensusFaultDetector(t *testing.T) {
+	ctx := context.Background()
+	minerAddr := address.MakeTestAddress("miner")
+	parents := types.NewSortedCidSet(types.SomeCid())
+
+	newDetector := func() *ConsensusFaultDetector {
+		return NewConsensusFaultDetector(func(ctx context.Context, first, second *types.Block) {})
+	}
+
+	// queued drains the faults d has queued for reporting.
+	queued := func(d *ConsensusFaultDetector) [][2]*types.Block {
+		var faults [][2]*types.Block
+		for {
+			select {
+			case fault := <-d.faults:
+				faults = append(faults, fault)
+			default:
+				return faults
+			}
+		}
+	}
+
+	t.Run("reports two blocks by the same miner at the same height and parents", func(t *testing.T) {
+		assert := assert.New(t)
+		d := newDetector()
+
+		first := &types.Block{Miner: minerAddr, Height: 5, Parents: parents, Nonce: 1}
+		second := &types.Block{Miner: minerAddr, Height: 5, Parents: parents, Nonce: 2}
+		d.Observe(ctx, first)
+		d.Observe(ctx, second)
+
+		faults := queued(d)
+		assert.Len(faults, 1)
+		assert.Equal(first, faults[0][0])
+		assert.Equal(second, faults[0][1])
+
+		// A third conflicting block does not produce another report.
+		d.Observe(ctx, &types.Block{Miner: minerAddr, Height: 5, Parents: parents, Nonce: 3})
+		assert.Empty(queued(d))
+	})
+
+	t.Run("ignores duplicates and unrelated blocks", func(t *testing.T) {
+		assert := assert.New(t)
+		d := newDetector()
+
+		blk := &types.Block{Miner: minerAddr, Height: 5, Parents: parents}
+		d.Observe(ctx, blk)
+		d.Observe(ctx, blk)
+		d.Observe(ctx, &types.Block{Miner: minerAddr, Height: 6, Parents: parents})
+		d.Observe(ctx, &types.Block{Miner: address.MakeTestAddress("other"), Height: 5, Parents: parents})
+		d.Observe(ctx, &types.Block{Miner: minerAddr, Height: 5, Parents: types.NewSortedCidSet(blk.Cid())})
+
+		assert.Empty(queued(d))
+	})
+
+	t.Run("forgets blocks outside the detection window", func(t *testing.T) {
+		assert := assert.New(t)
+		d := newDetector()
+
+		d.Observe(ctx, &types.Block{Miner: minerAddr, Height: 5, Parents: parents, Nonce: 1})
+		d.Observe(ctx, &types.Block{Miner: minerAddr, Height: types.Uint64(6 + FaultDetectionWindow), Parents: parents})
+		d.Observe(ctx, &types.Block{Miner: minerAddr, Height: 5, Parents: parents, Nonce: 2})
+
+		assert.Empty(queued(d))
+	})
+
+	t.Run("drops faults when the queue is full instead of blocking", func(t *testing.T) {
+		assert := assert.New(t)
+		d := newDetector()
+
+		for i := 0; i < FaultReportQueueSize+1; i++ {
+			height := types.Uint64(i)
+			d.Observe(ctx, &types.Block{Miner: minerAddr, Height: height, Parents: parents, Nonce: 1})
+			d.Observe(ctx, &types.Block{Miner: minerAddr, Height: height, Parents: parents, Nonce: 2})
+		}
+
+		assert.Len(queued(d), FaultReportQueueSize)
+	})
+
+	t.Run("run reports queued faults", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		reported := make(chan [2]*types.Block, 1)
+		d := NewConsensusFaultDetector(func(ctx context.Context, first, second *types.Block) {
+			reported <- [2]*types.Block{first, second}
+		})
+		runCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go d.Run(runCtx)
+
+		first := &types.Block{Miner: minerAddr, Height: 5, Parents: parents, Nonce: 1}
+		second := &types.Block{Miner: minerAddr, Height: 5, Parents: parents, Nonce: 2}
+		d.Observe(ctx, first)
+		d.Observe(ctx, second)
+
+		select {
+		case fault := <-reported:
+			assert.Equal(first, fault[0])
+			assert.Equal(second, fault[1])
+		case <-time.After(5 * time.Second):
+			require.Fail("fault was not reported")
+		}
+	})
+}
@@ -7,12 +7,14 @@ import (
 	"gx/ipfs/QmRXf2uUSdGSunRJsM9wXSUNVwLUGCY3So5fAs7h2CBJVf/go-hamt-ipld"
 	bstore "gx/ipfs/QmS2aqUZLJp8kF1ihE5rvDGE5LvmKDPnx32w9Z1BW9xLV5/go-ipfs-blockstore"
 
+	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin"
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/consensus"
 	"github.com/filecoin-project/go-filecoin/gengen/util"
 	"github.com/filecoin-project/go-filecoin/repo"
 	"github.com/filecoin-project/go-filecoin/state"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
 	"github.com/filecoin-project/go-filecoin/types"
 
 	"github.com/stretchr/testify/assert"
@@ -30,7 +32,7 @@ func TestTotal(t *testing.T) {
 	actual, err := (&consensus.MarketView{}).Total(ctx, st, bs)
 	require.NoError(err)
 
-	assert.Equal(power, actual)
+	assert.True(sectorsPower(power).Equal(actual))
 }
 
 func TestMiner(t *testing.T) {
@@ -44,7 +46,112 @@ func TestMiner(t *testing.T) {
 	actual, err := (&consensus.MarketView{}).Miner(ctx, st, bs, addr)
 	require.NoError(err)
 
-	assert.Equal(power, actual)
+	assert.True(sectorsPower(power).Equal(actual))
+}
+
+func TestPowerTable(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+	assert := assert.New(t)
+
+	power := uint64(7)
+	bs, addr, st := requireMinerWithPower(ctx, t, power)
+
+	view := &consensus.MarketView{}
+	table, err := view.PowerTable(ctx, st, bs)
+	require.NoError(err)
+
+	assert.True(sectorsPower(power).Equal(table.Total))
+	require.Len(table.Miners, 1)
+	assert.True(sectorsPower(power).Equal(table.Miners[addr]))
+
+	hasPower, err := view.HasPower(ctx, st, bs, addr)
+	require.NoError(err)
+	assert.True(hasPower)
+}
+
+func TestMarketViewCachesByStateRoot(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+	assert := assert.New(t)
+
+	bs1, addr1, st1 := requireMinerWithPower(ctx, t, 3)
+	bs2, addr2, st2 := requireMinerWithPower(ctx, t, 5)
+
+	view := &consensus.MarketView{}
+	for i := 0; i < 2; i++ {
+		power, err := view.Miner(ctx, st1, bs1, addr1)
+		require.NoError(err)
+		assert.True(sectorsPower(3).Equal(power))
+
+		total, err := view.Total(ctx, st1, bs1)
+		require.NoError(err)
+		assert.True(sectorsPower(3).Equal(total))
+
+		power, err = view.Miner(ctx, st2, bs2, addr2)
+		require.NoError(err)
+		assert.True(sectorsPower(5).Equal(power))
+
+		total, err = view.Total(ctx, st2, bs2)
+		require.NoError(err)
+		assert.True(sectorsPower(5).Equal(total))
+
+		_, err = view.Miner(ctx, st1, bs1, address.TestAddress)
+		assert.Error(err)
+	}
+}
+
+func TestHasPower(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("miners with power", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		bs, addr, st := requireMinerWithPower(ctx, t, 3)
+
+		hasPower, err := (&consensus.MarketView{}).HasPower(ctx, st, bs, addr)
+		require.NoError(err)
+		assert.True(hasPower)
+
+		hasPower, err = (&consensus.MarketView{}).HasPower(ctx, st, bs, address.TestAddress)
+		require.NoError(err)
+		assert.False(hasPower)
+	})
+
+	t.Run("states without a storage market", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		cst := hamt.NewCborStore()
+		st := state.NewEmptyStateTree(cst)
+		bs := bstore.NewBlockstore(repo.NewInMemoryRepo().Datastore())
+
+		hasPower, err := (&consensus.MarketView{}).HasPower(ctx, st, bs, address.TestAddress)
+		require.NoError(err)
+		assert.False(hasPower)
+	})
+
+	t.Run("states whose storage market cannot be read", func(t *testing.T) {
+		require := require.New(t)
+
+		cst := hamt.NewCborStore()
+		st := state.NewEmptyStateTree(cst)
+		bs := bstore.NewBlockstore(repo.NewInMemoryRepo().Datastore())
+
+		market := actor.NewActor(types.StorageMarketActorCodeCid, types.NewZeroAttoFIL())
+		market.Head = types.SomeCid()
+		require.NoError(st.SetActor(ctx, address.StorageMarketAddress, market))
+
+		_, err := (&consensus.MarketView{}).HasPower(ctx, st, bs, address.TestAddress)
+		require.Error(err)
+	})
+}
+
+// sectorsPower returns the power of a miner that has committed the given
+// number of sectors.
+func sectorsPower(sectors uint64) *types.BytesAmount {
+	return th.SectorSize().Mul(types.NewBytesAmount(sectors))
 }
 
 func requireMinerWithPower(ctx context.Context, t *testing.T, power uint64) (bstore.Blockstore, address.Address, state.Tree) {
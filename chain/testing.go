@@ -53,14 +53,15 @@ func MkFakeChild(params FakeChildParams) (*types.Block, error) {
 		th.NewTestProcessor(),
 		powerTableView,
 		params.GenesisCid,
-		proofs.NewFakeVerifier(true, nil))
+		proofs.NewFakeVerifier(true, nil),
+		&th.TestBlockSignatureValidator{})
 	params.Consensus = con
 	return MkFakeChildWithCon(params)
 }
 
 // MkFakeChildWithCon creates a chain with the given consensus weight function.
 func MkFakeChildWithCon(params FakeChildParams) (*types.Block, error) {
-	wFun := func(ts types.TipSet) (uint64, error) {
+	wFun := func(ts types.TipSet) (*types.ChainWeight, error) {
 		return params.Consensus.Weight(context.Background(), params.Parent, nil)
 	}
 	return MkFakeChildCore(params.Parent,
@@ -77,7 +78,7 @@ func MkFakeChildCore(parent types.TipSet,
 	nonce uint64,
 	nullBlockCount uint64,
 	minerAddress address.Address,
-	wFun func(types.TipSet) (uint64, error)) (*types.Block, error) {
+	wFun func(types.TipSet) (*types.ChainWeight, error)) (*types.Block, error) {
 	// State can be nil because it doesn't it is assumed consensus uses a
 	// power table view that does not access the state.
 	w, err := wFun(parent)
@@ -98,7 +99,7 @@ func MkFakeChildCore(parent types.TipSet,
 
 	// Override fake values with our values
 	newBlock.Parents = pIDs
-	newBlock.ParentWeight = types.Uint64(w)
+	newBlock.ParentWeight = w
 	newBlock.Nonce = types.Uint64(nonce)
 	newBlock.StateRoot = stateRoot
 
@@ -124,7 +125,7 @@ func RequireMkFakeChildWithCon(require *require.Assertions, params FakeChildPara
 // it does not errror.
 func RequireMkFakeChildCore(require *require.Assertions,
 	params FakeChildParams,
-	wFun func(types.TipSet) (uint64, error)) *types.Block {
+	wFun func(types.TipSet) (*types.ChainWeight, error)) *types.Block {
 	child, err := MkFakeChildCore(params.Parent, params.StateRoot, params.Nonce, params.NullBlockCount, params.MinerAddr, wFun)
 	require.NoError(err)
 	return child
@@ -158,7 +159,7 @@ func MakeProofAndWinningTicket(minerAddr address.Address, minerPower uint64, tot
 	for {
 		postProof = th.MakeRandomPoSTProofForTest()
 		ticket = consensus.CreateTicket(postProof, minerAddr)
-		if consensus.CompareTicketPower(ticket, minerPower, totalPower) {
+		if consensus.CompareTicketPower(ticket, types.NewBytesAmount(minerPower), types.NewBytesAmount(totalPower)) {
 			return postProof, ticket, nil
 		}
 	}
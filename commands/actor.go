@@ -2,10 +2,16 @@ package commands
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
+	"strings"
 
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/api"
+	"github.com/filecoin-project/go-filecoin/plumbing/mthdsig"
 
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
 	"gx/ipfs/Qma6uuSyjkecGhMFFLfzyJDPyoDtNJSHJNweDccZhaWkgU/go-ipfs-cmds"
 	"gx/ipfs/Qmde5VP1qUkyQXKCfmEUA7bP64V2HAptbJ7phuPp7jXWwg/go-ipfs-cmdkit"
 )
@@ -15,7 +21,9 @@ var actorCmd = &cmds.Command{
 		Tagline: "Interact with actors. Actors are built-in smart contracts.",
 	},
 	Subcommands: map[string]*cmds.Command{
-		"ls": actorLsCmd,
+		"ls":      actorLsCmd,
+		"methods": actorMethodsCmd,
+		"state":   actorStateCmd,
 	},
 }
 
@@ -49,3 +57,106 @@ var actorLsCmd = &cmds.Command{
 		}),
 	},
 }
+
+var actorMethodsCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "List the methods an actor exports",
+		ShortDescription: `
+Lists every method exported by the actor at <actor>, with the ABI types of its
+params and return values, and the exit codes its methods document. <actor> may
+be an actor address or the cid of an actor's code. Use --enc=json for a machine
+readable description.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("actor", true, false, "The address or code cid of the actor"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		var ai *mthdsig.ActorInterface
+		if addr, err := address.NewFromString(req.Arguments[0]); err == nil {
+			ai, err = GetPorcelainAPI(env).ActorDescribe(req.Context, addr)
+			if err != nil {
+				return err
+			}
+		} else {
+			code, err := cid.Decode(req.Arguments[0])
+			if err != nil {
+				return fmt.Errorf("%s is neither an address nor a cid", req.Arguments[0])
+			}
+			ai, err = GetPorcelainAPI(env).ActorDescribeCode(req.Context, code)
+			if err != nil {
+				return err
+			}
+		}
+
+		return re.Emit(ai)
+	},
+	Type: mthdsig.ActorInterface{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, ai *mthdsig.ActorInterface) error {
+			for _, m := range ai.Methods {
+				fmt.Fprintf(w, "%s(%s) (%s)\n", m.Name, typeList(m.Params), typeList(m.Return)) // nolint: errcheck
+			}
+			if len(ai.ExitCodes) > 0 {
+				fmt.Fprintln(w, "exit codes:") // nolint: errcheck
+			}
+			for _, ec := range ai.ExitCodes {
+				fmt.Fprintf(w, "  %d: %s\n", ec.Code, ec.Message) // nolint: errcheck
+			}
+			return nil
+		}),
+	},
+}
+
+var actorStateCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Show the state of a builtin actor",
+		ShortDescription: `
+Prints the state of the builtin actor at <actor> as JSON, as found in the state
+resulting from a tipset. The state is read directly, without calling any actor
+method. The head of the chain is used if no tipset is given.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("actor", true, false, "The address of the actor"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("tipset", "Comma separated cids of the blocks in the tipset"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		addr, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		tsKey, err := optionalTipSetKey(req.Options["tipset"])
+		if err != nil {
+			return err
+		}
+
+		st, err := GetPorcelainAPI(env).ActorState(req.Context, tsKey, addr)
+		if err != nil {
+			return err
+		}
+		if st == nil {
+			return fmt.Errorf("actor at %s has no state", addr)
+		}
+
+		return re.Emit(st)
+	},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeEncoder(func(req *cmds.Request, w io.Writer, res interface{}) error {
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "\t")
+			return encoder.Encode(res)
+		}),
+	},
+}
+
+func typeList(types []abi.Type) string {
+	names := make([]string, 0, len(types))
+	for _, t := range types {
+		names = append(names, t.String())
+	}
+	return strings.Join(names, ", ")
+}
@@ -5,8 +5,14 @@ import (
 	"encoding/json"
 	"testing"
 
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+
+	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/api"
+	"github.com/filecoin-project/go-filecoin/fixtures"
+	"github.com/filecoin-project/go-filecoin/plumbing/mthdsig"
 	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	"github.com/filecoin-project/go-filecoin/types"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -40,7 +46,7 @@ func TestActorDaemon(t *testing.T) {
 		// The order of actors is consistent, but only within builds of genesis.car.
 		// We just want to make sure the views have something valid in them.
 		for _, av := range avs {
-			assert.Contains([]string{"StoragemarketActor", "AccountActor", "PaymentbrokerActor", "MinerActor", "BootstrapMinerActor"}, av.ActorType)
+			assert.Contains([]string{"StoragemarketActor", "AccountActor", "PaymentbrokerActor", "MinerActor", "BootstrapMinerActor", "InitactorActor", "GovernanceActor"}, av.ActorType)
 			if av.ActorType == "AccountActor" {
 				assert.Zero(len(av.Exports))
 			} else {
@@ -48,4 +54,46 @@ func TestActorDaemon(t *testing.T) {
 			}
 		}
 	})
+	t.Run("actor methods describes the methods of an actor", func(t *testing.T) {
+		require := require.New(t)
+		assert := assert.New(t)
+
+		d := th.NewDaemon(t).Start()
+		defer d.ShutdownSuccess()
+
+		out := d.RunSuccess("actor", "methods", address.StorageMarketAddress.String()).ReadStdout()
+		assert.Contains(out, "createMiner(*big.Int, []byte, peer.ID) (address.Address)\n")
+		assert.Contains(out, "34: unknown miner\n")
+
+		out = d.RunSuccess("actor", "methods", types.StorageMarketActorCodeCid.String(), "--enc=json").ReadStdout()
+		var ai mthdsig.ActorInterface
+		require.NoError(json.Unmarshal([]byte(out), &ai))
+		assert.Equal(types.StorageMarketActorCodeCid, ai.Code)
+		assert.NotEmpty(ai.Methods)
+
+		d.RunFail("neither an address nor a cid", "actor", "methods", "notanactor")
+	})
+	t.Run("actor state shows the state of a builtin actor", func(t *testing.T) {
+		require := require.New(t)
+		assert := assert.New(t)
+
+		d := th.NewDaemon(t).Start()
+		defer d.ShutdownSuccess()
+
+		out := d.RunSuccess("actor", "state", fixtures.TestMiners[0]).ReadStdout()
+		var minerState map[string]interface{}
+		require.NoError(json.Unmarshal([]byte(out), &minerState))
+		assert.Contains(minerState, "Owner")
+		assert.Contains(minerState, "SectorCommitments")
+
+		head := d.RunSuccess("chain", "head", "--enc=json").ReadStdoutTrimNewlines()
+		var headCids []cid.Cid
+		require.NoError(json.Unmarshal([]byte(head), &headCids))
+		out = d.RunSuccess("actor", "state", address.StorageMarketAddress.String(), "--tipset", headCids[0].String()).ReadStdout()
+		var marketState map[string]interface{}
+		require.NoError(json.Unmarshal([]byte(out), &marketState))
+		assert.Contains(marketState, "TotalCommittedStorage")
+
+		d.RunFail("has no state", "actor", "state", fixtures.TestAddresses[0])
+	})
 }
@@ -44,8 +44,17 @@ type AddressLsResult struct {
 }
 
 var addrsNewCmd = &cmds.Command{
+	Options: []cmdkit.Option{
+		cmdkit.BoolOption("bls", "Create the address of a BLS key, whose message signatures blocks aggregate"),
+	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
-		addr, err := GetAPI(env).Address().Addrs().New(req.Context)
+		var addr address.Address
+		var err error
+		if useBLS, _ := req.Options["bls"].(bool); useBLS {
+			addr, err = GetPorcelainAPI(env).WalletNewBLSAddress()
+		} else {
+			addr, err = GetAPI(env).Address().Addrs().New(req.Context)
+		}
 		if err != nil {
 			return err
 		}
@@ -89,8 +98,19 @@ var addrsLsCmd = &cmds.Command{
 }
 
 var addrsLookupCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Look up the peer id of a miner, or the other address of an actor",
+		ShortDescription: `
+Prints the robust address of the actor an ID address was assigned to. With
+--id, prints the ID address of the actor at a robust address instead. Prints
+the peer id of the miner at any other address.
+`,
+	},
 	Arguments: []cmdkit.Argument{
-		cmdkit.StringArg("address", true, false, "Miner address to find peerId for"),
+		cmdkit.StringArg("address", true, false, "Address to look up"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.BoolOption("id", "Print the ID address of the actor"),
 	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
 		addr, err := address.NewFromString(req.Arguments[0])
@@ -98,6 +118,29 @@ var addrsLookupCmd = &cmds.Command{
 			return err
 		}
 
+		lookupID, _ := req.Options["id"].(bool)
+		if addr.IsID() || lookupID {
+			if addr.IsID() {
+				robust, ok, err := GetPorcelainAPI(env).ActorAddress(req.Context, types.SortedCidSet{}, addr)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					return fmt.Errorf("no actor has id address %s", addr)
+				}
+				return re.Emit(robust.String())
+			}
+
+			idAddr, ok, err := GetPorcelainAPI(env).ActorID(req.Context, types.SortedCidSet{}, addr)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return fmt.Errorf("actor %s has no id address", addr)
+			}
+			return re.Emit(idAddr.String())
+		}
+
 		v, err := GetAPI(env).Address().Addrs().Lookup(req.Context, addr)
 		if err != nil {
 			return err
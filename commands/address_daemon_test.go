@@ -30,6 +30,22 @@ func TestAddrsNewAndList(t *testing.T) {
 	}
 }
 
+func TestAddrsNewBLS(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	d := th.NewDaemon(t).Start()
+	defer d.ShutdownSuccess()
+
+	out := d.RunSuccess("wallet", "addrs", "new", "--bls").ReadStdoutTrimNewlines()
+	addr, err := address.NewFromString(out)
+	assert.NoError(err)
+	assert.True(addr.IsBLS())
+
+	list := d.RunSuccess("wallet", "addrs", "ls").ReadStdout()
+	assert.Contains(list, out)
+}
+
 func TestWalletBalance(t *testing.T) {
 	t.Parallel()
 	assert := assert.New(t)
@@ -94,6 +110,23 @@ func TestAddrLookupAndUpdate(t *testing.T) {
 	assert.NotEqual(lookupOutA, lookupOutB)
 }
 
+func TestAddrLookupID(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	d := th.NewDaemon(t).Start()
+	defer d.ShutdownSuccess()
+
+	idAddr := th.RunSuccessFirstLine(d, "address", "lookup", "--id", address.StorageMarketAddress.String())
+	assert.Equal(address.NewIDAddress(address.Mainnet, 1).String(), idAddr)
+
+	robust := th.RunSuccessFirstLine(d, "address", "lookup", idAddr)
+	assert.Equal(address.StorageMarketAddress.String(), robust)
+
+	d.RunFail("no actor has id address", "address", "lookup", address.NewIDAddress(address.Mainnet, 1000000).String())
+	d.RunFail("has no id address", "address", "lookup", "--id", address.NewForTestGetter()().String())
+}
+
 func TestWalletLoadFromFile(t *testing.T) {
 	assert := assert.New(t)
 
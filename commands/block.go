@@ -47,19 +47,14 @@ all other block properties will be included as well.`,
 	Type: types.Block{},
 	Encoders: cmds.EncoderMap{
 		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, block *types.Block) error {
-			wStr, err := types.FixedStr(uint64(block.ParentWeight))
-			if err != nil {
-				return err
-			}
-
-			_, err = fmt.Fprintf(w, `Block Details
+			_, err := fmt.Fprintf(w, `Block Details
 Miner:  %s
 Weight: %s
 Height: %s
 Nonce:  %s
 `,
 				block.Miner,
-				wStr,
+				block.ParentWeight,
 				strconv.FormatUint(uint64(block.Height), 10),
 				strconv.FormatUint(uint64(block.Nonce), 10),
 			)
@@ -8,9 +8,13 @@ import (
 	"strings"
 
 	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
 	"gx/ipfs/Qma6uuSyjkecGhMFFLfzyJDPyoDtNJSHJNweDccZhaWkgU/go-ipfs-cmds"
 	"gx/ipfs/Qmde5VP1qUkyQXKCfmEUA7bP64V2HAptbJ7phuPp7jXWwg/go-ipfs-cmdkit"
 
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/plumbing/evt"
 	"github.com/filecoin-project/go-filecoin/types"
 )
 
@@ -19,8 +23,9 @@ var chainCmd = &cmds.Command{
 		Tagline: "Inspect the filecoin blockchain",
 	},
 	Subcommands: map[string]*cmds.Command{
-		"head": chainHeadCmd,
-		"ls":   chainLsCmd,
+		"events": chainEventsCmd,
+		"head":   chainHeadCmd,
+		"ls":     chainLsCmd,
 	},
 }
 
@@ -102,3 +107,92 @@ var chainLsCmd = &cmds.Command{
 		}),
 	},
 }
+
+var chainEventsCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "List the events emitted by actors",
+		ShortDescription: `Lists the events recorded in the message receipts of the chain, oldest first.
+Each line has the height of the block, the emitting actor, the topic and the values of an event.
+With --follow, keeps listing the events of new blocks until interrupted.`,
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("actor", "Only list events emitted by the actor at this address"),
+		cmdkit.StringOption("topic", "Only list events with this topic"),
+		cmdkit.Uint64Option("since", "Only list events in blocks at or above this height"),
+		cmdkit.BoolOption("follow", "f", "Keep listing the events of new blocks"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		filter := evt.Filter{}
+		if o, ok := req.Options["actor"].(string); ok {
+			addr, err := address.NewFromString(o)
+			if err != nil {
+				return errors.Wrap(err, "invalid actor address")
+			}
+			filter.Actor = addr
+		}
+		if o, ok := req.Options["topic"].(string); ok {
+			filter.Topic = o
+		}
+		if o, ok := req.Options["since"].(uint64); ok {
+			filter.Since = types.NewBlockHeight(o)
+		}
+		follow, _ := req.Options["follow"].(bool)
+
+		// subscribe before listing past events so none are missed in between
+		var newEvents <-chan *evt.ChainEvent
+		if follow {
+			var err error
+			newEvents, err = GetPorcelainAPI(env).ChainSubscribeEvents(req.Context, filter)
+			if err != nil {
+				return err
+			}
+		}
+
+		events, err := GetPorcelainAPI(env).ChainEvents(req.Context, filter)
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			if err := re.Emit(e); err != nil {
+				return err
+			}
+		}
+
+		if follow {
+			for e := range newEvents {
+				if err := re.Emit(e); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	},
+	Type: evt.ChainEvent{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, e *evt.ChainEvent) error {
+			_, err := fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", e.Height, e.Actor, e.Topic, eventValues(e.Event))
+			return err
+		}),
+	},
+}
+
+// eventValues returns the values of an event separated by spaces, or its raw
+// data if they cannot be decoded.
+func eventValues(e *types.Event) string {
+	valTypes := make([]abi.Type, len(e.Types))
+	for i, name := range e.Types {
+		if err := valTypes[i].UnmarshalText([]byte(name)); err != nil {
+			return fmt.Sprintf("%x", []byte(e.Data))
+		}
+	}
+	vals, err := abi.DecodeValues(e.Data, valTypes)
+	if err != nil {
+		return fmt.Sprintf("%x", []byte(e.Data))
+	}
+
+	strs := make([]string, len(vals))
+	for i, val := range vals {
+		strs[i] = val.String()
+	}
+	return strings.Join(strs, " ")
+}
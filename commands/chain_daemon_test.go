@@ -8,6 +8,8 @@ import (
 
 	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
 
+	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
+	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/fixtures"
 	th "github.com/filecoin-project/go-filecoin/testhelpers"
 	"github.com/filecoin-project/go-filecoin/types"
@@ -110,4 +112,22 @@ func TestChainDaemon(t *testing.T) {
 		assert.Contains(chainLsResult, "1")
 		assert.Contains(chainLsResult, "0")
 	})
+
+	t.Run("chain events lists the events emitted by actors", func(t *testing.T) {
+		t.Parallel()
+		assert := assert.New(t)
+
+		d := th.NewDaemon(t, th.WithMiner(fixtures.TestMiners[0]), th.KeyFile(fixtures.KeyFilePaths()[0])).Start()
+		defer d.ShutdownSuccess()
+
+		d.RunSuccess("mining", "start")
+		minerAddr := d.CreateMinerAddr(d, fixtures.TestAddresses[0])
+
+		out := d.RunSuccess("chain", "events", "--topic", storagemarket.MinerCreatedTopic).ReadStdoutTrimNewlines()
+		assert.Contains(out, address.StorageMarketAddress.String())
+		assert.Contains(out, fmt.Sprintf("%s\t%s %s", storagemarket.MinerCreatedTopic, minerAddr, fixtures.TestAddresses[0]))
+
+		out = d.RunSuccess("chain", "events", "--actor", minerAddr.String()).ReadStdoutTrimNewlines()
+		assert.Empty(out)
+	})
 }
@@ -13,6 +13,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/api"
 	"github.com/filecoin-project/go-filecoin/protocol/storage"
+	"github.com/filecoin-project/go-filecoin/types"
 )
 
 var clientCmd = &cmds.Command{
@@ -24,6 +25,7 @@ var clientCmd = &cmds.Command{
 		"import":               clientImportDataCmd,
 		"propose-storage-deal": clientProposeStorageDealCmd,
 		"query-storage-deal":   clientQueryStorageDealCmd,
+		"list-deals":           clientListDealsCmd,
 		"list-asks":            clientListAsksCmd,
 		"payments":             paymentsCmd,
 	},
@@ -192,6 +194,134 @@ format is specified with the --enc flag.
 	},
 }
 
+// ClientDealResult is a deal listed by client list-deals.
+type ClientDealResult struct {
+	Miner    address.Address
+	PieceRef cid.Cid
+	Size     *types.BytesAmount
+	Duration uint64
+	Price    *types.AttoFIL
+
+	// ProposalCid and State are only set for deals proposed by this node.
+	ProposalCid *cid.Cid `json:",omitempty"`
+	State       string   `json:",omitempty"`
+
+	// DealID, Collateral, Committed, SectorID, Ended and Terminated are only
+	// set for deals published on chain.
+	DealID     *uint64        `json:",omitempty"`
+	Collateral *types.AttoFIL `json:",omitempty"`
+	Committed  bool           `json:",omitempty"`
+	SectorID   uint64         `json:",omitempty"`
+	Ended      bool           `json:",omitempty"`
+	Terminated bool           `json:",omitempty"`
+}
+
+var clientListDealsCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "List storage deals",
+		ShortDescription: `
+Lists the storage deals this node proposed as a client, with their state as last
+reported by their miner. With --on-chain, lists the deals that miners published
+in the storage market instead, which shows independently of the miners whether
+a deal was accepted, whether the sector holding its piece was committed, and
+whether that sector has since expired or been terminated.
+`,
+	},
+	Options: []cmdkit.Option{
+		cmdkit.BoolOption("on-chain", "List the deals published in the storage market"),
+		cmdkit.StringOption("client", "Address of the client whose deals to list with --on-chain, the default from address if not given"),
+		cmdkit.StringOption("tipset", "Comma separated cids of the blocks in the tipset to list deals of with --on-chain"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		if onChain, _ := req.Options["on-chain"].(bool); !onChain {
+			deals, err := GetAPI(env).Client().ListDeals(req.Context)
+			if err != nil {
+				return err
+			}
+			for _, deal := range deals {
+				proposalCid := deal.Response.ProposalCid
+				err := re.Emit(&ClientDealResult{
+					Miner:       deal.Miner,
+					PieceRef:    deal.Proposal.PieceRef,
+					Size:        deal.Proposal.Size,
+					Duration:    deal.Proposal.Duration,
+					Price:       deal.Proposal.TotalPrice,
+					ProposalCid: &proposalCid,
+					State:       deal.Response.State.String(),
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		client, err := optionalAddr(req.Options["client"])
+		if err != nil {
+			return err
+		}
+		if client.Empty() {
+			client, err = GetPorcelainAPI(env).GetAndMaybeSetDefaultSenderAddress()
+			if err != nil {
+				return err
+			}
+		}
+
+		tsKey, err := optionalTipSetKey(req.Options["tipset"])
+		if err != nil {
+			return err
+		}
+
+		deals, err := GetPorcelainAPI(env).StorageMarketDeals(req.Context, tsKey)
+		if err != nil {
+			return err
+		}
+		for _, deal := range deals {
+			if deal.Client != client {
+				continue
+			}
+			dealID := deal.ID
+			err := re.Emit(&ClientDealResult{
+				Miner:      deal.Miner,
+				PieceRef:   deal.PieceRef,
+				Size:       deal.Size,
+				Duration:   deal.Duration.AsBigInt().Uint64(),
+				Price:      deal.Price,
+				DealID:     &dealID,
+				Collateral: deal.Collateral,
+				Committed:  deal.Committed,
+				SectorID:   deal.SectorID,
+				Ended:      deal.Ended,
+				Terminated: deal.Terminated,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Type: ClientDealResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, deal *ClientDealResult) error {
+			if deal.DealID == nil {
+				_, err := fmt.Fprintf(w, "%s %s %s %s\n", deal.ProposalCid, deal.Miner, deal.PieceRef, deal.State)
+				return err
+			}
+			sector := "uncommitted"
+			if deal.Committed {
+				sector = fmt.Sprintf("sector %d", deal.SectorID)
+			}
+			if deal.Ended {
+				sector += " (ended)"
+			} else if deal.Terminated {
+				sector += " (terminated)"
+			}
+			_, err := fmt.Fprintf(w, "%d %s %s %s %d %s %s\n", *deal.DealID, deal.Miner, deal.PieceRef, deal.Size, deal.Duration, deal.Price, sector)
+			return err
+		}),
+	},
+}
+
 var clientListAsksCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline: "List all asks in the storage market",
@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/filecoin-project/go-filecoin/fixtures"
 	"github.com/filecoin-project/go-filecoin/protocol/storage"
 	th "github.com/filecoin-project/go-filecoin/testhelpers"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestListAsks(t *testing.T) {
@@ -105,6 +107,48 @@ func TestDuplicateDeals(t *testing.T) {
 	})
 }
 
+func TestListDeals(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	require := require.New(t)
+
+	miner := th.NewDaemon(t,
+		th.WithMiner(fixtures.TestMiners[0]),
+		th.KeyFile(fixtures.KeyFilePaths()[0]),
+		th.DefaultAddress(fixtures.TestAddresses[0]),
+	).Start()
+	defer miner.ShutdownSuccess()
+
+	client := th.NewDaemon(t, th.KeyFile(fixtures.KeyFilePaths()[2]), th.DefaultAddress(fixtures.TestAddresses[2])).Start()
+	defer client.ShutdownSuccess()
+
+	miner.RunSuccess("mining start")
+	miner.UpdatePeerID()
+
+	miner.ConnectSuccess(client)
+
+	miner.MinerSetPrice(fixtures.TestMiners[0], fixtures.TestAddresses[0], "20", "10")
+	dataCid := client.RunWithStdin(strings.NewReader("HODLHODLHODL"), "client", "import").ReadStdoutTrimNewlines()
+
+	proposeDealOutput := client.RunSuccess("client", "propose-storage-deal", fixtures.TestMiners[0], dataCid, "0", "5").ReadStdoutTrimNewlines()
+	splitOnSpace := strings.Split(proposeDealOutput, " ")
+	dealCid := splitOnSpace[len(splitOnSpace)-1]
+
+	listDealsOutput := client.RunSuccess("client", "list-deals").ReadStdoutTrimNewlines()
+	assert.Contains(listDealsOutput, dealCid)
+	assert.Contains(listDealsOutput, fixtures.TestMiners[0])
+
+	// the miner publishes the deal once it has fetched the data
+	require.NoError(th.WaitForIt(60, 500*time.Millisecond, func() (bool, error) {
+		onChainOutput := client.RunSuccess("client", "list-deals", "--on-chain").ReadStdoutTrimNewlines()
+		return strings.Contains(onChainOutput, dataCid), nil
+	}))
+
+	// deals are listed by client
+	onChainOutput := client.RunSuccess("client", "list-deals", "--on-chain", "--client", fixtures.TestAddresses[1]).ReadStdoutTrimNewlines()
+	assert.Empty(onChainOutput)
+}
+
 func TestDealWithSameDataAndDifferentMiners(t *testing.T) {
 	t.Parallel()
 	assert := assert.New(t)
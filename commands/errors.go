@@ -21,6 +21,9 @@ var (
 	// ErrInvalidPledge indicates that provided pledge was invalid.
 	ErrInvalidPledge = fmt.Errorf("invalid pledge")
 
+	// ErrInvalidSectorID indicates that the provided sector id was invalid.
+	ErrInvalidSectorID = fmt.Errorf("invalid sector id")
+
 	// ErrInvalidBlockHeight indicates that the provided block height was invalid.
 	ErrInvalidBlockHeight = fmt.Errorf("invalid block height")
 
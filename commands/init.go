@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	cmds "gx/ipfs/Qma6uuSyjkecGhMFFLfzyJDPyoDtNJSHJNweDccZhaWkgU/go-ipfs-cmds"
 	cmdkit "gx/ipfs/Qmde5VP1qUkyQXKCfmEUA7bP64V2HAptbJ7phuPp7jXWwg/go-ipfs-cmdkit"
@@ -21,6 +22,7 @@ var initCmd = &cmds.Command{
 		cmdkit.StringOption(PeerKeyFile, "path of file containing key to use for new node's libp2p identity"),
 		cmdkit.StringOption(WithMiner, "when set, creates a custom genesis block with a pre generated miner account, requires running the daemon using dev mode (--dev)"),
 		cmdkit.StringOption(DefaultAddress, "when set, sets the daemons's default address to the provided address"),
+		cmdkit.StringOption(Authorities, "comma separated addresses; when set, creates a genesis block for a round-robin proof-of-authority chain in which miners owned by these addresses take turns producing blocks"),
 		cmdkit.UintOption(AutoSealIntervalSeconds, "when set to a number > 0, configures the daemon to check for and seal any staged sectors on an interval.").WithDefault(uint(120)),
 		cmdkit.BoolOption(DevnetTest, "when set, populates config bootstrap addrs with the dns multiaddrs of the test devnet and other test devnet specific bootstrap parameters."),
 		cmdkit.BoolOption(DevnetNightly, "when set, populates config bootstrap addrs with the dns multiaddrs of the nightly devnet and other nightly devnet specific bootstrap parameters"),
@@ -57,6 +59,17 @@ var initCmd = &cmds.Command{
 			}
 		}
 
+		var authorities []address.Address
+		if a, ok := req.Options[Authorities].(string); ok {
+			for _, s := range strings.Split(a, ",") {
+				addr, err := address.NewFromString(strings.TrimSpace(s))
+				if err != nil {
+					return err
+				}
+				authorities = append(authorities, addr)
+			}
+		}
+
 		return GetAPI(env).Daemon().Init(
 			req.Context,
 			api.RepoDir(repoDir),
@@ -68,6 +81,7 @@ var initCmd = &cmds.Command{
 			api.DevnetUser(devnetUser),
 			api.AutoSealIntervalSeconds(autoSealIntervalSeconds),
 			api.DefaultAddress(defaultAddress),
+			api.Authorities(authorities),
 		)
 	},
 	Encoders: cmds.EncoderMap{
@@ -65,6 +65,9 @@ const (
 	// DefaultAddress when set, sets the daemons's default address to the provided address
 	DefaultAddress = "default-address"
 
+	// Authorities when set, creates a genesis block for a round-robin proof-of-authority chain with these block producers
+	Authorities = "authorities"
+
 	// GenesisFile is the path of file containing archive of genesis block DAG data
 	GenesisFile = "genesisfile"
 
@@ -106,6 +109,7 @@ VIEW DATA STRUCTURES
   go-filecoin chain                  - Inspect the filecoin blockchain
   go-filecoin dag                    - Interact with IPLD DAG objects
   go-filecoin show                   - Get human-readable representations of filecoin objects
+  go-filecoin state                  - Inspect the state tree
 
 NETWORK COMMANDS
   go-filecoin bootstrap              - Interact with bootstrap addresses
@@ -116,6 +120,7 @@ NETWORK COMMANDS
 ACTOR COMMANDS
   go-filecoin actor                  - Interact with actors. Actors are built-in smart contracts.
   go-filecoin paych                  - Payment channel operations
+  go-filecoin msig                   - Manage multisig wallets
 
 MESSAGE COMMANDS
   go-filecoin message                - Manage messages
@@ -162,10 +167,12 @@ var rootSubcmdsDaemon = map[string]*cmds.Command{
 	"miner":            minerCmd,
 	"mining":           miningCmd,
 	"mpool":            mpoolCmd,
+	"msig":             msigCmd,
 	"paych":            paymentChannelCmd,
 	"ping":             pingCmd,
 	"retrieval-client": retrievalClientCmd,
 	"show":             showCmd,
+	"state":            stateCmd,
 	"swarm":            swarmCmd,
 	"version":          versionCmd,
 	"wallet":           walletCmd,
@@ -319,6 +326,22 @@ func isConnectionRefused(err error) bool {
 var priceOption = cmdkit.StringOption("price", "Price (FIL e.g. 0.00013) to pay for each GasUnits consumed mining this message")
 var limitOption = cmdkit.Uint64Option("limit", "Maximum number of GasUnits this message is allowed to consume")
 var previewOption = cmdkit.BoolOption("preview", "Preview the Gas cost of this command without actually executing it")
+var valueOption = cmdkit.StringOption("value", "Value (FIL e.g. 0.5) to send with the message")
+
+// parseValueOption returns the value to send with a message, which is zero if
+// the value option is not set.
+func parseValueOption(req *cmds.Request) (*types.AttoFIL, error) {
+	o, ok := req.Options["value"].(string)
+	if !ok {
+		return types.ZeroAttoFIL, nil
+	}
+
+	value, ok := types.NewAttoFILFromFILString(o)
+	if !ok {
+		return nil, errors.New("invalid value (specify FIL as a decimal number)")
+	}
+	return value, nil
+}
 
 func parseGasOptions(req *cmds.Request) (types.AttoFIL, types.GasUnits, bool, error) {
 	priceOption := req.Options["price"]
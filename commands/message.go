@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"strings"
 
 	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
 	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
@@ -13,9 +14,11 @@ import (
 
 	"github.com/filecoin-project/go-filecoin/abi"
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/consensus"
 	"github.com/filecoin-project/go-filecoin/exec"
 	"github.com/filecoin-project/go-filecoin/plumbing/mthdsig"
 	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
 )
 
 var msgCmd = &cmds.Command{
@@ -24,8 +27,10 @@ var msgCmd = &cmds.Command{
 		Tagline: "Manage messages",
 	},
 	Subcommands: map[string]*cmds.Command{
-		"send": msgSendCmd,
-		"wait": msgWaitCmd,
+		"call":  msgCallCmd,
+		"send":  msgSendCmd,
+		"trace": msgTraceCmd,
+		"wait":  msgWaitCmd,
 	},
 }
 
@@ -33,18 +38,27 @@ type msgSendResult struct {
 	Cid     cid.Cid
 	GasUsed types.GasUnits
 	Preview bool
+	// Trace is the execution trace of a previewed message.
+	Trace *vm.ExecutionTrace `json:",omitempty"`
 }
 
 var msgSendCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline: "Send a message", // This feels too generic...
+		ShortDescription: `
+Sends a message to <target>, invoking --method with the given arguments. The
+arguments are parsed according to the method's signature (see
+'go-filecoin actor methods'): FIL amounts are decimal numbers of FIL, bytes are
+hex encoded and arrays are comma separated.
+`,
 	},
 	Arguments: []cmdkit.Argument{
 		cmdkit.StringArg("target", true, false, "Address of the actor to send the message to"),
-		cmdkit.StringArg("method", false, false, "The method to invoke on the target actor"),
+		cmdkit.StringArg("args", false, true, "The arguments of the method"),
 	},
 	Options: []cmdkit.Option{
-		cmdkit.IntOption("value", "Value to send with message, in AttoFIL"),
+		cmdkit.StringOption("method", "The method to invoke on the target actor"),
+		valueOption,
 		cmdkit.StringOption("from", "Address to send message from"),
 		priceOption,
 		limitOption,
@@ -57,9 +71,9 @@ var msgSendCmd = &cmds.Command{
 			return err
 		}
 
-		val, ok := req.Options["value"].(int)
-		if !ok {
-			val = 0
+		value, err := parseValueOption(req)
+		if err != nil {
+			return err
 		}
 
 		o := req.Options["from"]
@@ -82,12 +96,18 @@ var msgSendCmd = &cmds.Command{
 			method = ""
 		}
 
+		params, err := parseMethodArgs(req, env, target, method, req.Arguments[1:])
+		if err != nil {
+			return err
+		}
+
 		if preview {
-			usedGas, err := GetPorcelainAPI(env).MessagePreview(
+			usedGas, trace, err := GetPorcelainAPI(env).MessagePreviewTrace(
 				req.Context,
 				fromAddr,
 				target,
 				method,
+				params...,
 			)
 			if err != nil {
 				return err
@@ -96,6 +116,7 @@ var msgSendCmd = &cmds.Command{
 				Cid:     cid.Cid{},
 				GasUsed: usedGas,
 				Preview: true,
+				Trace:   trace,
 			})
 		}
 
@@ -103,10 +124,11 @@ var msgSendCmd = &cmds.Command{
 			req.Context,
 			fromAddr,
 			target,
-			types.NewAttoFILFromFIL(uint64(val)),
+			value,
 			gasPrice,
 			gasLimit,
 			method,
+			params...,
 		)
 		if err != nil {
 			return err
@@ -123,14 +145,57 @@ var msgSendCmd = &cmds.Command{
 		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res *msgSendResult) error {
 			if res.Preview {
 				output := strconv.FormatUint(uint64(res.GasUsed), 10)
-				_, err := w.Write([]byte(output))
-				return err
+				if _, err := w.Write([]byte(output)); err != nil {
+					return err
+				}
+				if res.Trace == nil {
+					return nil
+				}
+				if _, err := fmt.Fprintln(w); err != nil {
+					return err
+				}
+				return printTrace(w, res.Trace, 0)
 			}
 			return PrintString(w, res.Cid)
 		}),
 	},
 }
 
+// parseMethodArgs parses the string arguments of a call to method on target
+// according to the method's signature.
+func parseMethodArgs(req *cmds.Request, env cmds.Environment, target address.Address, method string, args []string) ([]interface{}, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+	if method == "" {
+		return nil, errors.New("arguments given without a method")
+	}
+
+	sig, err := GetPorcelainAPI(env).ActorGetSignature(req.Context, target, method)
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldn't get signature of %s", method)
+	}
+	return parseArgs(method, sig, args)
+}
+
+// parseArgs parses the string arguments of a call to method according to its
+// signature.
+func parseArgs(method string, sig *exec.FunctionSignature, args []string) ([]interface{}, error) {
+	if len(args) != len(sig.Params) {
+		return nil, fmt.Errorf("%s expects %d arguments, but got %d", method, len(sig.Params), len(args))
+	}
+
+	params := make([]interface{}, 0, len(args))
+	for i, arg := range args {
+		val, err := abi.ParseValue(arg, sig.Params[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid argument %d", i+1)
+		}
+		params = append(params, val)
+	}
+	return params, nil
+}
+
 // WaitResult is the result of a message wait call.
 type WaitResult struct {
 	Message   *types.SignedMessage
@@ -206,12 +271,17 @@ var msgWaitCmd = &cmds.Command{
 			}
 
 			if returnOpt && res.Receipt != nil && res.Signature != nil {
-				val, err := abi.Deserialize(res.Receipt.Return[0], res.Signature.Return[0])
-				if err != nil {
-					return errors.Wrap(err, "unable to deserialize return value")
-				}
+				for i, t := range res.Signature.Return {
+					if i >= len(res.Receipt.Return) {
+						break
+					}
+					val, err := abi.Deserialize(res.Receipt.Return[i], t)
+					if err != nil {
+						return errors.Wrap(err, "unable to deserialize return value")
+					}
 
-				marshaled = append(marshaled, []byte(val.Val.(Stringer).String())...)
+					marshaled = append(marshaled, []byte(val.String()+"\n")...)
+				}
 			}
 
 			_, err = w.Write(marshaled)
@@ -220,6 +290,184 @@ var msgWaitCmd = &cmds.Command{
 	},
 }
 
+type msgCallResult struct {
+	Receipt *types.MessageReceipt
+	GasUsed types.GasUnits
+	Error   string                   `json:",omitempty"`
+	Changes []*consensus.ActorChange `json:",omitempty"`
+}
+
+var msgCallCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Dry-run a message without sending it",
+		ShortDescription: `
+Applies a message to the state resulting from a tipset, as if it were included
+in the next block, and shows its receipt, the gas it used and the actors it
+changed. The message is not signed, its nonce is not checked and nothing is
+committed. The head of the chain is used if no tipset is given. Arguments are
+parsed as for 'go-filecoin message send'.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("target", true, false, "Address of the actor to send the message to"),
+		cmdkit.StringArg("args", false, true, "The arguments of the method"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("method", "The method to invoke on the target actor"),
+		valueOption,
+		cmdkit.StringOption("from", "Address to send message from"),
+		cmdkit.Uint64Option("limit", "Maximum number of GasUnits the message is allowed to consume (defaults to the block gas limit)"),
+		cmdkit.StringOption("tipset", "Comma separated cids of the blocks in the tipset"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		target, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		var fromAddr address.Address
+		if o, ok := req.Options["from"].(string); ok {
+			fromAddr, err = address.NewFromString(o)
+			if err != nil {
+				return errors.Wrap(err, "invalid from address")
+			}
+		} else {
+			fromAddr, err = GetPorcelainAPI(env).GetAndMaybeSetDefaultSenderAddress()
+			if err != nil {
+				return err
+			}
+		}
+
+		value, err := parseValueOption(req)
+		if err != nil {
+			return err
+		}
+
+		gasLimit := types.BlockGasLimit
+		if o, ok := req.Options["limit"].(uint64); ok {
+			gasLimit = types.NewGasUnits(o)
+		}
+
+		method, _ := req.Options["method"].(string)
+
+		tsKey, err := optionalTipSetKey(req.Options["tipset"])
+		if err != nil {
+			return err
+		}
+
+		params, err := parseMethodArgs(req, env, target, method, req.Arguments[1:])
+		if err != nil {
+			return err
+		}
+		encodedParams, err := abi.ToEncodedValues(params...)
+		if err != nil {
+			return err
+		}
+
+		msg := types.NewMessage(fromAddr, target, 0, value, method, encodedParams)
+		res, err := GetPorcelainAPI(env).MessageCall(req.Context, tsKey, msg, gasLimit)
+		if err != nil {
+			return err
+		}
+
+		out := &msgCallResult{
+			Receipt: res.Receipt,
+			GasUsed: res.GasUsed,
+			Changes: res.Changes,
+		}
+		if res.ExecutionError != nil {
+			out.Error = res.ExecutionError.Error()
+		}
+		return re.Emit(out)
+	},
+	Type: msgCallResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res *msgCallResult) error {
+			fmt.Fprintf(w, "exit code: %d\n", res.Receipt.ExitCode) // nolint: errcheck
+			fmt.Fprintf(w, "gas used: %d\n", res.GasUsed)           // nolint: errcheck
+			if res.Error != "" {
+				fmt.Fprintf(w, "error: %s\n", res.Error) // nolint: errcheck
+			}
+			for _, change := range res.Changes {
+				if change.Before == nil {
+					fmt.Fprintf(w, "%s: created with balance %s\n", change.Address, change.After.Balance) // nolint: errcheck
+					continue
+				}
+				_, err := fmt.Fprintf(w, "%s: balance %s -> %s, nonce %d -> %d, head %s -> %s\n",
+					change.Address, change.Before.Balance, change.After.Balance,
+					change.Before.Nonce, change.After.Nonce, change.Before.Head, change.After.Head)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+	},
+}
+
+var msgTraceCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Show how a message on chain was executed",
+		ShortDescription: `
+Re-executes the message on the state it was applied to and shows every message
+sent while executing it, with the gas each used, its return values and its exit
+code.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("cid", true, false, "The cid of the message to trace"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		msgCid, err := cid.Parse(req.Arguments[0])
+		if err != nil {
+			return errors.Wrap(err, "invalid message cid")
+		}
+
+		trace, err := GetPorcelainAPI(env).MessageTrace(req.Context, msgCid)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(trace)
+	},
+	Type: vm.ExecutionTrace{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, trace *vm.ExecutionTrace) error {
+			return printTrace(w, trace, 0)
+		}),
+	},
+}
+
+func printTrace(w io.Writer, trace *vm.ExecutionTrace, depth int) error {
+	if trace == nil {
+		return nil
+	}
+
+	method := trace.Method
+	if method == "" {
+		method = "(transfer)"
+	}
+	value := types.ZeroAttoFIL
+	if trace.Value != nil {
+		value = trace.Value
+	}
+
+	line := fmt.Sprintf("%s%s -> %s %s value=%s gas=%d exit=%d", strings.Repeat("  ", depth), trace.From, trace.To, method, value, trace.GasUsed, trace.ExitCode)
+	if trace.Error != "" {
+		line += fmt.Sprintf(" error=%q", trace.Error)
+	}
+	if _, err := fmt.Fprintln(w, line); err != nil {
+		return err
+	}
+
+	for _, sub := range trace.Subcalls {
+		if err := printTrace(w, sub, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func appendJSON(val interface{}, out []byte) ([]byte, error) {
 	m, err := json.MarshalIndent(val, "", "\t")
 	if err != nil {
@@ -37,6 +37,15 @@ func TestMessageSend(t *testing.T) {
 		"--value=10", "xyz",
 	)
 
+	t.Log("[failure] invalid value")
+	d.RunFail(
+		"invalid value",
+		"message", "send",
+		"--from", fixtures.TestAddresses[0],
+		"--price", "0", "--limit", "300",
+		"--value=ten", fixtures.TestAddresses[1],
+	)
+
 	t.Log("[success] with from")
 	defaultaddr := d.GetDefaultAddress()
 	d.RunSuccess("message", "send",
@@ -51,6 +60,13 @@ func TestMessageSend(t *testing.T) {
 		"--price", "0", "--limit", "300",
 		"--value=10", fixtures.TestAddresses[1],
 	)
+
+	t.Log("[success] with a fractional value")
+	d.RunSuccess("message", "send",
+		"--from", fixtures.TestAddresses[0],
+		"--price", "0", "--limit", "300",
+		"--value=0.5", fixtures.TestAddresses[1],
+	)
 }
 
 func TestMessageWait(t *testing.T) {
@@ -95,6 +111,115 @@ func TestMessageWait(t *testing.T) {
 
 		wg.Wait()
 	})
+
+	t.Run("[success] method with typed arguments", func(t *testing.T) {
+		assert := assert.New(t)
+
+		msg := d.RunSuccess(
+			"message", "send",
+			"--from", fixtures.TestAddresses[0],
+			"--price", "0", "--limit", "500",
+			"--method", "addAsk",
+			fixtures.TestMiners[0], "0.5", "100",
+		)
+
+		msgcid := strings.Trim(msg.ReadStdout(), "\n")
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			wait := d.RunSuccess(
+				"message", "wait",
+				"--message=false",
+				"--receipt=false",
+				"--return",
+				msgcid,
+			)
+			// the return value is the id of the new ask
+			_, err := strconv.ParseUint(strings.Trim(wait.ReadStdout(), "\n"), 10, 64)
+			assert.NoError(err)
+			wg.Done()
+		}()
+
+		d.RunSuccess("mining once")
+
+		wg.Wait()
+	})
+
+	t.Run("[failure] method with the wrong arguments", func(t *testing.T) {
+		d.RunFail("addAsk expects 2 arguments, but got 1",
+			"message", "send",
+			"--from", fixtures.TestAddresses[0],
+			"--price", "0", "--limit", "300",
+			"--method", "addAsk",
+			fixtures.TestMiners[0], "0.5",
+		)
+
+		d.RunFail("invalid argument 2",
+			"message", "send",
+			"--from", fixtures.TestAddresses[0],
+			"--price", "0", "--limit", "300",
+			"--method", "addAsk",
+			fixtures.TestMiners[0], "0.5", "soon",
+		)
+	})
+}
+
+func TestMessageCall(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	d := th.NewDaemon(
+		t,
+		th.WithMiner(fixtures.TestMiners[0]),
+		th.KeyFile(fixtures.KeyFilePaths()[0]),
+	).Start()
+	defer d.ShutdownSuccess()
+
+	balanceBefore := d.RunSuccess("wallet", "balance", fixtures.TestAddresses[0]).ReadStdoutTrimNewlines()
+
+	out := d.RunSuccess(
+		"message", "call",
+		"--from", fixtures.TestAddresses[0],
+		"--value", "10",
+		fixtures.TestAddresses[1],
+	).ReadStdoutTrimNewlines()
+	assert.Contains(out, "exit code: 0")
+	assert.Contains(out, fixtures.TestAddresses[0]+": balance ")
+	assert.Contains(out, fixtures.TestAddresses[1]+": ")
+
+	// nothing was sent
+	assert.Equal(balanceBefore, d.RunSuccess("wallet", "balance", fixtures.TestAddresses[0]).ReadStdoutTrimNewlines())
+
+	d.RunFail("invalid value", "message", "call", "--value", "ten", fixtures.TestAddresses[1])
+}
+
+func TestMessageTrace(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	d := th.NewDaemon(
+		t,
+		th.WithMiner(fixtures.TestMiners[0]),
+		th.KeyFile(fixtures.KeyFilePaths()[0]),
+	).Start()
+	defer d.ShutdownSuccess()
+
+	msg := d.RunSuccess(
+		"message", "send",
+		"--from", fixtures.TestAddresses[0],
+		"--price", "0", "--limit", "300",
+		"--value=10",
+		fixtures.TestAddresses[1],
+	)
+	msgcid := strings.Trim(msg.ReadStdout(), "\n")
+
+	d.RunSuccess("mining", "once")
+
+	trace := d.RunSuccess("message", "trace", msgcid).ReadStdoutTrimNewlines()
+	assert.Equal(fixtures.TestAddresses[0]+" -> "+fixtures.TestAddresses[1]+" (transfer) value=10 gas=0 exit=0", trace)
+
+	d.RunFail("not found", "message", "trace", types.SomeCid().String())
 }
 
 func TestMessageSendBlockGasLimit(t *testing.T) {
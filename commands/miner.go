@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"math/big"
+	"sort"
 	"strconv"
 
 	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
@@ -22,13 +23,16 @@ var minerCmd = &cmds.Command{
 		Tagline: "Manage a single miner actor",
 	},
 	Subcommands: map[string]*cmds.Command{
-		"create":        minerCreateCmd,
-		"add-ask":       minerAddAskCmd,
-		"owner":         minerOwnerCmd,
-		"pledge":        minerPledgeCmd,
-		"power":         minerPowerCmd,
-		"set-price":     minerSetPriceCmd,
-		"update-peerid": minerUpdatePeerIDCmd,
+		"create":           minerCreateCmd,
+		"add-ask":          minerAddAskCmd,
+		"collateral":       minerCollateralCmd,
+		"owner":            minerOwnerCmd,
+		"pledge":           minerPledgeCmd,
+		"power":            minerPowerCmd,
+		"power-table":      minerPowerTableCmd,
+		"set-price":        minerSetPriceCmd,
+		"terminate-sector": minerTerminateSectorCmd,
+		"update-peerid":    minerUpdatePeerIDCmd,
 	},
 }
 
@@ -56,6 +60,247 @@ var minerPledgeCmd = &cmds.Command{
 		re.Emit(str) // nolint: errcheck
 		return nil
 	},
+	Subcommands: map[string]*cmds.Command{
+		"increase": minerPledgeIncreaseCmd,
+	},
+}
+
+var minerPledgeIncreaseCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Pledge <sectors> more sectors for <miner>",
+		ShortDescription: `Issues a new message to the network to increase the miner's pledge. The
+miner's collateral must cover the increased pledge at 0.001 FIL per sector.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("miner", true, false, "The miner address"),
+		cmdkit.StringArg("sectors", true, false, "The number of sectors to add to the pledge"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address to send from"),
+		priceOption,
+		limitOption,
+		previewOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		minerAddr, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		sectors, ok := big.NewInt(0).SetString(req.Arguments[1], 10)
+		if !ok || sectors.Sign() <= 0 {
+			return ErrInvalidPledge
+		}
+
+		return sendMinerMessage(req, re, env, minerAddr, nil, "increasePledge", sectors)
+	},
+	Type:     &MinerMessageResult{},
+	Encoders: minerMessageEncoders,
+}
+
+var minerCollateralCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline:          "View the collateral of <miner>",
+		ShortDescription: `Shows the amount of FIL the given miner address holds as collateral`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("miner", true, false, "The miner address"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		minerAddr, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		minerState, err := GetPorcelainAPI(env).MinerState(req.Context, types.SortedCidSet{}, minerAddr)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(minerState.Collateral)
+	},
+	Type: &types.AttoFIL{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, collateral *types.AttoFIL) error {
+			return PrintString(w, collateral)
+		}),
+	},
+	Subcommands: map[string]*cmds.Command{
+		"add":      minerCollateralAddCmd,
+		"withdraw": minerCollateralWithdrawCmd,
+	},
+}
+
+var minerCollateralAddCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline:          "Add <amount> FIL to the collateral of <miner>",
+		ShortDescription: `Issues a new message to the network sending <amount> FIL to the miner as collateral.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("miner", true, false, "The miner address"),
+		cmdkit.StringArg("amount", true, false, "The amount of collateral in FIL to add"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address to send from"),
+		priceOption,
+		limitOption,
+		previewOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		minerAddr, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		amount, ok := types.NewAttoFILFromFILString(req.Arguments[1])
+		if !ok || !amount.IsPositive() {
+			return ErrInvalidCollateral
+		}
+
+		return sendMinerMessage(req, re, env, minerAddr, amount, "addCollateral")
+	},
+	Type:     &MinerMessageResult{},
+	Encoders: minerMessageEncoders,
+}
+
+var minerCollateralWithdrawCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Withdraw <amount> FIL of collateral from <miner>",
+		ShortDescription: `Issues a new message to the network returning <amount> FIL of collateral to the
+miner's owner. The collateral left must still cover the miner's pledge at
+0.001 FIL per sector.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("miner", true, false, "The miner address"),
+		cmdkit.StringArg("amount", true, false, "The amount of collateral in FIL to withdraw"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address to send from"),
+		priceOption,
+		limitOption,
+		previewOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		minerAddr, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		amount, ok := types.NewAttoFILFromFILString(req.Arguments[1])
+		if !ok || !amount.IsPositive() {
+			return ErrInvalidCollateral
+		}
+
+		return sendMinerMessage(req, re, env, minerAddr, nil, "withdrawCollateral", amount)
+	},
+	Type:     &MinerMessageResult{},
+	Encoders: minerMessageEncoders,
+}
+
+var minerTerminateSectorCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Terminate the committed <sector> of <miner>",
+		ShortDescription: `Issues a new message to the network removing the sector from the miner's
+power. Unless the sector has expired, the share of collateral backing one
+pledged sector is burnt as a penalty.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("miner", true, false, "The miner address"),
+		cmdkit.StringArg("sector", true, false, "The id of the sector to terminate"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address to send from"),
+		priceOption,
+		limitOption,
+		previewOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		minerAddr, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		sectorID, err := strconv.ParseUint(req.Arguments[1], 10, 64)
+		if err != nil {
+			return ErrInvalidSectorID
+		}
+
+		return sendMinerMessage(req, re, env, minerAddr, nil, "terminateSector", sectorID)
+	},
+	Type:     &MinerMessageResult{},
+	Encoders: minerMessageEncoders,
+}
+
+// MinerMessageResult is the type returned by the commands changing a miner's pledge,
+// collateral or sectors.
+type MinerMessageResult struct {
+	Cid     cid.Cid
+	GasUsed types.GasUnits
+	Preview bool
+}
+
+var minerMessageEncoders = cmds.EncoderMap{
+	cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res *MinerMessageResult) error {
+		if res.Preview {
+			output := strconv.FormatUint(uint64(res.GasUsed), 10)
+			_, err := w.Write([]byte(output))
+			return err
+		}
+		return PrintString(w, res.Cid)
+	}),
+}
+
+// sendMinerMessage sends a message calling method on the miner from the
+// address in the from option, or previews its gas cost.
+func sendMinerMessage(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment, minerAddr address.Address, value *types.AttoFIL, method string, params ...interface{}) error {
+	fromAddr, err := optionalAddr(req.Options["from"])
+	if err != nil {
+		return err
+	}
+
+	gasPrice, gasLimit, preview, err := parseGasOptions(req)
+	if err != nil {
+		return err
+	}
+
+	if preview {
+		usedGas, err := GetPorcelainAPI(env).MessagePreview(
+			req.Context,
+			fromAddr,
+			minerAddr,
+			method,
+			params...,
+		)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(&MinerMessageResult{
+			Cid:     cid.Cid{},
+			GasUsed: usedGas,
+			Preview: true,
+		})
+	}
+
+	c, err := GetPorcelainAPI(env).MessageSendWithDefaultAddress(
+		req.Context,
+		fromAddr,
+		minerAddr,
+		value,
+		gasPrice,
+		gasLimit,
+		method,
+		params...,
+	)
+	if err != nil {
+		return err
+	}
+
+	return re.Emit(&MinerMessageResult{
+		Cid:     c,
+		GasUsed: types.NewGasUnits(0),
+		Preview: false,
+	})
 }
 
 // MinerCreateResult is the type returned when creating a miner.
@@ -488,7 +733,7 @@ Values will be output as a ratio where the first number is the miner power and s
 			return err
 		}
 
-		str := fmt.Sprintf("%d / %d", power, total)
+		str := fmt.Sprintf("%s / %s", power, total)
 		return re.Emit(str)
 	},
 	Arguments: []cmdkit.Argument{
@@ -501,3 +746,59 @@ Values will be output as a ratio where the first number is the miner power and s
 		}),
 	},
 }
+
+// MinerPowerTableResult is the type returned by miner power-table.
+type MinerPowerTableResult struct {
+	Total  *types.BytesAmount
+	Miners []MinerPowerResult
+}
+
+// MinerPowerResult is the power of a single miner.
+type MinerPowerResult struct {
+	Address address.Address
+	Power   *types.BytesAmount
+}
+
+var minerPowerTableCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "List the power of every miner",
+		ShortDescription: `Shows the power of every miner in the storage market and the total power of the network
+as of the given tipset. The head of the chain is used if no tipset is given.`,
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("tipset", "Comma separated cids of the blocks in the tipset"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		tsKey, err := optionalTipSetKey(req.Options["tipset"])
+		if err != nil {
+			return err
+		}
+
+		table, err := GetPorcelainAPI(env).MinerGetPowerTable(req.Context, tsKey)
+		if err != nil {
+			return err
+		}
+
+		res := &MinerPowerTableResult{Total: table.Total}
+		for addr, power := range table.Miners {
+			res.Miners = append(res.Miners, MinerPowerResult{Address: addr, Power: power})
+		}
+		sort.Slice(res.Miners, func(i, j int) bool {
+			return res.Miners[i].Address.String() < res.Miners[j].Address.String()
+		})
+
+		return re.Emit(res)
+	},
+	Type: &MinerPowerTableResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res *MinerPowerTableResult) error {
+			for _, m := range res.Miners {
+				if _, err := fmt.Fprintf(w, "%s %s\n", m.Address, m.Power); err != nil {
+					return err
+				}
+			}
+			_, err := fmt.Fprintf(w, "total %s\n", res.Total)
+			return err
+		}),
+	},
+}
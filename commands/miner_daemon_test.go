@@ -10,6 +10,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/types"
 	"io/ioutil"
 	"math/big"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
@@ -36,11 +37,14 @@ func TestMinerHelp(t *testing.T) {
 
 		expected := []string{
 			"miner add-ask <miner> <price> <expiry>  - DEPRECATED: Use set-price",
+			"miner collateral <miner>                - View the collateral of <miner>",
 			"miner create <pledge> <collateral>      - Create a new file miner with <pledge> sectors and <collateral> FIL",
 			"miner owner <miner>                     - Show the actor address of <miner>",
 			"miner pledge <miner>                    - View number of pledged sectors for <miner>",
 			"miner power <miner>                     - Get the power of a miner versus the total storage market power",
+			"miner power-table                       - List the power of every miner",
 			"miner set-price <storageprice> <expiry> - Set the minimum price for storage",
+			"miner terminate-sector <miner> <sector> - Terminate the committed <sector> of <miner>",
 			"miner update-peerid <address> <peerid>  - Change the libp2p identity that a miner is operating",
 		}
 
@@ -54,6 +58,20 @@ func TestMinerHelp(t *testing.T) {
 		t.Parallel()
 		result := runHelpSuccess(t, "miner", "pledge", "--help")
 		assert.Contains(result, "Shows the number of pledged sectors for the given miner address")
+		assert.Contains(result, "miner pledge increase <miner> <sectors> - Pledge <sectors> more sectors for <miner>")
+	})
+
+	t.Run("collateral --help shows collateral help", func(t *testing.T) {
+		t.Parallel()
+		result := runHelpSuccess(t, "miner", "collateral", "--help")
+		expected := []string{
+			"Shows the amount of FIL the given miner address holds as collateral",
+			"miner collateral add <miner> <amount>",
+			"miner collateral withdraw <miner> <amount>",
+		}
+		for _, elem := range expected {
+			assert.Contains(result, elem)
+		}
 	})
 
 	t.Run("update-peerid --help shows update-peerid help", func(t *testing.T) {
@@ -166,6 +184,35 @@ func TestMinerPledge(t *testing.T) {
 	})
 }
 
+func TestMinerCollateral(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	d := th.NewDaemon(t, th.WithMiner(fixtures.TestMiners[0]), th.KeyFile(fixtures.KeyFilePaths()[0]), th.DefaultAddress(fixtures.TestAddresses[0])).Start()
+	defer d.ShutdownSuccess()
+
+	collateral := func() *types.AttoFIL {
+		out := d.RunSuccess("miner", "collateral", fixtures.TestMiners[0])
+		amount, ok := types.NewAttoFILFromFILString(out.ReadStdoutTrimNewlines())
+		assert.True(ok)
+		return amount
+	}
+	before := collateral()
+
+	d.RunSuccess("miner", "collateral", "add", fixtures.TestMiners[0], "10", "--price", "0", "--limit", "300")
+	d.RunSuccess("mining once")
+	assert.True(before.Add(types.NewAttoFILFromFIL(10)).Equal(collateral()))
+
+	d.RunSuccess("miner", "collateral", "withdraw", fixtures.TestMiners[0], "10", "--price", "0", "--limit", "300")
+	d.RunSuccess("mining once")
+	assert.True(before.Equal(collateral()))
+
+	d.RunSuccess("miner", "pledge", "increase", fixtures.TestMiners[0], "1", "--price", "0", "--limit", "300")
+	d.RunSuccess("mining once")
+	pledge := d.RunSuccess("miner", "pledge", fixtures.TestMiners[0])
+	assert.Equal("10001", pledge.ReadStdoutTrimNewlines())
+}
+
 func TestMinerCreate(t *testing.T) {
 	t.Parallel()
 	assert := assert.New(t)
@@ -198,7 +245,7 @@ func TestMinerCreate(t *testing.T) {
 
 			d1.ConnectSuccess(d)
 
-			args := []string{"miner", "create", "--from", fromAddress.String(), "--price", "0", "--limit", "300"}
+			args := []string{"miner", "create", "--from", fromAddress.String(), "--price", "0", "--limit", "1000"}
 
 			if pid.Pretty() != peer.ID("").Pretty() {
 				args = append(args, "--peerid", pid.Pretty())
@@ -277,7 +324,7 @@ func TestMinerCreate(t *testing.T) {
 		go func() {
 			d.RunFail("pledge must be at least",
 				"miner", "create",
-				"--from", testAddr.String(), "--price", "0", "--limit", "300", "1", "10",
+				"--from", testAddr.String(), "--price", "0", "--limit", "1000", "1", "10",
 			)
 			wg.Done()
 		}()
@@ -297,7 +344,7 @@ func TestMinerSetPrice(t *testing.T) {
 
 	d1.RunSuccess("mining", "start")
 
-	setPrice := d1.RunSuccess("miner", "set-price", "62", "6", "--price", "0", "--limit", "300")
+	setPrice := d1.RunSuccess("miner", "set-price", "62", "6", "--price", "0", "--limit", "500")
 	assert.Contains(setPrice.ReadStdoutTrimNewlines(), fmt.Sprintf("Set price for miner %s to 62.", fixtures.TestMiners[0]))
 
 	configuredPrice := d1.RunSuccess("config", "mining.storagePrice")
@@ -317,7 +364,7 @@ func TestMinerAddAskSuccess(t *testing.T) {
 	var wg sync.WaitGroup
 	wg.Add(1)
 	go func() {
-		miner := d.RunSuccess("miner", "create", "--from", fixtures.TestAddresses[2], "--price", "0", "--limit", "300", "100", "200")
+		miner := d.RunSuccess("miner", "create", "--from", fixtures.TestAddresses[2], "--price", "0", "--limit", "1000", "100", "200")
 		addr, err := address.NewFromString(strings.Trim(miner.ReadStdout(), "\n"))
 		assert.NoError(err)
 		assert.NotEqual(addr, address.Address{})
@@ -346,9 +393,14 @@ func TestMinerCreateChargesGas(t *testing.T) {
 	// make sure the FIL shows up in the MinerAccount
 	startingBalance := queryBalance(t, d, miningMinerAddr)
 
+	// the gas used depends on the gas schedule, so ask for it up front
+	preview := d.RunSuccess("miner", "create", "--from", fixtures.TestAddresses[2], "--price", "0", "--limit", "1000", "--preview", "100", "200")
+	gasUsed, err := strconv.ParseInt(preview.ReadStdoutTrimNewlines(), 10, 64)
+	require.NoError(err)
+
 	wg.Add(1)
 	go func() {
-		miner := d.RunSuccess("miner", "create", "--from", fixtures.TestAddresses[2], "--price", "333", "--limit", "300", "100", "200")
+		miner := d.RunSuccess("miner", "create", "--from", fixtures.TestAddresses[2], "--price", "333", "--limit", "1000", "100", "200")
 		addr, err := address.NewFromString(strings.Trim(miner.ReadStdout(), "\n"))
 		assert.NoError(err)
 		assert.NotEqual(addr, address.Address{})
@@ -360,7 +412,7 @@ func TestMinerCreateChargesGas(t *testing.T) {
 
 	expectedBlockReward := consensus.NewDefaultBlockRewarder().BlockRewardAmount()
 	expectedPrice := types.NewAttoFILFromFIL(333)
-	expectedGasCost := big.NewInt(100)
+	expectedGasCost := big.NewInt(gasUsed)
 	expectedBalance := expectedBlockReward.Add(expectedPrice.MulBigInt(expectedGasCost))
 	newBalance := queryBalance(t, d, miningMinerAddr)
 	assert.Equal(expectedBalance.String(), newBalance.Sub(startingBalance).String())
@@ -395,7 +447,7 @@ func TestMinerAddAskFail(t *testing.T) {
 	go func() {
 		miner := d.RunSuccess("miner", "create",
 			"--from", fixtures.TestAddresses[2],
-			"--price", "0", "--limit", "300",
+			"--price", "0", "--limit", "1000",
 			"--peerid", th.RequireRandomPeerID().Pretty(),
 			"100", "20",
 		)
@@ -503,8 +555,46 @@ func TestMinerPower(t *testing.T) {
 
 	power := powerOutput.ReadStdoutTrimNewlines()
 
+	sectorSize := th.SectorSize()
+	expected := fmt.Sprintf("%s / %s", sectorSize.Mul(types.NewBytesAmount(3)), sectorSize.Mul(types.NewBytesAmount(6)))
+
 	assert.NoError(err)
-	assert.Equal("3 / 6", power)
+	assert.Equal(expected, power)
+}
+
+func TestMinerPowerTable(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fi, err := ioutil.TempFile("", "gengentest")
+	require.NoError(err)
+
+	_, err = gengen.GenGenesisCar(testConfig, fi, 0)
+	require.NoError(err)
+	require.NoError(fi.Close())
+
+	d := th.NewDaemon(t, th.GenesisFile(fi.Name())).Start()
+	defer d.ShutdownSuccess()
+
+	out := d.RunSuccess("miner", "power-table", "--enc=json")
+
+	var table MinerPowerTableResult
+	require.NoError(json.Unmarshal([]byte(out.ReadStdout()), &table))
+	sectorSize := th.SectorSize()
+	assert.True(sectorSize.Mul(types.NewBytesAmount(6)).Equal(table.Total))
+	require.Len(table.Miners, 2)
+	for _, m := range table.Miners {
+		assert.True(sectorSize.Mul(types.NewBytesAmount(3)).Equal(m.Power))
+	}
+
+	head := d.RunSuccess("chain", "head", "--enc=json").ReadStdoutTrimNewlines()
+	var headCids []map[string]string
+	require.NoError(json.Unmarshal([]byte(head), &headCids))
+	require.Len(headCids, 1)
+
+	out = d.RunSuccess("miner", "power-table", "--tipset", headCids[0]["/"])
+	assert.Contains(out.ReadStdout(), "total 6")
 }
 
 var testConfig = &gengen.GenesisCfg{
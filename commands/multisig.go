@@ -0,0 +1,315 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+	cmds "gx/ipfs/Qma6uuSyjkecGhMFFLfzyJDPyoDtNJSHJNweDccZhaWkgU/go-ipfs-cmds"
+	cmdkit "gx/ipfs/Qmde5VP1qUkyQXKCfmEUA7bP64V2HAptbJ7phuPp7jXWwg/go-ipfs-cmdkit"
+
+	"github.com/filecoin-project/go-filecoin/actor/builtin/multisig"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+var msigCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Manage multisig wallets",
+		ShortDescription: `
+A multisig wallet holds funds that can only be spent once a number of its
+signers approve. Any signer may propose a transaction, and the transaction is
+executed when enough signers approved it. Signers and the number of required
+approvals are changed by proposing a transaction to the wallet itself, with
+method addSigner, removeSigner or changeRequirement.
+`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"approve": msigApproveCmd,
+		"cancel":  msigCancelCmd,
+		"create":  msigCreateCmd,
+		"info":    msigInfoCmd,
+		"propose": msigProposeCmd,
+	},
+}
+
+var msigCreateCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Create a multisig wallet",
+		ShortDescription: `
+Creates a multisig wallet with the given comma separated signers, of which
+<required> must approve every transaction, and waits for it to be mined. The
+wallet is funded with --value, which may be locked to unlock linearly over
+--unlock-duration blocks. Prints the address of the wallet.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("signers", true, false, "Comma separated addresses of the signers"),
+		cmdkit.StringArg("required", true, false, "Number of signers that must approve a transaction"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address to send from"),
+		cmdkit.StringOption("value", "Amount in FIL to fund the wallet with").WithDefault("0"),
+		cmdkit.Uint64Option("unlock-duration", "Number of blocks over which the funds unlock").WithDefault(uint64(0)),
+		priceOption,
+		limitOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		fromAddr, err := optionalAddr(req.Options["from"])
+		if err != nil {
+			return err
+		}
+
+		var signers []address.Address
+		for _, s := range strings.Split(req.Arguments[0], ",") {
+			signer, err := address.NewFromString(s)
+			if err != nil {
+				return errors.Wrapf(err, "invalid signer %s", s)
+			}
+			signers = append(signers, signer)
+		}
+
+		required, ok := big.NewInt(0).SetString(req.Arguments[1], 10)
+		if !ok || !required.IsUint64() {
+			return fmt.Errorf("required must be a valid integer")
+		}
+
+		value, ok := types.NewAttoFILFromFILString(req.Options["value"].(string))
+		if !ok {
+			return ErrInvalidAmount
+		}
+
+		unlockDuration, _ := req.Options["unlock-duration"].(uint64)
+
+		gasPrice, gasLimit, _, err := parseGasOptions(req)
+		if err != nil {
+			return err
+		}
+
+		msigAddr, err := GetPorcelainAPI(env).MsigCreate(req.Context, fromAddr, gasPrice, gasLimit, value, signers, required.Uint64(), types.NewBlockHeight(unlockDuration))
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(msigAddr)
+	},
+	Type: address.Address{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, a *address.Address) error {
+			return PrintString(w, a)
+		}),
+	},
+}
+
+var msigProposeCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Propose a transaction from a multisig wallet",
+		ShortDescription: `
+Proposes that the multisig wallet at <wallet> sends a message to <target>,
+invoking --method with the given arguments, and waits for the proposal to be
+mined. The proposal counts as an approval by the proposer. Arguments are parsed
+as for 'go-filecoin message send'. Prints the id of the transaction.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("wallet", true, false, "Address of the multisig wallet"),
+		cmdkit.StringArg("target", true, false, "Address of the actor to send the message to"),
+		cmdkit.StringArg("args", false, true, "The arguments of the method"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address of the proposing signer"),
+		cmdkit.StringOption("method", "The method to invoke on the target actor"),
+		cmdkit.StringOption("value", "Amount in FIL to send with the message").WithDefault("0"),
+		priceOption,
+		limitOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		fromAddr, err := optionalAddr(req.Options["from"])
+		if err != nil {
+			return err
+		}
+
+		msigAddr, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return errors.Wrap(err, "invalid wallet address")
+		}
+
+		target, err := address.NewFromString(req.Arguments[1])
+		if err != nil {
+			return errors.Wrap(err, "invalid target address")
+		}
+
+		value, ok := types.NewAttoFILFromFILString(req.Options["value"].(string))
+		if !ok {
+			return ErrInvalidAmount
+		}
+
+		method, _ := req.Options["method"].(string)
+
+		// transactions to the wallet itself use methods it does not export
+		var params []interface{}
+		if target == msigAddr {
+			sig, ok := multisig.SelfMethods[method]
+			if !ok {
+				return fmt.Errorf("multisig wallets have no method %q", method)
+			}
+			params, err = parseArgs(method, sig, req.Arguments[2:])
+		} else {
+			params, err = parseMethodArgs(req, env, target, method, req.Arguments[2:])
+		}
+		if err != nil {
+			return err
+		}
+
+		gasPrice, gasLimit, _, err := parseGasOptions(req)
+		if err != nil {
+			return err
+		}
+
+		txID, err := GetPorcelainAPI(env).MsigPropose(req.Context, fromAddr, msigAddr, gasPrice, gasLimit, target, value, method, params...)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(txID)
+	},
+	Type: big.Int{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, txID *big.Int) error {
+			return PrintString(w, txID)
+		}),
+	},
+}
+
+var msigApproveCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Approve a pending transaction of a multisig wallet",
+		ShortDescription: `
+Approves the pending transaction <txid> of the multisig wallet at <wallet> and
+waits for the approval to be mined. The transaction is executed if this is the
+last approval it needs.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("wallet", true, false, "Address of the multisig wallet"),
+		cmdkit.StringArg("txid", true, false, "Id of the transaction"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address of the approving signer"),
+		priceOption,
+		limitOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		fromAddr, msigAddr, txID, err := parseMsigTxArgs(req)
+		if err != nil {
+			return err
+		}
+
+		gasPrice, gasLimit, _, err := parseGasOptions(req)
+		if err != nil {
+			return err
+		}
+
+		return GetPorcelainAPI(env).MsigApprove(req.Context, fromAddr, msigAddr, gasPrice, gasLimit, txID)
+	},
+}
+
+var msigCancelCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Cancel a pending transaction of a multisig wallet",
+		ShortDescription: `
+Cancels the pending transaction <txid> of the multisig wallet at <wallet> and
+waits for the cancellation to be mined. Only the proposer of a transaction may
+cancel it.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("wallet", true, false, "Address of the multisig wallet"),
+		cmdkit.StringArg("txid", true, false, "Id of the transaction"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address of the proposer"),
+		priceOption,
+		limitOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		fromAddr, msigAddr, txID, err := parseMsigTxArgs(req)
+		if err != nil {
+			return err
+		}
+
+		gasPrice, gasLimit, _, err := parseGasOptions(req)
+		if err != nil {
+			return err
+		}
+
+		return GetPorcelainAPI(env).MsigCancel(req.Context, fromAddr, msigAddr, gasPrice, gasLimit, txID)
+	},
+}
+
+var msigInfoCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Show the signers and pending transactions of a multisig wallet",
+		ShortDescription: `
+Prints the state of the multisig wallet at <wallet> as JSON, as found in the
+state resulting from a tipset. The head of the chain is used if no tipset is
+given.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("wallet", true, false, "Address of the multisig wallet"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("tipset", "Comma separated cids of the blocks in the tipset"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		msigAddr, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		tsKey, err := optionalTipSetKey(req.Options["tipset"])
+		if err != nil {
+			return err
+		}
+
+		st, err := GetPorcelainAPI(env).MultisigState(req.Context, tsKey, msigAddr)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(st)
+	},
+	Type: multisig.State{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, st *multisig.State) error {
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "\t")
+			return encoder.Encode(st)
+		}),
+	},
+}
+
+// parseMsigTxArgs parses the from address and the wallet and transaction id
+// arguments shared by the commands acting on a pending transaction.
+func parseMsigTxArgs(req *cmds.Request) (address.Address, address.Address, *big.Int, error) {
+	fromAddr, err := optionalAddr(req.Options["from"])
+	if err != nil {
+		return address.Address{}, address.Address{}, nil, err
+	}
+
+	msigAddr, err := address.NewFromString(req.Arguments[0])
+	if err != nil {
+		return address.Address{}, address.Address{}, nil, errors.Wrap(err, "invalid wallet address")
+	}
+
+	txID, ok := big.NewInt(0).SetString(req.Arguments[1], 10)
+	if !ok {
+		return address.Address{}, address.Address{}, nil, fmt.Errorf("txid must be a valid integer")
+	}
+
+	return fromAddr, msigAddr, txID, nil
+}
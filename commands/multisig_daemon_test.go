@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/fixtures"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+)
+
+func TestMultisig(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	require := require.New(t)
+
+	d := th.NewDaemon(
+		t,
+		th.WithMiner(fixtures.TestMiners[0]),
+		th.KeyFile(fixtures.KeyFilePaths()[0]),
+	).Start()
+	defer d.ShutdownSuccess()
+
+	signer := fixtures.TestAddresses[0]
+	target := fixtures.TestAddresses[1]
+
+	create := runWhileMining(d, "msig", "create", "--from", signer, "--value", "100", "--price", "0", "--limit", "1000", signer, "1")
+	msigAddr, err := address.NewFromString(create.ReadStdoutTrimNewlines())
+	require.NoError(err)
+
+	// one approval is all the wallet needs, so the proposal executes right away
+	runWhileMining(d, "msig", "propose", "--from", signer, "--value", "10", "--price", "0", "--limit", "1000", msigAddr.String(), target)
+
+	balance := d.RunSuccess("wallet", "balance", msigAddr.String())
+	assert.Equal("90", balance.ReadStdoutTrimNewlines())
+
+	info := d.RunSuccess("msig", "info", msigAddr.String())
+	assert.Contains(info.ReadStdout(), signer)
+
+	d.RunFail("is not a multisig wallet", "msig", "info", signer)
+}
+
+// runWhileMining runs a command that waits for its message to be mined,
+// mining blocks until it completes.
+func runWhileMining(d *th.TestDaemon, args ...string) *th.Output {
+	done := make(chan *th.Output)
+	go func() {
+		done <- d.RunSuccess(args...)
+	}()
+
+	for {
+		select {
+		case out := <-done:
+			return out
+		case <-time.After(500 * time.Millisecond):
+			d.RunSuccess("mining", "once")
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+	cmds "gx/ipfs/Qma6uuSyjkecGhMFFLfzyJDPyoDtNJSHJNweDccZhaWkgU/go-ipfs-cmds"
+	cmdkit "gx/ipfs/Qmde5VP1qUkyQXKCfmEUA7bP64V2HAptbJ7phuPp7jXWwg/go-ipfs-cmdkit"
+
+	"github.com/filecoin-project/go-filecoin/state"
+)
+
+var stateCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Inspect the state tree",
+	},
+	Subcommands: map[string]*cmds.Command{
+		"diff": stateDiffCmd,
+	},
+}
+
+var stateDiffCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Show the actors that differ between two state trees",
+		ShortDescription: `
+Lists the actors added (+), removed (-) and changed (~) going from the state
+tree with root <root1> to the one with root <root2>. For builtin actors the
+fields of their storage that changed are listed too.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("root1", true, false, "The cid of the first state root"),
+		cmdkit.StringArg("root2", true, false, "The cid of the second state root"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		before, err := cid.Decode(req.Arguments[0])
+		if err != nil {
+			return errors.Wrap(err, "invalid state root")
+		}
+		after, err := cid.Decode(req.Arguments[1])
+		if err != nil {
+			return errors.Wrap(err, "invalid state root")
+		}
+
+		diffs, err := GetPorcelainAPI(env).StateDiff(req.Context, before, after)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(diffs)
+	},
+	Type: []*state.ActorDiff{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, diffs *[]*state.ActorDiff) error {
+			for _, diff := range *diffs {
+				if err := printActorDiff(w, diff); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+	},
+}
+
+func printActorDiff(w io.Writer, diff *state.ActorDiff) error {
+	var err error
+	switch diff.Kind {
+	case state.ActorAdded:
+		_, err = fmt.Fprintf(w, "+ %s code=%s balance=%s nonce=%d\n", diff.Address, diff.After.Code, diff.After.Balance, diff.After.Nonce)
+	case state.ActorRemoved:
+		_, err = fmt.Fprintf(w, "- %s\n", diff.Address)
+	default:
+		b, a := diff.Before, diff.After
+		_, err = fmt.Fprintf(w, "~ %s", diff.Address)
+		if err == nil && !b.Code.Equals(a.Code) {
+			_, err = fmt.Fprintf(w, " code=%s->%s", b.Code, a.Code)
+		}
+		if err == nil && !b.Balance.Equal(a.Balance) {
+			_, err = fmt.Fprintf(w, " balance=%s->%s", b.Balance, a.Balance)
+		}
+		if err == nil && b.Nonce != a.Nonce {
+			_, err = fmt.Fprintf(w, " nonce=%d->%d", b.Nonce, a.Nonce)
+		}
+		if err == nil && !b.Head.Equals(a.Head) {
+			_, err = fmt.Fprintf(w, " head=%s->%s", b.Head, a.Head)
+		}
+		if err == nil {
+			_, err = fmt.Fprintln(w)
+		}
+		for _, field := range diff.Storage {
+			if err != nil {
+				break
+			}
+			_, err = fmt.Fprintf(w, "    %s: %v -> %v\n", field.Field, field.Before, field.After)
+		}
+	}
+	return err
+}
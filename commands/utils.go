@@ -3,6 +3,10 @@ package commands
 import (
 	"fmt"
 	"io"
+	"strings"
+
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
 
 	"github.com/filecoin-project/go-filecoin/types"
 )
@@ -29,3 +33,21 @@ func optionalBlockHeight(o interface{}) (ret *types.BlockHeight, err error) {
 	}
 	return validAt, nil
 }
+
+// optionalTipSetKey parses a comma separated list of block cids identifying a
+// tipset. An empty key is returned if no value is given.
+func optionalTipSetKey(o interface{}) (types.SortedCidSet, error) {
+	if o == nil || o.(string) == "" {
+		return types.SortedCidSet{}, nil
+	}
+
+	var cids []cid.Cid
+	for _, s := range strings.Split(o.(string), ",") {
+		c, err := cid.Decode(strings.TrimSpace(s))
+		if err != nil {
+			return types.SortedCidSet{}, errors.Wrapf(err, "invalid block cid %q in tipset", s)
+		}
+		cids = append(cids, c)
+	}
+	return types.NewSortedCidSet(cids...), nil
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"os"
 	"reflect"
 	"regexp"
@@ -103,13 +104,25 @@ type MiningConfig struct {
 	MinerAddress            address.Address `json:"minerAddress"`
 	AutoSealIntervalSeconds uint            `json:"autoSealIntervalSeconds"`
 	StoragePrice            *types.AttoFIL  `json:"storagePrice"`
+	// SubmitConsensusFaults makes the node submit evidence to the storage
+	// market when it sees a miner produce conflicting blocks.
+	SubmitConsensusFaults bool `json:"submitConsensusFaults"`
+	// ConsensusFaultGasPrice is the gas price the node pays to submit
+	// evidence of a consensus fault. DefaultConsensusFaultGasPrice is used
+	// if it is null.
+	ConsensusFaultGasPrice *types.AttoFIL `json:"consensusFaultGasPrice"`
 }
 
+// DefaultConsensusFaultGasPrice is the gas price a node pays to submit
+// evidence of a consensus fault when its config does not set one.
+var DefaultConsensusFaultGasPrice = types.NewAttoFIL(big.NewInt(1))
+
 func newDefaultMiningConfig() *MiningConfig {
 	return &MiningConfig{
 		MinerAddress:            address.Address{},
 		AutoSealIntervalSeconds: 120,
 		StoragePrice:            types.NewZeroAttoFIL(),
+		ConsensusFaultGasPrice:  DefaultConsensusFaultGasPrice,
 	}
 }
 
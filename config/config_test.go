@@ -66,7 +66,9 @@ func TestWriteFile(t *testing.T) {
 	"mining": {
 		"minerAddress": "",
 		"autoSealIntervalSeconds": 120,
-		"storagePrice": "0"
+		"storagePrice": "0",
+		"submitConsensusFaults": false,
+		"consensusFaultGasPrice": "1"
 	},
 	"wallet": {
 		"defaultAddress": ""
@@ -0,0 +1,52 @@
+package consensus
+
+import (
+	"context"
+
+	"gx/ipfs/QmS2aqUZLJp8kF1ihE5rvDGE5LvmKDPnx32w9Z1BW9xLV5/go-ipfs-blockstore"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
+	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+)
+
+// ErrInvalidBlockSignature is returned when a block is not signed by the
+// owner of the miner that produced it.
+var ErrInvalidBlockSignature = errors.New("block is not signed by the owner of its miner")
+
+// BlockSignatureValidator checks that a block is signed by the miner that
+// produced it.
+type BlockSignatureValidator interface {
+	// ValidateBlockSignature returns an error if blk is not signed by its
+	// miner, as recorded in st, the state the block was produced on.
+	ValidateBlockSignature(ctx context.Context, st state.Tree, bs blockstore.Blockstore, blk *types.Block) error
+}
+
+// MinerKeySignatureValidator validates block signatures against the owner
+// and key recorded in the miner actor of the block.
+type MinerKeySignatureValidator struct{}
+
+var _ BlockSignatureValidator = (*MinerKeySignatureValidator)(nil)
+
+// ValidateBlockSignature implements BlockSignatureValidator.
+func (v *MinerKeySignatureValidator) ValidateBlockSignature(ctx context.Context, st state.Tree, bs blockstore.Blockstore, blk *types.Block) error {
+	minerActor, err := st.GetActor(ctx, blk.Miner)
+	if err != nil {
+		return errors.Wrapf(err, "could not load miner %s", blk.Miner)
+	}
+	if !minerActor.Code.Equals(types.MinerActorCodeCid) {
+		return errors.Errorf("block producer %s is not a miner", blk.Miner)
+	}
+
+	minerState, err := miner.LoadState(vm.NewStorageMap(bs).NewStorage(blk.Miner, minerActor))
+	if err != nil {
+		return errors.Wrapf(err, "could not load state of miner %s", blk.Miner)
+	}
+
+	if !blk.VerifySignature(minerState.Owner, minerState.PublicKey) {
+		return ErrInvalidBlockSignature
+	}
+	return nil
+}
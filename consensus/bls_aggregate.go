@@ -0,0 +1,64 @@
+package consensus
+
+import (
+	"context"
+
+	"gx/ipfs/QmS2aqUZLJp8kF1ihE5rvDGE5LvmKDPnx32w9Z1BW9xLV5/go-ipfs-blockstore"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/filecoin-project/go-filecoin/actor/builtin/account"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+)
+
+// validateBLSAggregates checks the BLS aggregate signature of every block in
+// ts. Aggregated messages that carry no public key are verified with the key
+// another message of the tipset carries for their sender, or else with the key
+// recorded by the sender's account actor in st, the parent state of ts.
+func validateBLSAggregates(ctx context.Context, st state.Tree, bs blockstore.Blockstore, ts types.TipSet) error {
+	carried := make(map[address.Address][]byte)
+	for _, blk := range ts.ToSlice() {
+		for _, msg := range blk.Messages {
+			if len(msg.PublicKey) > 0 && types.IsKeyOfAddress(msg.PublicKey, msg.From) {
+				carried[msg.From] = msg.PublicKey
+			}
+		}
+	}
+
+	publicKey := func(addr address.Address) ([]byte, error) {
+		if pubKey, ok := carried[addr]; ok {
+			return pubKey, nil
+		}
+		return recordedPublicKey(ctx, st, bs, addr)
+	}
+
+	for _, blk := range ts.ToSlice() {
+		if !blk.VerifyBLSAggregate(publicKey) {
+			return errors.Errorf("block %s has invalid BLS aggregate signature", blk.Cid())
+		}
+	}
+	return nil
+}
+
+// recordedPublicKey returns the public key recorded by the account actor at
+// addr in st.
+func recordedPublicKey(ctx context.Context, st state.Tree, bs blockstore.Blockstore, addr address.Address) ([]byte, error) {
+	act, err := st.GetActor(ctx, addr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not load account %s", addr)
+	}
+	if !act.Code.Equals(types.AccountActorCodeCid) {
+		return nil, errors.Errorf("%s is not an account", addr)
+	}
+
+	pubKey, err := account.LoadPublicKey(vm.NewStorageMap(bs).NewStorage(addr, act))
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not load state of account %s", addr)
+	}
+	if len(pubKey) == 0 {
+		return nil, errors.Errorf("account %s has recorded no public key", addr)
+	}
+	return pubKey, nil
+}
@@ -0,0 +1,135 @@
+package consensus
+
+import (
+	"context"
+	"sort"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+	"github.com/filecoin-project/go-filecoin/vm/errors"
+)
+
+// ActorChange records the state of an actor before and after a message was
+// applied. Before is nil if the actor did not exist.
+type ActorChange struct {
+	Address address.Address `json:"address"`
+	Before  *actor.Actor    `json:"before"`
+	After   *actor.Actor    `json:"after"`
+}
+
+// DryRunResult is the outcome of a message applied by DryRunMessage.
+type DryRunResult struct {
+	Receipt        *types.MessageReceipt
+	GasUsed        types.GasUnits
+	ExecutionError error
+	// Changes are the actors the message changed, ordered by address. They
+	// are empty if the message was reverted.
+	Changes []*ActorChange
+}
+
+// DryRunMessage applies msg to the state st as if it were included in a block
+// at height bh and reports what happened. The message is not validated: it
+// need not be signed, its nonce is not checked or incremented, and no gas is
+// paid for it. Neither st nor vms is changed.
+func DryRunMessage(ctx context.Context, st state.Tree, vms vm.StorageMap, msg *types.Message, gasLimit types.GasUnits, bh *types.BlockHeight, ancestors []types.TipSet) (*DryRunResult, error) {
+	// not committing or flushing storage structures guarantees changes won't make it to stored state tree or datastore
+	cachedSt := state.NewCachedStateTree(st)
+
+	fromActor, err := cachedSt.GetActor(ctx, msg.From)
+	if err != nil {
+		return nil, errors.ApplyErrorPermanentWrapf(err, "failed to get From actor %s", msg.From)
+	}
+
+	toActor, err := cachedSt.GetOrCreateActor(ctx, msg.To, func() (*actor.Actor, error) {
+		return &actor.Actor{}, nil
+	})
+	if err != nil {
+		return nil, errors.FaultErrorWrap(err, "failed to get To actor")
+	}
+
+	gasTracker := vm.NewGasTracker()
+	gasTracker.MsgGasLimit = gasLimit
+	gasTracker.Schedule = vm.GasScheduleAt(bh)
+
+	vmCtxParams := vm.NewContextParams{
+		From:        fromActor,
+		To:          toActor,
+		Message:     msg,
+		State:       cachedSt,
+		StorageMap:  vms,
+		GasTracker:  gasTracker,
+		BlockHeight: bh,
+		Ancestors:   ancestors,
+		LookBack:    LookBackParameter,
+		Events:      vm.NewEventLog(),
+	}
+	vmCtx := vm.NewVMContext(vmCtxParams)
+
+	ret, exitCode, vmErr := vm.Send(ctx, vmCtx)
+	if errors.IsFault(vmErr) {
+		return nil, vmErr
+	}
+
+	res := &DryRunResult{
+		Receipt: &types.MessageReceipt{
+			ExitCode:   exitCode,
+			GasAttoFIL: types.ZeroAttoFIL,
+		},
+		GasUsed:        vmCtx.GasUnits(),
+		ExecutionError: vmErr,
+	}
+	for _, b := range ret {
+		res.Receipt.Return = append(res.Receipt.Return, b)
+	}
+	if vmErr != nil {
+		return res, nil
+	}
+	res.Receipt.Events = vmCtxParams.Events.Events()
+
+	res.Changes, err = actorChanges(ctx, st, cachedSt)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// actorChanges compares the actors cached in cachedSt with their state in st.
+func actorChanges(ctx context.Context, st state.Tree, cachedSt *state.CachedTree) ([]*ActorChange, error) {
+	var changes []*ActorChange
+	err := cachedSt.ForEachCachedActor(func(addr address.Address, after *actor.Actor) error {
+		before, err := st.GetActor(ctx, addr)
+		if state.IsActorNotFoundError(err) {
+			before = nil
+		} else if err != nil {
+			return errors.FaultErrorWrapf(err, "failed to get actor %s", addr)
+		}
+
+		afterCid, err := after.Cid()
+		if err != nil {
+			return errors.FaultErrorWrap(err, "failed to get actor cid")
+		}
+		if before != nil {
+			beforeCid, err := before.Cid()
+			if err != nil {
+				return errors.FaultErrorWrap(err, "failed to get actor cid")
+			}
+			if beforeCid.Equals(afterCid) {
+				return nil
+			}
+		}
+
+		changes = append(changes, &ActorChange{Address: addr, Before: before, After: after})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Address.String() < changes[j].Address.String()
+	})
+	return changes, nil
+}
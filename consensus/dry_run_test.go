@@ -0,0 +1,88 @@
+package consensus_test
+
+import (
+	"context"
+	"testing"
+
+	"gx/ipfs/QmRXf2uUSdGSunRJsM9wXSUNVwLUGCY3So5fAs7h2CBJVf/go-hamt-ipld"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin"
+	"github.com/filecoin-project/go-filecoin/address"
+	. "github.com/filecoin-project/go-filecoin/consensus"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRunMessage(t *testing.T) {
+	ctx := context.Background()
+
+	// Install the fake actor so we can execute it.
+	fakeActorCodeCid := types.NewCidForTestGetter()()
+	builtin.Actors[fakeActorCodeCid] = &actor.FakeActor{}
+	defer delete(builtin.Actors, fakeActorCodeCid)
+
+	t.Run("reports changed actors without changing the state", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		newAddress := address.NewForTestGetter()
+		addr0, addr1 := newAddress(), newAddress()
+		preCid, st := requireMakeStateTree(require, hamt.NewCborStore(), map[address.Address]*actor.Actor{
+			addr0: th.RequireNewAccountActor(require, types.NewAttoFILFromFIL(100)),
+		})
+
+		msg := types.NewMessage(addr0, addr1, 0, types.NewAttoFILFromFIL(30), "", nil)
+		res, err := DryRunMessage(ctx, st, th.VMStorage(), msg, types.NewGasUnits(1000), types.NewBlockHeight(1), nil)
+		require.NoError(err)
+		require.NoError(res.ExecutionError)
+		assert.Equal(uint8(0), res.Receipt.ExitCode)
+
+		require.Len(res.Changes, 2)
+		changes := map[address.Address]*ActorChange{}
+		for _, change := range res.Changes {
+			changes[change.Address] = change
+		}
+		assert.Equal(types.NewAttoFILFromFIL(100), changes[addr0].Before.Balance)
+		assert.Equal(types.NewAttoFILFromFIL(70), changes[addr0].After.Balance)
+		assert.Nil(changes[addr1].Before)
+		assert.Equal(types.NewAttoFILFromFIL(30), changes[addr1].After.Balance)
+
+		postCid, err := st.Flush(ctx)
+		require.NoError(err)
+		assert.True(preCid.Equals(postCid))
+	})
+
+	t.Run("reports gas used and no changes when the message reverts", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		newAddress := address.NewForTestGetter()
+		addr0, addr1 := newAddress(), newAddress()
+		vms := th.VMStorage()
+		_, st := requireMakeStateTree(require, hamt.NewCborStore(), map[address.Address]*actor.Actor{
+			addr0: th.RequireNewAccountActor(require, types.NewAttoFILFromFIL(100)),
+			addr1: th.RequireNewFakeActor(require, vms, addr1, fakeActorCodeCid),
+		})
+
+		msg := types.NewMessage(addr0, addr1, 0, types.ZeroAttoFIL, "chargeGasAndRevertError", nil)
+		res, err := DryRunMessage(ctx, st, vms, msg, types.NewGasUnits(1000), types.NewBlockHeight(1), nil)
+		require.NoError(err)
+		assert.EqualError(res.ExecutionError, "boom")
+		assert.Equal(types.NewGasUnits(100), res.GasUsed)
+		assert.Empty(res.Changes)
+	})
+
+	t.Run("fails if the sender does not exist", func(t *testing.T) {
+		require := require.New(t)
+
+		newAddress := address.NewForTestGetter()
+		_, st := requireMakeStateTree(require, hamt.NewCborStore(), map[address.Address]*actor.Actor{})
+
+		msg := types.NewMessage(newAddress(), newAddress(), 0, types.ZeroAttoFIL, "", nil)
+		_, err := DryRunMessage(ctx, st, th.VMStorage(), msg, types.NewGasUnits(1000), types.NewBlockHeight(1), nil)
+		require.Error(err)
+	})
+}
@@ -91,13 +91,16 @@ type Expected struct {
 	genesisCid cid.Cid
 
 	verifier proofs.Verifier
+
+	// sigValidator checks that blocks are signed by their miner.
+	sigValidator BlockSignatureValidator
 }
 
 // Ensure Expected satisfies the Protocol interface at compile time.
 var _ Protocol = (*Expected)(nil)
 
 // NewExpected is the constructor for the Expected consenus.Protocol module.
-func NewExpected(cs *hamt.CborIpldStore, bs blockstore.Blockstore, processor Processor, pt PowerTableView, gCid cid.Cid, verifier proofs.Verifier) Protocol {
+func NewExpected(cs *hamt.CborIpldStore, bs blockstore.Blockstore, processor Processor, pt PowerTableView, gCid cid.Cid, verifier proofs.Verifier, sv BlockSignatureValidator) Protocol {
 	return &Expected{
 		cstore:       cs,
 		bstore:       bs,
@@ -105,6 +108,7 @@ func NewExpected(cs *hamt.CborIpldStore, bs blockstore.Blockstore, processor Pro
 		PwrTableView: pt,
 		genesisCid:   gCid,
 		verifier:     verifier,
+		sigValidator: sv,
 	}
 }
 
@@ -121,61 +125,61 @@ func (c *Expected) NewValidTipSet(ctx context.Context, blks []*types.Block) (typ
 	return types.NewTipSet(blks...)
 }
 
-// ValidateBlockStructure verifies that this block, on its own, is structurally and
-// cryptographically valid. This means checking that all of its fields are
-// properly filled out and its signatures are correct. Checking the validity of
-// state changes must be done separately and only once the state of the
-// previous block has been validated. TODO: not yet signature checking
+// validateBlockStructure verifies that this block, on its own, is
+// structurally valid. Its signatures are checked in RunStateTransition, where
+// the parent state is available: the block signature against the key of the
+// miner's owner by validateMining, and the BLS aggregate against the keys of
+// the senders of its messages by validateBLSAggregates. Checking the validity
+// of state changes must likewise be done once the state of the previous block
+// has been validated.
 func (c *Expected) validateBlockStructure(ctx context.Context, b *types.Block) error {
-	// TODO: validate signature on block
 	ctx = log.Start(ctx, "Expected.validateBlockStructure")
 	log.LogKV(ctx, "ValidateBlockStructure", b.Cid().String())
 	if !b.StateRoot.Defined() {
 		return fmt.Errorf("block has nil StateRoot")
 	}
+	if len(b.Authorities) > 0 {
+		return fmt.Errorf("only the genesis block may set authorities")
+	}
 
 	return nil
 }
 
-// Weight returns the EC weight of this TipSet in uint64 encoded fixed point
-// representation.
-func (c *Expected) Weight(ctx context.Context, ts types.TipSet, pSt state.Tree) (uint64, error) {
+// Weight returns the EC weight of this TipSet.
+func (c *Expected) Weight(ctx context.Context, ts types.TipSet, pSt state.Tree) (*types.ChainWeight, error) {
 	ctx = log.Start(ctx, "Expected.Weight")
 	log.LogKV(ctx, "Weight", ts.String())
 	if len(ts) == 1 && ts.ToSlice()[0].Cid().Equals(c.genesisCid) {
-		return uint64(0), nil
+		return types.ZeroWeight, nil
 	}
 	// Compute parent weight.
 	parentW, err := ts.ParentWeight()
 	if err != nil {
-		return uint64(0), err
+		return nil, err
 	}
 
-	w, err := types.FixedToBig(parentW)
-	if err != nil {
-		return uint64(0), err
-	}
 	// Each block in the tipset adds ECV + ECPrm * miner_power to parent weight.
 	totalBytes, err := c.PwrTableView.Total(ctx, pSt, c.bstore)
 	if err != nil {
-		return uint64(0), err
+		return nil, err
 	}
-	floatTotalBytes := new(big.Float).SetInt64(int64(totalBytes))
+	floatTotalBytes := new(big.Float).SetInt(totalBytes.AsBigInt())
 	floatECV := new(big.Float).SetInt64(int64(ECV))
 	floatECPrM := new(big.Float).SetInt64(int64(ECPrM))
+	w := new(big.Float)
 	for _, blk := range ts.ToSlice() {
 		minerBytes, err := c.PwrTableView.Miner(ctx, pSt, c.bstore, blk.Miner)
 		if err != nil {
-			return uint64(0), err
+			return nil, err
 		}
-		floatOwnBytes := new(big.Float).SetInt64(int64(minerBytes))
+		floatOwnBytes := new(big.Float).SetInt(minerBytes.AsBigInt())
 		wBlk := new(big.Float)
 		wBlk.Quo(floatOwnBytes, floatTotalBytes)
 		wBlk.Mul(wBlk, floatECPrM) // Power addition
 		wBlk.Add(wBlk, floatECV)   // Constant addition
 		w.Add(w, wBlk)
 	}
-	return types.BigToFixed(w)
+	return parentW.Add(types.NewChainWeightFromFloat(w)), nil
 }
 
 // IsHeavier returns true if tipset a is heavier than tipset b, and false
@@ -196,8 +200,8 @@ func (c *Expected) IsHeavier(ctx context.Context, a, b types.TipSet, aSt, bSt st
 	}
 
 	// Without ties pass along the comparison.
-	if aW != bW {
-		return aW > bW, nil
+	if !aW.Equal(bW) {
+		return aW.GreaterThan(bW), nil
 	}
 
 	// To break ties compare the min tickets.
@@ -236,6 +240,9 @@ func (c *Expected) RunStateTransition(ctx context.Context, ts types.TipSet, ance
 	if err != nil {
 		return nil, err
 	}
+	if err := validateBLSAggregates(ctx, pSt, c.bstore, ts); err != nil {
+		return nil, err
+	}
 
 	sl := ts.ToSlice()
 	one := sl[0]
@@ -251,7 +258,7 @@ func (c *Expected) RunStateTransition(ctx context.Context, ts types.TipSet, ance
 	}
 
 	vms := vm.NewStorageMap(c.bstore)
-	st, err := c.runMessages(ctx, pSt, vms, ts, ancestors)
+	st, err := runMessages(ctx, c.cstore, c.processor, pSt, vms, ts, ancestors)
 	if err != nil {
 		return nil, err
 	}
@@ -267,6 +274,7 @@ func (c *Expected) RunStateTransition(ctx context.Context, ts types.TipSet, ance
 //    	* any tipset's block was mined by an invalid miner address.
 //      * the block proof is invalid for the challenge
 //      * the block ticket is incorrectly computed
+//      * the block is not signed by the owner of its miner
 //      * the block ticket fails the power check, i.e. is not a winning ticket
 //    Returns nil if all the above checks pass.
 // See https://github.com/filecoin-project/specs/blob/master/mining.md#chain-validation
@@ -297,7 +305,9 @@ func (c *Expected) validateMining(ctx context.Context, st state.Tree, ts types.T
 			return errors.New("ticket incorrectly computed")
 		}
 
-		// TODO: Also need to validate BlockSig
+		if err := c.sigValidator.ValidateBlockSignature(ctx, st, c.bstore, blk); err != nil {
+			return errors.Wrap(err, "invalid block signature")
+		}
 
 		// See https://github.com/filecoin-project/specs/blob/master/mining.md#ticket-checking
 		result, err := IsWinningTicket(ctx, c.bstore, c.PwrTableView, st, blk.Ticket, blk.Miner)
@@ -333,12 +343,12 @@ func IsWinningTicket(ctx context.Context, bs blockstore.Blockstore, ptv PowerTab
 
 // CompareTicketPower abstracts the actual comparison logic so it can be used by some test
 // helpers
-func CompareTicketPower(ticket types.Signature, minerPower uint64, totalPower uint64) bool {
+func CompareTicketPower(ticket types.Signature, minerPower *types.BytesAmount, totalPower *types.BytesAmount) bool {
 	lhs := &big.Int{}
 	lhs.SetBytes(ticket)
-	lhs.Mul(lhs, big.NewInt(int64(totalPower)))
+	lhs.Mul(lhs, totalPower.AsBigInt())
 	rhs := &big.Int{}
-	rhs.Mul(big.NewInt(int64(minerPower)), ticketDomain)
+	rhs.Mul(minerPower.AsBigInt(), ticketDomain)
 	return lhs.Cmp(rhs) < 0
 }
 
@@ -381,7 +391,10 @@ func CreateTicket(proof proofs.PoStProof, minerAddr address.Address) []byte {
 // An error is returned if individual blocks contain messages that do not
 // lead to successful state transitions.  An error is also returned if the node
 // faults while running aggregate state computation.
-func (c *Expected) runMessages(ctx context.Context, st state.Tree, vms vm.StorageMap, ts types.TipSet, ancestors []types.TipSet) (state.Tree, error) {
+//
+// runMessages is shared by all consensus protocols: they differ in how blocks
+// are produced, not in how their messages are applied.
+func runMessages(ctx context.Context, cstore *hamt.CborIpldStore, processor Processor, st state.Tree, vms vm.StorageMap, ts types.TipSet, ancestors []types.TipSet) (state.Tree, error) {
 	var cpySt state.Tree
 
 	// TODO: order blocks in the tipset by ticket
@@ -392,18 +405,17 @@ func (c *Expected) runMessages(ctx context.Context, st state.Tree, vms vm.Storag
 			return nil, errors.Wrap(err, "error validating block state")
 		}
 		// state copied so changes don't propagate between block validations
-		cpySt, err = state.LoadStateTree(ctx, c.cstore, cpyCid, builtin.Actors)
+		cpySt, err = state.LoadStateTree(ctx, cstore, cpyCid, builtin.Actors)
 		if err != nil {
 			return nil, errors.Wrap(err, "error validating block state")
 		}
 
-		receipts, err := c.processor.ProcessBlock(ctx, cpySt, vms, blk, ancestors)
+		results, err := processor.ProcessBlock(ctx, cpySt, vms, blk, ancestors)
 		if err != nil {
 			return nil, errors.Wrap(err, "error validating block state")
 		}
-		// TODO: check that receipts actually match
-		if len(receipts) != len(blk.MessageReceipts) {
-			return nil, fmt.Errorf("found invalid message receipts: %v %v", receipts, blk.MessageReceipts)
+		if err := verifyReceipts(ctx, cstore, blk, results); err != nil {
+			return nil, err
 		}
 
 		outCid, err := cpySt.Flush(ctx)
@@ -421,9 +433,42 @@ func (c *Expected) runMessages(ctx context.Context, st state.Tree, vms vm.Storag
 	// NOTE: It is possible to optimize further by applying block validation
 	// in sorted order to reuse first block transitions as the starting state
 	// for the tipSetProcessor.
-	_, err := c.processor.ProcessTipSet(ctx, st, vms, ts, ancestors)
+	_, err := processor.ProcessTipSet(ctx, st, vms, ts, ancestors)
 	if err != nil {
 		return nil, errors.Wrap(err, "error validating tipset")
 	}
 	return st, nil
 }
+
+// verifyReceipts checks that the receipts produced by applying blk's messages
+// match the receipts root blk commits to. A *ReceiptMismatchError is returned
+// if they do not.
+func verifyReceipts(ctx context.Context, cstore *hamt.CborIpldStore, blk *types.Block, results []*ApplicationResult) error {
+	computed := make([]*types.MessageReceipt, len(results))
+	for i, r := range results {
+		computed[i] = r.Receipt
+	}
+
+	computedRoot, err := ReceiptsRoot(ctx, cstore, computed)
+	if err != nil {
+		return errors.Wrap(err, "error validating block receipts")
+	}
+	if computedRoot.Equals(blk.MessageReceipts) {
+		return nil
+	}
+
+	mismatch := &ReceiptMismatchError{
+		Block:    blk.Cid(),
+		Claimed:  blk.MessageReceipts,
+		Computed: computedRoot,
+		Index:    -1,
+	}
+	// The claimed receipts are only available if they were fetched along
+	// with the block; when they are, report exactly what differs.
+	if claimed, err := LoadReceipts(ctx, cstore, blk.MessageReceipts); err == nil {
+		if idx, reason := compareReceipts(claimed, computed); idx >= 0 {
+			mismatch.Index, mismatch.Reason = idx, reason
+		}
+	}
+	return mismatch
+}
@@ -29,7 +29,7 @@ func TestNewExpected(t *testing.T) {
 	t.Run("a new Expected can be created", func(t *testing.T) {
 		cst, bstore, verifier := setupCborBlockstoreProofs()
 		ptv := testhelpers.NewTestPowerTableView(1, 5)
-		exp := consensus.NewExpected(cst, bstore, consensus.NewDefaultProcessor(), ptv, types.SomeCid(), verifier)
+		exp := consensus.NewExpected(cst, bstore, consensus.NewDefaultProcessor(), ptv, types.SomeCid(), verifier, &testhelpers.TestBlockSignatureValidator{})
 		assert.NotNil(exp)
 	})
 }
@@ -48,7 +48,7 @@ func TestExpected_NewValidTipSet(t *testing.T) {
 		genesisBlock, err := consensus.InitGenesis(cistore, bstore)
 		require.NoError(err)
 
-		exp := consensus.NewExpected(cistore, bstore, consensus.NewDefaultProcessor(), ptv, genesisBlock.Cid(), verifier)
+		exp := consensus.NewExpected(cistore, bstore, consensus.NewDefaultProcessor(), ptv, genesisBlock.Cid(), verifier, &testhelpers.TestBlockSignatureValidator{})
 
 		pTipSet, err := exp.NewValidTipSet(ctx, []*types.Block{genesisBlock})
 		require.NoError(err)
@@ -76,9 +76,11 @@ func TestExpected_NewValidTipSet(t *testing.T) {
 			ExitCode: 123,
 			Return:   []types.Bytes{retVal},
 		}
-		blocks[0].MessageReceipts = []*types.MessageReceipt{receipt}
+		receiptsRoot, err := consensus.ReceiptsRoot(ctx, cistore, []*types.MessageReceipt{receipt})
+		require.NoError(err)
+		blocks[0].MessageReceipts = receiptsRoot
 
-		exp := consensus.NewExpected(cistore, bstore, consensus.NewDefaultProcessor(), ptv, types.SomeCid(), verifier)
+		exp := consensus.NewExpected(cistore, bstore, consensus.NewDefaultProcessor(), ptv, types.SomeCid(), verifier, &testhelpers.TestBlockSignatureValidator{})
 
 		tipSet, err := exp.NewValidTipSet(ctx, blocks)
 		assert.Error(err, "Foo")
@@ -114,7 +116,7 @@ func TestExpected_RunStateTransition_validateMining(t *testing.T) {
 		totalPower := uint64(1)
 
 		ptv := testhelpers.NewTestPowerTableView(minerPower, totalPower)
-		exp := consensus.NewExpected(cistore, bstore, testhelpers.NewTestProcessor(), ptv, genesisBlock.Cid(), verifier)
+		exp := consensus.NewExpected(cistore, bstore, testhelpers.NewTestProcessor(), ptv, genesisBlock.Cid(), verifier, &testhelpers.TestBlockSignatureValidator{})
 
 		pTipSet, err := exp.NewValidTipSet(ctx, []*types.Block{genesisBlock})
 		require.NoError(err)
@@ -134,7 +136,7 @@ func TestExpected_RunStateTransition_validateMining(t *testing.T) {
 	t.Run("returns nil + mining error when IsWinningTicket fails due to miner power error", func(t *testing.T) {
 
 		ptv := NewFailingMinerTestPowerTableView(1, 5)
-		exp := consensus.NewExpected(cistore, bstore, consensus.NewDefaultProcessor(), ptv, types.SomeCid(), verifier)
+		exp := consensus.NewExpected(cistore, bstore, consensus.NewDefaultProcessor(), ptv, types.SomeCid(), verifier, &testhelpers.TestBlockSignatureValidator{})
 
 		pTipSet, err := exp.NewValidTipSet(ctx, []*types.Block{genesisBlock})
 		require.NoError(err)
@@ -150,6 +152,49 @@ func TestExpected_RunStateTransition_validateMining(t *testing.T) {
 		_, err = exp.RunStateTransition(ctx, tipSet, []types.TipSet{pTipSet}, stateTree)
 		assert.EqualError(err, "can't check for winning ticket: Couldn't get minerPower: something went wrong with the miner power")
 	})
+	t.Run("returns nil + mining error when a block is not signed by its miner", func(t *testing.T) {
+
+		ptv := testhelpers.NewTestPowerTableView(1, 1)
+		exp := consensus.NewExpected(cistore, bstore, testhelpers.NewTestProcessor(), ptv, genesisBlock.Cid(), verifier, &consensus.MinerKeySignatureValidator{})
+
+		pTipSet, err := exp.NewValidTipSet(ctx, []*types.Block{genesisBlock})
+		require.NoError(err)
+
+		blocks := makeSomeBlocks(pTipSet)
+
+		tipSet, err := exp.NewValidTipSet(ctx, blocks)
+		require.NoError(err)
+
+		stateTree, err := state.LoadStateTree(ctx, cistore, genesisBlock.StateRoot, builtin.Actors)
+		require.NoError(err)
+
+		_, err = exp.RunStateTransition(ctx, tipSet, []types.TipSet{pTipSet}, stateTree)
+		assert.Error(err)
+		assert.Contains(err.Error(), "invalid block signature")
+	})
+
+	t.Run("returns nil + error when the BLS aggregate signature is invalid", func(t *testing.T) {
+
+		ptv := testhelpers.NewTestPowerTableView(1, 1)
+		exp := consensus.NewExpected(cistore, bstore, testhelpers.NewTestProcessor(), ptv, genesisBlock.Cid(), verifier, &testhelpers.TestBlockSignatureValidator{})
+
+		pTipSet, err := exp.NewValidTipSet(ctx, []*types.Block{genesisBlock})
+		require.NoError(err)
+
+		// the block has no aggregated messages, so it must have no aggregate
+		blocks := makeSomeBlocks(pTipSet)
+		blocks[0].BLSAggregate = []byte{1, 2, 3}
+
+		tipSet, err := exp.NewValidTipSet(ctx, blocks)
+		require.NoError(err)
+
+		stateTree, err := state.LoadStateTree(ctx, cistore, genesisBlock.StateRoot, builtin.Actors)
+		require.NoError(err)
+
+		_, err = exp.RunStateTransition(ctx, tipSet, []types.TipSet{pTipSet}, stateTree)
+		assert.Error(err)
+		assert.Contains(err.Error(), "invalid BLS aggregate signature")
+	})
 }
 
 func TestIsWinningTicket(t *testing.T) {
@@ -279,16 +324,16 @@ func NewFailingTestPowerTableView(minerPower int64, totalPower int64) *FailingTe
 	return &FailingTestPowerTableView{uint64(minerPower), uint64(totalPower)}
 }
 
-func (tv *FailingTestPowerTableView) Total(ctx context.Context, st state.Tree, bstore blockstore.Blockstore) (uint64, error) {
-	return tv.totalPower, errors.New("something went wrong with the total power")
+func (tv *FailingTestPowerTableView) Total(ctx context.Context, st state.Tree, bstore blockstore.Blockstore) (*types.BytesAmount, error) {
+	return types.NewBytesAmount(tv.totalPower), errors.New("something went wrong with the total power")
 }
 
-func (tv *FailingTestPowerTableView) Miner(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (uint64, error) {
-	return uint64(tv.minerPower), nil
+func (tv *FailingTestPowerTableView) Miner(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (*types.BytesAmount, error) {
+	return types.NewBytesAmount(tv.minerPower), nil
 }
 
-func (tv *FailingTestPowerTableView) HasPower(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) bool {
-	return true
+func (tv *FailingTestPowerTableView) HasPower(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (bool, error) {
+	return true, nil
 }
 
 type FailingMinerTestPowerTableView struct{ minerPower, totalPower uint64 }
@@ -297,14 +342,14 @@ func NewFailingMinerTestPowerTableView(minerPower int64, totalPower int64) *Fail
 	return &FailingMinerTestPowerTableView{uint64(minerPower), uint64(totalPower)}
 }
 
-func (tv *FailingMinerTestPowerTableView) Total(ctx context.Context, st state.Tree, bstore blockstore.Blockstore) (uint64, error) {
-	return tv.totalPower, nil
+func (tv *FailingMinerTestPowerTableView) Total(ctx context.Context, st state.Tree, bstore blockstore.Blockstore) (*types.BytesAmount, error) {
+	return types.NewBytesAmount(tv.totalPower), nil
 }
 
-func (tv *FailingMinerTestPowerTableView) Miner(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (uint64, error) {
-	return tv.minerPower, errors.New("something went wrong with the miner power")
+func (tv *FailingMinerTestPowerTableView) Miner(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (*types.BytesAmount, error) {
+	return types.NewBytesAmount(tv.minerPower), errors.New("something went wrong with the miner power")
 }
 
-func (tv *FailingMinerTestPowerTableView) HasPower(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) bool {
-	return true
+func (tv *FailingMinerTestPowerTableView) HasPower(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (bool, error) {
+	return true, nil
 }
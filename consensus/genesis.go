@@ -1,17 +1,26 @@
 package consensus
 
 import (
+	"bytes"
 	"context"
+	"math/big"
+	"os"
+	"sort"
 
 	"gx/ipfs/QmRXf2uUSdGSunRJsM9wXSUNVwLUGCY3So5fAs7h2CBJVf/go-hamt-ipld"
 	"gx/ipfs/QmS2aqUZLJp8kF1ihE5rvDGE5LvmKDPnx32w9Z1BW9xLV5/go-ipfs-blockstore"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
 
 	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/account"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/governance"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/initactor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/proofs"
 	"github.com/filecoin-project/go-filecoin/state"
 	"github.com/filecoin-project/go-filecoin/types"
 	"github.com/filecoin-project/go-filecoin/vm"
@@ -37,6 +46,15 @@ type Config struct {
 	accounts map[address.Address]*types.AttoFIL
 	nonces   map[address.Address]uint64
 	actors   map[address.Address]*actor.Actor
+	// authorities, when set, selects round-robin proof-of-authority
+	// consensus with miners owned by these addresses as block producers.
+	authorities []address.Address
+	// governor is the address allowed to schedule upgrades of actor code.
+	governor address.Address
+	// network is the network of the ID addresses the init actor assigns.
+	network address.Network
+	// sectorSize is the size in bytes of the sectors miners commit.
+	sectorSize *types.BytesAmount
 }
 
 // GenOption is a configuration option for the GenesisInitFunction.
@@ -68,12 +86,70 @@ func AddActor(addr address.Address, actor *actor.Actor) GenOption {
 	}
 }
 
+// Authorities returns a config option that selects round-robin
+// proof-of-authority consensus. A miner owned by each of the given addresses
+// is created at AuthorityMinerAddress, and the miners take turns to produce
+// blocks in the order given.
+func Authorities(addrs ...address.Address) GenOption {
+	return func(gc *Config) error {
+		if len(addrs) == 0 {
+			return errors.New("at least one authority is required")
+		}
+		gc.authorities = addrs
+		return nil
+	}
+}
+
+// Governor returns a config option that allows addr to schedule upgrades of
+// the code of existing actors through the governance actor.
+func Governor(addr address.Address) GenOption {
+	return func(gc *Config) error {
+		gc.governor = addr
+		return nil
+	}
+}
+
+// Network returns a config option that sets the network of the ID addresses
+// assigned to actors. It defaults to the main network.
+func Network(network address.Network) GenOption {
+	return func(gc *Config) error {
+		gc.network = network
+		return nil
+	}
+}
+
+// SectorSize returns a config option that sets the size in bytes of the
+// sectors miners commit. It defaults to DefaultSectorSize.
+func SectorSize(size *types.BytesAmount) GenOption {
+	return func(gc *Config) error {
+		if _, err := proofs.SectorStoreTypeOfSize(size.Uint64()); err != nil {
+			return err
+		}
+		gc.sectorSize = size
+		return nil
+	}
+}
+
+// DefaultSectorSize returns the size in bytes of the sectors of networks
+// created without the SectorSize option. Setting FIL_USE_SMALL_SECTORS to
+// true selects small sectors, which are quick to seal in tests. It is only
+// read when a genesis block is created, and the size is then recorded in the
+// state of the storage market.
+func DefaultSectorSize() *types.BytesAmount {
+	if os.Getenv("FIL_USE_SMALL_SECTORS") == "true" {
+		return types.NewBytesAmount(proofs.SectorSize(proofs.Test))
+	}
+	return types.NewBytesAmount(proofs.SectorSize(proofs.Live))
+}
+
 // NewEmptyConfig inits and returns an empty config
 func NewEmptyConfig() *Config {
 	return &Config{
-		accounts: make(map[address.Address]*types.AttoFIL),
-		nonces:   make(map[address.Address]uint64),
-		actors:   make(map[address.Address]*actor.Actor),
+		accounts:   make(map[address.Address]*types.AttoFIL),
+		nonces:     make(map[address.Address]uint64),
+		actors:     make(map[address.Address]*actor.Actor),
+		network:    address.Mainnet,
+		sectorSize: DefaultSectorSize(),
 	}
 }
 
@@ -112,7 +188,7 @@ func MakeGenesisFunc(opts ...GenOption) func(cst *hamt.CborIpldStore, bs blockst
 				return nil, err
 			}
 		}
-		if err := SetupDefaultActors(ctx, st, storageMap); err != nil {
+		if err := SetupDefaultActors(ctx, st, storageMap, genCfg.network, genCfg.governor, genCfg.sectorSize); err != nil {
 			return nil, err
 		}
 		// Now add any other actors configured.
@@ -121,6 +197,17 @@ func MakeGenesisFunc(opts ...GenOption) func(cst *hamt.CborIpldStore, bs blockst
 				return nil, err
 			}
 		}
+		var authorityMiners []address.Address
+		for _, owner := range genCfg.authorities {
+			minerAddr, err := setupAuthorityMiner(ctx, st, storageMap, owner, genCfg.sectorSize)
+			if err != nil {
+				return nil, err
+			}
+			authorityMiners = append(authorityMiners, minerAddr)
+		}
+		if err := AssignActorIDs(ctx, st, storageMap); err != nil {
+			return nil, err
+		}
 
 		c, err := st.Flush(ctx)
 		if err != nil {
@@ -128,8 +215,9 @@ func MakeGenesisFunc(opts ...GenOption) func(cst *hamt.CborIpldStore, bs blockst
 		}
 
 		genesis := &types.Block{
-			StateRoot: c,
-			Nonce:     1337,
+			StateRoot:   c,
+			Nonce:       1337,
+			Authorities: authorityMiners,
 		}
 
 		if _, err := cst.Put(ctx, genesis); err != nil {
@@ -150,8 +238,33 @@ func InitGenesis(cst *hamt.CborIpldStore, bs blockstore.Blockstore) (*types.Bloc
 	return MakeGenesisFunc()(cst, bs)
 }
 
+// AuthorityMinerAddress returns the address of the miner created at genesis
+// for the authority with the given owner.
+func AuthorityMinerAddress(owner address.Address) address.Address {
+	return address.New(owner.Network(), address.Hash(append([]byte("authority"), owner.Bytes()...)))
+}
+
+// setupAuthorityMiner creates the miner that produces the blocks of the given
+// authority. It has no power or collateral and is only used to record the
+// owner whose key signs its blocks.
+func setupAuthorityMiner(ctx context.Context, st state.Tree, storageMap vm.StorageMap, owner address.Address, sectorSize *types.BytesAmount) (address.Address, error) {
+	minerAddr := AuthorityMinerAddress(owner)
+	minerAct := miner.NewActor()
+	err := (&miner.Actor{}).InitializeState(storageMap.NewStorage(minerAddr, minerAct), miner.NewState(owner, []byte{}, big.NewInt(0), "", types.NewZeroAttoFIL(), sectorSize))
+	if err != nil {
+		return address.Address{}, err
+	}
+	if err := st.SetActor(ctx, minerAddr, minerAct); err != nil {
+		return address.Address{}, err
+	}
+	return minerAddr, nil
+}
+
 // SetupDefaultActors inits the builtin actors that are required to run filecoin.
-func SetupDefaultActors(ctx context.Context, st state.Tree, storageMap vm.StorageMap) error {
+// The init actor assigns ID addresses of the given network, and miners commit
+// sectors of the given size in bytes. Upgrades of actor code are disabled
+// unless a governor is given.
+func SetupDefaultActors(ctx context.Context, st state.Tree, storageMap vm.StorageMap, network address.Network, governor address.Address, sectorSize *types.BytesAmount) error {
 	for addr, val := range defaultAccounts {
 		a, err := account.NewActor(val)
 		if err != nil {
@@ -167,7 +280,7 @@ func SetupDefaultActors(ctx context.Context, st state.Tree, storageMap vm.Storag
 	if err != nil {
 		return err
 	}
-	err = (&storagemarket.Actor{}).InitializeState(storageMap.NewStorage(address.StorageMarketAddress, stAct), nil)
+	err = (&storagemarket.Actor{}).InitializeState(storageMap.NewStorage(address.StorageMarketAddress, stAct), storagemarket.NewState(sectorSize))
 	if err != nil {
 		return err
 	}
@@ -183,5 +296,65 @@ func SetupDefaultActors(ctx context.Context, st state.Tree, storageMap vm.Storag
 
 	pbAct.Balance = types.NewAttoFILFromFIL(0)
 
-	return st.SetActor(ctx, address.PaymentBrokerAddress, pbAct)
+	if err := st.SetActor(ctx, address.PaymentBrokerAddress, pbAct); err != nil {
+		return err
+	}
+
+	initAct := initactor.NewActor()
+	err = (&initactor.Actor{}).InitializeState(storageMap.NewStorage(address.InitAddress, initAct), initactor.NewState(network))
+	if err != nil {
+		return err
+	}
+	if err := st.SetActor(ctx, address.InitAddress, initAct); err != nil {
+		return err
+	}
+
+	govAct := governance.NewActor()
+	err = governance.NewCode().InitializeState(storageMap.NewStorage(address.GovernanceAddress, govAct), governance.NewState(governor))
+	if err != nil {
+		return err
+	}
+	if err := st.SetActor(ctx, address.GovernanceAddress, govAct); err != nil {
+		return err
+	}
+
+	// the singletons get the first ids, in a fixed order
+	cachedSt := state.NewCachedStateTree(st)
+	for _, addr := range []address.Address{address.InitAddress, address.StorageMarketAddress, address.PaymentBrokerAddress, address.GovernanceAddress} {
+		if err := vm.RegisterActorID(ctx, cachedSt, storageMap, addr); err != nil {
+			return err
+		}
+	}
+	return cachedSt.Commit(ctx)
+}
+
+// AssignActorIDs assigns ID addresses to the actors in the state tree that do
+// not have one yet, in the order of their addresses. Genesis functions call it
+// after setting up all actors, so that ids do not depend on the order in which
+// they were set up.
+func AssignActorIDs(ctx context.Context, st state.Tree, storageMap vm.StorageMap) error {
+	// actors are only visited once they are flushed
+	if _, err := st.Flush(ctx); err != nil {
+		return err
+	}
+
+	var addrs []address.Address
+	err := st.ForEachActor(ctx, func(addr address.Address, _ *actor.Actor) error {
+		addrs = append(addrs, addr)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i].Bytes(), addrs[j].Bytes()) < 0
+	})
+
+	cachedSt := state.NewCachedStateTree(st)
+	for _, addr := range addrs {
+		if err := vm.RegisterActorID(ctx, cachedSt, storageMap, addr); err != nil {
+			return err
+		}
+	}
+	return cachedSt.Commit(ctx)
 }
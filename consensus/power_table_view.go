@@ -2,13 +2,19 @@ package consensus
 
 import (
 	"context"
-	"math/big"
+	"sync"
 
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	"gx/ipfs/QmRXf2uUSdGSunRJsM9wXSUNVwLUGCY3So5fAs7h2CBJVf/go-hamt-ipld"
 	"gx/ipfs/QmS2aqUZLJp8kF1ihE5rvDGE5LvmKDPnx32w9Z1BW9xLV5/go-ipfs-blockstore"
 	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
 
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/exec"
 	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/types"
 	"github.com/filecoin-project/go-filecoin/vm"
 )
 
@@ -17,76 +23,218 @@ import (
 type PowerTableView interface {
 	// Total returns the total bytes stored by all miners in the given
 	// state.
-	Total(ctx context.Context, st state.Tree, bstore blockstore.Blockstore) (uint64, error)
+	Total(ctx context.Context, st state.Tree, bstore blockstore.Blockstore) (*types.BytesAmount, error)
 
 	// Miner returns the total bytes stored by the miner of the
 	// input address in the given state.
-	Miner(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (uint64, error)
+	Miner(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (*types.BytesAmount, error)
 
 	// HasPower returns true if the input address is associated with a
 	// miner that has storage power in the network.
-	HasPower(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) bool
+	HasPower(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (bool, error)
+}
+
+// PowerTable is a snapshot of the storage power of every miner in a state.
+type PowerTable struct {
+	// Total is the total power of the network in bytes.
+	Total *types.BytesAmount
+	// Miners maps every miner's address to its power in bytes.
+	Miners map[address.Address]*types.BytesAmount
+}
+
+// LoadPowerTable reads the power table kept by the storage market in st. It
+// reads the power of every miner, so consensus, which only needs the power of
+// single miners, reads them from the market as needed instead.
+func LoadPowerTable(ctx context.Context, st state.Tree, bstore blockstore.Blockstore) (*PowerTable, error) {
+	marketState, storage, err := loadMarketState(ctx, st, bstore)
+	if err != nil {
+		return nil, err
+	}
+
+	miners, err := actor.LoadTypedLookup(ctx, storage, marketState.Miners, types.BytesAmount{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load storage market miners")
+	}
+	kvs, err := miners.Values(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read storage market miners")
+	}
+
+	table := &PowerTable{
+		Total:  marketState.TotalCommittedStorage,
+		Miners: make(map[address.Address]*types.BytesAmount, len(kvs)),
+	}
+	for _, kv := range kvs {
+		addr, err := address.NewFromString(kv.Key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid miner address %s", kv.Key)
+		}
+		power := kv.Value.(types.BytesAmount)
+		table.Miners[addr] = &power
+	}
+
+	return table, nil
+}
+
+// loadMarketState reads the state of the storage market in st, and returns it
+// with the market's storage.
+func loadMarketState(ctx context.Context, st state.Tree, bstore blockstore.Blockstore) (*storagemarket.State, exec.Storage, error) {
+	act, err := st.GetActor(ctx, address.StorageMarketAddress)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get storage market actor")
+	}
+
+	storage := vm.NewStorageMap(bstore).NewStorage(address.StorageMarketAddress, act)
+	marketState, err := storagemarket.LoadState(storage)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to decode storage market state")
+	}
+	return marketState, storage, nil
+}
+
+// errNotAMiner is returned by MarketView.Miner for addresses the storage
+// market has not created a miner at.
+var errNotAMiner = errors.New("not a miner")
+
+// powerTableCacheSize is the number of state roots a MarketView caches the
+// power of.
+const powerTableCacheSize = 64
+
+// marketPower is the power read from the storage market of one state. The
+// power of single miners is read as it is queried, and a nil power records
+// an address that is not a miner.
+type marketPower struct {
+	total  *types.BytesAmount
+	miners map[address.Address]*types.BytesAmount
 }
 
 // MarketView is the power table view used for running expected consensus in
 // production.  It's methods use data from an input state's storage market to
-// determine power values in a chain.
-type MarketView struct{}
+// determine power values in a chain. The storage market keeps the power of
+// every miner up to date as it changes, so each query reads a single value.
+// Values read are cached by state root, so repeated queries against the same
+// state, as when validating the blocks of a tipset, are cheap. A state that
+// changes gets a new root, so cached values never go stale. The zero value is
+// ready for use.
+type MarketView struct {
+	lk    sync.Mutex
+	cache map[cid.Cid]*marketPower
+	// order records cached state roots, oldest first, for eviction.
+	order []cid.Cid
+}
 
 var _ PowerTableView = &MarketView{}
 
-// Total returns the total storage as a uint64.  If the total storage
-// value exceeds the max value of a uint64 this method errors.
-// TODO: uint64 has enough bits to express about 1 exabyte of total storage.
-// This should be increased for v1.
-func (v *MarketView) Total(ctx context.Context, st state.Tree, bstore blockstore.Blockstore) (uint64, error) {
-	vms := vm.NewStorageMap(bstore)
-	rets, ec, err := CallQueryMethod(ctx, st, vms, address.StorageMarketAddress, "getTotalStorage", []byte{}, address.Address{}, nil)
+// PowerTable returns the power table of the given state.
+func (v *MarketView) PowerTable(ctx context.Context, st state.Tree, bstore blockstore.Blockstore) (*PowerTable, error) {
+	return LoadPowerTable(ctx, st, bstore)
+}
+
+// Total returns the total storage committed in the network, in bytes.
+func (v *MarketView) Total(ctx context.Context, st state.Tree, bstore blockstore.Blockstore) (*types.BytesAmount, error) {
+	root, err := st.Flush(ctx)
 	if err != nil {
-		return 0, err
+		return nil, errors.Wrap(err, "failed to flush state tree")
 	}
 
-	if ec != 0 {
-		return 0, errors.Errorf("non-zero return code from query message: %d", ec)
+	v.lk.Lock()
+	total := v.cached(root).total
+	v.lk.Unlock()
+	if total != nil {
+		return total, nil
 	}
-	res := big.NewInt(0)
-	res.SetBytes(rets[0])
 
-	return res.Uint64(), nil
+	marketState, _, err := loadMarketState(ctx, st, bstore)
+	if err != nil {
+		return nil, err
+	}
+
+	v.lk.Lock()
+	defer v.lk.Unlock()
+	v.cached(root).total = marketState.TotalCommittedStorage
+	return marketState.TotalCommittedStorage, nil
 }
 
-// Miner returns the storage that this miner has committed as a uint64.
-// If the total storage value exceeds the max value of a uint64 this method
-// errors.
-// TODO: currently power is in sectors, figure out if & how it should be converted to bytes.
-// TODO: uint64 has enough bits to express about 1 exabyte.  This
-// should probably be increased for v1.
-func (v *MarketView) Miner(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (uint64, error) {
-	vms := vm.NewStorageMap(bstore)
-	rets, ec, err := CallQueryMethod(ctx, st, vms, mAddr, "getPower", []byte{}, address.Address{}, nil)
+// Miner returns the storage that this miner has committed, in bytes.
+func (v *MarketView) Miner(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (*types.BytesAmount, error) {
+	root, err := st.Flush(ctx)
 	if err != nil {
-		return 0, err
+		return nil, errors.Wrap(err, "failed to flush state tree")
+	}
+
+	v.lk.Lock()
+	power, ok := v.cached(root).miners[mAddr]
+	v.lk.Unlock()
+	if ok {
+		if power == nil {
+			return nil, errors.Wrapf(errNotAMiner, "%s", mAddr)
+		}
+		return power, nil
 	}
 
-	if ec != 0 {
-		return 0, errors.Errorf("non-zero return code from query message: %d", ec)
+	power, err = loadMinerPower(ctx, st, bstore, mAddr)
+	if err != nil && errors.Cause(err) != errNotAMiner {
+		return nil, err
 	}
-	ret := big.NewInt(0).SetBytes(rets[0])
 
-	return ret.Uint64(), nil
+	v.lk.Lock()
+	defer v.lk.Unlock()
+	v.cached(root).miners[mAddr] = power
+	return power, err
 }
 
 // HasPower returns true if the provided address belongs to a miner with power
 // in the storage market
-func (v *MarketView) HasPower(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) bool {
-	numBytes, err := v.Miner(ctx, st, bstore, mAddr)
+func (v *MarketView) HasPower(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (bool, error) {
+	power, err := v.Miner(ctx, st, bstore, mAddr)
+	if state.IsActorNotFoundError(err) || errors.Cause(err) == errNotAMiner {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return power.IsPositive(), nil
+}
+
+// cached returns the cached power of the state with the given root, adding
+// an empty entry, and evicting the oldest one, if it is not cached yet. The
+// caller must hold v.lk.
+func (v *MarketView) cached(root cid.Cid) *marketPower {
+	if entry, ok := v.cache[root]; ok {
+		return entry
+	}
+
+	if v.cache == nil {
+		v.cache = make(map[cid.Cid]*marketPower)
+	}
+	if len(v.order) >= powerTableCacheSize {
+		delete(v.cache, v.order[0])
+		v.order = v.order[1:]
+	}
+	entry := &marketPower{miners: make(map[address.Address]*types.BytesAmount)}
+	v.cache[root] = entry
+	v.order = append(v.order, root)
+	return entry
+}
+
+// loadMinerPower reads the power of a miner from the storage market in st.
+func loadMinerPower(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (*types.BytesAmount, error) {
+	marketState, storage, err := loadMarketState(ctx, st, bstore)
 	if err != nil {
-		if state.IsActorNotFoundError(err) {
-			return false
-		}
+		return nil, err
+	}
 
-		panic(err) //hey guys, dropping errors is BAD
+	miners, err := actor.LoadTypedLookup(ctx, storage, marketState.Miners, types.BytesAmount{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load storage market miners")
+	}
+	value, err := miners.Find(ctx, mAddr.String())
+	if err == hamt.ErrNotFound {
+		return nil, errors.Wrapf(errNotAMiner, "%s", mAddr)
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the power of %s", mAddr)
 	}
 
-	return numBytes > 0
+	power := value.(types.BytesAmount)
+	return &power, nil
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/account"
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/exec"
 	"github.com/filecoin-project/go-filecoin/state"
 	"github.com/filecoin-project/go-filecoin/types"
 	"github.com/filecoin-project/go-filecoin/vm"
@@ -18,7 +19,9 @@ import (
 // This also includes other validations limited to the scope of the message and its fromActor
 type SignedMessageValidator interface {
 	// Validate validates that the given message is ready to be processed.
-	Validate(ctx context.Context, msg *types.SignedMessage, fromActor *actor.Actor) error
+	// fromKey is the public key recorded for the sender, or nil if it has not
+	// sent a message yet.
+	Validate(ctx context.Context, msg *types.SignedMessage, fromActor *actor.Actor, fromKey []byte) error
 }
 
 // BlockRewarder applies all rewards due to the miner for processing a block including block reward and gas
@@ -36,6 +39,9 @@ type BlockRewarder interface {
 type ApplicationResult struct {
 	Receipt        *types.MessageReceipt
 	ExecutionError error
+	// Trace is the execution trace of the message. It is only recorded by
+	// processors created with NewTracingProcessor.
+	Trace *vm.ExecutionTrace
 }
 
 // ProcessTipSetResponse records the results of successfully applied messages,
@@ -52,6 +58,7 @@ type ProcessTipSetResponse struct {
 type DefaultProcessor struct {
 	signedMessageValidator SignedMessageValidator
 	blockRewarder          BlockRewarder
+	traceMessages          bool
 }
 
 var _ Processor = (*DefaultProcessor)(nil)
@@ -64,6 +71,17 @@ func NewDefaultProcessor() *DefaultProcessor {
 	}
 }
 
+// NewTracingProcessor creates a default processor that records an execution
+// trace of every message it applies. Tracing is not free, so it should only be
+// used to inspect messages rather than to validate blocks.
+func NewTracingProcessor() *DefaultProcessor {
+	return &DefaultProcessor{
+		signedMessageValidator: NewDefaultMessageValidator(),
+		blockRewarder:          NewDefaultBlockRewarder(),
+		traceMessages:          true,
+	}
+}
+
 // NewConfiguredProcessor creates a default processor with custom validation and rewards.
 func NewConfiguredProcessor(validator SignedMessageValidator, rewarder BlockRewarder) *DefaultProcessor {
 	return &DefaultProcessor{
@@ -269,7 +287,7 @@ func (p *DefaultProcessor) ApplyMessage(ctx context.Context, st state.Tree, vms
 
 	cachedStateTree := state.NewCachedStateTree(st)
 
-	r, err := p.attemptApplyMessage(ctx, cachedStateTree, vms, msg, bh, gasTracker, ancestors)
+	r, trace, err := p.attemptApplyMessage(ctx, cachedStateTree, vms, msg, bh, gasTracker, ancestors)
 	if err == nil {
 		err = cachedStateTree.Commit(ctx)
 		if err != nil {
@@ -311,11 +329,14 @@ func (p *DefaultProcessor) ApplyMessage(ctx context.Context, st state.Tree, vms
 		return nil, errors.FaultErrorWrap(err, "couldn't load from actor")
 	}
 	fromActor.IncNonce()
+	if err := recordPublicKey(vms, msg, fromActor); err != nil {
+		return nil, err
+	}
 	if err := st.SetActor(ctx, msg.From, fromActor); err != nil {
 		return nil, errors.FaultErrorWrap(err, "could not set from actor after inc nonce")
 	}
 
-	return &ApplicationResult{Receipt: r, ExecutionError: executionError}, nil
+	return &ApplicationResult{Receipt: r, ExecutionError: executionError, Trace: trace}, nil
 }
 
 var (
@@ -337,13 +358,18 @@ var (
 // not make any changes to the state/blockchain and is useful for interrogating
 // actor state. Block height bh is optional; some methods will ignore it.
 func CallQueryMethod(ctx context.Context, st state.Tree, vms vm.StorageMap, to address.Address, method string, params []byte, from address.Address, optBh *types.BlockHeight) ([][]byte, uint8, error) {
-	toActor, err := st.GetActor(ctx, to)
+	// not committing or flushing storage structures guarantees changes won't make it to stored state tree or datastore
+	cachedSt := state.NewCachedStateTree(st)
+
+	to, err := vm.ResolveAddress(ctx, cachedSt, vms, to)
 	if err != nil {
-		return nil, 1, errors.ApplyErrorPermanentWrapf(err, "failed to get To actor")
+		return nil, errors.CodeError(err), err
 	}
 
-	// not committing or flushing storage structures guarantees changes won't make it to stored state tree or datastore
-	cachedSt := state.NewCachedStateTree(st)
+	toActor, err := cachedSt.GetActor(ctx, to)
+	if err != nil {
+		return nil, 1, errors.ApplyErrorPermanentWrapf(err, "failed to get To actor")
+	}
 
 	msg := &types.Message{
 		From:   from,
@@ -357,6 +383,7 @@ func CallQueryMethod(ctx context.Context, st state.Tree, vms vm.StorageMap, to a
 	// Set the gas limit to the max because this message send should always succeed; it doesn't cost gas.
 	gasTracker := vm.NewGasTracker()
 	gasTracker.MsgGasLimit = types.BlockGasLimit
+	gasTracker.Schedule = vm.GasScheduleAt(optBh)
 
 	vmCtxParams := vm.NewContextParams{
 		To:          toActor,
@@ -373,15 +400,21 @@ func CallQueryMethod(ctx context.Context, st state.Tree, vms vm.StorageMap, to a
 }
 
 // PreviewQueryMethod estimates the amount of gas that will be used by a method
-// call. It accepts all the same arguments as CallQueryMethod.
-func PreviewQueryMethod(ctx context.Context, st state.Tree, vms vm.StorageMap, to address.Address, method string, params []byte, from address.Address, optBh *types.BlockHeight) (types.GasUnits, error) {
-	toActor, err := st.GetActor(ctx, to)
+// call and returns an execution trace of the call. It accepts all the same
+// arguments as CallQueryMethod.
+func PreviewQueryMethod(ctx context.Context, st state.Tree, vms vm.StorageMap, to address.Address, method string, params []byte, from address.Address, optBh *types.BlockHeight) (types.GasUnits, *vm.ExecutionTrace, error) {
+	// not committing or flushing storage structures guarantees changes won't make it to stored state tree or datastore
+	cachedSt := state.NewCachedStateTree(st)
+
+	to, err := vm.ResolveAddress(ctx, cachedSt, vms, to)
 	if err != nil {
-		return types.NewGasUnits(0), errors.ApplyErrorPermanentWrapf(err, "failed to get To actor")
+		return types.NewGasUnits(0), nil, err
 	}
 
-	// not committing or flushing storage structures guarantees changes won't make it to stored state tree or datastore
-	cachedSt := state.NewCachedStateTree(st)
+	toActor, err := cachedSt.GetActor(ctx, to)
+	if err != nil {
+		return types.NewGasUnits(0), nil, errors.ApplyErrorPermanentWrapf(err, "failed to get To actor")
+	}
 
 	msg := &types.Message{
 		From:   from,
@@ -395,7 +428,9 @@ func PreviewQueryMethod(ctx context.Context, st state.Tree, vms vm.StorageMap, t
 	// Set the gas limit to the max because this message send should always succeed; it doesn't cost gas.
 	gasTracker := vm.NewGasTracker()
 	gasTracker.MsgGasLimit = types.BlockGasLimit
+	gasTracker.Schedule = vm.GasScheduleAt(optBh)
 
+	trace := &vm.ExecutionTrace{}
 	vmCtxParams := vm.NewContextParams{
 		To:          toActor,
 		Message:     msg,
@@ -403,11 +438,12 @@ func PreviewQueryMethod(ctx context.Context, st state.Tree, vms vm.StorageMap, t
 		StorageMap:  vms,
 		GasTracker:  gasTracker,
 		BlockHeight: optBh,
+		Trace:       trace,
 	}
 	vmCtx := vm.NewVMContext(vmCtxParams)
 	_, _, err = vm.Send(ctx, vmCtx)
 
-	return vmCtx.GasUnits(), err
+	return vmCtx.GasUnits(), trace, err
 }
 
 // attemptApplyMessage encapsulates the work of trying to apply the message in order
@@ -415,13 +451,14 @@ func PreviewQueryMethod(ctx context.Context, st state.Tree, vms vm.StorageMap, t
 // should deal with trying to apply the message to the state tree whereas
 // ApplyMessage should deal with any side effects and how it should be presented
 // to the caller. attemptApplyMessage should only be called from ApplyMessage.
-func (p *DefaultProcessor) attemptApplyMessage(ctx context.Context, st *state.CachedTree, store vm.StorageMap, msg *types.SignedMessage, bh *types.BlockHeight, gasTracker *vm.GasTracker, ancestors []types.TipSet) (*types.MessageReceipt, error) {
+func (p *DefaultProcessor) attemptApplyMessage(ctx context.Context, st *state.CachedTree, store vm.StorageMap, msg *types.SignedMessage, bh *types.BlockHeight, gasTracker *vm.GasTracker, ancestors []types.TipSet) (*types.MessageReceipt, *vm.ExecutionTrace, error) {
 	gasTracker.ResetForNewMessage(msg.MeteredMessage)
+	gasTracker.Schedule = vm.GasScheduleAt(bh)
 	if err := blockGasLimitError(gasTracker); err != nil {
 		return &types.MessageReceipt{
 			ExitCode:   errors.CodeError(err),
 			GasAttoFIL: types.ZeroAttoFIL,
-		}, err
+		}, nil, err
 	}
 
 	fromActor, err := st.GetActor(ctx, msg.From)
@@ -429,53 +466,99 @@ func (p *DefaultProcessor) attemptApplyMessage(ctx context.Context, st *state.Ca
 		return &types.MessageReceipt{
 			ExitCode:   errors.CodeError(err),
 			GasAttoFIL: types.ZeroAttoFIL,
-		}, errFromAccountNotFound
+		}, nil, errFromAccountNotFound
 	} else if err != nil {
-		return nil, errors.FaultErrorWrapf(err, "failed to get From actor %s", msg.From)
+		return nil, nil, errors.FaultErrorWrapf(err, "failed to get From actor %s", msg.From)
 	}
 
 	// processing an external message from an empty actor upgrades it to an account actor.
 	if !fromActor.Code.Defined() {
 		err := account.UpgradeActor(fromActor)
 		if err != nil {
-			return nil, errors.FaultErrorWrap(err, "failed to upgrade empty actor")
+			return nil, nil, errors.FaultErrorWrap(err, "failed to upgrade empty actor")
+		}
+		if err := vm.RegisterActorID(ctx, st, store, msg.From); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var fromKey []byte
+	if fromActor.Code.Equals(types.AccountActorCodeCid) {
+		fromKey, err = account.LoadPublicKey(store.NewStorage(msg.From, fromActor))
+		if err != nil {
+			return nil, nil, errors.FaultErrorWrapf(err, "failed to load public key of %s", msg.From)
 		}
 	}
 
-	err = p.signedMessageValidator.Validate(ctx, msg, fromActor)
+	err = p.signedMessageValidator.Validate(ctx, msg, fromActor, fromKey)
 	if err != nil {
 		return &types.MessageReceipt{
 			ExitCode:   errors.CodeError(err),
 			GasAttoFIL: types.ZeroAttoFIL,
-		}, err
+		}, nil, err
 	}
 
-	toActor, err := st.GetOrCreateActor(ctx, msg.To, func() (*actor.Actor, error) {
+	// messages to ID addresses are executed as messages to the robust
+	// address of the actor, which its storage is kept under
+	vmMsg := &msg.Message
+	if msg.To.IsID() {
+		to, err := vm.ResolveAddress(ctx, st, store, msg.To)
+		if err != nil {
+			return &types.MessageReceipt{
+				ExitCode:   errors.CodeError(err),
+				GasAttoFIL: types.ZeroAttoFIL,
+			}, nil, err
+		}
+		if to == msg.From {
+			return &types.MessageReceipt{
+				ExitCode:   errors.CodeError(errSelfSend),
+				GasAttoFIL: types.ZeroAttoFIL,
+			}, nil, errSelfSend
+		}
+		resolved := msg.Message
+		resolved.To = to
+		vmMsg = &resolved
+	}
+
+	toActor, err := st.GetOrCreateActor(ctx, vmMsg.To, func() (*actor.Actor, error) {
 		// Addresses are deterministic so sending a message to a non-existent address must not install an actor,
 		// else actors could be installed ahead of address activation. So here we create the empty, upgradable
 		// actor to collect any balance that may be transferred.
 		return &actor.Actor{}, nil
 	})
 	if err != nil {
-		return nil, errors.FaultErrorWrap(err, "failed to get To actor")
+		return nil, nil, errors.FaultErrorWrap(err, "failed to get To actor")
 	}
 
 	vmCtxParams := vm.NewContextParams{
 		From:        fromActor,
 		To:          toActor,
-		Message:     &msg.Message,
+		Message:     vmMsg,
+		Origin:      &msg.Message,
 		State:       st,
 		StorageMap:  store,
 		GasTracker:  gasTracker,
 		BlockHeight: bh,
 		Ancestors:   ancestors,
 		LookBack:    LookBackParameter,
+		Events:      vm.NewEventLog(),
+	}
+	var trace *vm.ExecutionTrace
+	if p.traceMessages {
+		trace = &vm.ExecutionTrace{}
+		vmCtxParams.Trace = trace
 	}
 	vmCtx := vm.NewVMContext(vmCtxParams)
 
 	ret, exitCode, vmErr := vm.Send(ctx, vmCtx)
+	if errors.IsFault(vmErr) && vm.IsOutOfGas(vmErr) {
+		// Running out of gas part way through a storage operation may surface
+		// from the actor as a fault; it is the message's failure, not the node's.
+		exitCode = exec.ErrInsufficientGas
+		vmErr = errors.RevertErrorWrap(vmErr, "Insufficient gas")
+	}
 	if errors.IsFault(vmErr) {
-		return nil, vmErr
+		return nil, nil, vmErr
 	}
 
 	// compute gas charge
@@ -491,8 +574,11 @@ func (p *DefaultProcessor) attemptApplyMessage(ctx context.Context, st *state.Ca
 	for _, b := range ret {
 		receipt.Return = append(receipt.Return, b)
 	}
+	if vmErr == nil {
+		receipt.Events = vmCtxParams.Events.Events()
+	}
 
-	return receipt, vmErr
+	return receipt, trace, vmErr
 }
 
 // ApplyMessagesResponse is the output struct of ApplyMessages.  It exists to
@@ -518,6 +604,11 @@ func (p *DefaultProcessor) ApplyMessagesAndPayRewards(ctx context.Context, st st
 	var emptyRet ApplyMessagesResponse
 	var ret ApplyMessagesResponse
 
+	// migrate actors whose scheduled upgrades are due before anything else.
+	if err := applyUpgrades(ctx, st, vms, bh); err != nil {
+		return ApplyMessagesResponse{}, err
+	}
+
 	// transfer block reward to miner from network address.
 	if err := p.blockRewarder.BlockReward(ctx, st, minerAddr); err != nil {
 		return ApplyMessagesResponse{}, err
@@ -548,6 +639,40 @@ func (p *DefaultProcessor) ApplyMessagesAndPayRewards(ctx context.Context, st st
 	return ret, nil
 }
 
+// recordPublicKey records the public key of the sender of an applied message
+// in its account actor, if none has been recorded yet. Only keys that verify
+// the signature of the message and belong to the sender are recorded.
+func recordPublicKey(vms vm.StorageMap, msg *types.SignedMessage, fromActor *actor.Actor) error {
+	if !fromActor.Code.Equals(types.AccountActorCodeCid) {
+		return nil
+	}
+
+	storage := vms.NewStorage(msg.From, fromActor)
+	recorded, err := account.LoadPublicKey(storage)
+	if err != nil {
+		return errors.FaultErrorWrapf(err, "failed to load public key of %s", msg.From)
+	}
+	if recorded != nil {
+		return nil
+	}
+	if msg.IsAggregated() {
+		if !types.IsKeyOfAddress(msg.PublicKey, msg.From) {
+			return nil
+		}
+	} else if !msg.VerifySignature() {
+		return nil
+	}
+
+	pubKey, err := msg.SenderPublicKey()
+	if err != nil {
+		return nil
+	}
+	if err := account.RecordPublicKey(storage, pubKey); err != nil {
+		return errors.FaultErrorWrapf(err, "failed to record public key of %s", msg.From)
+	}
+	return nil
+}
+
 // DefaultMessageValidator validates that a message coming in from the network is valid.
 type DefaultMessageValidator struct{}
 
@@ -559,8 +684,25 @@ func NewDefaultMessageValidator() *DefaultMessageValidator {
 var _ SignedMessageValidator = (*DefaultMessageValidator)(nil)
 
 // Validate validates that the given message is ready to be processed.
-func (nmv *DefaultMessageValidator) Validate(ctx context.Context, msg *types.SignedMessage, fromActor *actor.Actor) error {
-	if !msg.VerifySignature() {
+// Messages from accounts with a recorded public key are verified against it,
+// without recovering the key from the signature. The signatures of aggregated
+// BLS messages are verified with the block that includes them, so only the
+// key they carry is checked here, or that a key has been recorded for them.
+func (nmv *DefaultMessageValidator) Validate(ctx context.Context, msg *types.SignedMessage, fromActor *actor.Actor, fromKey []byte) error {
+	switch {
+	case msg.IsAggregated() && len(msg.PublicKey) > 0:
+		if !types.IsKeyOfAddress(msg.PublicKey, msg.From) {
+			return errInvalidSignature
+		}
+	case msg.IsAggregated():
+		if fromKey == nil {
+			return errInvalidSignature
+		}
+	case fromKey != nil:
+		if !msg.VerifySignatureWithKey(fromKey) {
+			return errInvalidSignature
+		}
+	case !msg.VerifySignature():
 		return errInvalidSignature
 	}
 
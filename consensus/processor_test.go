@@ -12,6 +12,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/abi"
 	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/account"
 	"github.com/filecoin-project/go-filecoin/address"
 	. "github.com/filecoin-project/go-filecoin/consensus"
 	"github.com/filecoin-project/go-filecoin/state"
@@ -257,6 +258,66 @@ func TestProcessBlockBadMsgSig(t *testing.T) {
 	assert.EqualError(err, "apply message failed: invalid signature by sender over message data")
 }
 
+func TestApplyMessageRecordsPublicKey(t *testing.T) {
+	ctx := context.Background()
+	kis := types.MustGenerateKeyInfo(2, types.GenerateKeyInfoSeed())
+	mockSigner := types.NewMockSigner(kis[:1])
+	fromAddr := mockSigner.Addresses[0]
+	toAddr := address.NewForTestGetter()()
+
+	pubKey, err := kis[0].PublicKey()
+	require.NoError(t, err)
+	otherPubKey, err := kis[1].PublicKey()
+	require.NoError(t, err)
+
+	t.Run("the first message records the key of the sender", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		cst := hamt.NewCborStore()
+		vms := th.VMStorage()
+		_, st := requireMakeStateTree(require, cst, map[address.Address]*actor.Actor{
+			fromAddr: th.RequireNewAccountActor(require, types.NewAttoFILFromFIL(1000)),
+		})
+
+		msg := types.NewMessage(fromAddr, toAddr, 0, types.NewAttoFILFromFIL(1), "", nil)
+		smsg, err := types.NewSignedMessage(*msg, mockSigner, types.NewGasPrice(0), types.NewGasUnits(0))
+		require.NoError(err)
+		smsg.PublicKey = pubKey
+
+		_, err = NewDefaultProcessor().ApplyMessage(ctx, st, vms, smsg, toAddr, types.NewBlockHeight(0), vm.NewGasTracker(), nil)
+		require.NoError(err)
+
+		fromAct, err := st.GetActor(ctx, fromAddr)
+		require.NoError(err)
+		recorded, err := account.LoadPublicKey(vms.NewStorage(fromAddr, fromAct))
+		require.NoError(err)
+		assert.Equal(pubKey, recorded)
+	})
+
+	t.Run("later messages are verified against the recorded key", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		cst := hamt.NewCborStore()
+		vms := th.VMStorage()
+		fromAct := th.RequireNewAccountActor(require, types.NewAttoFILFromFIL(1000))
+		require.NoError(account.RecordPublicKey(vms.NewStorage(fromAddr, fromAct), otherPubKey))
+		_, st := requireMakeStateTree(require, cst, map[address.Address]*actor.Actor{
+			fromAddr: fromAct,
+		})
+
+		// the signature is valid for the sender address, but not for the recorded key
+		msg := types.NewMessage(fromAddr, toAddr, 0, types.NewAttoFILFromFIL(1), "", nil)
+		smsg, err := types.NewSignedMessage(*msg, mockSigner, types.NewGasPrice(0), types.NewGasUnits(0))
+		require.NoError(err)
+
+		_, err = NewDefaultProcessor().ApplyMessage(ctx, st, vms, smsg, toAddr, types.NewBlockHeight(0), vm.NewGasTracker(), nil)
+		require.Error(err)
+		assert.Equal("invalid signature by sender over message data", err.(*errors.ApplyErrorPermanent).Cause().Error())
+	})
+}
+
 // ProcessBlock should not fail with an unsigned block reward message.
 func TestProcessBlockReward(t *testing.T) {
 	assert := assert.New(t)
@@ -895,13 +956,13 @@ func TestApplyMessageChargesGas(t *testing.T) {
 		minerActor, err := st.GetActor(ctx, minerAddr)
 		require.NoError(err)
 
-		// miner receives (3 FIL/gas * 100 gas * 2 messages)
-		assert.Equal(types.NewAttoFILFromFIL(1600), minerActor.Balance)
+		// miner receives (3 FIL/gas * (100 gas * 2 messages + 10 gas for the send + 1 gas for a word of params))
+		assert.Equal(types.NewAttoFILFromFIL(1633), minerActor.Balance)
 
 		accountActor, err := st.GetActor(ctx, addr0)
 		require.NoError(err)
 		// sender's resulting balance of FIL
-		assert.Equal(types.NewAttoFILFromFIL(1400), accountActor.Balance)
+		assert.Equal(types.NewAttoFILFromFIL(1367), accountActor.Balance)
 	})
 
 	t.Run("ApplyMessage when it sends another message with insufficient gas fails with correct message", func(t *testing.T) {
@@ -939,6 +1000,66 @@ func TestApplyMessageChargesGas(t *testing.T) {
 	})
 }
 
+func TestApplyMessageRecordsTrace(t *testing.T) {
+	ctx := context.Background()
+
+	// Install the fake actor so we can execute it.
+	fakeActorCodeCid := types.NewCidForTestGetter()()
+	builtin.Actors[fakeActorCodeCid] = &actor.FakeActor{}
+	defer delete(builtin.Actors, fakeActorCodeCid)
+
+	newMessage := func(t *testing.T) (state.Tree, *types.SignedMessage, []address.Address) {
+		addresses, st, mockSigner := setupActorsForGasTest(t, th.VMStorage(), fakeActorCodeCid, 1000)
+
+		params, err := abi.ToEncodedValues(addresses[2])
+		require.NoError(t, err)
+
+		msg := types.NewMessage(addresses[0], addresses[1], 0, types.ZeroAttoFIL, "runsAnotherMessage", params)
+		smsg, err := types.NewSignedMessage(*msg, mockSigner, types.NewGasPrice(0), types.NewGasUnits(1000))
+		require.NoError(t, err)
+		return st, smsg, addresses
+	}
+
+	t.Run("the default processor records no trace", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		st, smsg, addresses := newMessage(t)
+		result, err := NewDefaultProcessor().ApplyMessage(ctx, st, th.VMStorage(), smsg, addresses[3], types.NewBlockHeight(0), vm.NewGasTracker(), nil)
+		require.NoError(err)
+		assert.Nil(result.Trace)
+	})
+
+	t.Run("a tracing processor records the message and its nested sends", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		st, smsg, addresses := newMessage(t)
+		result, err := NewTracingProcessor().ApplyMessage(ctx, st, th.VMStorage(), smsg, addresses[3], types.NewBlockHeight(0), vm.NewGasTracker(), nil)
+		require.NoError(err)
+		require.NoError(result.ExecutionError)
+		require.NotNil(result.Trace)
+
+		trace := result.Trace
+		assert.Equal(addresses[0], trace.From)
+		assert.Equal(addresses[1], trace.To)
+		assert.Equal("runsAnotherMessage", trace.Method)
+		assert.Equal(smsg.Params, trace.Params)
+		assert.Equal(types.NewGasUnits(211), trace.GasUsed)
+		assert.Equal(uint8(0), trace.ExitCode)
+		assert.Empty(trace.Error)
+		require.Len(trace.Subcalls, 1)
+
+		sub := trace.Subcalls[0]
+		assert.Equal(addresses[1], sub.From)
+		assert.Equal(addresses[2], sub.To)
+		assert.Equal("hasReturnValue", sub.Method)
+		assert.Equal(types.NewGasUnits(100), sub.GasUsed)
+		assert.Len(sub.Return, 1)
+		assert.Empty(sub.Subcalls)
+	})
+}
+
 func TestBlockGasLimitBehavior(t *testing.T) {
 	fakeActorCodeCid := types.NewCidForTestGetter()()
 	builtin.Actors[fakeActorCodeCid] = &actor.FakeActor{}
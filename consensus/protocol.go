@@ -29,7 +29,7 @@ type Protocol interface {
 	// blocks were mined according to protocol rules (RunStateTransition does these checks).
 	NewValidTipSet(ctx context.Context, blks []*types.Block) (types.TipSet, error)
 	// Weight returns the weight given to the input ts by this consensus protocol.
-	Weight(ctx context.Context, ts types.TipSet, pSt state.Tree) (uint64, error)
+	Weight(ctx context.Context, ts types.TipSet, pSt state.Tree) (*types.ChainWeight, error)
 	// IsHeaver returns 1 if tipset a is heavier than tipset b and -1 if
 	// tipset b is heavier than tipset a.
 	IsHeavier(ctx context.Context, a, b types.TipSet, aSt, bSt state.Tree) (bool, error)
@@ -0,0 +1,188 @@
+package consensus
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	"gx/ipfs/QmRXf2uUSdGSunRJsM9wXSUNVwLUGCY3So5fAs7h2CBJVf/go-hamt-ipld"
+	cbor "gx/ipfs/QmRoARq3nkUb13HSKZGepCZSWe5GrVPwx7xURJGZ7KWv9V/go-ipld-cbor"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+	"gx/ipfs/QmfWqohMtbivn5NRJvtrLzCW3EU4QmoLvVNtmvo9vbdtVA/refmt/obj"
+	"gx/ipfs/QmfWqohMtbivn5NRJvtrLzCW3EU4QmoLvVNtmvo9vbdtVA/refmt/shared"
+
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// Receipts for the messages in a block are stored in a HAMT keyed by the
+// index of the message in the block. The block only carries the root of the
+// HAMT, which lets a light client fetch and verify a single receipt without
+// downloading the rest. A block with no receipts has an undefined root.
+
+// ReceiptMismatchError is returned when the receipts produced by applying a
+// block's messages differ from the receipts the block commits to.
+type ReceiptMismatchError struct {
+	// Block is the cid of the offending block.
+	Block cid.Cid
+	// Claimed is the receipts root found in the block.
+	Claimed cid.Cid
+	// Computed is the receipts root produced by validation.
+	Computed cid.Cid
+	// Index is the position of the first differing receipt, or -1 if the
+	// claimed receipts could not be loaded.
+	Index int
+	// Reason describes which part of the receipt differs.
+	Reason string
+}
+
+func (e *ReceiptMismatchError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("block %s has invalid message receipts: claimed root %s, computed root %s", e.Block, e.Claimed, e.Computed)
+	}
+	return fmt.Sprintf("block %s has invalid message receipt %d: %s (claimed root %s, computed root %s)", e.Block, e.Index, e.Reason, e.Claimed, e.Computed)
+}
+
+// IsReceiptMismatchError is true of the error returned when validation
+// produces receipts that do not match those committed to by the block.
+func IsReceiptMismatchError(err error) bool {
+	_, ok := errors.Cause(err).(*ReceiptMismatchError)
+	return ok
+}
+
+// ReceiptsRoot stores the receipts in cst and returns the root of the
+// collection, or cid.Undef if there are none.
+func ReceiptsRoot(ctx context.Context, cst *hamt.CborIpldStore, receipts []*types.MessageReceipt) (cid.Cid, error) {
+	if len(receipts) == 0 {
+		return cid.Undef, nil
+	}
+
+	root := hamt.NewNode(cst)
+	for i, r := range receipts {
+		if err := root.Set(ctx, receiptKey(i), r); err != nil {
+			return cid.Undef, errors.Wrapf(err, "failed to set receipt %d", i)
+		}
+	}
+
+	if err := root.Flush(ctx); err != nil {
+		return cid.Undef, errors.Wrap(err, "failed to flush receipts")
+	}
+
+	return cst.Put(ctx, root)
+}
+
+// LoadReceipt returns the receipt at index from the collection rooted at
+// root. It returns hamt.ErrNotFound if there is no such receipt.
+func LoadReceipt(ctx context.Context, cst *hamt.CborIpldStore, root cid.Cid, index int) (*types.MessageReceipt, error) {
+	if !root.Defined() {
+		return nil, hamt.ErrNotFound
+	}
+
+	node, err := hamt.LoadNode(ctx, cst, root)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load receipts")
+	}
+
+	return findReceipt(ctx, node, index)
+}
+
+// LoadReceipts returns all receipts in the collection rooted at root, in
+// message order.
+func LoadReceipts(ctx context.Context, cst *hamt.CborIpldStore, root cid.Cid) ([]*types.MessageReceipt, error) {
+	var receipts []*types.MessageReceipt
+	if !root.Defined() {
+		return receipts, nil
+	}
+
+	node, err := hamt.LoadNode(ctx, cst, root)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load receipts")
+	}
+
+	for i := 0; ; i++ {
+		r, err := findReceipt(ctx, node, i)
+		if err == hamt.ErrNotFound {
+			return receipts, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		receipts = append(receipts, r)
+	}
+}
+
+func findReceipt(ctx context.Context, node *hamt.Node, index int) (*types.MessageReceipt, error) {
+	data, err := node.Find(ctx, receiptKey(index))
+	if err != nil {
+		return nil, err
+	}
+
+	var r types.MessageReceipt
+	if err := transferObject(data, &r); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode receipt %d", index)
+	}
+
+	return &r, nil
+}
+
+func receiptKey(index int) string {
+	return strconv.Itoa(index)
+}
+
+// compareReceipts returns the index of the first receipt that differs between
+// claimed and computed along with a description of the difference. It returns
+// -1 if the receipts are equal.
+func compareReceipts(claimed, computed []*types.MessageReceipt) (int, string) {
+	for i := 0; i < len(claimed) && i < len(computed); i++ {
+		c, a := claimed[i], computed[i]
+		if c.ExitCode != a.ExitCode {
+			return i, fmt.Sprintf("exit code %d, expected %d", c.ExitCode, a.ExitCode)
+		}
+		if !returnValuesEqual(c.Return, a.Return) {
+			return i, "return values differ"
+		}
+		if !c.GasAttoFIL.Equal(a.GasAttoFIL) {
+			return i, fmt.Sprintf("gas %s, expected %s", c.GasAttoFIL, a.GasAttoFIL)
+		}
+	}
+
+	if len(claimed) != len(computed) {
+		n := len(claimed)
+		if len(computed) < n {
+			n = len(computed)
+		}
+		return n, fmt.Sprintf("found %d receipts, expected %d", len(claimed), len(computed))
+	}
+
+	return -1, ""
+}
+
+func returnValuesEqual(a, b []types.Bytes) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func transferObject(from, to interface{}) error {
+	m := obj.NewMarshaller(cbor.CborAtlas)
+	if err := m.Bind(from); err != nil {
+		return err
+	}
+
+	u := obj.NewUnmarshaller(cbor.CborAtlas)
+	if err := u.Bind(to); err != nil {
+		return err
+	}
+
+	return shared.TokenPump{
+		TokenSource: m,
+		TokenSink:   u,
+	}.Run()
+}
@@ -0,0 +1,85 @@
+package consensus_test
+
+import (
+	"context"
+	"testing"
+
+	"gx/ipfs/QmRXf2uUSdGSunRJsM9wXSUNVwLUGCY3So5fAs7h2CBJVf/go-hamt-ipld"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReceiptsRoot(t *testing.T) {
+	ctx := context.Background()
+
+	receipts := []*types.MessageReceipt{
+		{ExitCode: 0, Return: []types.Bytes{[]byte{1, 2}}, GasAttoFIL: types.NewAttoFILFromFIL(1)},
+		{ExitCode: 33, GasAttoFIL: types.NewAttoFILFromFIL(2)},
+	}
+
+	t.Run("receipts can be loaded individually and in order", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+		cst, _, _ := setupCborBlockstoreProofs()
+
+		root, err := consensus.ReceiptsRoot(ctx, cst, receipts)
+		require.NoError(err)
+		assert.True(root.Defined())
+
+		r, err := consensus.LoadReceipt(ctx, cst, root, 1)
+		require.NoError(err)
+		assert.Equal(uint8(33), r.ExitCode)
+		assert.True(types.NewAttoFILFromFIL(2).Equal(r.GasAttoFIL))
+
+		_, err = consensus.LoadReceipt(ctx, cst, root, 2)
+		assert.Equal(hamt.ErrNotFound, err)
+
+		all, err := consensus.LoadReceipts(ctx, cst, root)
+		require.NoError(err)
+		require.Len(all, 2)
+		assert.Equal([]types.Bytes{[]byte{1, 2}}, all[0].Return)
+		assert.Equal(uint8(33), all[1].ExitCode)
+	})
+
+	t.Run("root depends on receipt contents", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+		cst, _, _ := setupCborBlockstoreProofs()
+
+		root1, err := consensus.ReceiptsRoot(ctx, cst, receipts)
+		require.NoError(err)
+
+		root2, err := consensus.ReceiptsRoot(ctx, cst, []*types.MessageReceipt{receipts[0], {ExitCode: 34, GasAttoFIL: types.NewAttoFILFromFIL(2)}})
+		require.NoError(err)
+
+		assert.False(root1.Equals(root2))
+	})
+
+	t.Run("no receipts yields an undefined root", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+		cst, _, _ := setupCborBlockstoreProofs()
+
+		root, err := consensus.ReceiptsRoot(ctx, cst, nil)
+		require.NoError(err)
+		assert.False(root.Defined())
+
+		all, err := consensus.LoadReceipts(ctx, cst, root)
+		require.NoError(err)
+		assert.Empty(all)
+	})
+}
+
+func TestIsReceiptMismatchError(t *testing.T) {
+	assert := assert.New(t)
+
+	err := &consensus.ReceiptMismatchError{Block: types.SomeCid(), Index: 1, Reason: "exit code 1, expected 0"}
+	assert.True(consensus.IsReceiptMismatchError(err))
+	assert.True(consensus.IsReceiptMismatchError(errors.Wrap(err, "validating")))
+	assert.False(consensus.IsReceiptMismatchError(errors.New("other")))
+	assert.Contains(err.Error(), "exit code 1")
+}
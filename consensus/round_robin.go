@@ -0,0 +1,173 @@
+package consensus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	"gx/ipfs/QmRXf2uUSdGSunRJsM9wXSUNVwLUGCY3So5fAs7h2CBJVf/go-hamt-ipld"
+	"gx/ipfs/QmS2aqUZLJp8kF1ihE5rvDGE5LvmKDPnx32w9Z1BW9xLV5/go-ipfs-blockstore"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+)
+
+// ErrWrongProducer is returned when a block is produced by an address other
+// than the authority whose turn it is.
+var ErrWrongProducer = errors.New("block was not produced by the authority for its height")
+
+// RoundRobin implements a proof-of-authority consensus protocol in which a
+// fixed set of authorities, recorded in the genesis block, take turns
+// producing blocks: the block at height h is produced by authority h mod n.
+// Authorities are miners, and each block must be signed by the owner of the
+// miner that produced it.
+// An authority that misses its turn leaves a null block, so the chain keeps
+// moving as long as any authority is online.
+//
+// It is intended for tests and private networks, where a fast and
+// deterministic chain is more useful than a secure one. Messages are applied
+// exactly as under Expected consensus.
+type RoundRobin struct {
+	// authorities are the block producers in turn order.
+	authorities []address.Address
+
+	// cstore is used for loading state trees during message running.
+	cstore *hamt.CborIpldStore
+
+	// bstore contains data referenced by actors within the state
+	// during message running.
+	bstore blockstore.Blockstore
+
+	// processor is what we use to process messages and pay rewards
+	processor Processor
+
+	genesisCid cid.Cid
+
+	// sigValidator checks that blocks are signed by their miner.
+	sigValidator BlockSignatureValidator
+}
+
+// Ensure RoundRobin satisfies the Protocol interface at compile time.
+var _ Protocol = (*RoundRobin)(nil)
+
+// NewRoundRobin is the constructor for the RoundRobin consensus.Protocol
+// module. The authorities must be those recorded in the genesis block.
+func NewRoundRobin(cs *hamt.CborIpldStore, bs blockstore.Blockstore, processor Processor, gCid cid.Cid, authorities []address.Address, sv BlockSignatureValidator) Protocol {
+	return &RoundRobin{
+		authorities:  authorities,
+		cstore:       cs,
+		bstore:       bs,
+		processor:    processor,
+		genesisCid:   gCid,
+		sigValidator: sv,
+	}
+}
+
+// RoundRobinProducer returns the authority entitled to produce the block at
+// the given height.
+func RoundRobinProducer(authorities []address.Address, height uint64) address.Address {
+	return authorities[height%uint64(len(authorities))]
+}
+
+// NewValidTipSet creates a new tipset from the input blocks that is
+// guaranteed to be valid. Since a single authority produces each height a
+// valid tipset contains exactly one block.
+func (c *RoundRobin) NewValidTipSet(ctx context.Context, blks []*types.Block) (types.TipSet, error) {
+	if len(blks) != 1 {
+		return nil, fmt.Errorf("round-robin tipsets contain a single block, got %d", len(blks))
+	}
+	for _, blk := range blks {
+		if err := c.validateBlockStructure(ctx, blk); err != nil {
+			return nil, err
+		}
+	}
+	return types.NewTipSet(blks...)
+}
+
+// validateBlockStructure verifies that this block, on its own, is
+// structurally valid and claims to be produced by the right authority. The
+// claim is checked against the block signature in RunStateTransition, where
+// the state recording the authority's owner is available.
+func (c *RoundRobin) validateBlockStructure(ctx context.Context, b *types.Block) error {
+	if b.Cid().Equals(c.genesisCid) {
+		return nil
+	}
+	if !b.StateRoot.Defined() {
+		return fmt.Errorf("block has nil StateRoot")
+	}
+	if len(b.Authorities) > 0 {
+		return fmt.Errorf("only the genesis block may set authorities")
+	}
+	if b.Miner != RoundRobinProducer(c.authorities, uint64(b.Height)) {
+		return errors.Wrapf(ErrWrongProducer, "block %s at height %d produced by %s", b.Cid(), b.Height, b.Miner)
+	}
+	return nil
+}
+
+// Weight returns the weight of this TipSet. Every block adds one to the
+// weight of its parent, so the heaviest chain is the one with the fewest null
+// blocks.
+func (c *RoundRobin) Weight(ctx context.Context, ts types.TipSet, pSt state.Tree) (*types.ChainWeight, error) {
+	if len(ts) == 1 && ts.ToSlice()[0].Cid().Equals(c.genesisCid) {
+		return types.ZeroWeight, nil
+	}
+	parentW, err := ts.ParentWeight()
+	if err != nil {
+		return nil, err
+	}
+	return parentW.Add(types.NewChainWeight(uint64(len(ts)))), nil
+}
+
+// IsHeavier returns true if tipset a is heavier than tipset b, and false
+// vice versa. Ties are broken by comparing the concatenation of block cids.
+func (c *RoundRobin) IsHeavier(ctx context.Context, a, b types.TipSet, aSt, bSt state.Tree) (bool, error) {
+	aW, err := c.Weight(ctx, a, aSt)
+	if err != nil {
+		return false, err
+	}
+	bW, err := c.Weight(ctx, b, bSt)
+	if err != nil {
+		return false, err
+	}
+	if !aW.Equal(bW) {
+		return aW.GreaterThan(bW), nil
+	}
+
+	cmp := strings.Compare(a.String(), b.String())
+	if cmp == 0 {
+		return false, ErrUnorderedTipSets
+	}
+	return cmp == 1, nil
+}
+
+// RunStateTransition is the chain transition function that goes from a
+// starting state and a tipset to a new state. It errors if a block was
+// produced out of turn or is not signed by its authority, or if running the
+// messages in the tipset results in an error.
+func (c *RoundRobin) RunStateTransition(ctx context.Context, ts types.TipSet, ancestors []types.TipSet, pSt state.Tree) (state.Tree, error) {
+	for _, blk := range ts.ToSlice() {
+		if err := c.validateBlockStructure(ctx, blk); err != nil {
+			return nil, err
+		}
+		if err := c.sigValidator.ValidateBlockSignature(ctx, pSt, c.bstore, blk); err != nil {
+			return nil, errors.Wrap(err, "invalid block signature")
+		}
+	}
+	if err := validateBLSAggregates(ctx, pSt, c.bstore, ts); err != nil {
+		return nil, err
+	}
+
+	vms := vm.NewStorageMap(c.bstore)
+	st, err := runMessages(ctx, c.cstore, c.processor, pSt, vms, ts, ancestors)
+	if err != nil {
+		return nil, err
+	}
+	if err := vms.Flush(); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
@@ -0,0 +1,129 @@
+package consensus_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/go-filecoin/actor/builtin"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/testhelpers"
+	"github.com/filecoin-project/go-filecoin/types"
+
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundRobinProducer(t *testing.T) {
+	assert := assert.New(t)
+
+	authorities := []address.Address{address.MakeTestAddress("a"), address.MakeTestAddress("b"), address.MakeTestAddress("c")}
+	assert.Equal(authorities[0], consensus.RoundRobinProducer(authorities, 0))
+	assert.Equal(authorities[1], consensus.RoundRobinProducer(authorities, 1))
+	assert.Equal(authorities[2], consensus.RoundRobinProducer(authorities, 5))
+	assert.Equal(authorities[0], consensus.RoundRobinProducer(authorities, 6))
+}
+
+func TestRoundRobin(t *testing.T) {
+	ctx := context.Background()
+	cistore, bstore, _ := setupCborBlockstoreProofs()
+
+	signer := types.NewMockSigner(types.MustGenerateKeyInfo(2, types.GenerateKeyInfoSeed()))
+	owners := signer.Addresses
+	genesisBlock, err := consensus.MakeGenesisFunc(consensus.Authorities(owners...))(cistore, bstore)
+	require.NoError(t, err)
+	authorities := []address.Address{consensus.AuthorityMinerAddress(owners[0]), consensus.AuthorityMinerAddress(owners[1])}
+	require.Equal(t, authorities, genesisBlock.Authorities)
+
+	rr := consensus.NewRoundRobin(cistore, bstore, testhelpers.NewTestProcessor(), genesisBlock.Cid(), genesisBlock.Authorities, &consensus.MinerKeySignatureValidator{})
+	genTipSet, err := rr.NewValidTipSet(ctx, []*types.Block{genesisBlock})
+	require.NoError(t, err)
+
+	t.Run("accepts a block from the authority for its height", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		blk := testhelpers.NewValidTestBlockFromTipSet(genTipSet, 1, authorities[1])
+		blk.ParentWeight = types.ZeroWeight
+		require.NoError(blk.Sign(signer, owners[1]))
+		ts, err := rr.NewValidTipSet(ctx, []*types.Block{blk})
+		require.NoError(err)
+
+		st, err := state.LoadStateTree(ctx, cistore, genesisBlock.StateRoot, builtin.Actors)
+		require.NoError(err)
+		_, err = rr.RunStateTransition(ctx, ts, []types.TipSet{genTipSet}, st)
+		assert.NoError(err)
+
+		w, err := rr.Weight(ctx, ts, st)
+		require.NoError(err)
+		assert.True(types.NewChainWeight(1).Equal(w))
+	})
+
+	t.Run("rejects a block not signed by the owner of the authority", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		blk := testhelpers.NewValidTestBlockFromTipSet(genTipSet, 1, authorities[1])
+		blk.ParentWeight = types.ZeroWeight
+		require.NoError(blk.Sign(signer, owners[0]))
+		ts, err := rr.NewValidTipSet(ctx, []*types.Block{blk})
+		require.NoError(err)
+
+		st, err := state.LoadStateTree(ctx, cistore, genesisBlock.StateRoot, builtin.Actors)
+		require.NoError(err)
+		_, err = rr.RunStateTransition(ctx, ts, []types.TipSet{genTipSet}, st)
+		assert.Equal(consensus.ErrInvalidBlockSignature, errors.Cause(err))
+	})
+
+	t.Run("rejects a block produced out of turn", func(t *testing.T) {
+		assert := assert.New(t)
+
+		blk := testhelpers.NewValidTestBlockFromTipSet(genTipSet, 1, authorities[0])
+		_, err := rr.NewValidTipSet(ctx, []*types.Block{blk})
+		assert.Equal(consensus.ErrWrongProducer, errors.Cause(err))
+	})
+
+	t.Run("rejects tipsets with more than one block", func(t *testing.T) {
+		assert := assert.New(t)
+
+		blks := []*types.Block{
+			testhelpers.NewValidTestBlockFromTipSet(genTipSet, 1, authorities[1]),
+			testhelpers.NewValidTestBlockFromTipSet(genTipSet, 1, authorities[1]),
+		}
+		_, err := rr.NewValidTipSet(ctx, blks)
+		assert.Error(err)
+	})
+
+	t.Run("rejects blocks that set authorities", func(t *testing.T) {
+		assert := assert.New(t)
+
+		blk := testhelpers.NewValidTestBlockFromTipSet(genTipSet, 1, authorities[1])
+		blk.Authorities = authorities
+		_, err := rr.NewValidTipSet(ctx, []*types.Block{blk})
+		assert.Error(err)
+	})
+
+	t.Run("a chain with fewer null blocks is heavier", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		one := testhelpers.NewValidTestBlockFromTipSet(genTipSet, 1, authorities[1])
+		one.ParentWeight = types.ZeroWeight
+		oneTs := consensus.RequireNewTipSet(require, one)
+		oneW, err := rr.Weight(ctx, oneTs, nil)
+		require.NoError(err)
+
+		// Two blocks on top of genesis, against one block after a null round.
+		two := testhelpers.NewValidTestBlockFromTipSet(oneTs, 2, authorities[0])
+		two.ParentWeight = oneW
+		skipped := testhelpers.NewValidTestBlockFromTipSet(genTipSet, 3, authorities[1])
+		skipped.ParentWeight = types.ZeroWeight
+
+		heavier, err := rr.IsHeavier(ctx, consensus.RequireNewTipSet(require, two), consensus.RequireNewTipSet(require, skipped), nil, nil)
+		require.NoError(err)
+		assert.True(heavier)
+	})
+}
@@ -19,18 +19,18 @@ type TestView struct{}
 var _ PowerTableView = &TestView{}
 
 // Total always returns 1.
-func (tv *TestView) Total(ctx context.Context, st state.Tree, bstore blockstore.Blockstore) (uint64, error) {
-	return uint64(1), nil
+func (tv *TestView) Total(ctx context.Context, st state.Tree, bstore blockstore.Blockstore) (*types.BytesAmount, error) {
+	return types.NewBytesAmount(1), nil
 }
 
 // Miner always returns 1.
-func (tv *TestView) Miner(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (uint64, error) {
-	return uint64(1), nil
+func (tv *TestView) Miner(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (*types.BytesAmount, error) {
+	return types.NewBytesAmount(1), nil
 }
 
 // HasPower always returns true.
-func (tv *TestView) HasPower(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) bool {
-	return true
+func (tv *TestView) HasPower(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (bool, error) {
+	return true, nil
 }
 
 // RequireNewTipSet instantiates and returns a new tipset of the given blocks
@@ -58,18 +58,18 @@ func NewTestPowerTableView(minerPower uint64, totalPower uint64) *TestPowerTable
 }
 
 // Total always returns value that was supplied to NewTestPowerTableView.
-func (tv *TestPowerTableView) Total(ctx context.Context, st state.Tree, bstore blockstore.Blockstore) (uint64, error) {
-	return tv.totalPower, nil
+func (tv *TestPowerTableView) Total(ctx context.Context, st state.Tree, bstore blockstore.Blockstore) (*types.BytesAmount, error) {
+	return types.NewBytesAmount(tv.totalPower), nil
 }
 
 // Miner always returns value that was supplied to NewTestPowerTableView.
-func (tv *TestPowerTableView) Miner(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (uint64, error) {
-	return tv.minerPower, nil
+func (tv *TestPowerTableView) Miner(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (*types.BytesAmount, error) {
+	return types.NewBytesAmount(tv.minerPower), nil
 }
 
 // HasPower always returns true.
-func (tv *TestPowerTableView) HasPower(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) bool {
-	return true
+func (tv *TestPowerTableView) HasPower(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (bool, error) {
+	return true, nil
 }
 
 // TestSignedMessageValidator is a validator that doesn't validate to simplify message creation in tests.
@@ -78,7 +78,17 @@ type TestSignedMessageValidator struct{}
 var _ SignedMessageValidator = (*TestSignedMessageValidator)(nil)
 
 // Validate always returns nil
-func (tsmv *TestSignedMessageValidator) Validate(ctx context.Context, msg *types.SignedMessage, fromActor *actor.Actor) error {
+func (tsmv *TestSignedMessageValidator) Validate(ctx context.Context, msg *types.SignedMessage, fromActor *actor.Actor, fromKey []byte) error {
+	return nil
+}
+
+// TestBlockSignatureValidator is a validator that accepts any block signature to simplify block creation in tests.
+type TestBlockSignatureValidator struct{}
+
+var _ BlockSignatureValidator = (*TestBlockSignatureValidator)(nil)
+
+// ValidateBlockSignature always returns nil
+func (tbsv *TestBlockSignatureValidator) ValidateBlockSignature(ctx context.Context, st state.Tree, bs blockstore.Blockstore, blk *types.Block) error {
 	return nil
 }
 
@@ -0,0 +1,153 @@
+package consensus
+
+import (
+	"bytes"
+	"context"
+	"sort"
+
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	cbor "gx/ipfs/QmRoARq3nkUb13HSKZGepCZSWe5GrVPwx7xURJGZ7KWv9V/go-ipld-cbor"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/governance"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+	"github.com/filecoin-project/go-filecoin/vm/errors"
+)
+
+// applyUpgrades sends the system message that collects the upgrades due at
+// height bh from the governance actor, and migrates the actors they apply to
+// to their new code. Actors that no longer run the code an upgrade migrates
+// from, or whose migration fails, are skipped. States without a governance
+// actor have no upgrades.
+func applyUpgrades(ctx context.Context, st state.Tree, vms vm.StorageMap, bh *types.BlockHeight) error {
+	cachedSt := state.NewCachedStateTree(st)
+
+	govActor, err := cachedSt.GetActor(ctx, address.GovernanceAddress)
+	if state.IsActorNotFoundError(err) {
+		return nil
+	} else if err != nil {
+		return errors.FaultErrorWrap(err, "failed to get governance actor")
+	}
+	code, err := st.GetBuiltinActorCode(govActor.Code)
+	if err != nil {
+		return errors.FaultErrorWrap(err, "failed to get governance actor code")
+	}
+	govCode, ok := code.(*governance.Actor)
+	if !ok {
+		return errors.NewFaultErrorf("governance actor runs %s, which is not governance code", govActor.Code)
+	}
+
+	msg := &types.Message{
+		From:   address.SystemAddress,
+		To:     address.GovernanceAddress,
+		Method: "applyUpgrades",
+	}
+
+	// system messages are not limited by the gas of any sender
+	gasTracker := vm.NewGasTracker()
+	gasTracker.MsgGasLimit = types.BlockGasLimit
+	gasTracker.Schedule = vm.GasScheduleAt(bh)
+
+	vmCtx := vm.NewVMContext(vm.NewContextParams{
+		From:        &actor.Actor{},
+		To:          govActor,
+		Message:     msg,
+		State:       cachedSt,
+		StorageMap:  vms,
+		GasTracker:  gasTracker,
+		BlockHeight: bh,
+	})
+	ret, _, err := vm.Send(ctx, vmCtx)
+	if err != nil {
+		return errors.FaultErrorWrap(err, "failed to apply upgrades")
+	}
+	if err := cachedSt.Commit(ctx); err != nil {
+		return errors.FaultErrorWrap(err, "failed to commit governance actor")
+	}
+
+	var upgrades []governance.Upgrade
+	if err := cbor.DecodeInto(ret[0], &upgrades); err != nil {
+		return errors.FaultErrorWrap(err, "failed to decode upgrades")
+	}
+
+	for _, upgrade := range upgrades {
+		migration, ok := govCode.Migration(upgrade.From, upgrade.To)
+		if !ok {
+			log.Warningf("skipping upgrade from %s to %s: unknown migration", upgrade.From, upgrade.To)
+			continue
+		}
+
+		addrs := []address.Address{upgrade.Actor}
+		if upgrade.Actor.Empty() {
+			addrs, err = actorsRunning(ctx, st, upgrade.From)
+			if err != nil {
+				return err
+			}
+		}
+		for _, addr := range addrs {
+			if err := applyUpgrade(ctx, st, vms, addr, migration); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// actorsRunning returns the addresses of the actors running the given code,
+// in a deterministic order.
+func actorsRunning(ctx context.Context, st state.Tree, code cid.Cid) ([]address.Address, error) {
+	var addrs []address.Address
+	err := st.ForEachActor(ctx, func(addr address.Address, act *actor.Actor) error {
+		if act.Code.Equals(code) {
+			addrs = append(addrs, addr)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.FaultErrorWrapf(err, "failed to find actors running %s", code)
+	}
+
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i].Bytes(), addrs[j].Bytes()) < 0
+	})
+	return addrs, nil
+}
+
+// applyUpgrade migrates an actor to new code, or logs why it cannot. It only
+// returns an error if the state tree cannot be read or written.
+func applyUpgrade(ctx context.Context, st state.Tree, vms vm.StorageMap, addr address.Address, migration *governance.Migration) error {
+	// the actor is a copy, so a failed migration leaves the state tree as it was
+	act, err := st.GetActor(ctx, addr)
+	if state.IsActorNotFoundError(err) {
+		log.Warningf("skipping upgrade of actor %s: actor not found", addr)
+		return nil
+	} else if err != nil {
+		return errors.FaultErrorWrap(err, "failed to get upgraded actor")
+	}
+	if !act.Code.Equals(migration.From) {
+		log.Warningf("skipping upgrade of actor %s: actor runs %s, upgrade applies to %s", addr, act.Code, migration.From)
+		return nil
+	}
+
+	storageOf := func(other address.Address) (exec.Storage, error) {
+		otherAct, err := st.GetActor(ctx, other)
+		if err != nil {
+			return nil, err
+		}
+		return vms.NewStorage(other, otherAct), nil
+	}
+	if err := migration.Migrate(vms.NewStorage(addr, act), storageOf); err != nil {
+		log.Warningf("skipping upgrade of actor %s from %s to %s: migration failed: %s", addr, migration.From, migration.To, err)
+		return nil
+	}
+	act.Code = migration.To
+
+	if err := st.SetActor(ctx, addr, act); err != nil {
+		return errors.FaultErrorWrap(err, "failed to set upgraded actor")
+	}
+	return nil
+}
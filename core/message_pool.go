@@ -23,8 +23,19 @@ type MessagePool struct {
 	lk sync.RWMutex
 
 	pending map[cid.Cid]*types.SignedMessage // all pending messages
+
+	// signatures keeps the signatures of the BLS messages removed from the
+	// pool, so they can be added back if the blocks that aggregated their
+	// signatures are reorged out. removed orders them oldest first, to bound
+	// how many are kept.
+	signatures map[cid.Cid]types.Signature
+	removed    []cid.Cid
 }
 
+// maxRemovedSignatures is the number of signatures of removed BLS messages
+// the pool keeps.
+const maxRemovedSignatures = 10000
+
 // Add adds a message to the pool.
 func (pool *MessagePool) Add(msg *types.SignedMessage) (cid.Cid, error) {
 	pool.lk.Lock()
@@ -61,13 +72,43 @@ func (pool *MessagePool) Remove(c cid.Cid) {
 	pool.lk.Lock()
 	defer pool.lk.Unlock()
 
+	if msg, ok := pool.pending[c]; ok && msg.From.IsBLS() && len(msg.Signature) > 0 {
+		if _, kept := pool.signatures[c]; !kept {
+			pool.removed = append(pool.removed, c)
+		}
+		pool.signatures[c] = msg.Signature
+		for len(pool.removed) > maxRemovedSignatures {
+			delete(pool.signatures, pool.removed[0])
+			pool.removed = pool.removed[1:]
+		}
+	}
 	delete(pool.pending, c)
 }
 
+// withSignature returns the aggregated message msg with the signature it had
+// when it was removed from the pool, or false if the pool does not know it.
+func (pool *MessagePool) withSignature(msg *types.SignedMessage) (*types.SignedMessage, bool, error) {
+	pool.lk.Lock()
+	defer pool.lk.Unlock()
+
+	c, err := msg.Cid()
+	if err != nil {
+		return nil, false, err
+	}
+	sig, ok := pool.signatures[c]
+	if !ok {
+		return nil, false, nil
+	}
+	signed := *msg
+	signed.Signature = sig
+	return &signed, true, nil
+}
+
 // NewMessagePool constructs a new MessagePool.
 func NewMessagePool() *MessagePool {
 	return &MessagePool{
-		pending: make(map[cid.Cid]*types.SignedMessage),
+		pending:    make(map[cid.Cid]*types.SignedMessage),
+		signatures: make(map[cid.Cid]types.Signature),
 	}
 }
 
@@ -201,6 +242,19 @@ func UpdateMessagePool(ctx context.Context, pool *MessagePool, store *hamt.CborI
 
 	// Now actually update the pool.
 	for _, m := range addToPool {
+		// Aggregated messages lost their own signature to the block that
+		// included them, so only those whose signature the pool kept can be
+		// added back.
+		if m.IsAggregated() {
+			signed, ok, err := pool.withSignature(m)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			m = signed
+		}
 		_, err := pool.Add(m)
 		if err != nil {
 			return err
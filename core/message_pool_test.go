@@ -12,7 +12,9 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/filecoin-project/go-filecoin/address"
+	bls "github.com/filecoin-project/go-filecoin/bls-signatures"
 	"github.com/filecoin-project/go-filecoin/types"
+	wutil "github.com/filecoin-project/go-filecoin/wallet/util"
 )
 
 var seed = types.GenerateKeyInfoSeed()
@@ -398,6 +400,54 @@ func TestUpdateMessagePool(t *testing.T) {
 		UpdateMessagePool(ctx, p, store, oldTipSet, newTipSet)
 		assertPoolEquals(assert, p)
 	})
+
+	t.Run("Replace head re-adds the aggregated BLS messages the pool knew", func(t *testing.T) {
+		// Msg pool: [m0, m1],     Chain: b[]
+		// to
+		// Msg pool: [],           Chain: b[] -> b[m0, m1, m2] (aggregated)
+		// to
+		// Msg pool: [m0, m1],     Chain: b[] -> b[]
+		require := require.New(t)
+		store := hamt.NewCborStore()
+		p := NewMessagePool()
+
+		m := msgs{newBLSSignedMessage(t), newBLSSignedMessage(t), newBLSSignedMessage(t)}
+		MustAdd(p, m[0], m[1])
+		aggregated, _, err := types.AggregateBLSSignatures(m)
+		require.NoError(err)
+
+		root := NewChainWithMessages(store, types.TipSet{}, msgsSet{msgs{}})
+		oldChain := NewChainWithMessages(store, root[0], msgsSet{aggregated})
+		oldTipSet := headOf(oldChain)
+
+		require.NoError(UpdateMessagePool(ctx, p, store, root[0], oldTipSet))
+		assertPoolEquals(assert, p)
+
+		newChain := NewChainWithMessages(store, root[0], msgsSet{msgs{}})
+		newTipSet := headOf(newChain)
+
+		require.NoError(UpdateMessagePool(ctx, p, store, oldTipSet, newTipSet))
+		assertPoolEquals(assert, p, m[0], m[1])
+		for _, msg := range p.Pending() {
+			assert.True(msg.VerifySignature())
+		}
+	})
+}
+
+func newBLSSignedMessage(t *testing.T) *types.SignedMessage {
+	prv := bls.PrivateKeyGenerate()
+	ki := &types.KeyInfo{PrivateKey: prv[:], Curve: types.BLS}
+	from, err := ki.Address()
+	require.NoError(t, err)
+
+	msg := types.NewMessage(from, address.NewForTestGetter()(), 0, types.NewAttoFILFromFIL(1), "", nil)
+	meteredMsg := types.NewMeteredMessage(*msg, types.NewGasPrice(0), types.NewGasUnits(0))
+	bmsg, err := meteredMsg.Marshal()
+	require.NoError(t, err)
+	sig, err := wutil.SignBLS(ki.Key(), bmsg)
+	require.NoError(t, err)
+
+	return &types.SignedMessage{MeteredMessage: *meteredMsg, Signature: sig}
 }
 
 func TestOrderMessagesByNonce(t *testing.T) {
@@ -20,7 +20,8 @@ func (e Error) Error() string { return string(e) }
 const (
 	// ErrDecode indicates that a chunk an actor tried to write could not be decoded
 	ErrDecode = 33
-	// ErrDanglingPointer indicates that an actor attempted to commit a pointer to a non-existent chunk
+	// ErrDanglingPointer indicates that an actor attempted to commit a pointer to a non-existent chunk,
+	// or to a chunk outside its own storage
 	ErrDanglingPointer = 34
 	// ErrStaleHead indicates that an actor attempted to commit over a stale chunk
 	ErrStaleHead = 35
@@ -72,22 +73,31 @@ type VMContext interface {
 	Send(to address.Address, method string, value *types.AttoFIL, params []interface{}) ([][]byte, uint8, error)
 	AddressForNewActor() (address.Address, error)
 	BlockHeight() *types.BlockHeight
+	MyBalance() *types.AttoFIL
 	IsFromAccountActor() bool
 	Charge(cost types.GasUnits) error
+	VerifySignature(data []byte, signer address.Address, sig types.Signature) (bool, error)
+	EmitEvent(topic string, values ...interface{}) error
+	Rand(sampleHeight *types.BlockHeight) ([]byte, error)
 
 	CreateNewActor(addr address.Address, code cid.Cid, initalizationParams interface{}) error
-
-	// TODO: Remove these when Storage above is completely implemented
-	ReadStorage() ([]byte, error)
-	WriteStorage(interface{}) error
 }
 
-// Storage defines the storage module exposed to actors.
+// Storage defines the storage module exposed to actors. Chunks put in storage
+// are staged until they are reachable from a committed head.
 type Storage interface {
-	// TODO: Forgot that Put() can fail in the spec, need to update.
+	// Put stages a chunk and returns its id. Values that are not already
+	// encoded are encoded as cbor. It fails if the value cannot be encoded.
 	Put(interface{}) (cid.Cid, error)
+	// Get returns the encoded chunk with the given id.
 	Get(cid.Cid) ([]byte, error)
-	Commit(cid.Cid, cid.Cid) error
+	// GetObject decodes the chunk with the given id into the given value.
+	GetObject(cid.Cid, interface{}) error
+	// Commit replaces the head of the actor's storage, which must be the
+	// given old id, by the given new id. It fails if any chunk reachable from
+	// the new id is neither staged nor already part of the actor's storage.
+	Commit(newID cid.Cid, oldID cid.Cid) error
+	// Head returns the id of the root chunk of the actor's storage.
 	Head() cid.Cid
 }
 
@@ -167,7 +167,7 @@ function wait_mpool_size {
 
 function add_ask {
   ./go-filecoin miner add-ask "$1" "$2" "$3" \
-    --price=0 --limit=300 \
+    --price=0 --limit=500 \
     --repodir="$4"
 }
 
@@ -41,7 +41,8 @@ type Miner struct {
 	// PeerID is the peer ID to set as the miners owner
 	PeerID string
 
-	// Power is the amount of power this miner should start off with
+	// Power is the number of sectors this miner should start off with
+	// committed. Its power in bytes is this many times the sector size.
 	// TODO: this will get more complicated when we actually have to
 	// prove real files
 	Power uint64
@@ -59,6 +60,19 @@ type GenesisCfg struct {
 
 	// Miners is a list of miners that should be set up at the start of the network
 	Miners []Miner
+
+	// RoundRobin, when set, creates a round-robin proof-of-authority chain
+	// in which the miners take turns producing blocks in the order listed.
+	RoundRobin bool
+
+	// Governor, when set, is the key allowed to schedule upgrades of the
+	// code of existing actors. Upgrades are disabled without a governor.
+	Governor *int
+
+	// Network is the network of the ID addresses assigned to actors, "fc"
+	// for the main network or "tf" for the test network. It defaults to the
+	// main network.
+	Network string
 }
 
 // RenderedGenInfo contains information about a genesis block creation
@@ -81,7 +95,7 @@ type RenderedMinerInfo struct {
 	// Address is the address generated on-chain for the miner
 	Address address.Address
 
-	// Power is the amount of storage power this miner was created with
+	// Power is the number of sectors this miner was created with
 	Power uint64
 }
 
@@ -100,7 +114,26 @@ func GenGen(ctx context.Context, cfg *GenesisCfg, cst *hamt.CborIpldStore, bs bl
 	st := state.NewEmptyStateTreeWithActors(cst, builtin.Actors)
 	storageMap := vm.NewStorageMap(bs)
 
-	if err := consensus.SetupDefaultActors(ctx, st, storageMap); err != nil {
+	var governor address.Address
+	if cfg.Governor != nil {
+		if *cfg.Governor < 0 || *cfg.Governor >= len(keys) {
+			return nil, fmt.Errorf("governor key %d does not exist", *cfg.Governor)
+		}
+		governor, err = keys[*cfg.Governor].Address()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	network := address.Mainnet
+	if cfg.Network != "" {
+		network, err = address.NetworkFromString(cfg.Network)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := consensus.SetupDefaultActors(ctx, st, storageMap, network, governor, consensus.DefaultSectorSize()); err != nil {
 		return nil, err
 	}
 
@@ -113,6 +146,10 @@ func GenGen(ctx context.Context, cfg *GenesisCfg, cst *hamt.CborIpldStore, bs bl
 		return nil, err
 	}
 
+	if err := consensus.AssignActorIDs(ctx, st, storageMap); err != nil {
+		return nil, err
+	}
+
 	if err := cst.Blocks.AddBlock(types.StorageMarketActorCodeObj); err != nil {
 		return nil, err
 	}
@@ -128,6 +165,12 @@ func GenGen(ctx context.Context, cfg *GenesisCfg, cst *hamt.CborIpldStore, bs bl
 	if err := cst.Blocks.AddBlock(types.PaymentBrokerActorCodeObj); err != nil {
 		return nil, err
 	}
+	if err := cst.Blocks.AddBlock(types.InitActorCodeObj); err != nil {
+		return nil, err
+	}
+	if err := cst.Blocks.AddBlock(types.GovernanceActorCodeObj); err != nil {
+		return nil, err
+	}
 
 	stateRoot, err := st.Flush(ctx)
 	if err != nil {
@@ -142,6 +185,14 @@ func GenGen(ctx context.Context, cfg *GenesisCfg, cst *hamt.CborIpldStore, bs bl
 	geneblk := &types.Block{
 		StateRoot: stateRoot,
 	}
+	if cfg.RoundRobin {
+		if len(miners) == 0 {
+			return nil, fmt.Errorf("round-robin consensus requires at least one miner")
+		}
+		for _, m := range miners {
+			geneblk.Authorities = append(geneblk.Authorities, m.Address)
+		}
+	}
 
 	c, err := cst.Put(ctx, geneblk)
 	if err != nil {
@@ -285,7 +336,7 @@ func setupMiners(st state.Tree, sm vm.StorageMap, keys []*types.KeyInfo, miners
 			if _, err := pnrg.Read(sealProof[:]); err != nil {
 				return nil, err
 			}
-			_, err := applyMessageDirect(ctx, st, sm, addr, maddr, types.NewAttoFILFromFIL(0), "commitSector", sectorID, commD, commR, commRStar, sealProof)
+			_, err := applyMessageDirect(ctx, st, sm, addr, maddr, types.NewAttoFILFromFIL(0), "commitSector", sectorID, commD, commR, commRStar, sealProof, []uint64{})
 			if err != nil {
 				return nil, err
 			}
@@ -353,7 +404,7 @@ type messageValidator struct{}
 var _ consensus.SignedMessageValidator = (*messageValidator)(nil)
 
 // Validate always returns nil
-func (ggmv *messageValidator) Validate(ctx context.Context, msg *types.SignedMessage, fromActor *actor.Actor) error {
+func (ggmv *messageValidator) Validate(ctx context.Context, msg *types.SignedMessage, fromActor *actor.Actor, fromKey []byte) error {
 	return nil
 }
 
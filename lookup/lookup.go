@@ -10,8 +10,9 @@ import (
 	"gx/ipfs/QmS2aqUZLJp8kF1ihE5rvDGE5LvmKDPnx32w9Z1BW9xLV5/go-ipfs-blockstore"
 	"gx/ipfs/QmY5Grm8pJdiSSVsYxx4uNRgweY72EmYwuSDbRnbFok3iY/go-libp2p-peer"
 
+	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
 	"github.com/filecoin-project/go-filecoin/chain"
-	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/types"
 	"github.com/filecoin-project/go-filecoin/vm"
 )
 
@@ -22,48 +23,40 @@ type PeerLookupService interface {
 
 // ChainLookupService is a ChainManager-backed implementation of the PeerLookupService interface.
 type ChainLookupService struct {
-	chainReader            chain.ReadStore
-	bstore                 blockstore.Blockstore
-	queryMethodFromAddress func() (address.Address, error)
+	chainReader chain.ReadStore
+	bstore      blockstore.Blockstore
 }
 
 var _ PeerLookupService = &ChainLookupService{}
 
-// NewChainLookupService creates a new ChainLookupService from a ChainStore and a Wallet.
-func NewChainLookupService(chain chain.ReadStore, queryMethodFromAddr func() (address.Address, error), bstore blockstore.Blockstore) *ChainLookupService {
+// NewChainLookupService creates a new ChainLookupService from a ChainStore and a Blockstore.
+func NewChainLookupService(chain chain.ReadStore, bstore blockstore.Blockstore) *ChainLookupService {
 	return &ChainLookupService{
-		chainReader:            chain,
-		bstore:                 bstore,
-		queryMethodFromAddress: queryMethodFromAddr,
+		chainReader: chain,
+		bstore:      bstore,
 	}
 }
 
-// GetPeerIDByMinerAddress attempts to get a miner's libp2p identity by loading the actor from the state tree and sending
-// it a "getPeerID" message. The MinerActor is currently the only type of actor which has a peer ID.
+// GetPeerIDByMinerAddress attempts to get a miner's libp2p identity by loading the actor from the state tree and reading
+// the peer ID from its state. The MinerActor is currently the only type of actor which has a peer ID.
 func (c *ChainLookupService) GetPeerIDByMinerAddress(ctx context.Context, minerAddr address.Address) (peer.ID, error) {
 	st, err := c.chainReader.LatestState(ctx)
 	if err != nil {
 		return peer.ID(""), errors.Wrap(err, "failed to load state tree")
 	}
-	addr, err := c.queryMethodFromAddress()
-	if err != nil {
-		return peer.ID(""), errors.Wrap(err, "failed to obtain a default from-address")
-	}
 
-	vms := vm.NewStorageMap(c.bstore)
-	retValue, retCode, err := consensus.CallQueryMethod(ctx, st, vms, minerAddr, "getPeerID", []byte{}, addr, nil)
+	minerActor, err := st.GetActor(ctx, minerAddr)
 	if err != nil {
-		return peer.ID(""), errors.Wrapf(err, "failed to query local state tree(from %s, miner %s)", addr.String(), minerAddr.String())
+		return peer.ID(""), errors.Wrapf(err, "failed to get actor %s", minerAddr.String())
 	}
-
-	if retCode != 0 {
-		return peer.ID(""), errors.Wrap(err, "non-zero status code from getPeerID")
+	if !minerActor.Code.Equals(types.MinerActorCodeCid) && !minerActor.Code.Equals(types.BootstrapMinerActorCodeCid) {
+		return peer.ID(""), errors.Errorf("actor %s is not a miner", minerAddr.String())
 	}
 
-	pid, err := peer.IDFromBytes(retValue[0])
+	state, err := miner.LoadState(vm.NewStorage(c.bstore, minerActor))
 	if err != nil {
-		return peer.ID(""), errors.Wrap(err, "could not decode to peer.ID from message-bytes")
+		return peer.ID(""), errors.Wrapf(err, "failed to load state of miner %s", minerAddr.String())
 	}
 
-	return pid, nil
+	return state.PeerID, nil
 }
@@ -169,14 +169,13 @@ func TestHeartbeatRunSuccess(t *testing.T) {
 
 func mustMakeTipset(t *testing.T, height types.Uint64) types.TipSet {
 	ts, err := types.NewTipSet(&types.Block{
-		Miner:           address.NewForTestGetter()(),
-		Ticket:          nil,
-		Parents:         types.SortedCidSet{},
-		ParentWeight:    0,
-		Height:          types.Uint64(height),
-		Nonce:           0,
-		Messages:        nil,
-		MessageReceipts: nil,
+		Miner:        address.NewForTestGetter()(),
+		Ticket:       nil,
+		Parents:      types.SortedCidSet{},
+		ParentWeight: types.ZeroWeight,
+		Height:       types.Uint64(height),
+		Nonce:        0,
+		Messages:     nil,
 	})
 	if err != nil {
 		t.Fatal(err)
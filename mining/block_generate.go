@@ -10,6 +10,7 @@ import (
 
 	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
 
+	"github.com/filecoin-project/go-filecoin/consensus"
 	"github.com/filecoin-project/go-filecoin/core"
 	"github.com/filecoin-project/go-filecoin/proofs"
 	"github.com/filecoin-project/go-filecoin/types"
@@ -33,7 +34,11 @@ func (w *DefaultWorker) Generate(ctx context.Context,
 		return nil, errors.Wrap(err, "get state tree")
 	}
 
-	if !w.powerTable.HasPower(ctx, stateTree, w.blockstore, w.minerAddr) {
+	hasPower, err := w.powerTable.HasPower(ctx, stateTree, w.blockstore, w.minerAddr)
+	if err != nil {
+		return nil, errors.Wrap(err, "get miner power")
+	}
+	if !hasPower {
 		return nil, errors.Errorf("bad miner address, miner must store files before mining: %s", w.minerAddr)
 	}
 
@@ -79,18 +84,35 @@ func (w *DefaultWorker) Generate(ctx context.Context,
 		receipts = append(receipts, r.Receipt)
 	}
 
+	receiptsRoot, err := consensus.ReceiptsRoot(ctx, w.cstore, receipts)
+	if err != nil {
+		return nil, errors.Wrap(err, "generate receipts root")
+	}
+
+	// messages from BLS addresses are included without their signatures,
+	// which the block carries aggregated into one
+	blockMessages, blsAggregate, err := types.AggregateBLSSignatures(res.SuccessfulMessages)
+	if err != nil {
+		return nil, errors.Wrap(err, "generate aggregate BLS signatures")
+	}
+
 	next := &types.Block{
 		Miner:           w.minerAddr,
 		Height:          types.Uint64(blockHeight),
-		Messages:        res.SuccessfulMessages,
-		MessageReceipts: receipts,
+		Messages:        blockMessages,
+		BLSAggregate:    blsAggregate,
+		MessageReceipts: receiptsRoot,
 		Parents:         baseTipSet.ToSortedCidSet(),
-		ParentWeight:    types.Uint64(weight),
+		ParentWeight:    weight,
 		Proof:           proof,
 		StateRoot:       newStateTreeCid,
 		Ticket:          ticket,
 	}
 
+	if err := next.Sign(w.signer, w.minerOwnerAddr); err != nil {
+		return nil, errors.Wrap(err, "generate sign block")
+	}
+
 	// TODO: Should we really be pruning the message pool here at all? Maybe this should happen elsewhere.
 	for i, msg := range res.PermanentFailures {
 		// We will not be able to apply this message in the future because the error was permanent.
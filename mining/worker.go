@@ -51,9 +51,8 @@ type Worker interface {
 // its own function to facilitate testing.
 type GetStateTree func(context.Context, types.TipSet) (state.Tree, error)
 
-// GetWeight is a function that calculates the weight of a TipSet.  Weight is
-// expressed as two uint64s comprising a rational number.
-type GetWeight func(context.Context, types.TipSet) (uint64, error)
+// GetWeight is a function that calculates the weight of a TipSet.
+type GetWeight func(context.Context, types.TipSet) (*types.ChainWeight, error)
 
 // GetAncestors is a function that returns the necessary ancestor chain to
 // process the input tipset.
@@ -70,6 +69,10 @@ type DefaultWorker struct {
 	createPoST DoSomeWorkFunc  // TODO: rename createPoSTFunc
 	minerAddr  address.Address // TODO: needs to be a key in the near future
 
+	// minerOwnerAddr signs the blocks the worker generates with signer.
+	minerOwnerAddr address.Address
+	signer         types.Signer
+
 	// consensus things
 	getStateTree GetStateTree
 	getWeight    GetWeight
@@ -82,32 +85,45 @@ type DefaultWorker struct {
 	blockstore  blockstore.Blockstore
 	cstore      *hamt.CborIpldStore
 	blockTime   time.Duration
+
+	// authorities, when set, are the block producers of a round-robin
+	// proof-of-authority chain.
+	authorities []address.Address
 }
 
 // NewDefaultWorker instantiates a new Worker.
-func NewDefaultWorker(messagePool *core.MessagePool, getStateTree GetStateTree, getWeight GetWeight, getAncestors GetAncestors, processor MessageApplier, powerTable consensus.PowerTableView, bs blockstore.Blockstore, cst *hamt.CborIpldStore, miner address.Address, bt time.Duration) *DefaultWorker {
-	w := NewDefaultWorkerWithDeps(messagePool, getStateTree, getWeight, getAncestors, processor, powerTable, bs, cst, miner, bt, func() {})
+func NewDefaultWorker(messagePool *core.MessagePool, getStateTree GetStateTree, getWeight GetWeight, getAncestors GetAncestors, processor MessageApplier, powerTable consensus.PowerTableView, bs blockstore.Blockstore, cst *hamt.CborIpldStore, miner address.Address, minerOwner address.Address, signer types.Signer, bt time.Duration) *DefaultWorker {
+	w := NewDefaultWorkerWithDeps(messagePool, getStateTree, getWeight, getAncestors, processor, powerTable, bs, cst, miner, minerOwner, signer, bt, func() {})
 	w.createPoST = w.fakeCreatePoST
 	return w
 }
 
 // NewDefaultWorkerWithDeps instantiates a new Worker with custom functions.
-func NewDefaultWorkerWithDeps(messagePool *core.MessagePool, getStateTree GetStateTree, getWeight GetWeight, getAncestors GetAncestors, processor MessageApplier, powerTable consensus.PowerTableView, bs blockstore.Blockstore, cst *hamt.CborIpldStore, miner address.Address, bt time.Duration, createPoST DoSomeWorkFunc) *DefaultWorker {
+func NewDefaultWorkerWithDeps(messagePool *core.MessagePool, getStateTree GetStateTree, getWeight GetWeight, getAncestors GetAncestors, processor MessageApplier, powerTable consensus.PowerTableView, bs blockstore.Blockstore, cst *hamt.CborIpldStore, miner address.Address, minerOwner address.Address, signer types.Signer, bt time.Duration, createPoST DoSomeWorkFunc) *DefaultWorker {
 	return &DefaultWorker{
-		getStateTree: getStateTree,
-		getWeight:    getWeight,
-		getAncestors: getAncestors,
-		messagePool:  messagePool,
-		processor:    processor,
-		powerTable:   powerTable,
-		blockstore:   bs,
-		cstore:       cst,
-		createPoST:   createPoST,
-		minerAddr:    miner,
-		blockTime:    bt,
+		getStateTree:   getStateTree,
+		getWeight:      getWeight,
+		getAncestors:   getAncestors,
+		messagePool:    messagePool,
+		processor:      processor,
+		powerTable:     powerTable,
+		blockstore:     bs,
+		cstore:         cst,
+		createPoST:     createPoST,
+		minerAddr:      miner,
+		minerOwnerAddr: minerOwner,
+		signer:         signer,
+		blockTime:      bt,
 	}
 }
 
+// SetAuthorities switches the worker to round-robin proof-of-authority
+// mining: instead of checking its ticket against its power the worker
+// produces a block only at heights where its miner is the authority.
+func (w *DefaultWorker) SetAuthorities(authorities []address.Address) {
+	w.authorities = authorities
+}
+
 // DoSomeWorkFunc is a dummy function that mimics doing something time-consuming
 // in the mining loop such as computing proofs. Pass a function that calls Sleep()
 // is a good idea for now.
@@ -160,8 +176,7 @@ func (w *DefaultWorker) Mine(ctx context.Context, base types.TipSet, nullBlkCoun
 		ticket = consensus.CreateTicket(proof, w.minerAddr)
 	}
 
-	// TODO: Test the interplay of isWinningTicket() and createPoST()
-	weHaveAWinner, err := consensus.IsWinningTicket(ctx, w.blockstore, w.powerTable, st, ticket, w.minerAddr)
+	weHaveAWinner, err := w.isWinner(ctx, base, nullBlkCount, st, ticket)
 
 	if err != nil {
 		log.Errorf("Worker.Mine couldn't compute ticket: %s", err.Error())
@@ -182,6 +197,21 @@ func (w *DefaultWorker) Mine(ctx context.Context, base types.TipSet, nullBlkCoun
 	return false
 }
 
+// isWinner reports whether the worker's miner may produce the next block.
+// TODO: Test the interplay of isWinningTicket() and createPoST()
+func (w *DefaultWorker) isWinner(ctx context.Context, base types.TipSet, nullBlkCount int, st state.Tree, ticket types.Signature) (bool, error) {
+	if len(w.authorities) == 0 {
+		return consensus.IsWinningTicket(ctx, w.blockstore, w.powerTable, st, ticket, w.minerAddr)
+	}
+
+	baseHeight, err := base.Height()
+	if err != nil {
+		return false, err
+	}
+	height := baseHeight + uint64(nullBlkCount) + 1
+	return consensus.RoundRobinProducer(w.authorities, height) == w.minerAddr, nil
+}
+
 // TODO: Actually use the results of the PoST once it is implemented.
 // Currently createProof just passes the challenge seed through.
 func createProof(challengeSeed proofs.PoStChallengeSeed, createPoST DoSomeWorkFunc) <-chan proofs.PoStChallengeSeed {
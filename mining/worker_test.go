@@ -41,7 +41,7 @@ func Test_Mine(t *testing.T) {
 
 	// Success case. TODO: this case isn't testing much.  Testing w.Mine
 	// further needs a lot more attention.
-	worker := NewDefaultWorker(pool, getStateTree, getWeightTest, getAncestors, th.NewTestProcessor(), NewTestPowerTableView(1), bs, cst, addrs[3], th.BlockTimeTest)
+	worker := NewDefaultWorker(pool, getStateTree, getWeightTest, getAncestors, th.NewTestProcessor(), NewTestPowerTableView(1), bs, cst, addrs[3], addrs[4], &mockSigner, th.BlockTimeTest)
 
 	outCh := make(chan Output)
 	doSomeWorkCalled := false
@@ -53,7 +53,7 @@ func Test_Mine(t *testing.T) {
 	cancel()
 	// Block generation fails.
 	ctx, cancel = context.WithCancel(context.Background())
-	worker = NewDefaultWorker(pool, makeExplodingGetStateTree(st), getWeightTest, getAncestors, th.NewTestProcessor(), NewTestPowerTableView(1), bs, cst, addrs[3], th.BlockTimeTest)
+	worker = NewDefaultWorker(pool, makeExplodingGetStateTree(st), getWeightTest, getAncestors, th.NewTestProcessor(), NewTestPowerTableView(1), bs, cst, addrs[3], addrs[4], &mockSigner, th.BlockTimeTest)
 	outCh = make(chan Output)
 	doSomeWorkCalled = false
 	worker.createPoST = func() { doSomeWorkCalled = true }
@@ -65,7 +65,7 @@ func Test_Mine(t *testing.T) {
 
 	// Sent empty tipset
 	ctx, cancel = context.WithCancel(context.Background())
-	worker = NewDefaultWorker(pool, getStateTree, getWeightTest, getAncestors, th.NewTestProcessor(), NewTestPowerTableView(1), bs, cst, addrs[3], th.BlockTimeTest)
+	worker = NewDefaultWorker(pool, getStateTree, getWeightTest, getAncestors, th.NewTestProcessor(), NewTestPowerTableView(1), bs, cst, addrs[3], addrs[4], &mockSigner, th.BlockTimeTest)
 	outCh = make(chan Output)
 	doSomeWorkCalled = false
 	worker.createPoST = func() { doSomeWorkCalled = true }
@@ -77,6 +77,28 @@ func Test_Mine(t *testing.T) {
 	cancel()
 }
 
+func TestIsWinnerRoundRobin(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	baseBlock := &types.Block{Height: 2, StateRoot: types.SomeCid()}
+	tipSet := th.RequireNewTipSet(require, baseBlock)
+
+	st, pool, addrs, cst, bs := sharedSetup(t)
+	worker := NewDefaultWorker(pool, nil, getWeightTest, nil, th.NewTestProcessor(), NewTestPowerTableView(1), bs, cst, addrs[3], addrs[4], &mockSigner, th.BlockTimeTest)
+	worker.SetAuthorities([]address.Address{addrs[3], addrs[4]})
+
+	// Height 3 belongs to the second authority, height 4 to the worker.
+	won, err := worker.isWinner(ctx, tipSet, 0, st, nil)
+	require.NoError(err)
+	assert.False(won)
+
+	won, err = worker.isWinner(ctx, tipSet, 1, st, nil)
+	require.NoError(err)
+	assert.True(won)
+}
+
 var seed = types.GenerateKeyInfoSeed()
 var ki = types.MustGenerateKeyInfo(10, seed)
 var mockSigner = types.NewMockSigner(ki)
@@ -193,20 +215,20 @@ func TestGenerateMultiBlockTipSet(t *testing.T) {
 	getAncestors := func(ctx context.Context, ts types.TipSet, newBlockHeight *types.BlockHeight) ([]types.TipSet, error) {
 		return nil, nil
 	}
-	worker := NewDefaultWorker(pool, getStateTree, getWeightTest, getAncestors, th.NewTestProcessor(), &th.TestView{}, bs, cst, addrs[3], th.BlockTimeTest)
+	worker := NewDefaultWorker(pool, getStateTree, getWeightTest, getAncestors, th.NewTestProcessor(), &th.TestView{}, bs, cst, addrs[3], addrs[4], &mockSigner, th.BlockTimeTest)
 
 	parents := types.NewSortedCidSet(newCid())
 	stateRoot := newCid()
 	baseBlock1 := types.Block{
 		Parents:      parents,
 		Height:       types.Uint64(100),
-		ParentWeight: types.Uint64(1000),
+		ParentWeight: types.NewChainWeight(1000),
 		StateRoot:    stateRoot,
 	}
 	baseBlock2 := types.Block{
 		Parents:      parents,
 		Height:       types.Uint64(100),
-		ParentWeight: types.Uint64(1000),
+		ParentWeight: types.NewChainWeight(1000),
 		StateRoot:    stateRoot,
 		Nonce:        1,
 	}
@@ -215,7 +237,8 @@ func TestGenerateMultiBlockTipSet(t *testing.T) {
 
 	assert.Len(blk.Messages, 0)
 	assert.Equal(types.Uint64(101), blk.Height)
-	assert.Equal(types.Uint64(1020), blk.ParentWeight)
+	assert.True(types.NewChainWeight(1020).Equal(blk.ParentWeight))
+	assert.True(blk.VerifySignature(addrs[4], nil))
 }
 
 // After calling Generate, do the new block and new state of the message pool conform to our expectations?
@@ -233,7 +256,7 @@ func TestGeneratePoolBlockResults(t *testing.T) {
 	getAncestors := func(ctx context.Context, ts types.TipSet, newBlockHeight *types.BlockHeight) ([]types.TipSet, error) {
 		return nil, nil
 	}
-	worker := NewDefaultWorker(pool, getStateTree, getWeightTest, getAncestors, consensus.NewDefaultProcessor(), &th.TestView{}, bs, cst, addrs[3], th.BlockTimeTest)
+	worker := NewDefaultWorker(pool, getStateTree, getWeightTest, getAncestors, consensus.NewDefaultProcessor(), &th.TestView{}, bs, cst, addrs[3], addrs[4], &mockSigner, th.BlockTimeTest)
 
 	// addr3 doesn't correspond to an extant account, so this will trigger errAccountNotFound -- a temporary failure.
 	msg1 := types.NewMessage(addrs[2], addrs[0], 0, nil, "", nil)
@@ -293,10 +316,10 @@ func TestGenerateSetsBasicFields(t *testing.T) {
 	getAncestors := func(ctx context.Context, ts types.TipSet, newBlockHeight *types.BlockHeight) ([]types.TipSet, error) {
 		return nil, nil
 	}
-	worker := NewDefaultWorker(pool, getStateTree, getWeightTest, getAncestors, consensus.NewDefaultProcessor(), &th.TestView{}, bs, cst, addrs[3], th.BlockTimeTest)
+	worker := NewDefaultWorker(pool, getStateTree, getWeightTest, getAncestors, consensus.NewDefaultProcessor(), &th.TestView{}, bs, cst, addrs[3], addrs[4], &mockSigner, th.BlockTimeTest)
 
 	h := types.Uint64(100)
-	w := types.Uint64(1000)
+	w := types.NewChainWeight(1000)
 	baseBlock := types.Block{
 		Height:       h,
 		ParentWeight: w,
@@ -314,7 +337,7 @@ func TestGenerateSetsBasicFields(t *testing.T) {
 	assert.NoError(err)
 
 	assert.Equal(h+2, blk.Height)
-	assert.Equal(w+10.0, blk.ParentWeight)
+	assert.True(w.Add(types.NewChainWeight(10)).Equal(blk.ParentWeight))
 	assert.Equal(addrs[3], blk.Miner)
 }
 
@@ -332,7 +355,7 @@ func TestGenerateWithoutMessages(t *testing.T) {
 	getAncestors := func(ctx context.Context, ts types.TipSet, newBlockHeight *types.BlockHeight) ([]types.TipSet, error) {
 		return nil, nil
 	}
-	worker := NewDefaultWorker(pool, getStateTree, getWeightTest, getAncestors, consensus.NewDefaultProcessor(), &th.TestView{}, bs, cst, addrs[3], th.BlockTimeTest)
+	worker := NewDefaultWorker(pool, getStateTree, getWeightTest, getAncestors, consensus.NewDefaultProcessor(), &th.TestView{}, bs, cst, addrs[3], addrs[4], &mockSigner, th.BlockTimeTest)
 
 	assert.Len(pool.Pending(), 0)
 	baseBlock := types.Block{
@@ -360,7 +383,7 @@ func TestGenerateError(t *testing.T) {
 	getAncestors := func(ctx context.Context, ts types.TipSet, newBlockHeight *types.BlockHeight) ([]types.TipSet, error) {
 		return nil, nil
 	}
-	worker := NewDefaultWorker(pool, makeExplodingGetStateTree(st), getWeightTest, getAncestors, consensus.NewDefaultProcessor(), &th.TestView{}, bs, cst, addrs[3], th.BlockTimeTest)
+	worker := NewDefaultWorker(pool, makeExplodingGetStateTree(st), getWeightTest, getAncestors, consensus.NewDefaultProcessor(), &th.TestView{}, bs, cst, addrs[3], addrs[4], &mockSigner, th.BlockTimeTest)
 
 	// This is actually okay and should result in a receipt
 	msg := types.NewMessage(addrs[0], addrs[1], 0, nil, "", nil)
@@ -400,12 +423,12 @@ func (st *StateTreeForTest) Flush(ctx context.Context) (cid.Cid, error) {
 	return st.TestFlush(ctx)
 }
 
-func getWeightTest(c context.Context, ts types.TipSet) (uint64, error) {
+func getWeightTest(c context.Context, ts types.TipSet) (*types.ChainWeight, error) {
 	w, err := ts.ParentWeight()
 	if err != nil {
-		return uint64(0), err
+		return nil, err
 	}
-	return w + uint64(len(ts))*consensus.ECV, nil
+	return w.Add(types.NewChainWeight(uint64(len(ts)) * consensus.ECV)), nil
 }
 
 func makeExplodingGetStateTree(st state.Tree) func(context.Context, types.TipSet) (state.Tree, error) {
@@ -7,6 +7,9 @@ import (
 	"gx/ipfs/QmVRxA4J3UPQpw74dLrQ6NJkfysCA1H4GU28gVpXQt9zMU/go-libp2p-pubsub"
 	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
 
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/porcelain"
 	"github.com/filecoin-project/go-filecoin/types"
 )
 
@@ -54,3 +57,38 @@ func (node *Node) processBlock(ctx context.Context, pubSubMsg *pubsub.Message) (
 
 	return nil
 }
+
+// consensusFaultReporter returns a chain.FaultReporter that submits evidence
+// of a consensus fault to the storage market from the node's default address,
+// paying gasPrice for the gas a preview of the message uses.
+func consensusFaultReporter(api *porcelain.API, gasPrice types.AttoFIL) chain.FaultReporter {
+	return func(ctx context.Context, first, second *types.Block) {
+		from, err := api.GetAndMaybeSetDefaultSenderAddress()
+		if err != nil {
+			log.Errorf("failed to get address to submit consensus fault by miner %s: %s", first.Miner, err)
+			return
+		}
+
+		block1, block2 := first.ToNode().RawData(), second.ToNode().RawData()
+		gasUnits, err := api.MessagePreview(ctx, from, address.StorageMarketAddress, "slashConsensusFault", block1, block2)
+		if err != nil {
+			log.Errorf("failed to preview consensus fault by miner %s: %s", first.Miner, err)
+			return
+		}
+
+		_, err = api.MessageSend(
+			ctx,
+			from,
+			address.StorageMarketAddress,
+			types.NewZeroAttoFIL(),
+			gasPrice,
+			gasUnits,
+			"slashConsensusFault",
+			block1,
+			block2,
+		)
+		if err != nil {
+			log.Errorf("failed to submit consensus fault by miner %s: %s", first.Miner, err)
+		}
+	}
+}
@@ -69,7 +69,7 @@ func TestBlockPropsManyNodes(t *testing.T) {
 		Miner:        minerAddr,
 		Parents:      baseTS.ToSortedCidSet(),
 		Height:       types.Uint64(1),
-		ParentWeight: types.Uint64(10000),
+		ParentWeight: types.NewChainWeight(10),
 		StateRoot:    baseTS.ToSlice()[0].StateRoot,
 		Proof:        proof,
 		Ticket:       consensus.CreateTicket(proof, minerAddr),
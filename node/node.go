@@ -36,7 +36,6 @@ import (
 	logging "gx/ipfs/QmcuXC5cxs79ro2cUuHs4HQ2bkDLJUYokwL8aivcX6HW3C/go-log"
 	"gx/ipfs/Qmf4xQhNomPNhrtZc67qSnfJSjxjXs9LWvknJtSXwimPrM/go-datastore"
 
-	"github.com/filecoin-project/go-filecoin/abi"
 	"github.com/filecoin-project/go-filecoin/actor/builtin"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
 	"github.com/filecoin-project/go-filecoin/address"
@@ -51,9 +50,12 @@ import (
 	"github.com/filecoin-project/go-filecoin/plumbing"
 	"github.com/filecoin-project/go-filecoin/plumbing/cfg"
 	"github.com/filecoin-project/go-filecoin/plumbing/chn"
+	"github.com/filecoin-project/go-filecoin/plumbing/evt"
 	"github.com/filecoin-project/go-filecoin/plumbing/msg"
 	"github.com/filecoin-project/go-filecoin/plumbing/mthdsig"
 	"github.com/filecoin-project/go-filecoin/plumbing/ntwk"
+	"github.com/filecoin-project/go-filecoin/plumbing/pwr"
+	"github.com/filecoin-project/go-filecoin/plumbing/stt"
 	"github.com/filecoin-project/go-filecoin/porcelain"
 	"github.com/filecoin-project/go-filecoin/proofs"
 	"github.com/filecoin-project/go-filecoin/proofs/sectorbuilder"
@@ -89,6 +91,9 @@ type Node struct {
 	ChainReader chain.ReadStore
 	Syncer      chain.Syncer
 	PowerTable  consensus.PowerTableView
+	// Authorities are the block producers of a chain that uses round-robin
+	// proof-of-authority consensus. It is empty under expected consensus.
+	Authorities []address.Address
 
 	PorcelainAPI *porcelain.API
 
@@ -99,6 +104,9 @@ type Node struct {
 	HeaviestTipSetHandled func()
 	MsgPool               *core.MessagePool
 
+	// faultDetector reports consensus faults seen by the syncer.
+	faultDetector *chain.ConsensusFaultDetector
+
 	Wallet *wallet.Wallet
 
 	// Mining stuff.
@@ -378,15 +386,21 @@ func (nc *Config) Build(ctx context.Context) (*Node, error) {
 		processor = consensus.NewConfiguredProcessor(consensus.NewDefaultMessageValidator(), nc.Rewarder)
 	}
 
+	// The genesis block records which consensus protocol the chain uses.
+	var genesis types.Block
+	if err := cstOffline.Get(ctx, genCid, &genesis); err != nil {
+		return nil, errors.Wrap(err, "failed to load genesis block")
+	}
+
 	var nodeConsensus consensus.Protocol
-	if nc.Verifier == nil {
-		nodeConsensus = consensus.NewExpected(&cstOffline, bs, processor, powerTable, genCid, &proofs.RustVerifier{})
+	if len(genesis.Authorities) > 0 {
+		nodeConsensus = consensus.NewRoundRobin(&cstOffline, bs, processor, genCid, genesis.Authorities, &consensus.MinerKeySignatureValidator{})
+	} else if nc.Verifier == nil {
+		nodeConsensus = consensus.NewExpected(&cstOffline, bs, processor, powerTable, genCid, &proofs.RustVerifier{}, &consensus.MinerKeySignatureValidator{})
 	} else {
-		nodeConsensus = consensus.NewExpected(&cstOffline, bs, processor, powerTable, genCid, nc.Verifier)
+		nodeConsensus = consensus.NewExpected(&cstOffline, bs, processor, powerTable, genCid, nc.Verifier, &consensus.MinerKeySignatureValidator{})
 	}
 
-	// only the syncer gets the storage which is online connected
-	chainSyncer := chain.NewDefaultSyncer(&cstOnline, &cstOffline, nodeConsensus, chainStore)
 	chainReader, ok := chainStore.(chain.ReadStore)
 	if !ok {
 		return nil, errors.New("failed to cast chain.Store to chain.ReadStore")
@@ -407,16 +421,35 @@ func (nc *Config) Build(ctx context.Context) (*Node, error) {
 	PorcelainAPI := porcelain.New(plumbing.New(&plumbing.APIDeps{
 		Chain:        chn.New(chainReader),
 		Config:       cfg.NewConfig(nc.Repo),
+		EventFinder:  evt.NewFinder(chainReader, &cstOffline),
 		MessagePool:  msgPool,
+		MsgCaller:    msg.NewCaller(chainReader, &cstOffline, bs),
 		MsgPreviewer: msg.NewPreviewer(fcWallet, chainReader, &cstOffline, bs),
 		MsgQueryer:   msg.NewQueryer(nc.Repo, fcWallet, chainReader, &cstOffline, bs),
 		MsgSender:    msg.NewSender(nc.Repo, fcWallet, chainReader, msgPool, fsub.Publish),
+		MsgTracer:    msg.NewTracer(chainReader, &cstOffline, bs),
 		MsgWaiter:    msg.NewWaiter(chainReader, bs, &cstOffline),
 		Network:      ntwk.NewNetwork(peerHost),
+		PowerGetter:  pwr.NewGetter(chainReader, &cstOffline, bs),
 		SigGetter:    mthdsig.NewGetter(chainReader),
+		StateDiffer:  stt.NewDiffer(&cstOffline, bs),
+		StateReader:  stt.NewReader(chainReader, &cstOffline, bs),
 		Wallet:       fcWallet,
 	}))
 
+	var faultReporter chain.FaultReporter
+	if nc.Repo.Config().Mining.SubmitConsensusFaults {
+		gasPrice := nc.Repo.Config().Mining.ConsensusFaultGasPrice
+		if gasPrice == nil {
+			gasPrice = config.DefaultConsensusFaultGasPrice
+		}
+		faultReporter = consensusFaultReporter(PorcelainAPI, *gasPrice)
+	}
+	faultDetector := chain.NewConsensusFaultDetector(faultReporter)
+
+	// only the syncer gets the storage which is online connected
+	chainSyncer := chain.NewDefaultSyncer(&cstOnline, &cstOffline, nodeConsensus, chainStore, faultDetector)
+
 	nd := &Node{
 		blockservice: bservice,
 		Blockstore:   bs,
@@ -426,6 +459,7 @@ func (nc *Config) Build(ctx context.Context) (*Node, error) {
 		ChainReader:  chainReader,
 		Syncer:       chainSyncer,
 		PowerTable:   powerTable,
+		Authorities:  genesis.Authorities,
 		PorcelainAPI: PorcelainAPI,
 		Exchange:     bswap,
 		host:         peerHost,
@@ -439,6 +473,7 @@ func (nc *Config) Build(ctx context.Context) (*Node, error) {
 		blockTime:    nc.BlockTime,
 		Router:       router,
 	}
+	nd.faultDetector = faultDetector
 
 	// Bootstrapping network peers.
 	periodStr := nd.Repo.Config().Bootstrap.Period
@@ -457,10 +492,7 @@ func (nc *Config) Build(ctx context.Context) (*Node, error) {
 	nd.Bootstrapper = filnet.NewBootstrapper(bpi, nd.Host(), nd.Host().Network(), nd.Router, minPeerThreshold, period)
 
 	// On-chain lookup service
-	defaultAddressGetter := func() (address.Address, error) {
-		return nd.PorcelainAPI.GetAndMaybeSetDefaultSenderAddress()
-	}
-	nd.lookup = lookup.NewChainLookupService(nd.ChainReader, defaultAddressGetter, bs)
+	nd.lookup = lookup.NewChainLookupService(nd.ChainReader, bs)
 
 	return nd, nil
 }
@@ -520,6 +552,7 @@ func (node *Node) Start(ctx context.Context) error {
 
 	go node.handleSubscription(cctx, node.processBlock, "processBlock", node.BlockSub, "BlockSub")
 	go node.handleSubscription(cctx, node.processMessage, "processMessage", node.MessageSub, "MessageSub")
+	go node.faultDetector.Run(cctx)
 
 	node.HeaviestTipSetHandled = func() {}
 	node.HeaviestTipSetCh = node.ChainReader.HeadEvents().Sub(chain.NewHeadTopic)
@@ -776,10 +809,10 @@ func (node *Node) StartMining(ctx context.Context) error {
 		getState := func(ctx context.Context, ts types.TipSet) (state.Tree, error) {
 			return getStateFromKey(ctx, ts.String())
 		}
-		getWeight := func(ctx context.Context, ts types.TipSet) (uint64, error) {
+		getWeight := func(ctx context.Context, ts types.TipSet) (*types.ChainWeight, error) {
 			parent, err := ts.Parents()
 			if err != nil {
-				return uint64(0), err
+				return nil, err
 			}
 			// TODO handle genesis cid more gracefully
 			if parent.Len() == 0 {
@@ -787,7 +820,7 @@ func (node *Node) StartMining(ctx context.Context) error {
 			}
 			pSt, err := getStateFromKey(ctx, parent.String())
 			if err != nil {
-				return uint64(0), err
+				return nil, err
 			}
 			return node.Consensus.Weight(ctx, ts, pSt)
 		}
@@ -795,7 +828,10 @@ func (node *Node) StartMining(ctx context.Context) error {
 			return chain.GetRecentAncestors(ctx, ts, node.ChainReader, newBlockHeight, consensus.AncestorRoundsNeeded, consensus.LookBackParameter)
 		}
 		processor := consensus.NewDefaultProcessor()
-		worker := mining.NewDefaultWorker(node.MsgPool, getState, getWeight, getAncestors, processor, node.PowerTable, node.Blockstore, node.CborStore(), minerAddr, blockTime)
+		worker := mining.NewDefaultWorker(node.MsgPool, getState, getWeight, getAncestors, processor, node.PowerTable, node.Blockstore, node.CborStore(), minerAddr, minerOwnerAddr, node.Wallet, blockTime)
+		if len(node.Authorities) > 0 {
+			worker.SetAuthorities(node.Authorities)
+		}
 		node.MiningScheduler = mining.NewScheduler(worker, mineDelay, node.ChainReader.Head)
 	}
 
@@ -829,9 +865,10 @@ func (node *Node) StartMining(ctx context.Context) error {
 
 					// TODO: determine these algorithmically by simulating call and querying historical prices
 					gasPrice := types.NewGasPrice(0)
-					gasUnits := types.NewGasUnits(300)
+					gasUnits := types.NewGasUnits(1000)
 
 					val := result.SealingResult
+					dealIDs := node.StorageMiner.DealIDsForSector(val.SectorID)
 					// This call can fail due to, e.g. nonce collisions. Our miners existence depends on this.
 					// We should deal with this, but MessageSendWithRetry is problematic.
 					_, err := node.PorcelainAPI.MessageSend(
@@ -847,6 +884,7 @@ func (node *Node) StartMining(ctx context.Context) error {
 						val.CommR[:],
 						val.CommRStar[:],
 						val.Proof[:],
+						dealIDs,
 					)
 					if err != nil {
 						log.Errorf("failed to send commitSector message from %s to %s for sector with id %d: %s", minerOwnerAddr, minerAddr, val.SectorID, err)
@@ -886,26 +924,12 @@ func (node *Node) StartMining(ctx context.Context) error {
 }
 
 func (node *Node) getLastUsedSectorID(ctx context.Context, minerAddr address.Address) (uint64, error) {
-	rets, methodSignature, err := node.PorcelainAPI.MessageQuery(
-		ctx,
-		address.Address{},
-		minerAddr,
-		"getLastUsedSectorID",
-	)
+	state, err := node.PorcelainAPI.MinerState(ctx, types.SortedCidSet{}, minerAddr)
 	if err != nil {
-		return 0, errors.Wrap(err, "failed to call query method getLastUsedSectorID")
+		return 0, errors.Wrap(err, "failed to get miner state")
 	}
 
-	lastUsedSectorIDVal, err := abi.Deserialize(rets[0], methodSignature.Return[0])
-	if err != nil {
-		return 0, errors.Wrap(err, "failed to convert returned ABI value")
-	}
-	lastUsedSectorID, ok := lastUsedSectorIDVal.Val.(uint64)
-	if !ok {
-		return 0, errors.New("failed to convert returned ABI value to uint64")
-	}
-
-	return lastUsedSectorID, nil
+	return state.LastUsedSectorID, nil
 }
 
 func initSectorBuilderForNode(ctx context.Context, node *Node, sectorStoreType proofs.SectorStoreType) (sectorbuilder.SectorBuilder, error) {
@@ -1058,17 +1082,12 @@ func (node *Node) saveMinerAddressToConfig(addr address.Address) error {
 // MiningOwnerAddress returns the owner of the passed in mining address.
 // TODO: find a better home for this method
 func (node *Node) MiningOwnerAddress(ctx context.Context, miningAddr address.Address) (address.Address, error) {
-	res, _, err := node.PorcelainAPI.MessageQuery(
-		ctx,
-		address.Address{},
-		miningAddr,
-		"getOwner",
-	)
+	state, err := node.PorcelainAPI.MinerState(ctx, types.SortedCidSet{}, miningAddr)
 	if err != nil {
-		return address.Address{}, errors.Wrap(err, "failed to getOwner")
+		return address.Address{}, errors.Wrap(err, "failed to get miner state")
 	}
 
-	return address.NewFromBytes(res[0])
+	return state.Owner, nil
 }
 
 // BlockHeight returns the current block height of the chain.
@@ -45,6 +45,24 @@ func TestNodeConstruct(t *testing.T) {
 	nd.Stop(context.Background())
 }
 
+func TestNodeConstructWithNullConsensusFaultGasPrice(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	r := repo.NewInMemoryRepo()
+	require.NoError(Init(ctx, r, consensus.InitGenesis))
+	r.Config().Mining.SubmitConsensusFaults = true
+	r.Config().Mining.ConsensusFaultGasPrice = nil
+	opts, err := OptionsFromRepo(r)
+	require.NoError(err)
+
+	nd, err := New(ctx, opts...)
+	require.NoError(err)
+	assert.NotNil(nd.faultDetector)
+}
+
 func TestNodeNetworking(t *testing.T) {
 	t.Parallel()
 	ctx := context.Background()
@@ -269,10 +269,10 @@ func RunCreateMiner(t *testing.T, node *Node, from address.Address, pledge uint6
 	getStateTree := func(ctx context.Context, ts types.TipSet) (state.Tree, error) {
 		return getStateFromKey(ctx, ts.String())
 	}
-	getWeight := func(ctx context.Context, ts types.TipSet) (uint64, error) {
+	getWeight := func(ctx context.Context, ts types.TipSet) (*types.ChainWeight, error) {
 		parent, err := ts.Parents()
 		if err != nil {
-			return uint64(0), err
+			return nil, err
 		}
 		// TODO handle genesis cid more gracefully
 		if parent.Len() == 0 {
@@ -280,14 +280,14 @@ func RunCreateMiner(t *testing.T, node *Node, from address.Address, pledge uint6
 		}
 		pSt, err := getStateFromKey(ctx, parent.String())
 		if err != nil {
-			return uint64(0), err
+			return nil, err
 		}
 		return node.Consensus.Weight(ctx, ts, pSt)
 	}
 	getAncestors := func(ctx context.Context, ts types.TipSet, newBlockHeight *types.BlockHeight) ([]types.TipSet, error) {
 		return chain.GetRecentAncestors(ctx, ts, node.ChainReader, newBlockHeight, consensus.AncestorRoundsNeeded, consensus.LookBackParameter)
 	}
-	w := mining.NewDefaultWorker(node.MsgPool, getStateTree, getWeight, getAncestors, consensus.NewDefaultProcessor(), node.PowerTable, node.Blockstore, node.CborStore(), address.TestAddress, testhelpers.BlockTimeTest)
+	w := mining.NewDefaultWorker(node.MsgPool, getStateTree, getWeight, getAncestors, consensus.NewDefaultProcessor(), node.PowerTable, node.Blockstore, node.CborStore(), address.TestAddress, from, node.Wallet, testhelpers.BlockTimeTest)
 	cur := node.ChainReader.Head()
 	out, err := mining.MineOnce(ctx, w, mining.MineDelayTest, cur)
 	require.NoError(err)
@@ -7,15 +7,25 @@ import (
 	"gx/ipfs/QmY5Grm8pJdiSSVsYxx4uNRgweY72EmYwuSDbRnbFok3iY/go-libp2p-peer"
 	logging "gx/ipfs/QmcuXC5cxs79ro2cUuHs4HQ2bkDLJUYokwL8aivcX6HW3C/go-log"
 
+	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/multisig"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/consensus"
 	"github.com/filecoin-project/go-filecoin/core"
 	"github.com/filecoin-project/go-filecoin/exec"
 	"github.com/filecoin-project/go-filecoin/plumbing/cfg"
 	"github.com/filecoin-project/go-filecoin/plumbing/chn"
+	"github.com/filecoin-project/go-filecoin/plumbing/evt"
 	"github.com/filecoin-project/go-filecoin/plumbing/msg"
 	"github.com/filecoin-project/go-filecoin/plumbing/mthdsig"
 	"github.com/filecoin-project/go-filecoin/plumbing/ntwk"
+	"github.com/filecoin-project/go-filecoin/plumbing/pwr"
+	"github.com/filecoin-project/go-filecoin/plumbing/stt"
+	"github.com/filecoin-project/go-filecoin/state"
 	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
 	"github.com/filecoin-project/go-filecoin/wallet"
 )
 
@@ -29,13 +39,19 @@ type API struct {
 
 	chain        *chn.Reader
 	config       *cfg.Config
+	eventFinder  *evt.Finder
 	messagePool  *core.MessagePool
+	msgCaller    *msg.Caller
 	msgPreviewer *msg.Previewer
 	msgQueryer   *msg.Queryer
 	msgSender    *msg.Sender
+	msgTracer    *msg.Tracer
 	msgWaiter    *msg.Waiter
 	network      *ntwk.Network
+	powerGetter  *pwr.Getter
 	sigGetter    *mthdsig.Getter
+	stateDiffer  *stt.Differ
+	stateReader  *stt.Reader
 	wallet       *wallet.Wallet
 }
 
@@ -43,13 +59,19 @@ type API struct {
 type APIDeps struct {
 	Chain        *chn.Reader
 	Config       *cfg.Config
+	EventFinder  *evt.Finder
 	MessagePool  *core.MessagePool
+	MsgCaller    *msg.Caller
 	MsgPreviewer *msg.Previewer
 	MsgQueryer   *msg.Queryer
 	MsgSender    *msg.Sender
+	MsgTracer    *msg.Tracer
 	MsgWaiter    *msg.Waiter
 	Network      *ntwk.Network
+	PowerGetter  *pwr.Getter
 	SigGetter    *mthdsig.Getter
+	StateDiffer  *stt.Differ
+	StateReader  *stt.Reader
 	Wallet       *wallet.Wallet
 }
 
@@ -60,13 +82,19 @@ func New(deps *APIDeps) *API {
 
 		chain:        deps.Chain,
 		config:       deps.Config,
+		eventFinder:  deps.EventFinder,
 		messagePool:  deps.MessagePool,
+		msgCaller:    deps.MsgCaller,
 		msgPreviewer: deps.MsgPreviewer,
 		msgQueryer:   deps.MsgQueryer,
 		msgSender:    deps.MsgSender,
+		msgTracer:    deps.MsgTracer,
 		msgWaiter:    deps.MsgWaiter,
 		network:      deps.Network,
+		powerGetter:  deps.PowerGetter,
 		sigGetter:    deps.SigGetter,
+		stateDiffer:  deps.StateDiffer,
+		stateReader:  deps.StateReader,
 		wallet:       deps.Wallet,
 	}
 }
@@ -78,6 +106,84 @@ func (api *API) ActorGetSignature(ctx context.Context, actorAddr address.Address
 	return api.sigGetter.Get(ctx, actorAddr, method)
 }
 
+// ActorDescribe returns the methods and exit codes of the actor at the given
+// address.
+func (api *API) ActorDescribe(ctx context.Context, actorAddr address.Address) (*mthdsig.ActorInterface, error) {
+	return api.sigGetter.Describe(ctx, actorAddr)
+}
+
+// ActorDescribeCode returns the methods and exit codes of actors with the
+// given code.
+func (api *API) ActorDescribeCode(ctx context.Context, code cid.Cid) (*mthdsig.ActorInterface, error) {
+	return api.sigGetter.DescribeCode(ctx, code)
+}
+
+// ActorState returns the decoded state of the builtin actor at the given
+// address in the state resulting from the tipset with the given key, without
+// executing actor code. An empty key selects the head.
+func (api *API) ActorState(ctx context.Context, tsKey types.SortedCidSet, actorAddr address.Address) (interface{}, error) {
+	return api.stateReader.ActorState(ctx, tsKey, actorAddr)
+}
+
+// MinerState returns the state of the given miner in the state resulting from
+// the tipset with the given key. An empty key selects the head.
+func (api *API) MinerState(ctx context.Context, tsKey types.SortedCidSet, minerAddr address.Address) (*miner.State, error) {
+	return api.stateReader.MinerState(ctx, tsKey, minerAddr)
+}
+
+// MinerAsks returns the open asks of the given miner, ordered by id, in the
+// state resulting from the tipset with the given key. An empty key selects the
+// head.
+func (api *API) MinerAsks(ctx context.Context, tsKey types.SortedCidSet, minerAddr address.Address) ([]*miner.Ask, error) {
+	return api.stateReader.MinerAsks(ctx, tsKey, minerAddr)
+}
+
+// MinerSectorCommitments returns the commitments of all sectors the given
+// miner has committed, by sector id, in the state resulting from the tipset
+// with the given key. An empty key selects the head.
+func (api *API) MinerSectorCommitments(ctx context.Context, tsKey types.SortedCidSet, minerAddr address.Address) (map[string]types.Commitments, error) {
+	return api.stateReader.MinerSectorCommitments(ctx, tsKey, minerAddr)
+}
+
+// MultisigState returns the state of the given multisig wallet in the state
+// resulting from the tipset with the given key. An empty key selects the head.
+func (api *API) MultisigState(ctx context.Context, tsKey types.SortedCidSet, msigAddr address.Address) (*multisig.State, error) {
+	return api.stateReader.MultisigState(ctx, tsKey, msigAddr)
+}
+
+// StorageMarketState returns the state of the storage market in the state
+// resulting from the tipset with the given key. An empty key selects the head.
+func (api *API) StorageMarketState(ctx context.Context, tsKey types.SortedCidSet) (*storagemarket.State, error) {
+	return api.stateReader.StorageMarketState(ctx, tsKey)
+}
+
+// StorageMarketDeals returns all deals published in the storage market,
+// ordered by id, in the state resulting from the tipset with the given key.
+// An empty key selects the head.
+func (api *API) StorageMarketDeals(ctx context.Context, tsKey types.SortedCidSet) ([]*storagemarket.Deal, error) {
+	return api.stateReader.StorageMarketDeals(ctx, tsKey)
+}
+
+// ActorID returns the ID address assigned to the actor at addr in the state
+// resulting from the tipset with the given key. An empty key selects the head.
+func (api *API) ActorID(ctx context.Context, tsKey types.SortedCidSet, addr address.Address) (address.Address, bool, error) {
+	return api.stateReader.ActorID(ctx, tsKey, addr)
+}
+
+// ActorAddress returns the robust address of the actor the given ID address
+// was assigned to in the state resulting from the tipset with the given key.
+// An empty key selects the head.
+func (api *API) ActorAddress(ctx context.Context, tsKey types.SortedCidSet, idAddr address.Address) (address.Address, bool, error) {
+	return api.stateReader.ActorAddress(ctx, tsKey, idAddr)
+}
+
+// PaymentChannels returns the payment channels of the given payer by channel
+// id in the state resulting from the tipset with the given key. An empty key
+// selects the head.
+func (api *API) PaymentChannels(ctx context.Context, tsKey types.SortedCidSet, payer address.Address) (map[string]*paymentbroker.PaymentChannel, error) {
+	return api.stateReader.PaymentChannels(ctx, tsKey, payer)
+}
+
 // ConfigSet sets the given parameters at the given path in the local config.
 // The given path may be either a single field name, or a dotted path to a field.
 // The JSON value may be either a single value or a whole data structure to be replace.
@@ -104,11 +210,31 @@ func (api *API) ChainLs(ctx context.Context) <-chan interface{} {
 	return api.chain.Ls(ctx)
 }
 
+// ChainEvents returns the events on chain selected by the filter, oldest
+// first.
+func (api *API) ChainEvents(ctx context.Context, filter evt.Filter) ([]*evt.ChainEvent, error) {
+	return api.eventFinder.Find(ctx, filter)
+}
+
+// ChainSubscribeEvents returns a channel of the events selected by the filter
+// in every new head of the chain, until ctx is done.
+func (api *API) ChainSubscribeEvents(ctx context.Context, filter evt.Filter) (<-chan *evt.ChainEvent, error) {
+	return api.eventFinder.Subscribe(ctx, filter)
+}
+
 // BlockGet gets a block by CID
 func (api *API) BlockGet(ctx context.Context, id cid.Cid) (*types.Block, error) {
 	return api.chain.BlockGet(ctx, id)
 }
 
+// MessageCall applies an unsigned message to the state resulting from the
+// tipset with the given key and reports its receipt, the gas it used and the
+// actors it changed, without committing anything. An empty key selects the
+// head.
+func (api *API) MessageCall(ctx context.Context, tsKey types.SortedCidSet, msg *types.Message, gasLimit types.GasUnits) (*consensus.DryRunResult, error) {
+	return api.msgCaller.Call(ctx, tsKey, msg, gasLimit)
+}
+
 // MessagePoolRemove removes a message from the message pool
 func (api *API) MessagePoolRemove(cid cid.Cid) {
 	api.messagePool.Remove(cid)
@@ -117,6 +243,14 @@ func (api *API) MessagePoolRemove(cid cid.Cid) {
 // MessagePreview previews the Gas cost of a message by running it locally on the client and
 // recording the amount of Gas used.
 func (api *API) MessagePreview(ctx context.Context, from, to address.Address, method string, params ...interface{}) (types.GasUnits, error) {
+	usedGas, _, err := api.msgPreviewer.Preview(ctx, from, to, method, params...)
+	return usedGas, err
+}
+
+// MessagePreviewTrace is like MessagePreview, but also returns a trace of the
+// execution of the message, including every message sent by the actors it
+// called.
+func (api *API) MessagePreviewTrace(ctx context.Context, from, to address.Address, method string, params ...interface{}) (types.GasUnits, *vm.ExecutionTrace, error) {
 	return api.msgPreviewer.Preview(ctx, from, to, method, params...)
 }
 
@@ -136,6 +270,13 @@ func (api *API) MessageSend(ctx context.Context, from, to address.Address, value
 	return api.msgSender.Send(ctx, from, to, value, gasPrice, gasLimit, method, params...)
 }
 
+// MessageTrace re-executes the message with the given cid on the state it was
+// applied to and returns a trace of its execution, including every message
+// sent by the actors it called.
+func (api *API) MessageTrace(ctx context.Context, msgCid cid.Cid) (*vm.ExecutionTrace, error) {
+	return api.msgTracer.Trace(ctx, msgCid)
+}
+
 // MessageWait invokes the callback when a message with the given cid appears on chain.
 // It will find the message in both the case that it is already on chain and
 // the case that it appears in a newly mined block. An error is returned if one is
@@ -145,6 +286,12 @@ func (api *API) MessageWait(ctx context.Context, msgCid cid.Cid, cb func(*types.
 	return api.msgWaiter.Wait(ctx, msgCid, cb)
 }
 
+// MinerGetPowerTable returns the power of every miner in the state resulting
+// from the tipset with the given key. An empty key selects the head.
+func (api *API) MinerGetPowerTable(ctx context.Context, tsKey types.SortedCidSet) (*consensus.PowerTable, error) {
+	return api.powerGetter.PowerTable(ctx, tsKey)
+}
+
 // NetworkGetPeerID gets the current peer id from Util
 func (api *API) NetworkGetPeerID() peer.ID {
 	return api.network.GetPeerID()
@@ -155,6 +302,12 @@ func (api *API) SignBytes(data []byte, addr address.Address) (types.Signature, e
 	return api.wallet.SignBytes(data, addr)
 }
 
+// StateDiff reports the actors that were added, removed or changed going from
+// the state tree with root before to the one with root after.
+func (api *API) StateDiff(ctx context.Context, before, after cid.Cid) ([]*state.ActorDiff, error) {
+	return api.stateDiffer.Diff(ctx, before, after)
+}
+
 // WalletAddresses gets addresses from the wallet
 func (api *API) WalletAddresses() []address.Address {
 	return api.wallet.Addresses()
@@ -169,3 +322,8 @@ func (api *API) WalletFind(address address.Address) (wallet.Backend, error) {
 func (api *API) WalletNewAddress() (address.Address, error) {
 	return wallet.NewAddress(api.wallet)
 }
+
+// WalletNewBLSAddress generates a new wallet address of a BLS key
+func (api *API) WalletNewBLSAddress() (address.Address, error) {
+	return wallet.NewBLSAddress(api.wallet)
+}
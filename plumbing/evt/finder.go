@@ -0,0 +1,325 @@
+package evt
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	"gx/ipfs/QmRXf2uUSdGSunRJsM9wXSUNVwLUGCY3So5fAs7h2CBJVf/go-hamt-ipld"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+	logging "gx/ipfs/QmcuXC5cxs79ro2cUuHs4HQ2bkDLJUYokwL8aivcX6HW3C/go-log"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+var log = logging.Logger("plumbing/evt")
+
+// Filter selects events. Zero valued fields match every event.
+type Filter struct {
+	// Actor matches events emitted by the actor at this address.
+	Actor address.Address
+	// Topic matches events with this topic.
+	Topic string
+	// Since matches events in blocks at or above this height.
+	Since *types.BlockHeight
+}
+
+// Match returns true if the event, in a block at the given height, is
+// selected by the filter.
+func (f Filter) Match(e *types.Event, height uint64) bool {
+	if !f.Actor.Empty() && f.Actor != e.Actor {
+		return false
+	}
+	if f.Topic != "" && f.Topic != e.Topic {
+		return false
+	}
+	if f.Since != nil && types.NewBlockHeight(height).LessThan(f.Since) {
+		return false
+	}
+	return true
+}
+
+// ChainEvent is an event along with where on chain it was emitted.
+type ChainEvent struct {
+	*types.Event
+	// Block is the cid of the block whose receipts hold the event.
+	Block cid.Cid `json:"block"`
+	// Height is the height of the block.
+	Height uint64 `json:"height"`
+	// Message is the cid of the message that emitted the event.
+	Message cid.Cid `json:"message"`
+}
+
+// Finder looks up the events recorded in message receipts on chain.
+//
+// The finder keeps an index of the events on the chain it last saw, by topic.
+// It is built by walking the whole chain on the first search, and brought up
+// to date with the tipsets that changed since on every later one.
+type Finder struct {
+	chainReader chain.ReadStore
+	cst         *hamt.CborIpldStore
+
+	lk sync.Mutex
+	// head is the head of the chain the index is up to date with.
+	head types.TipSet
+	// all holds every indexed event and byTopic the indexed events of each
+	// topic, both ordered by height.
+	all     []*ChainEvent
+	byTopic map[string][]*ChainEvent
+}
+
+// NewFinder returns a new Finder.
+func NewFinder(chainReader chain.ReadStore, cst *hamt.CborIpldStore) *Finder {
+	return &Finder{
+		chainReader: chainReader,
+		cst:         cst,
+		byTopic:     map[string][]*ChainEvent{},
+	}
+}
+
+// Find returns the events on chain selected by the filter, oldest first.
+func (f *Finder) Find(ctx context.Context, filter Filter) ([]*ChainEvent, error) {
+	f.lk.Lock()
+	defer f.lk.Unlock()
+
+	if err := f.updateIndex(ctx); err != nil {
+		return nil, err
+	}
+
+	candidates := f.all
+	if filter.Topic != "" {
+		candidates = f.byTopic[filter.Topic]
+	}
+	if filter.Since != nil {
+		candidates = candidates[sort.Search(len(candidates), func(i int) bool {
+			return !types.NewBlockHeight(candidates[i].Height).LessThan(filter.Since)
+		}):]
+	}
+
+	found := []*ChainEvent{}
+	for _, e := range candidates {
+		if filter.Match(e.Event, e.Height) {
+			found = append(found, e)
+		}
+	}
+	return found, nil
+}
+
+// updateIndex drops the events of the tipsets that left the chain since the
+// index was last updated, and indexes the events of the tipsets that joined
+// it.
+func (f *Finder) updateIndex(ctx context.Context) error {
+	head := f.chainReader.Head()
+	if head.Equals(f.head) {
+		return nil
+	}
+
+	added, ancestor, err := f.tipSetsSince(ctx, f.head, head)
+	if err != nil {
+		return err
+	}
+
+	if ancestor == nil {
+		f.all = nil
+		f.byTopic = map[string][]*ChainEvent{}
+	} else {
+		height, err := ancestor.Height()
+		if err != nil {
+			return err
+		}
+		f.all = truncateAbove(f.all, height)
+		for topic, events := range f.byTopic {
+			if events = truncateAbove(events, height); len(events) > 0 {
+				f.byTopic[topic] = events
+			} else {
+				delete(f.byTopic, topic)
+			}
+		}
+	}
+
+	for _, ts := range added {
+		events, err := f.tipSetEvents(ctx, ts, Filter{})
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			f.all = append(f.all, e)
+			f.byTopic[e.Topic] = append(f.byTopic[e.Topic], e)
+		}
+	}
+
+	f.head = head
+	return nil
+}
+
+// Subscribe returns a channel of the events selected by the filter in every
+// new head of the chain, until ctx is done. When the head moves by more than
+// one tipset, the events of every tipset from the old head to the new one are
+// sent, oldest first.
+func (f *Finder) Subscribe(ctx context.Context, filter Filter) (<-chan *ChainEvent, error) {
+	headCh := f.chainReader.HeadEvents().Sub(chain.NewHeadTopic)
+	out := make(chan *ChainEvent)
+	prevHead := f.chainReader.Head()
+
+	go func() {
+		defer close(out)
+		defer f.chainReader.HeadEvents().Unsub(headCh, chain.NewHeadTopic)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case raw, more := <-headCh:
+				if !more {
+					return
+				}
+				head, ok := raw.(types.TipSet)
+				if !ok {
+					continue
+				}
+				added, _, err := f.tipSetsSince(ctx, prevHead, head)
+				if err != nil {
+					log.Errorf("failed to walk to new head: %s", err)
+					continue
+				}
+				prevHead = head
+
+				for _, ts := range added {
+					events, err := f.tipSetEvents(ctx, ts, filter)
+					if err != nil {
+						log.Errorf("failed to load events of new head: %s", err)
+						continue
+					}
+					for _, e := range events {
+						select {
+						case out <- e:
+						case <-ctx.Done():
+							return
+						}
+					}
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// tipSetsSince returns the tipsets on the chain of head that are not on the
+// chain of prevHead, oldest first, along with the newest tipset both chains
+// share. The shared tipset is nil if the chains have none in common, or if
+// prevHead is empty.
+func (f *Finder) tipSetsSince(ctx context.Context, prevHead, head types.TipSet) ([]types.TipSet, types.TipSet, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var prevCh <-chan interface{}
+	var prev types.TipSet
+	if len(prevHead) > 0 {
+		prevCh = f.chainReader.BlockHistory(ctx, prevHead)
+	}
+	prevHeight := uint64(0)
+
+	var added []types.TipSet
+	for raw := range f.chainReader.BlockHistory(ctx, head) {
+		ts, err := toTipSet(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		height, err := ts.Height()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// step the previous chain down to the height of this tipset
+		for prevCh != nil && (prev == nil || prevHeight > height) {
+			raw, more := <-prevCh
+			if !more {
+				prevCh = nil
+				prev = nil
+				break
+			}
+			if prev, err = toTipSet(raw); err != nil {
+				return nil, nil, err
+			}
+			if prevHeight, err = prev.Height(); err != nil {
+				return nil, nil, err
+			}
+		}
+		if prev != nil && prev.Equals(ts) {
+			return reverseTipSets(added), ts, nil
+		}
+
+		added = append(added, ts)
+	}
+	return reverseTipSets(added), nil, nil
+}
+
+func toTipSet(raw interface{}) (types.TipSet, error) {
+	switch v := raw.(type) {
+	case error:
+		return nil, errors.Wrap(v, "failed to read chain")
+	case types.TipSet:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("unexpected type in channel: %T", raw)
+	}
+}
+
+// tipSetEvents returns the events selected by the filter in the receipts of
+// every block of the tipset.
+func (f *Finder) tipSetEvents(ctx context.Context, ts types.TipSet, filter Filter) ([]*ChainEvent, error) {
+	blks := ts.ToSlice()
+	types.SortBlocks(blks)
+
+	var events []*ChainEvent
+	for _, blk := range blks {
+		receipts, err := consensus.LoadReceipts(ctx, f.cst, blk.MessageReceipts)
+		if err != nil {
+			return nil, err
+		}
+		for i, receipt := range receipts {
+			if receipt == nil || i >= len(blk.Messages) {
+				continue
+			}
+			msgCid, err := blk.Messages[i].Cid()
+			if err != nil {
+				return nil, err
+			}
+			for _, e := range receipt.Events {
+				if !filter.Match(e, uint64(blk.Height)) {
+					continue
+				}
+				events = append(events, &ChainEvent{
+					Event:   e,
+					Block:   blk.Cid(),
+					Height:  uint64(blk.Height),
+					Message: msgCid,
+				})
+			}
+		}
+	}
+	return events, nil
+}
+
+// truncateAbove drops the events above the given height from events ordered
+// by height.
+func truncateAbove(events []*ChainEvent, height uint64) []*ChainEvent {
+	return events[:sort.Search(len(events), func(i int) bool {
+		return events[i].Height > height
+	})]
+}
+
+// reverseTipSets returns the tipsets in reverse order.
+func reverseTipSets(tipSets []types.TipSet) []types.TipSet {
+	out := make([]types.TipSet, len(tipSets))
+	for i, ts := range tipSets {
+		out[len(tipSets)-1-i] = ts
+	}
+	return out
+}
@@ -0,0 +1,213 @@
+package evt_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gx/ipfs/QmRXf2uUSdGSunRJsM9wXSUNVwLUGCY3So5fAs7h2CBJVf/go-hamt-ipld"
+	bstore "gx/ipfs/QmS2aqUZLJp8kF1ihE5rvDGE5LvmKDPnx32w9Z1BW9xLV5/go-ipfs-blockstore"
+	bserv "gx/ipfs/QmYPZzd9VqmJDwxUnThfeSbV1Y5o53aVPDijTB7j7rS9Ep/go-blockservice"
+	offline "gx/ipfs/QmYZwey1thDTynSrvd6qQkX24UpTka6TFhQ2v569UpoqxD/go-ipfs-exchange-offline"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/plumbing/evt"
+	"github.com/filecoin-project/go-filecoin/repo"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var newSignedMessage = types.NewSignedMessageForTestGetter(types.NewMockSigner(types.MustGenerateKeyInfo(1, types.GenerateKeyInfoSeed())))
+
+func TestFilterMatch(t *testing.T) {
+	assert := assert.New(t)
+
+	addrGetter := address.NewForTestGetter()
+	actorAddr, otherAddr := addrGetter(), addrGetter()
+	e := &types.Event{Actor: actorAddr, Topic: "ping"}
+
+	assert.True(evt.Filter{}.Match(e, 3))
+	assert.True(evt.Filter{Actor: actorAddr, Topic: "ping", Since: types.NewBlockHeight(3)}.Match(e, 3))
+	assert.False(evt.Filter{Actor: otherAddr}.Match(e, 3))
+	assert.False(evt.Filter{Topic: "pong"}.Match(e, 3))
+	assert.False(evt.Filter{Since: types.NewBlockHeight(4)}.Match(e, 3))
+}
+
+func TestFinder(t *testing.T) {
+	t.Parallel()
+
+	actorAddr := address.NewForTestGetter()()
+
+	t.Run("finds the events of past blocks oldest first", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+		ctx := context.Background()
+
+		chainStore, cst := requireChainStore(require)
+		requireAddBlock(ctx, require, chainStore, cst, &types.Event{Actor: actorAddr, Topic: "ping"})
+		requireAddBlock(ctx, require, chainStore, cst, &types.Event{Actor: actorAddr, Topic: "pong"})
+		requireAddBlock(ctx, require, chainStore, cst, &types.Event{Actor: actorAddr, Topic: "ping"})
+
+		finder := evt.NewFinder(chainStore, cst)
+
+		events, err := finder.Find(ctx, evt.Filter{})
+		require.NoError(err)
+		require.Len(events, 3)
+		assert.Equal([]uint64{1, 2, 3}, []uint64{events[0].Height, events[1].Height, events[2].Height})
+		assert.Equal("pong", events[1].Topic)
+
+		events, err = finder.Find(ctx, evt.Filter{Topic: "ping", Since: types.NewBlockHeight(2)})
+		require.NoError(err)
+		require.Len(events, 1)
+		assert.Equal(uint64(3), events[0].Height)
+		assert.Equal(actorAddr, events[0].Actor)
+	})
+
+	t.Run("finds the events of blocks added after a search", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+		ctx := context.Background()
+
+		chainStore, cst := requireChainStore(require)
+		requireAddBlock(ctx, require, chainStore, cst, &types.Event{Actor: actorAddr, Topic: "ping"})
+
+		finder := evt.NewFinder(chainStore, cst)
+		events, err := finder.Find(ctx, evt.Filter{Topic: "ping"})
+		require.NoError(err)
+		require.Len(events, 1)
+
+		requireAddBlock(ctx, require, chainStore, cst, &types.Event{Actor: actorAddr, Topic: "ping"})
+
+		events, err = finder.Find(ctx, evt.Filter{Topic: "ping"})
+		require.NoError(err)
+		require.Len(events, 2)
+		assert.Equal([]uint64{1, 2}, []uint64{events[0].Height, events[1].Height})
+	})
+
+	t.Run("drops the events of blocks that left the chain", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+		ctx := context.Background()
+
+		chainStore, cst := requireChainStore(require)
+		requireAddBlock(ctx, require, chainStore, cst, &types.Event{Actor: actorAddr, Topic: "ping"})
+		base := chainStore.Head()
+		requireAddBlock(ctx, require, chainStore, cst, &types.Event{Actor: actorAddr, Topic: "ping"})
+
+		finder := evt.NewFinder(chainStore, cst)
+		events, err := finder.Find(ctx, evt.Filter{})
+		require.NoError(err)
+		require.Len(events, 2)
+
+		fork := requirePutBlock(ctx, require, chainStore, cst, base, &types.Event{Actor: actorAddr, Topic: "pong"})
+		require.NoError(chainStore.SetHead(ctx, fork))
+
+		events, err = finder.Find(ctx, evt.Filter{})
+		require.NoError(err)
+		require.Len(events, 2)
+		assert.Equal("ping", events[0].Topic)
+		assert.Equal("pong", events[1].Topic)
+
+		events, err = finder.Find(ctx, evt.Filter{Topic: "ping"})
+		require.NoError(err)
+		assert.Len(events, 1)
+	})
+
+	t.Run("streams the events of new heads", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		chainStore, cst := requireChainStore(require)
+		finder := evt.NewFinder(chainStore, cst)
+
+		ch, err := finder.Subscribe(ctx, evt.Filter{Topic: "ping"})
+		require.NoError(err)
+
+		requireAddBlock(ctx, require, chainStore, cst, &types.Event{Actor: actorAddr, Topic: "pong"})
+		requireAddBlock(ctx, require, chainStore, cst, &types.Event{Actor: actorAddr, Topic: "ping"})
+
+		select {
+		case e := <-ch:
+			assert.Equal("ping", e.Topic)
+			assert.Equal(uint64(2), e.Height)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for event")
+		}
+	})
+
+	t.Run("streams the events of every tipset up to a new head", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		chainStore, cst := requireChainStore(require)
+		finder := evt.NewFinder(chainStore, cst)
+
+		ch, err := finder.Subscribe(ctx, evt.Filter{Topic: "ping"})
+		require.NoError(err)
+
+		// the head moves by two tipsets at once
+		ts := requirePutBlock(ctx, require, chainStore, cst, chainStore.Head(), &types.Event{Actor: actorAddr, Topic: "ping"})
+		ts = requirePutBlock(ctx, require, chainStore, cst, ts, &types.Event{Actor: actorAddr, Topic: "ping"})
+		require.NoError(chainStore.SetHead(ctx, ts))
+
+		for _, height := range []uint64{1, 2} {
+			select {
+			case e := <-ch:
+				assert.Equal("ping", e.Topic)
+				assert.Equal(height, e.Height)
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for event")
+			}
+		}
+	})
+}
+
+func requireChainStore(require *require.Assertions) (*chain.DefaultStore, *hamt.CborIpldStore) {
+	r := repo.NewInMemoryRepo()
+	bs := bstore.NewBlockstore(r.Datastore())
+	cst := &hamt.CborIpldStore{Blocks: bserv.New(bs, offline.Exchange(bs))}
+	chainStore, err := chain.Init(context.Background(), r, bs, cst, consensus.InitGenesis)
+	require.NoError(err)
+	return chainStore, cst
+}
+
+// requireAddBlock adds a block on top of the head with a single message whose
+// receipt holds the event, and makes it the new head.
+func requireAddBlock(ctx context.Context, require *require.Assertions, chainStore *chain.DefaultStore, cst *hamt.CborIpldStore, event *types.Event) {
+	ts := requirePutBlock(ctx, require, chainStore, cst, chainStore.Head(), event)
+	require.NoError(chainStore.SetHead(ctx, ts))
+}
+
+// requirePutBlock stores a block on top of the parent with a single message
+// whose receipt holds the event, and returns its tipset.
+func requirePutBlock(ctx context.Context, require *require.Assertions, chainStore *chain.DefaultStore, cst *hamt.CborIpldStore, parent types.TipSet, event *types.Event) types.TipSet {
+	height, err := parent.Height()
+	require.NoError(err)
+
+	receipts, err := consensus.ReceiptsRoot(ctx, cst, []*types.MessageReceipt{{GasAttoFIL: types.ZeroAttoFIL, Events: []*types.Event{event}}})
+	require.NoError(err)
+
+	blk := &types.Block{
+		Parents:         parent.ToSortedCidSet(),
+		Height:          types.Uint64(height + 1),
+		Messages:        []*types.SignedMessage{newSignedMessage()},
+		MessageReceipts: receipts,
+		StateRoot:       parent.ToSlice()[0].StateRoot,
+	}
+	_, err = cst.Put(ctx, blk)
+	require.NoError(err)
+
+	ts := types.RequireNewTipSet(require, blk)
+	chain.RequirePutTsas(ctx, require, chainStore, &chain.TipSetAndState{
+		TipSet:          ts,
+		TipSetStateRoot: blk.StateRoot,
+	})
+	return ts
+}
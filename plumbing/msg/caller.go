@@ -0,0 +1,62 @@
+package msg
+
+import (
+	"context"
+
+	hamt "gx/ipfs/QmRXf2uUSdGSunRJsM9wXSUNVwLUGCY3So5fAs7h2CBJVf/go-hamt-ipld"
+	bstore "gx/ipfs/QmS2aqUZLJp8kF1ihE5rvDGE5LvmKDPnx32w9Z1BW9xLV5/go-ipfs-blockstore"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/filecoin-project/go-filecoin/actor/builtin"
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+)
+
+// Caller dry-runs messages against the state of a tipset.
+type Caller struct {
+	// To find the state root of a tipset.
+	chainReader chain.ReadStore
+	// To load the tree for the state root.
+	cst *hamt.CborIpldStore
+	// For vm storage.
+	bs bstore.Blockstore
+}
+
+// NewCaller constructs a Caller.
+func NewCaller(chainReader chain.ReadStore, cst *hamt.CborIpldStore, bs bstore.Blockstore) *Caller {
+	return &Caller{chainReader, cst, bs}
+}
+
+// Call applies msg to the state resulting from the tipset with the given key,
+// as if it were included in the next block, without committing anything. An
+// empty key selects the head. See consensus.DryRunMessage.
+func (c *Caller) Call(ctx context.Context, tsKey types.SortedCidSet, msg *types.Message, gasLimit types.GasUnits) (*consensus.DryRunResult, error) {
+	key := tsKey.String()
+	if tsKey.Len() == 0 {
+		key = c.chainReader.Head().String()
+	}
+
+	tsas, err := c.chainReader.GetTipSetAndState(ctx, key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldnt get state of tipset %s", key)
+	}
+	st, err := state.LoadStateTree(ctx, c.cst, tsas.TipSetStateRoot, builtin.Actors)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldnt load state tree")
+	}
+
+	h, err := tsas.TipSet.Height()
+	if err != nil {
+		return nil, errors.Wrap(err, "couldnt get tipset height")
+	}
+	bh := types.NewBlockHeight(h + 1)
+	ancestors, err := chain.GetRecentAncestors(ctx, tsas.TipSet, c.chainReader, bh, consensus.AncestorRoundsNeeded, consensus.LookBackParameter)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldnt get ancestors")
+	}
+
+	return consensus.DryRunMessage(ctx, st, vm.NewStorageMap(c.bs), msg, gasLimit, bh, ancestors)
+}
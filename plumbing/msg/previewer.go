@@ -34,31 +34,32 @@ func NewPreviewer(wallet *wallet.Wallet, chainReader chain.ReadStore, cst *hamt.
 	return &Previewer{wallet, chainReader, cst, bs}
 }
 
-// Preview sends a read-only message to an actor.
-func (p *Previewer) Preview(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) (types.GasUnits, error) {
+// Preview sends a read-only message to an actor, and returns the gas it used
+// and a trace of its execution.
+func (p *Previewer) Preview(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) (types.GasUnits, *vm.ExecutionTrace, error) {
 	encodedParams, err := abi.ToEncodedValues(params...)
 	if err != nil {
-		return types.NewGasUnits(0), errors.Wrap(err, "couldnt encode message params")
+		return types.NewGasUnits(0), nil, errors.Wrap(err, "couldnt encode message params")
 	}
 
 	headTs := p.chainReader.Head()
 	tsas, err := p.chainReader.GetTipSetAndState(ctx, headTs.String())
 	if err != nil {
-		return types.NewGasUnits(0), errors.Wrap(err, "couldnt get latest state root")
+		return types.NewGasUnits(0), nil, errors.Wrap(err, "couldnt get latest state root")
 	}
 	st, err := state.LoadStateTree(ctx, p.cst, tsas.TipSetStateRoot, builtin.Actors)
 	if err != nil {
-		return types.NewGasUnits(0), errors.Wrap(err, "could load tree for latest state root")
+		return types.NewGasUnits(0), nil, errors.Wrap(err, "could load tree for latest state root")
 	}
 	h, err := headTs.Height()
 	if err != nil {
-		return types.NewGasUnits(0), errors.Wrap(err, "couldnt get base tipset height")
+		return types.NewGasUnits(0), nil, errors.Wrap(err, "couldnt get base tipset height")
 	}
 
 	vms := vm.NewStorageMap(p.bs)
-	usedGas, err := consensus.PreviewQueryMethod(ctx, st, vms, to, method, encodedParams, optFrom, types.NewBlockHeight(h))
+	usedGas, trace, err := consensus.PreviewQueryMethod(ctx, st, vms, to, method, encodedParams, optFrom, types.NewBlockHeight(h))
 	if err != nil {
-		return types.NewGasUnits(0), errors.Wrap(err, "query method returned an error")
+		return types.NewGasUnits(0), nil, errors.Wrap(err, "query method returned an error")
 	}
-	return usedGas, nil
+	return usedGas, trace, nil
 }
@@ -49,9 +49,14 @@ func TestPreview(t *testing.T) {
 		deps := requireCommonDepsWithGifAndBlockstore(require, testGen, r, bs)
 
 		previewer := NewPreviewer(deps.wallet, deps.chainStore, deps.cst, deps.blockstore)
-		returnValue, err := previewer.Preview(ctx, fromAddr, fakeActorAddr, "hasReturnValue")
+		returnValue, trace, err := previewer.Preview(ctx, fromAddr, fakeActorAddr, "hasReturnValue")
 		require.NoError(err)
 		require.NotNil(returnValue)
 		assert.Equal(types.NewGasUnits(100), returnValue)
+
+		require.NotNil(trace)
+		assert.Equal(fakeActorAddr, trace.To)
+		assert.Equal("hasReturnValue", trace.Method)
+		assert.Equal(returnValue, trace.GasUsed)
 	})
 }
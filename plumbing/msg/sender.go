@@ -67,6 +67,15 @@ func (s *Sender) Send(ctx context.Context, from, to address.Address, value *type
 		return cid.Undef, errors.Wrap(err, "failed to sign message")
 	}
 
+	// the first message of an account carries the public key for the account
+	// to record, so later messages can be verified with the recorded key.
+	if nonce == 0 {
+		smsg.PublicKey, err = s.wallet.PublicKey(from)
+		if err != nil {
+			return cid.Undef, errors.Wrap(err, "failed to get public key")
+		}
+	}
+
 	smsgdata, err := smsg.Marshal()
 	if err != nil {
 		return cid.Undef, errors.Wrap(err, "failed to marshal message")
@@ -41,6 +41,11 @@ func TestSend(t *testing.T) {
 		require.NoError(err)
 		assert.Equal(1, len(msgPool.Pending()))
 		assert.True(publishCalled)
+
+		// the first message of the account carries its public key
+		pubKey, err := w.PublicKey(addr)
+		require.NoError(err)
+		assert.Equal(types.Bytes(pubKey), msgPool.Pending()[0].PublicKey)
 	})
 
 	t.Run("send message avoids nonce race", func(t *testing.T) {
@@ -79,6 +84,9 @@ func TestSend(t *testing.T) {
 			_, found := nonces[uint64(message.Nonce)]
 			require.False(found)
 			nonces[uint64(message.Nonce)] = true
+
+			// only the first message carries the public key
+			assert.Equal(uint64(message.Nonce) == 0, len(message.PublicKey) > 0)
 		}
 	})
 
@@ -0,0 +1,118 @@
+package msg
+
+import (
+	"context"
+	"fmt"
+
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	hamt "gx/ipfs/QmRXf2uUSdGSunRJsM9wXSUNVwLUGCY3So5fAs7h2CBJVf/go-hamt-ipld"
+	bstore "gx/ipfs/QmS2aqUZLJp8kF1ihE5rvDGE5LvmKDPnx32w9Z1BW9xLV5/go-ipfs-blockstore"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/filecoin-project/go-filecoin/actor/builtin"
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+)
+
+// ErrMessageNotFound is returned when a message to trace is not on chain.
+var ErrMessageNotFound = errors.New("message not found on chain")
+
+// Tracer re-executes messages that are on chain and records how they were
+// executed.
+type Tracer struct {
+	// To find the message and the state it was applied to.
+	chainReader chain.ReadStore
+	// To load the tree for the parent state root.
+	cst *hamt.CborIpldStore
+	// For vm storage.
+	bs bstore.Blockstore
+}
+
+// NewTracer constructs a Tracer.
+func NewTracer(chainReader chain.ReadStore, cst *hamt.CborIpldStore, bs bstore.Blockstore) *Tracer {
+	return &Tracer{chainReader, cst, bs}
+}
+
+// Trace finds the tipset containing the message with the given cid and
+// re-executes that tipset's messages on its parent state, returning the
+// execution trace of the message. A message that conflicted with another
+// message of its tipset and was not applied has no trace.
+//
+// TODO: like Waiter.Wait this traverses the chain to find the message.
+func (t *Tracer) Trace(ctx context.Context, msgCid cid.Cid) (*vm.ExecutionTrace, error) {
+	ts, err := t.findTipSet(ctx, msgCid)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := ts.Parents()
+	if err != nil {
+		return nil, err
+	}
+	tsas, err := t.chainReader.GetTipSetAndState(ctx, ids.String())
+	if err != nil {
+		return nil, errors.Wrap(err, "couldnt get parent state root")
+	}
+	st, err := state.LoadStateTree(ctx, t.cst, tsas.TipSetStateRoot, builtin.Actors)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldnt load tree for parent state root")
+	}
+
+	tsHeight, err := ts.Height()
+	if err != nil {
+		return nil, err
+	}
+	ancestors, err := chain.GetRecentAncestors(ctx, tsas.TipSet, t.chainReader, types.NewBlockHeight(tsHeight), consensus.AncestorRoundsNeeded, consensus.LookBackParameter)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := consensus.NewTracingProcessor().ProcessTipSet(ctx, st, vm.NewStorageMap(t.bs), ts, ancestors)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldnt re-execute tipset")
+	}
+	if res.Failures.Has(msgCid) {
+		return nil, fmt.Errorf("message %s was not applied", msgCid)
+	}
+
+	j, err := msgIndexOfTipSet(msgCid, ts, res.Failures)
+	if err != nil {
+		return nil, err
+	}
+	if j >= len(res.Results) {
+		return nil, fmt.Errorf("no result for message %s", msgCid)
+	}
+	return res.Results[j].Trace, nil
+}
+
+// findTipSet walks back from the head to find the tipset containing the
+// message with the given cid.
+func (t *Tracer) findTipSet(ctx context.Context, msgCid cid.Cid) (types.TipSet, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for raw := range t.chainReader.BlockHistory(ctx, t.chainReader.Head()) {
+		switch v := raw.(type) {
+		case error:
+			return nil, v
+		case types.TipSet:
+			for _, blk := range v {
+				for _, msg := range blk.Messages {
+					c, err := msg.Cid()
+					if err != nil {
+						return nil, err
+					}
+					if c.Equals(msgCid) {
+						return v, nil
+					}
+				}
+			}
+		default:
+			return nil, fmt.Errorf("unexpected type in channel: %T", raw)
+		}
+	}
+	return nil, ErrMessageNotFound
+}
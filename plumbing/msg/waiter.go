@@ -133,10 +133,11 @@ func (w *Waiter) receiptFromTipSet(ctx context.Context, msgCid cid.Cid, ts types
 		if err != nil {
 			return nil, err
 		}
-		if j < len(b.MessageReceipts) {
-			rcpt = b.MessageReceipts[j]
+		rcpt, err = consensus.LoadReceipt(ctx, w.cst, b.MessageReceipts, j)
+		if err == hamt.ErrNotFound {
+			return nil, nil
 		}
-		return rcpt, nil
+		return rcpt, err
 	}
 
 	// Apply all the tipset's messages to determine the correct receipts.
@@ -0,0 +1,92 @@
+package mthdsig
+
+import (
+	"context"
+	"sort"
+
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor/builtin"
+	"github.com/filecoin-project/go-filecoin/address"
+)
+
+// ActorInterface describes everything an actor exports, so that clients can
+// call it without knowing it in advance.
+type ActorInterface struct {
+	Code cid.Cid `json:"code"`
+	// Methods are ordered by name.
+	Methods []*MethodDescription `json:"methods"`
+	// ExitCodes are the documented exit codes of the actor's methods, other
+	// than the generic ones of the vm, ordered by code.
+	ExitCodes []*ExitCodeDescription `json:"exitCodes"`
+}
+
+// MethodDescription describes an exported actor method.
+type MethodDescription struct {
+	Name   string     `json:"name"`
+	Params []abi.Type `json:"params"`
+	Return []abi.Type `json:"return"`
+}
+
+// ExitCodeDescription describes an exit code an actor's methods can return.
+type ExitCodeDescription struct {
+	Code    uint8  `json:"code"`
+	Message string `json:"message"`
+}
+
+// Describe returns the interface of the actor at the given address.
+func (sg *Getter) Describe(ctx context.Context, actorAddr address.Address) (*ActorInterface, error) {
+	st, err := sg.chainReader.LatestState(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldnt get current state tree")
+	}
+
+	actor, err := st.GetActor(ctx, actorAddr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get actor")
+	} else if !actor.Code.Defined() {
+		return nil, ErrNoActorImpl
+	}
+
+	return sg.DescribeCode(ctx, actor.Code)
+}
+
+// DescribeCode returns the interface of actors with the given code.
+func (sg *Getter) DescribeCode(ctx context.Context, code cid.Cid) (*ActorInterface, error) {
+	st, err := sg.chainReader.LatestState(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldnt get current state tree")
+	}
+
+	executable, err := st.GetBuiltinActorCode(code)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load actor code")
+	}
+
+	ai := &ActorInterface{
+		Code:      code,
+		Methods:   []*MethodDescription{},
+		ExitCodes: []*ExitCodeDescription{},
+	}
+	for name, sig := range executable.Exports() {
+		ai.Methods = append(ai.Methods, &MethodDescription{
+			Name:   name,
+			Params: append([]abi.Type{}, sig.Params...),
+			Return: append([]abi.Type{}, sig.Return...),
+		})
+	}
+	sort.Slice(ai.Methods, func(i, j int) bool {
+		return ai.Methods[i].Name < ai.Methods[j].Name
+	})
+
+	for exitCode, err := range builtin.ActorErrors[code] {
+		ai.ExitCodes = append(ai.ExitCodes, &ExitCodeDescription{Code: exitCode, Message: err.Error()})
+	}
+	sort.Slice(ai.ExitCodes, func(i, j int) bool {
+		return ai.ExitCodes[i].Code < ai.ExitCodes[j].Code
+	})
+
+	return ai, nil
+}
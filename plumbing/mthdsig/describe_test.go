@@ -0,0 +1,85 @@
+package mthdsig_test
+
+import (
+	"context"
+	"testing"
+
+	hamt "gx/ipfs/QmRXf2uUSdGSunRJsM9wXSUNVwLUGCY3So5fAs7h2CBJVf/go-hamt-ipld"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/plumbing/mthdsig"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescribe(t *testing.T) {
+	t.Parallel()
+
+	t.Run("describes the methods and exit codes of an actor's code", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		ctx := context.Background()
+		_, st := th.RequireMakeStateTree(require, hamt.NewCborStore(), map[address.Address]*actor.Actor{})
+		getter := mthdsig.NewGetter(&fakeChainReadStore{st})
+
+		ai, err := getter.DescribeCode(ctx, types.StorageMarketActorCodeCid)
+		require.NoError(err)
+		assert.Equal(types.StorageMarketActorCodeCid, ai.Code)
+
+		require.Len(ai.Methods, len((&storagemarket.Actor{}).Exports()))
+		for i := 1; i < len(ai.Methods); i++ {
+			assert.True(ai.Methods[i-1].Name < ai.Methods[i].Name)
+		}
+		var createMiner *mthdsig.MethodDescription
+		for _, m := range ai.Methods {
+			if m.Name == "createMiner" {
+				createMiner = m
+			}
+		}
+		require.NotNil(createMiner)
+		assert.Equal([]abi.Type{abi.Integer, abi.Bytes, abi.PeerID}, createMiner.Params)
+		assert.Equal([]abi.Type{abi.Address}, createMiner.Return)
+
+		require.Len(ai.ExitCodes, len(storagemarket.Errors))
+		for _, ec := range ai.ExitCodes {
+			assert.Equal(storagemarket.Errors[ec.Code].Error(), ec.Message)
+		}
+	})
+
+	t.Run("describes the actor at an address", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		ctx := context.Background()
+		addr := address.NewForTestGetter()()
+		_, st := th.RequireMakeStateTree(require, hamt.NewCborStore(), map[address.Address]*actor.Actor{
+			addr: th.RequireNewAccountActor(require, types.NewAttoFILFromFIL(10)),
+		})
+		getter := mthdsig.NewGetter(&fakeChainReadStore{st})
+
+		ai, err := getter.Describe(ctx, addr)
+		require.NoError(err)
+		assert.Equal(types.AccountActorCodeCid, ai.Code)
+		assert.Empty(ai.ExitCodes)
+	})
+
+	t.Run("errors if actor undefined", func(t *testing.T) {
+		require := require.New(t)
+
+		ctx := context.Background()
+		addr := address.NewForTestGetter()()
+		_, st := th.RequireMakeStateTree(require, hamt.NewCborStore(), map[address.Address]*actor.Actor{
+			addr: th.RequireNewEmptyActor(require, types.NewAttoFILFromFIL(0)),
+		})
+		getter := mthdsig.NewGetter(&fakeChainReadStore{st})
+
+		_, err := getter.Describe(ctx, addr)
+		require.Equal(mthdsig.ErrNoActorImpl, err)
+	})
+}
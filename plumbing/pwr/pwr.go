@@ -0,0 +1,56 @@
+package pwr
+
+import (
+	"context"
+
+	hamt "gx/ipfs/QmRXf2uUSdGSunRJsM9wXSUNVwLUGCY3So5fAs7h2CBJVf/go-hamt-ipld"
+	bstore "gx/ipfs/QmS2aqUZLJp8kF1ihE5rvDGE5LvmKDPnx32w9Z1BW9xLV5/go-ipfs-blockstore"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/filecoin-project/go-filecoin/actor/builtin"
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// ChainReadStore is the subset of chain.ReadStore that Getter needs.
+type ChainReadStore interface {
+	GetTipSetAndState(ctx context.Context, tsKey string) (*chain.TipSetAndState, error)
+	Head() types.TipSet
+}
+
+// Getter knows how to get the power table of a tipset.
+type Getter struct {
+	// To find the state root of a tipset.
+	chainReader ChainReadStore
+	// To load the state tree for the state root.
+	cst *hamt.CborIpldStore
+	// For actor storage.
+	bs bstore.Blockstore
+}
+
+// NewGetter returns a new Getter.
+func NewGetter(chainReader ChainReadStore, cst *hamt.CborIpldStore, bs bstore.Blockstore) *Getter {
+	return &Getter{chainReader, cst, bs}
+}
+
+// PowerTable returns the power table in the state resulting from applying
+// the tipset with the given key. An empty key selects the head.
+func (g *Getter) PowerTable(ctx context.Context, tsKey types.SortedCidSet) (*consensus.PowerTable, error) {
+	key := tsKey.String()
+	if tsKey.Len() == 0 {
+		key = g.chainReader.Head().String()
+	}
+
+	tsas, err := g.chainReader.GetTipSetAndState(ctx, key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldnt get state of tipset %s", key)
+	}
+	st, err := state.LoadStateTree(ctx, g.cst, tsas.TipSetStateRoot, builtin.Actors)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldnt load state tree")
+	}
+
+	return consensus.LoadPowerTable(ctx, st, g.bs)
+}
@@ -0,0 +1,41 @@
+package stt
+
+import (
+	"context"
+
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	hamt "gx/ipfs/QmRXf2uUSdGSunRJsM9wXSUNVwLUGCY3So5fAs7h2CBJVf/go-hamt-ipld"
+	bstore "gx/ipfs/QmS2aqUZLJp8kF1ihE5rvDGE5LvmKDPnx32w9Z1BW9xLV5/go-ipfs-blockstore"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/filecoin-project/go-filecoin/actor/builtin"
+	"github.com/filecoin-project/go-filecoin/state"
+)
+
+// Differ knows how to compare state trees.
+type Differ struct {
+	// To load the state trees.
+	cst *hamt.CborIpldStore
+	// For actor storage.
+	bs bstore.Blockstore
+}
+
+// NewDiffer returns a new Differ.
+func NewDiffer(cst *hamt.CborIpldStore, bs bstore.Blockstore) *Differ {
+	return &Differ{cst: cst, bs: bs}
+}
+
+// Diff reports the actors that differ between the state trees with the given
+// roots, decoding the storage of builtin actors. See state.Diff.
+func (d *Differ) Diff(ctx context.Context, beforeRoot, afterRoot cid.Cid) ([]*state.ActorDiff, error) {
+	before, err := state.LoadStateTree(ctx, d.cst, beforeRoot, builtin.Actors)
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldnt load state tree %s", beforeRoot)
+	}
+	after, err := state.LoadStateTree(ctx, d.cst, afterRoot, builtin.Actors)
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldnt load state tree %s", afterRoot)
+	}
+
+	return state.Diff(ctx, before, after, builtin.StorageDecoder(d.bs))
+}
@@ -0,0 +1,182 @@
+package stt
+
+import (
+	"context"
+	"fmt"
+
+	"gx/ipfs/QmRXf2uUSdGSunRJsM9wXSUNVwLUGCY3So5fAs7h2CBJVf/go-hamt-ipld"
+	bstore "gx/ipfs/QmS2aqUZLJp8kF1ihE5rvDGE5LvmKDPnx32w9Z1BW9xLV5/go-ipfs-blockstore"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/initactor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/multisig"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+)
+
+// Reader reads the state of builtin actors directly from the state of a
+// tipset. Unlike querying actor methods, it executes no actor code, costs no
+// gas and needs no from address.
+type Reader struct {
+	// To find the state root of a tipset.
+	chainReader chain.ReadStore
+	// To load the tree for the state root.
+	cst *hamt.CborIpldStore
+	// For actor storage.
+	bs bstore.Blockstore
+}
+
+// NewReader returns a new Reader.
+func NewReader(chainReader chain.ReadStore, cst *hamt.CborIpldStore, bs bstore.Blockstore) *Reader {
+	return &Reader{chainReader: chainReader, cst: cst, bs: bs}
+}
+
+// ActorState returns the decoded state of the actor at addr in the state
+// resulting from the tipset with the given key, or nil if the actor has no
+// state. An empty key selects the head.
+func (r *Reader) ActorState(ctx context.Context, tsKey types.SortedCidSet, addr address.Address) (interface{}, error) {
+	act, storage, err := r.actorStorage(ctx, tsKey, addr)
+	if err != nil {
+		return nil, err
+	}
+	return builtin.LoadState(ctx, act.Code, storage)
+}
+
+// MinerState returns the state of the miner actor at addr. An empty key
+// selects the head.
+func (r *Reader) MinerState(ctx context.Context, tsKey types.SortedCidSet, addr address.Address) (*miner.State, error) {
+	storage, err := r.minerStorage(ctx, tsKey, addr)
+	if err != nil {
+		return nil, err
+	}
+	return miner.LoadState(storage)
+}
+
+// MinerAsks returns the open asks of the miner actor at addr, ordered by id.
+// An empty key selects the head.
+func (r *Reader) MinerAsks(ctx context.Context, tsKey types.SortedCidSet, addr address.Address) ([]*miner.Ask, error) {
+	storage, err := r.minerStorage(ctx, tsKey, addr)
+	if err != nil {
+		return nil, err
+	}
+	return miner.LoadAsks(ctx, storage)
+}
+
+// MinerSectorCommitments returns the commitments of all sectors the miner
+// actor at addr has committed, by sector id. An empty key selects the head.
+func (r *Reader) MinerSectorCommitments(ctx context.Context, tsKey types.SortedCidSet, addr address.Address) (map[string]types.Commitments, error) {
+	storage, err := r.minerStorage(ctx, tsKey, addr)
+	if err != nil {
+		return nil, err
+	}
+	return miner.LoadSectorCommitments(ctx, storage)
+}
+
+// MultisigState returns the state of the multisig wallet at addr. An empty
+// key selects the head.
+func (r *Reader) MultisigState(ctx context.Context, tsKey types.SortedCidSet, addr address.Address) (*multisig.State, error) {
+	act, storage, err := r.actorStorage(ctx, tsKey, addr)
+	if err != nil {
+		return nil, err
+	}
+	if !act.Code.Equals(types.MultisigActorCodeCid) {
+		return nil, fmt.Errorf("actor at %s is not a multisig wallet", addr)
+	}
+	return multisig.LoadState(storage)
+}
+
+// StorageMarketState returns the state of the storage market actor. An empty
+// key selects the head.
+func (r *Reader) StorageMarketState(ctx context.Context, tsKey types.SortedCidSet) (*storagemarket.State, error) {
+	_, storage, err := r.actorStorage(ctx, tsKey, address.StorageMarketAddress)
+	if err != nil {
+		return nil, err
+	}
+	return storagemarket.LoadState(storage)
+}
+
+// StorageMarketDeals returns all deals published in the storage market,
+// ordered by id. An empty key selects the head.
+func (r *Reader) StorageMarketDeals(ctx context.Context, tsKey types.SortedCidSet) ([]*storagemarket.Deal, error) {
+	_, storage, err := r.actorStorage(ctx, tsKey, address.StorageMarketAddress)
+	if err != nil {
+		return nil, err
+	}
+	return storagemarket.LoadDeals(ctx, storage)
+}
+
+// ActorID returns the ID address the init actor assigned to the actor at
+// addr. An empty key selects the head.
+func (r *Reader) ActorID(ctx context.Context, tsKey types.SortedCidSet, addr address.Address) (address.Address, bool, error) {
+	_, storage, err := r.actorStorage(ctx, tsKey, address.InitAddress)
+	if err != nil {
+		return address.Address{}, false, err
+	}
+	return initactor.LookupID(ctx, storage, addr)
+}
+
+// ActorAddress returns the robust address of the actor the init actor
+// assigned the given ID address to. An empty key selects the head.
+func (r *Reader) ActorAddress(ctx context.Context, tsKey types.SortedCidSet, idAddr address.Address) (address.Address, bool, error) {
+	_, storage, err := r.actorStorage(ctx, tsKey, address.InitAddress)
+	if err != nil {
+		return address.Address{}, false, err
+	}
+	return initactor.LookupAddress(ctx, storage, idAddr)
+}
+
+// PaymentChannels returns the payment channels of payer by channel id. An
+// empty key selects the head.
+func (r *Reader) PaymentChannels(ctx context.Context, tsKey types.SortedCidSet, payer address.Address) (map[string]*paymentbroker.PaymentChannel, error) {
+	_, storage, err := r.actorStorage(ctx, tsKey, address.PaymentBrokerAddress)
+	if err != nil {
+		return nil, err
+	}
+	return paymentbroker.LoadChannels(ctx, storage, payer)
+}
+
+// minerStorage returns read only access to the storage of the miner actor at
+// addr, in the state resulting from the tipset with the given key.
+func (r *Reader) minerStorage(ctx context.Context, tsKey types.SortedCidSet, addr address.Address) (exec.Storage, error) {
+	act, storage, err := r.actorStorage(ctx, tsKey, addr)
+	if err != nil {
+		return nil, err
+	}
+	if !act.Code.Equals(types.MinerActorCodeCid) && !act.Code.Equals(types.BootstrapMinerActorCodeCid) {
+		return nil, fmt.Errorf("actor at %s is not a miner", addr)
+	}
+	return storage, nil
+}
+
+// actorStorage returns the actor at addr, and read only access to its
+// storage, in the state resulting from the tipset with the given key.
+func (r *Reader) actorStorage(ctx context.Context, tsKey types.SortedCidSet, addr address.Address) (*actor.Actor, exec.Storage, error) {
+	key := tsKey.String()
+	if tsKey.Len() == 0 {
+		key = r.chainReader.Head().String()
+	}
+
+	tsas, err := r.chainReader.GetTipSetAndState(ctx, key)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "couldnt get state of tipset %s", key)
+	}
+	st, err := state.LoadStateTree(ctx, r.cst, tsas.TipSetStateRoot, builtin.Actors)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "couldnt load state tree")
+	}
+
+	act, err := st.GetActor(ctx, addr)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "couldnt get actor %s", addr)
+	}
+	return act, vm.NewStorage(r.bs, act), nil
+}
@@ -0,0 +1,127 @@
+package stt_test
+
+import (
+	"context"
+	"testing"
+
+	"gx/ipfs/QmRXf2uUSdGSunRJsM9wXSUNVwLUGCY3So5fAs7h2CBJVf/go-hamt-ipld"
+	bstore "gx/ipfs/QmS2aqUZLJp8kF1ihE5rvDGE5LvmKDPnx32w9Z1BW9xLV5/go-ipfs-blockstore"
+	bserv "gx/ipfs/QmYPZzd9VqmJDwxUnThfeSbV1Y5o53aVPDijTB7j7rS9Ep/go-blockservice"
+	offline "gx/ipfs/QmYZwey1thDTynSrvd6qQkX24UpTka6TFhQ2v569UpoqxD/go-ipfs-exchange-offline"
+
+	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/plumbing/stt"
+	"github.com/filecoin-project/go-filecoin/repo"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReader(t *testing.T) {
+	t.Parallel()
+
+	account := address.NewForTestGetter()()
+
+	newReader := func(require *require.Assertions) (*stt.Reader, *chain.DefaultStore) {
+		r := repo.NewInMemoryRepo()
+		bs := bstore.NewBlockstore(r.Datastore())
+		cst := &hamt.CborIpldStore{Blocks: bserv.New(bs, offline.Exchange(bs))}
+		gif := consensus.MakeGenesisFunc(consensus.ActorAccount(account, types.NewAttoFILFromFIL(10)))
+		chainStore, err := chain.Init(context.Background(), r, bs, cst, gif)
+		require.NoError(err)
+		return stt.NewReader(chainStore, cst, bs), chainStore
+	}
+
+	t.Run("reads the state of the storage market", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+		ctx := context.Background()
+
+		reader, chainStore := newReader(require)
+
+		st, err := reader.StorageMarketState(ctx, types.SortedCidSet{})
+		require.NoError(err)
+		assert.True(types.NewBytesAmount(0).Equal(st.TotalCommittedStorage))
+
+		generic, err := reader.ActorState(ctx, chainStore.Head().ToSortedCidSet(), address.StorageMarketAddress)
+		require.NoError(err)
+		assert.Equal(st, generic.(*storagemarket.State))
+
+		deals, err := reader.StorageMarketDeals(ctx, types.SortedCidSet{})
+		require.NoError(err)
+		assert.Empty(deals)
+	})
+
+	t.Run("reads the payment channels of a payer", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		reader, _ := newReader(require)
+
+		channels, err := reader.PaymentChannels(context.Background(), types.SortedCidSet{}, account)
+		require.NoError(err)
+		assert.Empty(channels)
+	})
+
+	t.Run("returns no state for accounts", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		reader, _ := newReader(require)
+
+		st, err := reader.ActorState(context.Background(), types.SortedCidSet{}, account)
+		require.NoError(err)
+		assert.Nil(st)
+	})
+
+	t.Run("reads the ids of actors", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		reader, _ := newReader(require)
+
+		ctx := context.Background()
+
+		// the singletons come first
+		idAddr, ok, err := reader.ActorID(ctx, types.SortedCidSet{}, address.InitAddress)
+		require.NoError(err)
+		require.True(ok)
+		assert.Equal(address.NewIDAddress(address.Mainnet, 0), idAddr)
+
+		idAddr, ok, err = reader.ActorID(ctx, types.SortedCidSet{}, account)
+		require.NoError(err)
+		require.True(ok)
+		robust, ok, err := reader.ActorAddress(ctx, types.SortedCidSet{}, idAddr)
+		require.NoError(err)
+		require.True(ok)
+		assert.Equal(account, robust)
+	})
+
+	t.Run("errors when reading a non miner as a miner", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		reader, _ := newReader(require)
+
+		_, err := reader.MinerState(context.Background(), types.SortedCidSet{}, address.StorageMarketAddress)
+		assert.Error(err)
+		assert.Contains(err.Error(), "is not a miner")
+
+		_, err = reader.MinerSectorCommitments(context.Background(), types.SortedCidSet{}, address.StorageMarketAddress)
+		assert.Error(err)
+		assert.Contains(err.Error(), "is not a miner")
+	})
+	t.Run("errors when reading a non multisig as a multisig", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		reader, _ := newReader(require)
+
+		_, err := reader.MultisigState(context.Background(), types.SortedCidSet{}, account)
+		assert.Error(err)
+		assert.Contains(err.Error(), "is not a multisig wallet")
+	})
+}
@@ -46,6 +46,12 @@ func (a *API) ChainBlockHeight(ctx context.Context) (*types.BlockHeight, error)
 	return ChainBlockHeight(ctx, a)
 }
 
+// ChainSampleRandomness returns the chain randomness actors sample for the
+// tipset at sampleHeight
+func (a *API) ChainSampleRandomness(ctx context.Context, sampleHeight *types.BlockHeight) ([]byte, error) {
+	return ChainSampleRandomness(ctx, a, sampleHeight)
+}
+
 // CreatePayments establishes a payment channel and create multiple payments against it
 func (a *API) CreatePayments(ctx context.Context, config CreatePaymentsParams) (*CreatePaymentsReturn, error) {
 	return CreatePayments(ctx, a, config)
@@ -119,6 +125,26 @@ func (a *API) MinerPreviewSetPrice(
 	return MinerPreviewSetPrice(ctx, a, from, miner, price, expiry)
 }
 
+// MsigCreate creates a multisig wallet and returns its address
+func (a *API) MsigCreate(ctx context.Context, from address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits, value *types.AttoFIL, signers []address.Address, required uint64, unlockDuration *types.BlockHeight) (address.Address, error) {
+	return MsigCreate(ctx, a, from, gasPrice, gasLimit, value, signers, required, unlockDuration)
+}
+
+// MsigPropose proposes a transaction from a multisig wallet and returns its id
+func (a *API) MsigPropose(ctx context.Context, from, msigAddr address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits, to address.Address, value *types.AttoFIL, method string, params ...interface{}) (*big.Int, error) {
+	return MsigPropose(ctx, a, from, msigAddr, gasPrice, gasLimit, to, value, method, params...)
+}
+
+// MsigApprove approves a pending transaction of a multisig wallet
+func (a *API) MsigApprove(ctx context.Context, from, msigAddr address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits, txID *big.Int) error {
+	return MsigApprove(ctx, a, from, msigAddr, gasPrice, gasLimit, txID)
+}
+
+// MsigCancel cancels a pending transaction of a multisig wallet
+func (a *API) MsigCancel(ctx context.Context, from, msigAddr address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits, txID *big.Int) error {
+	return MsigCancel(ctx, a, from, msigAddr, gasPrice, gasLimit, txID)
+}
+
 // GetAndMaybeSetDefaultSenderAddress returns a default address from which to
 // send messsages. If none is set it picks the first address in the wallet and
 // sets it as the default in the config.
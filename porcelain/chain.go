@@ -3,6 +3,9 @@ package porcelain
 import (
 	"context"
 	"errors"
+	"fmt"
+
+	"github.com/filecoin-project/go-filecoin/consensus"
 	"github.com/filecoin-project/go-filecoin/types"
 )
 
@@ -27,3 +30,52 @@ func ChainBlockHeight(ctx context.Context, plumbing chPlumbing) (*types.BlockHei
 	}
 	return types.NewBlockHeight(currentHeight), nil
 }
+
+// ChainSampleRandomness returns the chain randomness sampled for the tipset
+// at sampleHeight, or for the last tipset before it if sampleHeight is a null
+// round, the way actors sample it: the min ticket of the tipset
+// consensus.LookBackParameter tipsets before it, or of the genesis tipset if
+// the chain is not that long.
+func ChainSampleRandomness(ctx context.Context, plumbing chPlumbing, sampleHeight *types.BlockHeight) ([]byte, error) {
+	lsCtx, cancelLs := context.WithCancel(ctx)
+	defer cancelLs()
+
+	found := false
+	lookBack := 0
+	var last types.TipSet
+	for raw := range plumbing.ChainLs(lsCtx) {
+		var ts types.TipSet
+		switch v := raw.(type) {
+		case error:
+			return nil, v
+		case types.TipSet:
+			ts = v
+		default:
+			return nil, fmt.Errorf("unexpected type %T in chain", raw)
+		}
+
+		height, err := ts.Height()
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			// heights above the head have not been mined yet
+			if last == nil && types.NewBlockHeight(height).LessThan(sampleHeight) {
+				break
+			}
+			found = types.NewBlockHeight(height).LessEqual(sampleHeight)
+		} else {
+			lookBack++
+		}
+		if found && lookBack == consensus.LookBackParameter {
+			return ts.MinTicket()
+		}
+		last = ts
+	}
+
+	if !found {
+		return nil, fmt.Errorf("no tipset at height %s to sample randomness from", sampleHeight)
+	}
+	// the chain is shorter than the look back, so last is the genesis tipset
+	return last.MinTicket()
+}
@@ -0,0 +1,91 @@
+package porcelain_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/filecoin-project/go-filecoin/porcelain"
+	"github.com/filecoin-project/go-filecoin/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type chainSamplePlumbing struct {
+	tipSets []types.TipSet
+}
+
+// newChainSamplePlumbing returns plumbing with a chain of tipsets at the given
+// heights, newest first, whose tickets are their heights.
+func newChainSamplePlumbing(require *require.Assertions, heights ...uint64) *chainSamplePlumbing {
+	var tipSets []types.TipSet
+	for _, h := range heights {
+		blk := &types.Block{Height: types.Uint64(h), Ticket: []byte(strconv.FormatUint(h, 10))}
+		tipSets = append(tipSets, types.RequireNewTipSet(require, blk))
+	}
+	return &chainSamplePlumbing{tipSets: tipSets}
+}
+
+func (csp *chainSamplePlumbing) ChainLs(ctx context.Context) <-chan interface{} {
+	out := make(chan interface{})
+	go func() {
+		defer close(out)
+		for _, ts := range csp.tipSets {
+			select {
+			case out <- ts:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func TestChainSampleRandomness(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("samples the tipset three tipsets back", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		// height 7 is a null round
+		plumbing := newChainSamplePlumbing(require, 10, 9, 8, 6, 5, 4, 3, 2, 1, 0)
+
+		r, err := porcelain.ChainSampleRandomness(ctx, plumbing, types.NewBlockHeight(9))
+		require.NoError(err)
+		assert.Equal([]byte("5"), r)
+	})
+
+	t.Run("samples genesis for the first tipsets", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		plumbing := newChainSamplePlumbing(require, 3, 2, 1, 0)
+
+		r, err := porcelain.ChainSampleRandomness(ctx, plumbing, types.NewBlockHeight(1))
+		require.NoError(err)
+		assert.Equal([]byte("0"), r)
+	})
+
+	t.Run("samples the tipset before a null round", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		plumbing := newChainSamplePlumbing(require, 10, 9, 8, 6, 5, 4, 3, 2, 1, 0)
+
+		r, err := porcelain.ChainSampleRandomness(ctx, plumbing, types.NewBlockHeight(7))
+		require.NoError(err)
+		assert.Equal([]byte("3"), r)
+	})
+
+	t.Run("fails above the head", func(t *testing.T) {
+		require := require.New(t)
+
+		plumbing := newChainSamplePlumbing(require, 3, 2, 1, 0)
+
+		_, err := porcelain.ChainSampleRandomness(ctx, plumbing, types.NewBlockHeight(4))
+		require.Error(err)
+	})
+}
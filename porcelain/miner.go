@@ -7,13 +7,11 @@ import (
 	"math/big"
 
 	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
-	cbor "gx/ipfs/QmRoARq3nkUb13HSKZGepCZSWe5GrVPwx7xURJGZ7KWv9V/go-ipld-cbor"
 	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
 	"gx/ipfs/QmY5Grm8pJdiSSVsYxx4uNRgweY72EmYwuSDbRnbFok3iY/go-libp2p-peer"
 
 	minerActor "github.com/filecoin-project/go-filecoin/actor/builtin/miner"
 	"github.com/filecoin-project/go-filecoin/address"
-	"github.com/filecoin-project/go-filecoin/exec"
 	"github.com/filecoin-project/go-filecoin/types"
 	vmErrors "github.com/filecoin-project/go-filecoin/vm/errors"
 	w "github.com/filecoin-project/go-filecoin/wallet"
@@ -201,54 +199,51 @@ func MinerPreviewSetPrice(ctx context.Context, plumbing mpspAPI, from address.Ad
 
 // mgoaAPI is the subset of the plumbing.API that MinerGetOwnerAddress uses.
 type mgoaAPI interface {
-	MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, *exec.FunctionSignature, error)
+	MinerState(ctx context.Context, tsKey types.SortedCidSet, minerAddr address.Address) (*minerActor.State, error)
 }
 
 // MinerGetOwnerAddress queries for the owner address of the given miner
 func MinerGetOwnerAddress(ctx context.Context, plumbing mgoaAPI, minerAddr address.Address) (address.Address, error) {
-	res, _, err := plumbing.MessageQuery(ctx, address.Address{}, minerAddr, "getOwner")
+	state, err := plumbing.MinerState(ctx, types.SortedCidSet{}, minerAddr)
 	if err != nil {
 		return address.Address{}, err
 	}
 
-	return address.NewFromBytes(res[0])
+	return state.Owner, nil
 }
 
 // mgaAPI is the subset of the plumbing.API that MinerGetAsk uses.
 type mgaAPI interface {
-	MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, *exec.FunctionSignature, error)
+	MinerAsks(ctx context.Context, tsKey types.SortedCidSet, minerAddr address.Address) ([]*minerActor.Ask, error)
 }
 
 // MinerGetAsk queries for an ask of the given miner
 func MinerGetAsk(ctx context.Context, plumbing mgaAPI, minerAddr address.Address, askID uint64) (minerActor.Ask, error) {
-	ret, _, err := plumbing.MessageQuery(ctx, address.Address{}, minerAddr, "getAsk", big.NewInt(int64(askID)))
+	asks, err := plumbing.MinerAsks(ctx, types.SortedCidSet{}, minerAddr)
 	if err != nil {
 		return minerActor.Ask{}, err
 	}
 
-	var ask minerActor.Ask
-	if err := cbor.DecodeInto(ret[0], &ask); err != nil {
-		return minerActor.Ask{}, err
+	for _, ask := range asks {
+		if ask.ID.IsUint64() && ask.ID.Uint64() == askID {
+			return *ask, nil
+		}
 	}
 
-	return ask, nil
+	return minerActor.Ask{}, fmt.Errorf("miner %s has no ask with id %d", minerAddr, askID)
 }
 
 // mgpidAPI is the subset of the plumbing.API that MinerGetPeerID uses.
 type mgpidAPI interface {
-	MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, *exec.FunctionSignature, error)
+	MinerState(ctx context.Context, tsKey types.SortedCidSet, minerAddr address.Address) (*minerActor.State, error)
 }
 
 // MinerGetPeerID queries for the peer id of the given miner
 func MinerGetPeerID(ctx context.Context, plumbing mgpidAPI, minerAddr address.Address) (peer.ID, error) {
-	res, _, err := plumbing.MessageQuery(ctx, address.Address{}, minerAddr, "getPeerID")
+	state, err := plumbing.MinerState(ctx, types.SortedCidSet{}, minerAddr)
 	if err != nil {
 		return "", err
 	}
 
-	pid, err := peer.IDFromBytes(res[0])
-	if err != nil {
-		return peer.ID(""), errors.Wrap(err, "could not decode to peer.ID from message-bytes")
-	}
-	return pid, nil
+	return state.PeerID, nil
 }
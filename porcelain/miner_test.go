@@ -7,12 +7,10 @@ import (
 	"testing"
 
 	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
-	cbor "gx/ipfs/QmRoARq3nkUb13HSKZGepCZSWe5GrVPwx7xURJGZ7KWv9V/go-ipld-cbor"
 	"gx/ipfs/QmY5Grm8pJdiSSVsYxx4uNRgweY72EmYwuSDbRnbFok3iY/go-libp2p-peer"
 
 	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
 	"github.com/filecoin-project/go-filecoin/address"
-	"github.com/filecoin-project/go-filecoin/exec"
 	"github.com/filecoin-project/go-filecoin/plumbing/cfg"
 	"github.com/filecoin-project/go-filecoin/repo"
 	"github.com/filecoin-project/go-filecoin/types"
@@ -351,64 +349,74 @@ func TestMinerPreviewSetPrice(t *testing.T) {
 	})
 }
 
-type minerGetOwnerPlumbing struct{}
+type minerStatePlumbing struct {
+	state *miner.State
+}
+
+func (msp *minerStatePlumbing) MinerState(ctx context.Context, tsKey types.SortedCidSet, minerAddr address.Address) (*miner.State, error) {
+	if minerAddr != address.TestAddress2 {
+		return nil, errors.New("no such miner")
+	}
+	return msp.state, nil
+}
 
-func (mgop *minerGetOwnerPlumbing) MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, *exec.FunctionSignature, error) {
-	return [][]byte{address.TestAddress.Bytes()}, nil, nil
+type minerAsksPlumbing struct {
+	asks []*miner.Ask
+}
+
+func (m *minerAsksPlumbing) MinerAsks(ctx context.Context, tsKey types.SortedCidSet, minerAddr address.Address) ([]*miner.Ask, error) {
+	if minerAddr != address.TestAddress2 {
+		return nil, errors.New("no such miner")
+	}
+	return m.asks, nil
 }
 
 func TestMinerGetOwnerAddress(t *testing.T) {
 	assert := assert.New(t)
 
-	addr, err := MinerGetOwnerAddress(context.Background(), &minerGetOwnerPlumbing{}, address.TestAddress2)
+	plumbing := &minerStatePlumbing{&miner.State{Owner: address.TestAddress}}
+	addr, err := MinerGetOwnerAddress(context.Background(), plumbing, address.TestAddress2)
 	assert.NoError(err)
 	assert.Equal(address.TestAddress, addr)
 }
 
-type minerGetPeerIDPlumbing struct{}
-
-func (mgop *minerGetPeerIDPlumbing) MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, *exec.FunctionSignature, error) {
-
-	peerID := requirePeerID()
-	return [][]byte{[]byte(peerID)}, nil, nil
-}
-
 func TestMinerGetPeerID(t *testing.T) {
 	assert := assert.New(t)
 	require := require.New(t)
 
-	id, err := MinerGetPeerID(context.Background(), &minerGetPeerIDPlumbing{}, address.TestAddress2)
+	plumbing := &minerStatePlumbing{&miner.State{PeerID: requirePeerID()}}
+	id, err := MinerGetPeerID(context.Background(), plumbing, address.TestAddress2)
 	require.NoError(err)
 
 	expected := requirePeerID()
-	require.NoError(err)
 	assert.Equal(expected, id)
 }
 
-type minerGetAskPlumbing struct{}
+func TestMinerGetAsk(t *testing.T) {
+	t.Run("returns the ask with the given id", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		plumbing := &minerAsksPlumbing{[]*miner.Ask{
+			{Price: types.NewAttoFILFromFIL(30), Expiry: types.NewBlockHeight(40), ID: big.NewInt(3)},
+			{Price: types.NewAttoFILFromFIL(32), Expiry: types.NewBlockHeight(41), ID: big.NewInt(4)},
+		}}
 
-func (mgop *minerGetAskPlumbing) MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, *exec.FunctionSignature, error) {
-	out, err := cbor.DumpObject(miner.Ask{
-		Price:  types.NewAttoFILFromFIL(32),
-		Expiry: types.NewBlockHeight(41),
-		ID:     big.NewInt(4),
-	})
-	if err != nil {
-		panic("Could not encode ask")
-	}
-	return [][]byte{out}, nil, nil
-}
+		ask, err := MinerGetAsk(context.Background(), plumbing, address.TestAddress2, 4)
+		require.NoError(err)
 
-func TestMinerGetAsk(t *testing.T) {
-	assert := assert.New(t)
-	require := require.New(t)
+		assert.Equal(types.NewAttoFILFromFIL(32), ask.Price)
+		assert.Equal(types.NewBlockHeight(41), ask.Expiry)
+		assert.Equal(big.NewInt(4), ask.ID)
+	})
 
-	ask, err := MinerGetAsk(context.Background(), &minerGetAskPlumbing{}, address.TestAddress2, 4)
-	require.NoError(err)
+	t.Run("errors when the ask does not exist", func(t *testing.T) {
+		assert := assert.New(t)
 
-	assert.Equal(types.NewAttoFILFromFIL(32), ask.Price)
-	assert.Equal(types.NewBlockHeight(41), ask.Expiry)
-	assert.Equal(big.NewInt(4), ask.ID)
+		plumbing := &minerAsksPlumbing{}
+		_, err := MinerGetAsk(context.Background(), plumbing, address.TestAddress2, 4)
+		assert.Error(err)
+	})
 }
 
 func requirePeerID() peer.ID {
@@ -0,0 +1,101 @@
+package porcelain
+
+import (
+	"context"
+	"math/big"
+
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/multisig"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/types"
+	vmErrors "github.com/filecoin-project/go-filecoin/vm/errors"
+)
+
+// msigAPI is the subset of the plumbing.API that the multisig calls use.
+type msigAPI interface {
+	MessageSendWithDefaultAddress(ctx context.Context, from, to address.Address, value *types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error)
+	MessageWait(ctx context.Context, msgCid cid.Cid, cb func(*types.Block, *types.SignedMessage, *types.MessageReceipt) error) error
+}
+
+// MsigCreate creates a multisig wallet with the given signers, of which
+// required must approve every transaction, and waits for it to be mined. The
+// value funds the wallet, and unlocks linearly over unlockDuration blocks.
+// It returns the address of the wallet.
+func MsigCreate(ctx context.Context, plumbing msigAPI, from address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits, value *types.AttoFIL, signers []address.Address, required uint64, unlockDuration *types.BlockHeight) (address.Address, error) {
+	// creation fails with the errors of the storage market, or of the
+	// initialization of the wallet
+	createErrors := map[uint8]error{}
+	for code, err := range multisig.Errors {
+		createErrors[code] = err
+	}
+	for code, err := range storagemarket.Errors {
+		createErrors[code] = err
+	}
+
+	ret, err := msigSendAndWait(ctx, plumbing, from, address.StorageMarketAddress, value, gasPrice, gasLimit, createErrors,
+		"createMultisig", signers, big.NewInt(0).SetUint64(required), unlockDuration)
+	if err != nil {
+		return address.Address{}, err
+	}
+
+	return address.NewFromBytes(ret[0])
+}
+
+// MsigPropose proposes a transaction from the multisig wallet at msigAddr to
+// the given method of the actor at to, and waits for the proposal to be
+// mined. The proposal counts as the approval of from. It returns the id of the
+// transaction.
+func MsigPropose(ctx context.Context, plumbing msigAPI, from, msigAddr address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits, to address.Address, value *types.AttoFIL, method string, params ...interface{}) (*big.Int, error) {
+	encodedParams, err := abi.ToEncodedValues(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't encode transaction params")
+	}
+
+	ret, err := msigSendAndWait(ctx, plumbing, from, msigAddr, types.NewZeroAttoFIL(), gasPrice, gasLimit, multisig.Errors,
+		"propose", to, value, method, encodedParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return big.NewInt(0).SetBytes(ret[0]), nil
+}
+
+// MsigApprove approves the pending transaction with the given id of the
+// multisig wallet at msigAddr, and waits for the approval to be mined.
+func MsigApprove(ctx context.Context, plumbing msigAPI, from, msigAddr address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits, txID *big.Int) error {
+	_, err := msigSendAndWait(ctx, plumbing, from, msigAddr, types.NewZeroAttoFIL(), gasPrice, gasLimit, multisig.Errors, "approve", txID)
+	return err
+}
+
+// MsigCancel cancels the pending transaction with the given id of the
+// multisig wallet at msigAddr, and waits for the cancellation to be mined.
+func MsigCancel(ctx context.Context, plumbing msigAPI, from, msigAddr address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits, txID *big.Int) error {
+	_, err := msigSendAndWait(ctx, plumbing, from, msigAddr, types.NewZeroAttoFIL(), gasPrice, gasLimit, multisig.Errors, "cancel", txID)
+	return err
+}
+
+// msigSendAndWait sends a message and waits for it to be mined, returning its
+// return values, or the error of its exit code if it failed.
+func msigSendAndWait(ctx context.Context, plumbing msigAPI, from, to address.Address, value *types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, actorErrors map[uint8]error, method string, params ...interface{}) ([]types.Bytes, error) {
+	msgCid, err := plumbing.MessageSendWithDefaultAddress(ctx, from, to, value, gasPrice, gasLimit, method, params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't send message")
+	}
+
+	var ret []types.Bytes
+	err = plumbing.MessageWait(ctx, msgCid, func(blk *types.Block, smsg *types.SignedMessage, receipt *types.MessageReceipt) error {
+		if receipt.ExitCode != uint8(0) {
+			return vmErrors.VMExitCodeToError(receipt.ExitCode, actorErrors)
+		}
+		ret = receipt.Return
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
@@ -0,0 +1,118 @@
+package porcelain
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/multisig"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type msigPlumbing struct {
+	msgCid cid.Cid
+
+	// the last message sent
+	to     address.Address
+	method string
+	params []interface{}
+
+	// the receipt of every message
+	receipt *types.MessageReceipt
+}
+
+func (mp *msigPlumbing) MessageSendWithDefaultAddress(ctx context.Context, from, to address.Address, value *types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error) {
+	mp.to = to
+	mp.method = method
+	mp.params = params
+	mp.msgCid = types.NewCidForTestGetter()()
+	return mp.msgCid, nil
+}
+
+func (mp *msigPlumbing) MessageWait(ctx context.Context, msgCid cid.Cid, cb func(*types.Block, *types.SignedMessage, *types.MessageReceipt) error) error {
+	if !msgCid.Equals(mp.msgCid) {
+		return nil
+	}
+	return cb(&types.Block{}, &types.SignedMessage{}, mp.receipt)
+}
+
+func TestMsigCreate(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	addrGetter := address.NewForTestGetter()
+	msigAddr := addrGetter()
+	signers := []address.Address{addrGetter(), addrGetter()}
+
+	plumbing := &msigPlumbing{receipt: &types.MessageReceipt{Return: []types.Bytes{msigAddr.Bytes()}}}
+	addr, err := MsigCreate(context.Background(), plumbing, address.Address{}, types.NewGasPrice(0), types.NewGasUnits(0), types.NewAttoFILFromFIL(10), signers, 2, types.NewBlockHeight(0))
+	require.NoError(err)
+	assert.Equal(msigAddr, addr)
+
+	assert.Equal(address.StorageMarketAddress, plumbing.to)
+	assert.Equal("createMultisig", plumbing.method)
+	assert.Equal(signers, plumbing.params[0])
+	assert.Equal(big.NewInt(2), plumbing.params[1])
+}
+
+func TestMsigPropose(t *testing.T) {
+	t.Run("encodes the params of the transaction", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		addrGetter := address.NewForTestGetter()
+		msigAddr, target := addrGetter(), addrGetter()
+
+		plumbing := &msigPlumbing{receipt: &types.MessageReceipt{Return: []types.Bytes{big.NewInt(7).Bytes()}}}
+		txID, err := MsigPropose(context.Background(), plumbing, address.Address{}, msigAddr, types.NewGasPrice(0), types.NewGasUnits(0), target, types.NewAttoFILFromFIL(1), "addSigner", target)
+		require.NoError(err)
+		assert.Equal(big.NewInt(7), txID)
+
+		assert.Equal(msigAddr, plumbing.to)
+		assert.Equal("propose", plumbing.method)
+		assert.Equal(target, plumbing.params[0])
+		assert.Equal("addSigner", plumbing.params[2])
+
+		vals, err := abi.DecodeValues(plumbing.params[3].([]byte), []abi.Type{abi.Address})
+		require.NoError(err)
+		assert.Equal(target, vals[0].Val)
+	})
+
+	t.Run("reports the error of a failed proposal", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		addrGetter := address.NewForTestGetter()
+		msigAddr, target := addrGetter(), addrGetter()
+
+		plumbing := &msigPlumbing{receipt: &types.MessageReceipt{ExitCode: multisig.ErrNotSigner}}
+		_, err := MsigPropose(context.Background(), plumbing, address.Address{}, msigAddr, types.NewGasPrice(0), types.NewGasUnits(0), target, types.NewAttoFILFromFIL(1), "")
+		require.Error(err)
+		assert.Contains(err.Error(), multisig.Errors[multisig.ErrNotSigner].Error())
+	})
+}
+
+func TestMsigApprove(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	msigAddr := address.NewForTestGetter()()
+
+	plumbing := &msigPlumbing{receipt: &types.MessageReceipt{}}
+	require.NoError(MsigApprove(context.Background(), plumbing, address.Address{}, msigAddr, types.NewGasPrice(0), types.NewGasUnits(0), big.NewInt(3)))
+	assert.Equal(msigAddr, plumbing.to)
+	assert.Equal("approve", plumbing.method)
+	assert.Equal([]interface{}{big.NewInt(3)}, plumbing.params)
+
+	plumbing.receipt = &types.MessageReceipt{ExitCode: multisig.ErrUnknownTransaction}
+	err := MsigApprove(context.Background(), plumbing, address.Address{}, msigAddr, types.NewGasPrice(0), types.NewGasUnits(0), big.NewInt(3))
+	require.Error(err)
+	assert.Contains(err.Error(), multisig.Errors[multisig.ErrUnknownTransaction].Error())
+}
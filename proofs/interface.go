@@ -1,5 +1,9 @@
 package proofs
 
+import (
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+)
+
 // VerifySealRequest represents a request to verify the output of a Seal() operation.
 type VerifySealRequest struct {
 	CommD     CommD           // returned from seal
@@ -45,3 +49,26 @@ const (
 	// Test configures the SectorBuilder to be used with large sectors, in tests.
 	Test
 )
+
+// SectorSize returns the size in bytes of a sector sealed by a SectorStore
+// of the given type. It is the amount of power a miner gains for each sector
+// it commits.
+func SectorSize(t SectorStoreType) uint64 {
+	if t == Test {
+		return 1024
+	}
+	return 268435456
+}
+
+// SectorStoreTypeOfSize returns the type of SectorStore that seals sectors of
+// the given size in bytes.
+func SectorStoreTypeOfSize(size uint64) (SectorStoreType, error) {
+	switch size {
+	case SectorSize(Live):
+		return Live, nil
+	case SectorSize(Test):
+		return Test, nil
+	default:
+		return Live, errors.Errorf("no sector store seals sectors of %d bytes", size)
+	}
+}
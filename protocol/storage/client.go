@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math/big"
+	"sort"
 	"sync"
 	"time"
 
@@ -20,6 +21,7 @@ import (
 
 	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
 	"github.com/filecoin-project/go-filecoin/address"
 	cbu "github.com/filecoin-project/go-filecoin/cborutil"
 	"github.com/filecoin-project/go-filecoin/porcelain"
@@ -52,7 +54,7 @@ const (
 	CreateChannelGasPrice = 0
 
 	// CreateChannelGasLimit is the gas limit of the message used to create the payment channel
-	CreateChannelGasLimit = 300
+	CreateChannelGasLimit = 1000
 )
 
 type clientNode interface {
@@ -68,9 +70,12 @@ type clientPorcelainAPI interface {
 	MinerGetAsk(ctx context.Context, minerAddr address.Address, askID uint64) (miner.Ask, error)
 	MinerGetOwnerAddress(ctx context.Context, minerAddr address.Address) (address.Address, error)
 	MinerGetPeerID(ctx context.Context, minerAddr address.Address) (peer.ID, error)
+	SignBytes(data []byte, addr address.Address) (types.Signature, error)
 }
 
-type clientDeal struct {
+// ClientDeal is a deal the client proposed, with the last response of its
+// miner.
+type ClientDeal struct {
 	Miner    address.Address
 	Proposal *DealProposal
 	Response *DealResponse
@@ -78,7 +83,7 @@ type clientDeal struct {
 
 // Client is used to make deals directly with storage miners.
 type Client struct {
-	deals   map[cid.Cid]*clientDeal
+	deals   map[cid.Cid]*ClientDeal
 	dealsDs repo.Datastore
 	dealsLk sync.Mutex
 
@@ -87,13 +92,13 @@ type Client struct {
 }
 
 func init() {
-	cbor.RegisterCborType(clientDeal{})
+	cbor.RegisterCborType(ClientDeal{})
 }
 
 // NewClient creates a new storage client.
 func NewClient(nd clientNode, api clientPorcelainAPI, dealsDs repo.Datastore) (*Client, error) {
 	smc := &Client{
-		deals:   make(map[cid.Cid]*clientDeal),
+		deals:   make(map[cid.Cid]*ClientDeal),
 		node:    nd,
 		api:     api,
 		dealsDs: dealsDs,
@@ -142,7 +147,6 @@ func (smc *Client) ProposeDeal(ctx context.Context, miner address.Address, data
 		TotalPrice:   totalPrice,
 		Duration:     duration,
 		MinerAddress: miner,
-		// TODO: Sign this proposal
 	}
 
 	if smc.isMaybeDupDeal(proposal) && !allowDuplicates {
@@ -169,6 +173,11 @@ func (smc *Client) ProposeDeal(ctx context.Context, miner address.Address, data
 	proposal.Payment.ChannelMsgCid = &cpResp.ChannelMsgCid
 	proposal.Payment.Vouchers = cpResp.Vouchers
 
+	proposal.Signature, err = storagemarket.SignDeal(miner, data, proposal.Size, types.NewBlockHeight(duration), totalPrice, fromAddress, smc.api)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sign proposal")
+	}
+
 	// send proposal
 	pid, err := smc.api.MinerGetPeerID(ctx, miner)
 	if err != nil {
@@ -209,7 +218,7 @@ func (smc *Client) recordResponse(resp *DealResponse, miner address.Address, p *
 		return fmt.Errorf("deal [%s] is already in progress", proposalCid.String())
 	}
 
-	smc.deals[proposalCid] = &clientDeal{
+	smc.deals[proposalCid] = &ClientDeal{
 		Miner:    miner,
 		Proposal: p,
 		Response: resp,
@@ -263,6 +272,21 @@ func (smc *Client) QueryDeal(ctx context.Context, proposalCid cid.Cid) (*DealRes
 	return &resp, nil
 }
 
+// ListDeals returns the deals the client proposed, ordered by proposal cid.
+func (smc *Client) ListDeals() []*ClientDeal {
+	smc.dealsLk.Lock()
+	defer smc.dealsLk.Unlock()
+
+	deals := make([]*ClientDeal, 0, len(smc.deals))
+	for _, deal := range smc.deals {
+		deals = append(deals, deal)
+	}
+	sort.Slice(deals, func(i, j int) bool {
+		return deals[i].Response.ProposalCid.KeyString() < deals[j].Response.ProposalCid.KeyString()
+	})
+	return deals
+}
+
 func (smc *Client) loadDeals() error {
 	res, err := smc.dealsDs.Query(query.Query{
 		Prefix: "/" + clientDatastorePrefix,
@@ -271,10 +295,10 @@ func (smc *Client) loadDeals() error {
 		return errors.Wrap(err, "failed to query deals from datastore")
 	}
 
-	smc.deals = make(map[cid.Cid]*clientDeal)
+	smc.deals = make(map[cid.Cid]*ClientDeal)
 
 	for entry := range res.Next() {
-		var deal clientDeal
+		var deal ClientDeal
 		if err := cbor.DecodeInto(entry.Value, &deal); err != nil {
 			return errors.Wrap(err, "failed to unmarshal deals from datastore")
 		}
@@ -323,7 +347,7 @@ func (smc *Client) LoadVouchersForDeal(dealCid cid.Cid) ([]*paymentbroker.Paymen
 	var results []*paymentbroker.PaymentVoucher
 
 	for entry := range queryResults.Next() {
-		var deal clientDeal
+		var deal ClientDeal
 		if err := cbor.DecodeInto(entry.Value, &deal); err != nil {
 			return results, errors.Wrap(err, "failed to unmarshal deals from datastore")
 		}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/porcelain"
 	"github.com/filecoin-project/go-filecoin/repo"
@@ -81,6 +82,10 @@ func TestProposeDeal(t *testing.T) {
 		assert.Equal(expectedTotalPrice, proposal.TotalPrice)
 	})
 
+	t.Run("and signs the proposal", func(t *testing.T) {
+		assert.True(storagemarket.VerifyDealSignature(minerAddr, dataCid, proposal.Size, types.NewBlockHeight(duration), proposal.TotalPrice, testAPI.payer, proposal.Signature))
+	})
+
 	t.Run("and creates a new payment channel", func(t *testing.T) {
 		// correct payment id and message cid in proposal implies a call to createChannel
 		assert.Equal(testAPI.channelID, proposal.Payment.Channel)
@@ -114,7 +119,7 @@ func TestProposeDeal(t *testing.T) {
 		// expect one entry to be the response
 		var response *DealResponse
 		for entry := range res.Next() {
-			var deal clientDeal
+			var deal ClientDeal
 			require.Equal("/client/"+dealResponse.ProposalCid.String(), entry.Key)
 			require.NoError(cbor.DecodeInto(entry.Value, &deal))
 			response = deal.Response
@@ -122,6 +127,11 @@ func TestProposeDeal(t *testing.T) {
 
 		assert.NotNil(response)
 		assert.Equal(response, dealResponse)
+
+		deals := client.ListDeals()
+		require.Len(deals, 1)
+		assert.Equal(minerAddr, deals[0].Miner)
+		assert.Equal(dealResponse, deals[0].Response)
 	})
 }
 
@@ -132,19 +142,22 @@ type clientTestAPI struct {
 	payer       address.Address
 	target      address.Address
 	perPayment  *types.AttoFIL
+	signer      types.MockSigner
 }
 
 func newTestClientAPI() *clientTestAPI {
 	cidGetter := types.NewCidForTestGetter()
 	addressGetter := address.NewForTestGetter()
+	signer := types.NewMockSigner(types.MustGenerateKeyInfo(1, types.GenerateKeyInfoSeed()))
 
 	return &clientTestAPI{
 		blockHeight: types.NewBlockHeight(773),
 		msgCid:      cidGetter(),
 		channelID:   types.NewChannelID(23),
-		payer:       addressGetter(),
+		payer:       signer.Addresses[0],
 		target:      addressGetter(),
 		perPayment:  types.NewAttoFILFromFIL(10),
+		signer:      signer,
 	}
 }
 
@@ -193,6 +206,10 @@ func (ctp *clientTestAPI) MinerGetPeerID(ctx context.Context, minerAddr address.
 	return id, nil
 }
 
+func (ctp *clientTestAPI) SignBytes(data []byte, addr address.Address) (types.Signature, error) {
+	return ctp.signer.SignBytes(data, addr)
+}
+
 func (ctp *clientTestAPI) GetAndMaybeSetDefaultSenderAddress() (address.Address, error) {
 	// always just default address
 	return ctp.payer, nil
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
-	"math/rand"
 	"strconv"
 	"sync"
 	"time"
@@ -24,12 +23,11 @@ import (
 	"gx/ipfs/Qmf4xQhNomPNhrtZc67qSnfJSjxjXs9LWvknJtSXwimPrM/go-datastore"
 	"gx/ipfs/Qmf4xQhNomPNhrtZc67qSnfJSjxjXs9LWvknJtSXwimPrM/go-datastore/query"
 
-	"github.com/filecoin-project/go-filecoin/abi"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
 	"github.com/filecoin-project/go-filecoin/address"
 	cbu "github.com/filecoin-project/go-filecoin/cborutil"
-	"github.com/filecoin-project/go-filecoin/exec"
 	"github.com/filecoin-project/go-filecoin/proofs"
 	"github.com/filecoin-project/go-filecoin/proofs/sectorbuilder"
 	"github.com/filecoin-project/go-filecoin/repo"
@@ -44,7 +42,10 @@ const queryDealProtocol = protocol.ID("/fil/storage/qry/1.0.0")
 
 // TODO: replace this with a queries to pick reasonable gas price and limits.
 const submitPostGasPrice = 0
-const submitPostGasLimit = 300
+const submitPostGasLimit = 1000
+
+const publishDealGasPrice = 0
+const publishDealGasLimit = 1000
 
 const waitForPaymentChannelDuration = 2 * time.Minute
 
@@ -76,16 +77,24 @@ type Miner struct {
 type storageDeal struct {
 	Proposal *DealProposal
 	Response *DealResponse
+
+	// DealID is the id of the deal in the storage market, once it has been
+	// published.
+	DealID *big.Int
 }
 
 // minerPorcelain is the subset of the porcelain API that storage.Miner needs.
 type minerPorcelain interface {
 	ChainBlockHeight(ctx context.Context) (*types.BlockHeight, error)
+	ChainSampleRandomness(ctx context.Context, sampleHeight *types.BlockHeight) ([]byte, error)
 	ConfigGet(dottedPath string) (interface{}, error)
 
 	MessageSend(ctx context.Context, from, to address.Address, value *types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error)
-	MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, *exec.FunctionSignature, error)
 	MessageWait(ctx context.Context, msgCid cid.Cid, cb func(*types.Block, *types.SignedMessage, *types.MessageReceipt) error) error
+
+	MinerState(ctx context.Context, tsKey types.SortedCidSet, minerAddr address.Address) (*miner.State, error)
+	MinerSectorCommitments(ctx context.Context, tsKey types.SortedCidSet, minerAddr address.Address) (map[string]types.Commitments, error)
+	PaymentChannels(ctx context.Context, tsKey types.SortedCidSet, payer address.Address) (map[string]*paymentbroker.PaymentChannel, error)
 }
 
 // node is subset of node on which this protocol depends. These deps
@@ -165,7 +174,9 @@ func (sm *Miner) handleMakeDeal(s inet.Stream) {
 
 // receiveStorageProposal is the entry point for the miner storage protocol
 func (sm *Miner) receiveStorageProposal(ctx context.Context, p *DealProposal) (*DealResponse, error) {
-	// TODO: Check signature
+	if p.Size == nil || p.TotalPrice == nil || !storagemarket.VerifyDealSignature(p.MinerAddress, p.PieceRef, p.Size, types.NewBlockHeight(p.Duration), p.TotalPrice, p.Payment.Payer, p.Signature) {
+		return sm.proposalRejector(ctx, sm, p, "invalid signature of proposal")
+	}
 
 	if err := sm.validateDealPayment(ctx, p); err != nil {
 		return sm.proposalRejector(ctx, sm, p, err.Error())
@@ -298,15 +309,10 @@ func (sm *Miner) getPaymentChannel(ctx context.Context, p *DealProposal) (*payme
 
 	payer := p.Payment.Payer
 
-	ret, _, err := sm.porcelainAPI.MessageQuery(ctx, address.Address{}, address.PaymentBrokerAddress, "ls", payer)
+	channels, err := sm.porcelainAPI.PaymentChannels(ctx, types.SortedCidSet{}, payer)
 	if err != nil {
 		return nil, errors.Wrap(err, "Error getting payment channel for payer")
 	}
-
-	var channels map[string]*paymentbroker.PaymentChannel
-	if err := cbor.DecodeInto(ret[0], &channels); err != nil {
-		return nil, errors.Wrap(err, "Could not decode payment channels for payer")
-	}
 	channel, ok := channels[p.Payment.Channel.KeyString()]
 	if !ok {
 		return nil, fmt.Errorf("could not find payment channel for payer %s and id %s", payer.String(), p.Payment.Channel.KeyString())
@@ -435,6 +441,13 @@ func (sm *Miner) processStorageDeal(c cid.Cid) {
 		}
 	}
 
+	// the deal is published while its piece is sealed, which takes far longer
+	// than the message to be mined
+	if err := sm.publishDeal(ctx, c); err != nil {
+		fail("failed to publish deal", fmt.Sprintf("failed to publish deal: %s", err))
+		return
+	}
+
 	pi := &sectorbuilder.PieceInfo{
 		Ref:  d.Proposal.PieceRef,
 		Size: d.Proposal.Size.Uint64(),
@@ -470,6 +483,97 @@ func (sm *Miner) processStorageDeal(c cid.Cid) {
 	}
 }
 
+// publishDeal sends a message recording the deal with the given proposal cid
+// in the storage market, so that the client can verify on chain that we
+// accepted it. It does not wait for the message to be mined; the id the
+// storage market assigns to the deal is stored once it is.
+// TODO: put up collateral for the deal once there is a policy for how much.
+func (sm *Miner) publishDeal(ctx context.Context, proposalCid cid.Cid) error {
+	d := sm.getStorageDeal(proposalCid)
+
+	gasPrice := types.NewGasPrice(publishDealGasPrice)
+	gasLimit := types.NewGasUnits(publishDealGasLimit)
+
+	msgCid, err := sm.porcelainAPI.MessageSend(
+		ctx,
+		sm.minerOwnerAddr,
+		address.StorageMarketAddress,
+		types.ZeroAttoFIL,
+		gasPrice,
+		gasLimit,
+		"publishDeal",
+		sm.minerAddr,
+		d.Proposal.Payment.Payer,
+		d.Proposal.PieceRef.Bytes(),
+		d.Proposal.Size,
+		types.NewBlockHeight(d.Proposal.Duration),
+		d.Proposal.TotalPrice,
+		[]byte(d.Proposal.Signature),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to send publishDeal message")
+	}
+
+	go sm.waitForDealID(proposalCid, msgCid)
+	return nil
+}
+
+// waitForDealID waits for the publishDeal message with the given cid to be
+// mined, and stores the id the storage market assigned to the deal with the
+// given proposal cid.
+func (sm *Miner) waitForDealID(proposalCid cid.Cid, msgCid cid.Cid) {
+	var dealID *big.Int
+	err := sm.porcelainAPI.MessageWait(context.Background(), msgCid, func(blk *types.Block, smsg *types.SignedMessage, receipt *types.MessageReceipt) error {
+		if receipt.ExitCode != 0 {
+			return fmt.Errorf("publishDeal failed with exit code %d", receipt.ExitCode)
+		}
+		dealID = big.NewInt(0).SetBytes(receipt.Return[0])
+		return nil
+	})
+	if err != nil {
+		log.Errorf("failed to publish deal %s: %s", proposalCid, err)
+		err := sm.updateDealResponse(proposalCid, func(resp *DealResponse) {
+			resp.Message = "failed to publish deal"
+			resp.State = Failed
+		})
+		if err != nil {
+			log.Errorf("could not update to deal to 'Failed' state: %s", err)
+		}
+		return
+	}
+
+	sm.dealsLk.Lock()
+	defer sm.dealsLk.Unlock()
+	sm.deals[proposalCid].DealID = dealID
+	if err := sm.saveDeal(proposalCid); err != nil {
+		log.Errorf("could not save id of deal %s: %s", proposalCid, err)
+	}
+}
+
+// DealIDsForSector returns the ids in the storage market of the published
+// deals whose pieces were added to the sector with the given id. Deals whose
+// publishDeal message has not been mined yet are left out. It must be called
+// before OnCommitmentAddedToChain for the sector, which forgets which deals
+// the sector holds.
+func (sm *Miner) DealIDsForSector(sectorID uint64) []uint64 {
+	sm.dealsAwaitingSeal.l.Lock()
+	dealCids := append([]cid.Cid{}, sm.dealsAwaitingSeal.SectorsToDeals[sectorID]...)
+	sm.dealsAwaitingSeal.l.Unlock()
+
+	sm.dealsLk.Lock()
+	defer sm.dealsLk.Unlock()
+
+	dealIDs := []uint64{}
+	for _, c := range dealCids {
+		deal, ok := sm.deals[c]
+		if !ok || deal.DealID == nil {
+			continue
+		}
+		dealIDs = append(dealIDs, deal.DealID.Uint64())
+	}
+	return dealIDs
+}
+
 // dealsAwaitingSealStruct is a container for keeping track of which sectors have
 // pieces from which deals. We need it to accommodate a race condition where
 // a sector commit message is added to chain before we can add the sector/deal
@@ -618,26 +722,15 @@ func (sm *Miner) onCommitFail(dealCid cid.Cid, message string) {
 func (sm *Miner) OnNewHeaviestTipSet(ts types.TipSet) {
 	ctx := context.Background()
 
-	rets, sig, err := sm.porcelainAPI.MessageQuery(
-		ctx,
-		address.Address{},
-		sm.minerAddr,
-		"getSectorCommitments",
-	)
+	state, err := sm.porcelainAPI.MinerState(ctx, types.SortedCidSet{}, sm.minerAddr)
 	if err != nil {
-		log.Errorf("failed to call query method getSectorCommitments: %s", err)
+		log.Errorf("failed to get miner state: %s", err)
 		return
 	}
 
-	commitmentsVal, err := abi.Deserialize(rets[0], sig.Return[0])
+	commitments, err := sm.porcelainAPI.MinerSectorCommitments(ctx, types.SortedCidSet{}, sm.minerAddr)
 	if err != nil {
-		log.Errorf("failed to convert returned ABI value: %s", err)
-		return
-	}
-
-	commitments, ok := commitmentsVal.Val.(map[string]types.Commitments)
-	if !ok {
-		log.Errorf("failed to convert returned ABI value to miner.Commitments")
+		log.Errorf("failed to get miner sector commitments: %s", err)
 		return
 	}
 
@@ -662,11 +755,7 @@ func (sm *Miner) OnNewHeaviestTipSet(ts types.TipSet) {
 		return
 	}
 
-	provingPeriodStart, err := sm.getProvingPeriodStart()
-	if err != nil {
-		log.Errorf("failed to get provingPeriodStart: %s", err)
-		return
-	}
+	provingPeriodStart := state.ProvingPeriodStart
 
 	sm.postInProcessLk.Lock()
 	defer sm.postInProcessLk.Unlock()
@@ -685,33 +774,24 @@ func (sm *Miner) OnNewHeaviestTipSet(ts types.TipSet) {
 	h := types.NewBlockHeight(height)
 	provingPeriodEnd := provingPeriodStart.Add(miner.ProvingPeriodBlocks)
 
+	gracePeriodEnd := provingPeriodEnd.Add(miner.GracePeriodBlocks)
+
 	if h.GreaterEqual(provingPeriodStart) {
-		if h.LessThan(provingPeriodEnd) {
-			// we are in a new proving period, lets get this post going
+		if h.LessThan(gracePeriodEnd) {
+			// we are in a new proving period, or late but still within its
+			// grace period, lets get this post going
+			if h.GreaterEqual(provingPeriodEnd) {
+				log.Warningf("submitting PoSt late, a fee will be charged start=%s end=%s current=%s", provingPeriodStart, provingPeriodEnd, h)
+			}
 			sm.postInProcess = provingPeriodStart
-			go sm.submitPoSt(provingPeriodStart, provingPeriodEnd, inputs)
+			go sm.submitPoSt(provingPeriodStart, gracePeriodEnd, inputs)
 		} else {
-			// we are too late
-			// TODO: figure out faults and payments here
+			// we are too late, the miner will be slashed
 			log.Errorf("too late start=%s  end=%s current=%s", provingPeriodStart, provingPeriodEnd, h)
 		}
 	}
 }
 
-func (sm *Miner) getProvingPeriodStart() (*types.BlockHeight, error) {
-	res, _, err := sm.porcelainAPI.MessageQuery(
-		context.Background(),
-		address.Address{},
-		sm.minerAddr,
-		"getProvingPeriodStart",
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	return types.NewBlockHeightFromBytes(res[0]), nil
-}
-
 // generatePoSt creates the required PoSt, given a list of sector ids and
 // matching seeds. It returns the Snark Proof for the PoSt, and a list of
 // sectors that faulted, if there were any faults.
@@ -728,12 +808,15 @@ func (sm *Miner) generatePoSt(commRs []proofs.CommR, challenge proofs.PoStChalle
 	return res.Proof, res.Faults, nil
 }
 
+// submitPoSt generates and submits the PoSt for the proving period that
+// started at start, if that is possible before end.
 func (sm *Miner) submitPoSt(start, end *types.BlockHeight, inputs []generatePostInput) {
-	// TODO: real seed generation
-	seed := proofs.PoStChallengeSeed{}
-	if _, err := rand.Read(seed[:]); err != nil {
-		panic(err)
+	randomness, err := sm.porcelainAPI.ChainSampleRandomness(context.Background(), start)
+	if err != nil {
+		log.Errorf("failed to sample PoSt challenge seed: %s", err)
+		return
 	}
+	seed := miner.PoStChallengeSeed(randomness)
 
 	commRs := make([]proofs.CommR, len(inputs))
 	for i, input := range inputs {
@@ -746,8 +829,9 @@ func (sm *Miner) submitPoSt(start, end *types.BlockHeight, inputs []generatePost
 		return
 	}
 	if len(faults) != 0 {
-		log.Warningf("some faults when generating PoSt: %v", faults)
-		// TODO: proper fault handling
+		log.Warningf("declaring faulty sectors in PoSt: %v", faults)
+	} else {
+		faults = []uint64{}
 	}
 
 	height, err := sm.node.BlockHeight()
@@ -763,7 +847,7 @@ func (sm *Miner) submitPoSt(start, end *types.BlockHeight, inputs []generatePost
 	}
 
 	if height.GreaterEqual(end) {
-		// TODO: we are too late, figure out faults and decide if we want to still submit
+		// the grace period is over, the PoSt would be rejected
 		log.Errorf("PoSt generation was too slow height=%s end=%s", height, end)
 		return
 	}
@@ -776,7 +860,7 @@ func (sm *Miner) submitPoSt(start, end *types.BlockHeight, inputs []generatePost
 	gasPrice := types.NewGasPrice(submitPostGasPrice)
 	gasLimit := types.NewGasUnits(submitPostGasLimit)
 
-	_, err = sm.porcelainAPI.MessageSend(ctx, sm.minerOwnerAddr, sm.minerAddr, types.ZeroAttoFIL, gasPrice, gasLimit, "submitPoSt", proof[:])
+	_, err = sm.porcelainAPI.MessageSend(ctx, sm.minerOwnerAddr, sm.minerAddr, types.ZeroAttoFIL, gasPrice, gasLimit, "submitPoSt", proof[:], faults)
 	if err != nil {
 		log.Errorf("failed to submit PoSt: %s", err)
 		return
@@ -2,14 +2,15 @@ package storage
 
 import (
 	"context"
+	"math/big"
 	"testing"
 
 	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
 
-	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
 	"github.com/filecoin-project/go-filecoin/address"
-	"github.com/filecoin-project/go-filecoin/exec"
 	"github.com/filecoin-project/go-filecoin/plumbing/cfg"
 	"github.com/filecoin-project/go-filecoin/proofs/sectorbuilder"
 	"github.com/filecoin-project/go-filecoin/repo"
@@ -68,6 +69,20 @@ func TestReceiveStorageProposal(t *testing.T) {
 		assert.Equal("proposed price (2500) is less than expected (5000) given asking price of 0.0005", res.Message)
 	})
 
+	t.Run("Rejects proposals not signed by their client", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		_, miner, proposal := newMinerTestSetup()
+		proposal.TotalPrice = types.NewAttoFILFromFIL(5000)
+
+		res, err := miner.receiveStorageProposal(context.Background(), proposal)
+		require.NoError(err)
+
+		assert.Equal(Rejected, res.State)
+		assert.Equal("invalid signature of proposal", res.Message)
+	})
+
 	t.Run("Rejects proposals with invalid payment channel", func(t *testing.T) {
 		assert := assert.New(t)
 		require := require.New(t)
@@ -311,6 +326,32 @@ func TestDealsAwaitingSeal(t *testing.T) {
 	})
 }
 
+func TestDealIDsForSector(t *testing.T) {
+	assert := assert.New(t)
+
+	newCid := types.NewCidForTestGetter()
+	published, unpublished, otherSector := newCid(), newCid(), newCid()
+
+	miner := &Miner{
+		deals: map[cid.Cid]*storageDeal{
+			published:   {DealID: big.NewInt(7)},
+			unpublished: {},
+			otherSector: {DealID: big.NewInt(8)},
+		},
+		dealsAwaitingSeal: &dealsAwaitingSealStruct{
+			SectorsToDeals:    make(map[uint64][]cid.Cid),
+			SuccessfulSectors: make(map[uint64]*sectorbuilder.SealedSectorMetadata),
+			FailedSectors:     make(map[uint64]string),
+		},
+	}
+	miner.dealsAwaitingSeal.add(42, published)
+	miner.dealsAwaitingSeal.add(42, unpublished)
+	miner.dealsAwaitingSeal.add(43, otherSector)
+
+	assert.Equal([]uint64{7}, miner.DealIDsForSector(42))
+	assert.Empty(miner.DealIDsForSector(44))
+}
+
 type minerTestPorcelain struct {
 	config        *cfg.Config
 	payerAddress  address.Address
@@ -359,7 +400,7 @@ func (mtp *minerTestPorcelain) MessageSend(ctx context.Context, from, to address
 	return cid.Cid{}, nil
 }
 
-func (mtp *minerTestPorcelain) MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, *exec.FunctionSignature, error) {
+func (mtp *minerTestPorcelain) PaymentChannels(ctx context.Context, tsKey types.SortedCidSet, payer address.Address) (map[string]*paymentbroker.PaymentChannel, error) {
 	channels := map[string]*paymentbroker.PaymentChannel{}
 
 	if !mtp.noChannels {
@@ -372,11 +413,15 @@ func (mtp *minerTestPorcelain) MessageQuery(ctx context.Context, optFrom, to add
 		}
 	}
 
-	channelsBytes, err := actor.MarshalStorage(channels)
-	if err != nil {
-		panic(err)
-	}
-	return [][]byte{channelsBytes}, nil, nil
+	return channels, nil
+}
+
+func (mtp *minerTestPorcelain) MinerState(ctx context.Context, tsKey types.SortedCidSet, minerAddr address.Address) (*miner.State, error) {
+	return &miner.State{}, nil
+}
+
+func (mtp *minerTestPorcelain) MinerSectorCommitments(ctx context.Context, tsKey types.SortedCidSet, minerAddr address.Address) (map[string]types.Commitments, error) {
+	return map[string]types.Commitments{}, nil
 }
 
 func (mtp *minerTestPorcelain) ConfigGet(dottedPath string) (interface{}, error) {
@@ -387,6 +432,10 @@ func (mtp *minerTestPorcelain) ChainBlockHeight(ctx context.Context) (*types.Blo
 	return mtp.blockHeight, nil
 }
 
+func (mtp *minerTestPorcelain) ChainSampleRandomness(ctx context.Context, sampleHeight *types.BlockHeight) ([]byte, error) {
+	return []byte{}, nil
+}
+
 func (mtp *minerTestPorcelain) MessageWait(ctx context.Context, msgCid cid.Cid, cb func(*types.Block, *types.SignedMessage, *types.MessageReceipt) error) error {
 	return nil
 }
@@ -429,10 +478,12 @@ func testDealProposal(porcelainAPI *minerTestPorcelain, voucherInterval int, amo
 		}
 	}
 
-	return &DealProposal{
-		TotalPrice: types.NewAttoFILFromFIL(2500),
-		Size:       types.NewBytesAmount(1000),
-		Duration:   10000,
+	proposal := &DealProposal{
+		PieceRef:     types.SomeCid(),
+		TotalPrice:   types.NewAttoFILFromFIL(2500),
+		Size:         types.NewBytesAmount(1000),
+		Duration:     10000,
+		MinerAddress: addr,
 		Payment: PaymentInfo{
 			Payer:         porcelainAPI.payerAddress,
 			PayChActor:    address.PaymentBrokerAddress,
@@ -441,4 +492,11 @@ func testDealProposal(porcelainAPI *minerTestPorcelain, voucherInterval int, amo
 			Vouchers:      vouchers,
 		},
 	}
+
+	var err error
+	proposal.Signature, err = storagemarket.SignDeal(proposal.MinerAddress, proposal.PieceRef, proposal.Size, types.NewBlockHeight(proposal.Duration), proposal.TotalPrice, porcelainAPI.payerAddress, porcelainAPI.signer)
+	if err != nil {
+		panic("Could not sign valid proposal")
+	}
+	return proposal
 }
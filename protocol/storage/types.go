@@ -61,7 +61,10 @@ type DealProposal struct {
 	// miner using on-chain information.
 	Payment PaymentInfo
 
-	// Signature types.Signature
+	// Signature is the signature of the deal by the client, the payer of
+	// Payment, which the miner publishes the deal with. See
+	// storagemarket.SignDeal.
+	Signature types.Signature
 }
 
 // DealResponse is the information sent over the wire, when a miner responds to a client.
@@ -59,6 +59,17 @@ func (t *CachedTree) GetOrCreateActor(ctx context.Context, address address.Addre
 	return actor, nil
 }
 
+// ForEachCachedActor calls walkFn for each actor read or written through the
+// cache since it was last committed.
+func (t *CachedTree) ForEachCachedActor(walkFn ActorWalkFn) error {
+	for addr, actor := range t.cache {
+		if err := walkFn(addr, actor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Commit takes all the cached actors and sets them into the underlying cache.
 func (t *CachedTree) Commit(ctx context.Context) error {
 	for addr, actor := range t.cache {
@@ -0,0 +1,181 @@
+package state
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"sort"
+
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	cbor "gx/ipfs/QmRoARq3nkUb13HSKZGepCZSWe5GrVPwx7xURJGZ7KWv9V/go-ipld-cbor"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/address"
+)
+
+// ActorDiffKind says how an actor differs between two state trees.
+type ActorDiffKind string
+
+const (
+	// ActorAdded is the kind of an actor only in the second tree.
+	ActorAdded = ActorDiffKind("added")
+	// ActorRemoved is the kind of an actor only in the first tree.
+	ActorRemoved = ActorDiffKind("removed")
+	// ActorChanged is the kind of an actor in both trees that differs
+	// between them.
+	ActorChanged = ActorDiffKind("changed")
+)
+
+// ActorDiff describes how an actor differs between two state trees. Before is
+// nil for an added actor and After is nil for a removed one.
+type ActorDiff struct {
+	Address address.Address `json:"address"`
+	Kind    ActorDiffKind   `json:"kind"`
+	Before  *actor.Actor    `json:"before"`
+	After   *actor.Actor    `json:"after"`
+	// Storage lists the fields of the actor's decoded storage that differ,
+	// if the storage could be decoded.
+	Storage []*FieldDiff `json:"storage,omitempty"`
+}
+
+// FieldDiff is a field of an actor's decoded storage that differs between
+// two state trees. Field is empty if the storage does not decode to a struct.
+type FieldDiff struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// StorageDecoder decodes the storage of an actor with the given code from its
+// head. It returns nil and no error if it does not know how to decode the
+// storage of actors with that code.
+type StorageDecoder func(ctx context.Context, code cid.Cid, head cid.Cid) (interface{}, error)
+
+// Diff reports the actors that were added, removed or changed going from the
+// state tree before to the state tree after, ordered by address. If decode
+// is not nil, it is used to report which fields of a changed actor's storage
+// differ.
+func Diff(ctx context.Context, before, after Tree, decode StorageDecoder) ([]*ActorDiff, error) {
+	beforeActors, err := actorsByAddress(ctx, before)
+	if err != nil {
+		return nil, err
+	}
+	afterActors, err := actorsByAddress(ctx, after)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []*ActorDiff
+	for addr, b := range beforeActors {
+		a, ok := afterActors[addr]
+		if !ok {
+			diffs = append(diffs, &ActorDiff{Address: addr, Kind: ActorRemoved, Before: b})
+			continue
+		}
+
+		same, err := sameActor(b, a)
+		if err != nil {
+			return nil, err
+		}
+		if same {
+			continue
+		}
+
+		diff := &ActorDiff{Address: addr, Kind: ActorChanged, Before: b, After: a}
+		if decode != nil && b.Code.Equals(a.Code) && !b.Head.Equals(a.Head) {
+			diff.Storage, err = storageDiff(ctx, decode, a.Code, b.Head, a.Head)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to diff storage of actor %s", addr)
+			}
+		}
+		diffs = append(diffs, diff)
+	}
+	for addr, a := range afterActors {
+		if _, ok := beforeActors[addr]; !ok {
+			diffs = append(diffs, &ActorDiff{Address: addr, Kind: ActorAdded, After: a})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Address.String() < diffs[j].Address.String()
+	})
+	return diffs, nil
+}
+
+func actorsByAddress(ctx context.Context, t Tree) (map[address.Address]*actor.Actor, error) {
+	actors := make(map[address.Address]*actor.Actor)
+	err := t.ForEachActor(ctx, func(addr address.Address, act *actor.Actor) error {
+		actors[addr] = act
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to walk state tree")
+	}
+	return actors, nil
+}
+
+func sameActor(a, b *actor.Actor) (bool, error) {
+	aCid, err := a.Cid()
+	if err != nil {
+		return false, err
+	}
+	bCid, err := b.Cid()
+	if err != nil {
+		return false, err
+	}
+	return aCid.Equals(bCid), nil
+}
+
+// storageDiff decodes the storage at both heads and compares it field by
+// field. It returns nil if the storage cannot be decoded.
+func storageDiff(ctx context.Context, decode StorageDecoder, code, beforeHead, afterHead cid.Cid) ([]*FieldDiff, error) {
+	if !beforeHead.Defined() || !afterHead.Defined() {
+		return nil, nil
+	}
+
+	b, err := decode(ctx, code, beforeHead)
+	if err != nil {
+		return nil, err
+	}
+	a, err := decode(ctx, code, afterHead)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil || b == nil {
+		return nil, nil
+	}
+
+	bv, av := reflect.Indirect(reflect.ValueOf(b)), reflect.Indirect(reflect.ValueOf(a))
+	if bv.Kind() != reflect.Struct || bv.Type() != av.Type() {
+		if sameValue(b, a) {
+			return nil, nil
+		}
+		return []*FieldDiff{{Before: b, After: a}}, nil
+	}
+
+	var fields []*FieldDiff
+	for i := 0; i < bv.NumField(); i++ {
+		field := bv.Type().Field(i)
+		if field.PkgPath != "" {
+			// unexported
+			continue
+		}
+		bf, af := bv.Field(i).Interface(), av.Field(i).Interface()
+		if !sameValue(bf, af) {
+			fields = append(fields, &FieldDiff{Field: field.Name, Before: bf, After: af})
+		}
+	}
+	return fields, nil
+}
+
+// sameValue compares values by their encoding, so that values that are equal
+// but represented differently in memory (such as big integers) are the same.
+func sameValue(a, b interface{}) bool {
+	aBytes, aErr := cbor.DumpObject(a)
+	bBytes, bErr := cbor.DumpObject(b)
+	if aErr != nil || bErr != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	return bytes.Equal(aBytes, bBytes)
+}
@@ -0,0 +1,119 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	"gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
+	"gx/ipfs/QmRXf2uUSdGSunRJsM9wXSUNVwLUGCY3So5fAs7h2CBJVf/go-hamt-ipld"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type diffTestStorage struct {
+	Count uint64
+	Name  string
+}
+
+func TestDiff(t *testing.T) {
+	ctx := context.Background()
+	newAddress := address.NewForTestGetter()
+	newCid := types.NewCidForTestGetter()
+
+	requireTree := func(require *require.Assertions, actors map[address.Address]*actor.Actor) Tree {
+		tree := NewEmptyStateTree(hamt.NewCborStore())
+		for addr, act := range actors {
+			require.NoError(tree.SetActor(ctx, addr, act))
+		}
+		return tree
+	}
+
+	t.Run("reports added, removed and changed actors", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		kept, removed, changed, added := newAddress(), newAddress(), newAddress(), newAddress()
+		before := requireTree(require, map[address.Address]*actor.Actor{
+			kept:    actor.NewActor(types.AccountActorCodeCid, types.NewAttoFILFromFIL(1)),
+			removed: actor.NewActor(types.AccountActorCodeCid, types.NewAttoFILFromFIL(2)),
+			changed: actor.NewActor(types.AccountActorCodeCid, types.NewAttoFILFromFIL(3)),
+		})
+		after := requireTree(require, map[address.Address]*actor.Actor{
+			kept:    actor.NewActor(types.AccountActorCodeCid, types.NewAttoFILFromFIL(1)),
+			changed: actor.NewActor(types.AccountActorCodeCid, types.NewAttoFILFromFIL(4)),
+			added:   actor.NewActor(types.AccountActorCodeCid, types.NewAttoFILFromFIL(5)),
+		})
+
+		diffs, err := Diff(ctx, before, after, nil)
+		require.NoError(err)
+		require.Len(diffs, 3)
+
+		byAddr := map[address.Address]*ActorDiff{}
+		for _, diff := range diffs {
+			byAddr[diff.Address] = diff
+		}
+		assert.NotContains(byAddr, kept)
+
+		assert.Equal(ActorRemoved, byAddr[removed].Kind)
+		assert.Nil(byAddr[removed].After)
+
+		assert.Equal(ActorAdded, byAddr[added].Kind)
+		assert.Nil(byAddr[added].Before)
+		assert.Equal(types.NewAttoFILFromFIL(5), byAddr[added].After.Balance)
+
+		assert.Equal(ActorChanged, byAddr[changed].Kind)
+		assert.Equal(types.NewAttoFILFromFIL(3), byAddr[changed].Before.Balance)
+		assert.Equal(types.NewAttoFILFromFIL(4), byAddr[changed].After.Balance)
+
+		for i := 1; i < len(diffs); i++ {
+			assert.True(diffs[i-1].Address.String() < diffs[i].Address.String())
+		}
+	})
+
+	t.Run("reports nothing for identical trees", func(t *testing.T) {
+		require := require.New(t)
+
+		addr := newAddress()
+		actors := map[address.Address]*actor.Actor{
+			addr: actor.NewActor(types.AccountActorCodeCid, types.NewAttoFILFromFIL(1)),
+		}
+		diffs, err := Diff(ctx, requireTree(require, actors), requireTree(require, actors), nil)
+		require.NoError(err)
+		require.Empty(diffs)
+	})
+
+	t.Run("reports changed storage fields", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		beforeHead, afterHead := newCid(), newCid()
+		storage := map[cid.Cid]*diffTestStorage{
+			beforeHead: {Count: 1, Name: "same"},
+			afterHead:  {Count: 2, Name: "same"},
+		}
+		decode := func(ctx context.Context, code cid.Cid, head cid.Cid) (interface{}, error) {
+			return storage[head], nil
+		}
+
+		addr := newAddress()
+		beforeActor := actor.NewActor(types.StorageMarketActorCodeCid, types.ZeroAttoFIL)
+		beforeActor.Head = beforeHead
+		afterActor := actor.NewActor(types.StorageMarketActorCodeCid, types.ZeroAttoFIL)
+		afterActor.Head = afterHead
+
+		diffs, err := Diff(ctx,
+			requireTree(require, map[address.Address]*actor.Actor{addr: beforeActor}),
+			requireTree(require, map[address.Address]*actor.Actor{addr: afterActor}),
+			decode)
+		require.NoError(err)
+		require.Len(diffs, 1)
+		require.Len(diffs[0].Storage, 1)
+		assert.Equal("Count", diffs[0].Storage[0].Field)
+		assert.Equal(uint64(1), diffs[0].Storage[0].Before)
+		assert.Equal(uint64(2), diffs[0].Storage[0].After)
+	})
+}
@@ -22,18 +22,18 @@ type TestView struct{}
 var _ consensus.PowerTableView = &TestView{}
 
 // Total always returns 1.
-func (tv *TestView) Total(ctx context.Context, st state.Tree, bstore blockstore.Blockstore) (uint64, error) {
-	return uint64(1), nil
+func (tv *TestView) Total(ctx context.Context, st state.Tree, bstore blockstore.Blockstore) (*types.BytesAmount, error) {
+	return types.NewBytesAmount(1), nil
 }
 
 // Miner always returns 1.
-func (tv *TestView) Miner(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (uint64, error) {
-	return uint64(1), nil
+func (tv *TestView) Miner(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (*types.BytesAmount, error) {
+	return types.NewBytesAmount(1), nil
 }
 
 // HasPower always returns true.
-func (tv *TestView) HasPower(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) bool {
-	return true
+func (tv *TestView) HasPower(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (bool, error) {
+	return true, nil
 }
 
 // RequireNewTipSet instantiates and returns a new tipset of the given blocks
@@ -61,18 +61,18 @@ func NewTestPowerTableView(minerPower uint64, totalPower uint64) *TestPowerTable
 }
 
 // Total always returns value that was supplied to NewTestPowerTableView.
-func (tv *TestPowerTableView) Total(ctx context.Context, st state.Tree, bstore blockstore.Blockstore) (uint64, error) {
-	return tv.totalPower, nil
+func (tv *TestPowerTableView) Total(ctx context.Context, st state.Tree, bstore blockstore.Blockstore) (*types.BytesAmount, error) {
+	return types.NewBytesAmount(tv.totalPower), nil
 }
 
 // Miner always returns value that was supplied to NewTestPowerTableView.
-func (tv *TestPowerTableView) Miner(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (uint64, error) {
-	return tv.minerPower, nil
+func (tv *TestPowerTableView) Miner(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (*types.BytesAmount, error) {
+	return types.NewBytesAmount(tv.minerPower), nil
 }
 
 // HasPower always returns true.
-func (tv *TestPowerTableView) HasPower(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) bool {
-	return true
+func (tv *TestPowerTableView) HasPower(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (bool, error) {
+	return true, nil
 }
 
 // NewValidTestBlockFromTipSet creates a block for when proofs & power table don't need
@@ -88,7 +88,7 @@ func NewValidTestBlockFromTipSet(baseTipSet types.TipSet, height uint64, minerAd
 		Miner:        minerAddr,
 		Ticket:       ticket,
 		Parents:      baseTipSet.ToSortedCidSet(),
-		ParentWeight: types.Uint64(10000 * height),
+		ParentWeight: types.NewChainWeight(10 * height),
 		Height:       types.Uint64(height),
 		Nonce:        types.Uint64(height),
 		StateRoot:    stateRoot,
@@ -113,7 +113,17 @@ type TestSignedMessageValidator struct{}
 var _ consensus.SignedMessageValidator = (*TestSignedMessageValidator)(nil)
 
 // Validate always returns nil
-func (tsmv *TestSignedMessageValidator) Validate(ctx context.Context, msg *types.SignedMessage, fromActor *actor.Actor) error {
+func (tsmv *TestSignedMessageValidator) Validate(ctx context.Context, msg *types.SignedMessage, fromActor *actor.Actor, fromKey []byte) error {
+	return nil
+}
+
+// TestBlockSignatureValidator is a validator that accepts any block signature to simplify block creation in tests.
+type TestBlockSignatureValidator struct{}
+
+var _ consensus.BlockSignatureValidator = (*TestBlockSignatureValidator)(nil)
+
+// ValidateBlockSignature always returns nil
+func (tbsv *TestBlockSignatureValidator) ValidateBlockSignature(ctx context.Context, st state.Tree, bs blockstore.Blockstore, blk *types.Block) error {
 	return nil
 }
 
@@ -147,13 +157,25 @@ func (ms testSigner) SignBytes(data []byte, addr address.Address) (types.Signatu
 
 // ApplyTestMessage sends a message directly to the vm, bypassing message validation
 func ApplyTestMessage(st state.Tree, store vm.StorageMap, msg *types.Message, bh *types.BlockHeight) (*consensus.ApplicationResult, error) {
-	smsg, err := types.NewSignedMessage(*msg, testSigner{}, types.NewGasPrice(0), types.NewGasUnits(300))
+	smsg, err := types.NewSignedMessage(*msg, testSigner{}, types.NewGasPrice(0), types.NewGasUnits(1000))
+	if err != nil {
+		panic(err)
+	}
+
+	ta := newTestApplier()
+	return newMessageApplier(smsg, ta, st, store, bh, address.Address{}, nil)
+}
+
+// ApplyTestMessageWithAncestors is like ApplyTestMessage, but lets the actors
+// sample chain randomness from the given ancestors, newest first.
+func ApplyTestMessageWithAncestors(st state.Tree, store vm.StorageMap, msg *types.Message, bh *types.BlockHeight, ancestors []types.TipSet) (*consensus.ApplicationResult, error) {
+	smsg, err := types.NewSignedMessage(*msg, testSigner{}, types.NewGasPrice(0), types.NewGasUnits(1000))
 	if err != nil {
 		panic(err)
 	}
 
 	ta := newTestApplier()
-	return newMessageApplier(smsg, ta, st, store, bh, address.Address{})
+	return newMessageApplier(smsg, ta, st, store, bh, address.Address{}, ancestors)
 }
 
 // ApplyTestMessageWithGas uses the TestBlockRewarder but the default SignedMessageValidator
@@ -165,12 +187,12 @@ func ApplyTestMessageWithGas(st state.Tree, store vm.StorageMap, msg *types.Mess
 		panic(err)
 	}
 	applier := consensus.NewConfiguredProcessor(consensus.NewDefaultMessageValidator(), consensus.NewDefaultBlockRewarder())
-	return newMessageApplier(smsg, applier, st, store, bh, minerAddr)
+	return newMessageApplier(smsg, applier, st, store, bh, minerAddr, nil)
 }
 
 func newMessageApplier(smsg *types.SignedMessage, processor *consensus.DefaultProcessor, st state.Tree, storageMap vm.StorageMap,
-	bh *types.BlockHeight, minerAddr address.Address) (*consensus.ApplicationResult, error) {
-	amr, err := processor.ApplyMessagesAndPayRewards(context.Background(), st, storageMap, []*types.SignedMessage{smsg}, minerAddr, bh, nil)
+	bh *types.BlockHeight, minerAddr address.Address, ancestors []types.TipSet) (*consensus.ApplicationResult, error) {
+	amr, err := processor.ApplyMessagesAndPayRewards(context.Background(), st, storageMap, []*types.SignedMessage{smsg}, minerAddr, bh, ancestors)
 
 	if len(amr.Results) > 0 {
 		return amr.Results[0], err
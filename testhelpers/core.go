@@ -58,7 +58,7 @@ func RequireNewAccountActor(require *require.Assertions, value *types.AttoFIL) *
 func RequireNewMinerActor(require *require.Assertions, vms vm.StorageMap, addr address.Address, owner address.Address, key []byte, pledge uint64, pid peer.ID, coll *types.AttoFIL) *actor.Actor {
 	act := actor.NewActor(types.MinerActorCodeCid, types.NewZeroAttoFIL())
 	storage := vms.NewStorage(addr, act)
-	initializerData := miner.NewState(owner, key, big.NewInt(int64(pledge)), pid, coll)
+	initializerData := miner.NewState(owner, key, big.NewInt(int64(pledge)), pid, coll, SectorSize())
 	err := (&miner.Actor{}).InitializeState(storage, initializerData)
 	require.NoError(storage.Flush())
 	require.NoError(err)
@@ -9,6 +9,7 @@ import (
 
 	"github.com/filecoin-project/go-filecoin/abi"
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/consensus"
 	"github.com/filecoin-project/go-filecoin/types"
 )
 
@@ -26,8 +27,8 @@ func CreateMinerMessage(from address.Address, nonce uint64, pledge uint64, pid p
 }
 
 // CommitSectorMessage creates a message to commit a sector.
-func CommitSectorMessage(miner, from address.Address, nonce, sectorID uint64, commD, commR, commRStar, proof []byte) (*types.Message, error) {
-	params, err := abi.ToEncodedValues(sectorID, commD, commR, commRStar, proof)
+func CommitSectorMessage(miner, from address.Address, nonce, sectorID uint64, commD, commR, commRStar, proof []byte, dealIDs []uint64) (*types.Message, error) {
+	params, err := abi.ToEncodedValues(sectorID, commD, commR, commRStar, proof, dealIDs)
 	if err != nil {
 		return nil, err
 	}
@@ -35,6 +36,12 @@ func CommitSectorMessage(miner, from address.Address, nonce, sectorID uint64, co
 	return types.NewMessage(from, miner, nonce, types.NewZeroAttoFIL(), "commitSector", params), nil
 }
 
+// SectorSize returns the power, in bytes, a miner gains for each sector it
+// commits on networks created by genesis blocks in this process.
+func SectorSize() *types.BytesAmount {
+	return consensus.DefaultSectorSize()
+}
+
 // MakeCommitment creates a random commitment.
 func MakeCommitment() []byte {
 	return MakeRandomBytes(32)
@@ -65,3 +65,13 @@ func (f *Filecoin) ClientQueryStorageDeal(ctx context.Context, prop cid.Cid) (*s
 func (f *Filecoin) ClientListAsks(ctx context.Context) (*json.Decoder, error) {
 	return f.RunCmdLDJSONWithStdin(ctx, nil, "go-filecoin", "client", "list-asks")
 }
+
+// ClientListDeals runs the client list-deals command against the filecoin process.
+// A json decoder is returned that deals may be decoded from.
+func (f *Filecoin) ClientListDeals(ctx context.Context, onChain bool) (*json.Decoder, error) {
+	args := []string{"go-filecoin", "client", "list-deals"}
+	if onChain {
+		args = append(args, "--on-chain")
+	}
+	return f.RunCmdLDJSONWithStdin(ctx, nil, args...)
+}
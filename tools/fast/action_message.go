@@ -19,12 +19,12 @@ func (f *Filecoin) MessageSend(ctx context.Context, target address.Address, meth
 		args = append(args, option()...)
 	}
 
-	args = append(args, target.String())
-
 	if method != "" {
-		args = append(args, method)
+		args = append(args, "--method", method)
 	}
 
+	args = append(args, target.String())
+
 	if err := f.RunCmdJSONWithStdin(ctx, nil, &out, args...); err != nil {
 		return cid.Undef, err
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math/big"
+	"strconv"
 
 	cid "gx/ipfs/QmR8BauakNcBa3RbE4nbQu76PDiJgoQgz8AJdhJuiU4TAw/go-cid"
 	"gx/ipfs/QmY5Grm8pJdiSSVsYxx4uNRgweY72EmYwuSDbRnbFok3iY/go-libp2p-peer"
@@ -11,6 +12,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/commands"
 	"github.com/filecoin-project/go-filecoin/porcelain"
+	"github.com/filecoin-project/go-filecoin/types"
 )
 
 // MinerCreate runs the `miner create` command against the filecoin process
@@ -102,6 +104,85 @@ func (f *Filecoin) MinerPledge(ctx context.Context, minerAddr address.Address) (
 	return &out, nil
 }
 
+// MinerPledgeIncrease runs the `miner pledge increase` command against the filecoin process
+func (f *Filecoin) MinerPledgeIncrease(ctx context.Context, minerAddr address.Address, sectors uint64, options ...ActionOption) (cid.Cid, error) {
+	var out commands.MinerMessageResult
+
+	args := []string{"go-filecoin", "miner", "pledge", "increase"}
+
+	for _, option := range options {
+		args = append(args, option()...)
+	}
+
+	args = append(args, minerAddr.String(), fmt.Sprintf("%d", sectors))
+
+	if err := f.RunCmdJSONWithStdin(ctx, nil, &out, args...); err != nil {
+		return cid.Undef, err
+	}
+
+	return out.Cid, nil
+}
+
+// MinerCollateral runs the `miner collateral` command against the filecoin process
+func (f *Filecoin) MinerCollateral(ctx context.Context, minerAddr address.Address) (*types.AttoFIL, error) {
+	var out types.AttoFIL
+
+	sMinerAddr := minerAddr.String()
+
+	if err := f.RunCmdJSONWithStdin(ctx, nil, &out, "go-filecoin", "miner", "collateral", sMinerAddr); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+// MinerCollateralAdd runs the `miner collateral add` command against the filecoin process
+func (f *Filecoin) MinerCollateralAdd(ctx context.Context, minerAddr address.Address, amount *types.AttoFIL, options ...ActionOption) (cid.Cid, error) {
+	return f.minerCollateralChange(ctx, "add", minerAddr, amount, options...)
+}
+
+// MinerCollateralWithdraw runs the `miner collateral withdraw` command against the filecoin process
+func (f *Filecoin) MinerCollateralWithdraw(ctx context.Context, minerAddr address.Address, amount *types.AttoFIL, options ...ActionOption) (cid.Cid, error) {
+	return f.minerCollateralChange(ctx, "withdraw", minerAddr, amount, options...)
+}
+
+func (f *Filecoin) minerCollateralChange(ctx context.Context, subcommand string, minerAddr address.Address, amount *types.AttoFIL, options ...ActionOption) (cid.Cid, error) {
+	var out commands.MinerMessageResult
+
+	args := []string{"go-filecoin", "miner", "collateral", subcommand}
+
+	for _, option := range options {
+		args = append(args, option()...)
+	}
+
+	args = append(args, minerAddr.String(), amount.String())
+
+	if err := f.RunCmdJSONWithStdin(ctx, nil, &out, args...); err != nil {
+		return cid.Undef, err
+	}
+
+	return out.Cid, nil
+}
+
+// MinerTerminateSector runs the `miner terminate-sector` command against the filecoin process
+func (f *Filecoin) MinerTerminateSector(ctx context.Context, minerAddr address.Address, sectorID uint64, options ...ActionOption) (cid.Cid, error) {
+	var out commands.MinerMessageResult
+
+	args := []string{"go-filecoin", "miner", "terminate-sector"}
+
+	for _, option := range options {
+		args = append(args, option()...)
+	}
+
+	args = append(args, minerAddr.String(), strconv.FormatUint(sectorID, 10))
+
+	if err := f.RunCmdJSONWithStdin(ctx, nil, &out, args...); err != nil {
+		return cid.Undef, err
+	}
+
+	return out.Cid, nil
+}
+
 // MinerPower runs the `miner power` command against the filecoin process
 func (f *Filecoin) MinerPower(ctx context.Context, minerAddr address.Address) (*big.Int, error) {
 	var out big.Int
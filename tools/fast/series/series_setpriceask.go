@@ -15,7 +15,7 @@ import (
 // canceled.
 func SetPriceGetAsk(ctx context.Context, miner *fast.Filecoin, price *big.Float, expiry *big.Int) (api.Ask, error) {
 	// Set a price
-	pinfo, err := miner.MinerSetPrice(ctx, price, expiry, fast.AOPrice(big.NewFloat(1.0)), fast.AOLimit(300))
+	pinfo, err := miner.MinerSetPrice(ctx, price, expiry, fast.AOPrice(big.NewFloat(1.0)), fast.AOLimit(500))
 	if err != nil {
 		return api.Ask{}, err
 	}
@@ -106,7 +106,7 @@ func TestRetrieval(t *testing.T) {
 	require.NoError(err)
 
 	// Create a miner on the miner node
-	_, err = miner.MinerCreate(ctx, 10, big.NewInt(10), fast.AOPrice(big.NewFloat(1.0)), fast.AOLimit(300))
+	_, err = miner.MinerCreate(ctx, 10, big.NewInt(10), fast.AOPrice(big.NewFloat(1.0)), fast.AOLimit(1000))
 	require.NoError(err)
 
 	//TODO(tperson): I don't think a miner is valid unless it has power. Does
@@ -32,7 +32,7 @@ type Block struct {
 	Parents SortedCidSet `json:"parents"`
 
 	// ParentWeight is the aggregate chain weight of the parent set.
-	ParentWeight Uint64 `json:"parentWeight"`
+	ParentWeight *ChainWeight `json:"parentWeight"`
 
 	// Height is the chain height of this block.
 	Height Uint64 `json:"height"`
@@ -48,12 +48,26 @@ type Block struct {
 	// transactions state transitions.
 	StateRoot cid.Cid `json:"stateRoot,omitempty" refmt:",omitempty"`
 
-	// MessageReceipts is a set of receipts matching to the sending of the `Messages`.
-	MessageReceipts []*MessageReceipt `json:"messageReceipts"`
+	// MessageReceipts is a cid pointer to the receipts produced by sending the
+	// `Messages`, keyed by message index. It is undefined when there are none.
+	MessageReceipts cid.Cid `json:"messageReceipts,omitempty" refmt:",omitempty"`
 
 	// Proof is a proof of spacetime generated using the hash of the previous ticket as
 	// a challenge
 	Proof proofs.PoStProof `json:"proof"`
+
+	// BLSAggregate is the aggregate of the signatures of the messages from
+	// BLS addresses, which are included without a signature of their own.
+	BLSAggregate Signature `json:"blsAggregate,omitempty" refmt:",omitempty"`
+
+	// BlockSig is the signature of the block by the owner of its miner, made
+	// over the block without this field.
+	BlockSig Signature `json:"blockSig,omitempty" refmt:",omitempty"`
+
+	// Authorities is only set on the genesis block of a chain that uses
+	// round-robin proof-of-authority consensus. It lists, in order, the
+	// miners that take turns producing blocks.
+	Authorities []address.Address `json:"authorities,omitempty" refmt:",omitempty"`
 }
 
 // Cid returns the content id of this block.
@@ -89,6 +103,37 @@ func (b *Block) String() string {
 	return fmt.Sprintf("Block cid=[%v]: %s", cid, string(js))
 }
 
+// SignatureData returns the bytes the block signature is made over, which is
+// the encoding of the block without its signature.
+func (b *Block) SignatureData() []byte {
+	unsigned := *b
+	unsigned.BlockSig = nil
+	return unsigned.ToNode().RawData()
+}
+
+// Sign sets the block signature to the signature of signer over the block.
+func (b *Block) Sign(s Signer, signer address.Address) error {
+	sig, err := s.SignBytes(b.SignatureData(), signer)
+	if err != nil {
+		return err
+	}
+	b.BlockSig = sig
+	return nil
+}
+
+// VerifySignature returns true iff the block is signed by signer. The
+// signature is verified against pubKey, the key recorded for signer, or, if
+// it is empty, against the key recovered from the signature.
+func (b *Block) VerifySignature(signer address.Address, pubKey []byte) bool {
+	if len(b.BlockSig) == 0 {
+		return false
+	}
+	if len(pubKey) == 0 {
+		return IsValidSignature(b.SignatureData(), signer, b.BlockSig)
+	}
+	return IsValidSignatureWithKey(b.SignatureData(), signer, pubKey, b.BlockSig)
+}
+
 // DecodeBlock decodes raw cbor bytes into a Block.
 func DecodeBlock(b []byte) (*Block, error) {
 	var out Block
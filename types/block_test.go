@@ -95,16 +95,19 @@ func TestTriangleEncoding(t *testing.T) {
 			Height:          Uint64(2),
 			Nonce:           3,
 			Messages:        []*SignedMessage{newSignedMessage()},
-			MessageReceipts: []*MessageReceipt{{ExitCode: 1}},
+			MessageReceipts: SomeCid(),
 			Parents:         NewSortedCidSet(SomeCid()),
-			ParentWeight:    Uint64(1000),
+			ParentWeight:    NewChainWeight(1),
 			Proof:           NewTestPoSt(),
 			StateRoot:       SomeCid(),
+			BLSAggregate:    Bytes([]byte{0x04, 0x05, 0x06}),
+			BlockSig:        Bytes([]byte{0x07, 0x08, 0x09}),
+			Authorities:     []address.Address{newAddress()},
 		}
 		s := reflect.TypeOf(*b)
 		// This check is here to request that you add a non-zero value for new fields
 		// to the above (and update the field count below).
-		require.Equal(t, 10, s.NumField())
+		require.Equal(t, 13, s.NumField())
 		testRoundTrip(t, b)
 	})
 }
@@ -163,16 +166,13 @@ func TestDecodeBlock(t *testing.T) {
 		assert.NoError(err)
 
 		before := &Block{
-			Miner:     addrGetter(),
-			Ticket:    []uint8{},
-			Parents:   NewSortedCidSet(c1),
-			Height:    2,
-			Messages:  []*SignedMessage{newSignedMessage(), newSignedMessage()},
-			StateRoot: c2,
-			MessageReceipts: []*MessageReceipt{
-				{ExitCode: 1, Return: []Bytes{[]byte{1, 2}}},
-				{ExitCode: 1, Return: []Bytes{[]byte{1, 2, 3}}},
-			},
+			Miner:           addrGetter(),
+			Ticket:          []uint8{},
+			Parents:         NewSortedCidSet(c1),
+			Height:          2,
+			Messages:        []*SignedMessage{newSignedMessage(), newSignedMessage()},
+			StateRoot:       c2,
+			MessageReceipts: c1,
 		}
 
 		after, err := DecodeBlock(before.ToNode().RawData())
@@ -212,6 +212,30 @@ func TestEquals(t *testing.T) {
 	assert.False(b3.Equals(b4))
 }
 
+func TestBlockSignature(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	ki := MustGenerateKeyInfo(2, GenerateKeyInfoSeed())
+	signer := NewMockSigner(ki)
+	owner, other := signer.Addresses[0], signer.Addresses[1]
+
+	blk := &Block{Miner: address.NewForTestGetter()(), Height: 3, Nonce: 4}
+	assert.False(blk.VerifySignature(owner, nil))
+
+	require.NoError(blk.Sign(signer, owner))
+	assert.True(blk.VerifySignature(owner, nil))
+	assert.False(blk.VerifySignature(other, nil))
+
+	pubKey, err := ki[0].PublicKey()
+	require.NoError(err)
+	assert.True(blk.VerifySignature(owner, pubKey))
+
+	// the signature covers every other field
+	blk.Nonce = 5
+	assert.False(blk.VerifySignature(owner, nil))
+}
+
 func TestBlockJsonMarshal(t *testing.T) {
 	assert := assert.New(t)
 
@@ -224,13 +248,9 @@ func TestBlockJsonMarshal(t *testing.T) {
 
 	message := newSignedMessage()
 
-	retVal := []byte{1, 2, 3}
-	receipt := &MessageReceipt{
-		ExitCode: 123,
-		Return:   []Bytes{retVal},
-	}
+	receiptsRoot := SomeCid()
 	child.Messages = []*SignedMessage{message}
-	child.MessageReceipts = []*MessageReceipt{receipt}
+	child.MessageReceipts = receiptsRoot
 
 	marshalled, e1 := json.Marshal(child)
 	assert.NoError(e1)
@@ -239,6 +259,7 @@ func TestBlockJsonMarshal(t *testing.T) {
 	assert.Contains(str, parent.Cid().String())
 	assert.Contains(str, message.From.String())
 	assert.Contains(str, message.To.String())
+	assert.Contains(str, receiptsRoot.String())
 
 	// marshal/unmarshal symmetry
 	var unmarshalled Block
@@ -248,6 +269,5 @@ func TestBlockJsonMarshal(t *testing.T) {
 	AssertHaveSameCid(assert, &child, &unmarshalled)
 	assert.True(child.Equals(&unmarshalled))
 
-	assert.Equal(uint8(123), unmarshalled.MessageReceipts[0].ExitCode)
-	assert.Equal([]Bytes{[]byte{1, 2, 3}}, unmarshalled.MessageReceipts[0].Return)
+	assert.Equal(receiptsRoot, unmarshalled.MessageReceipts)
 }
@@ -0,0 +1,71 @@
+package types
+
+import (
+	"github.com/filecoin-project/go-filecoin/address"
+	wutil "github.com/filecoin-project/go-filecoin/wallet/util"
+)
+
+// AggregateBLSSignatures returns the messages with the signatures of those
+// from BLS addresses removed, and the aggregate of the removed signatures.
+// The aggregate is nil if no signature was removed.
+func AggregateBLSSignatures(msgs []*SignedMessage) ([]*SignedMessage, Signature, error) {
+	out := make([]*SignedMessage, len(msgs))
+	var sigs [][]byte
+	for i, msg := range msgs {
+		if !msg.From.IsBLS() || len(msg.Signature) == 0 {
+			out[i] = msg
+			continue
+		}
+		sigs = append(sigs, msg.Signature)
+		out[i] = msg.withoutSignature()
+	}
+
+	if len(sigs) == 0 {
+		return out, nil, nil
+	}
+	aggregate, err := wutil.AggregateBLS(sigs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, aggregate, nil
+}
+
+// VerifyBLSAggregate returns true iff the BLS aggregate signature of the block
+// is the aggregate of the signatures of the aggregated messages it includes,
+// made with the public keys of their senders. A message that carries no public
+// key is verified with the key publicKey returns for its sender. Blocks without
+// aggregated messages must have no aggregate signature.
+func (b *Block) VerifyBLSAggregate(publicKey func(address.Address) ([]byte, error)) bool {
+	var pubKeys, data [][]byte
+	for _, msg := range b.Messages {
+		if !msg.IsAggregated() {
+			continue
+		}
+		pubKey := msg.PublicKey
+		if len(pubKey) == 0 {
+			var err error
+			pubKey, err = publicKey(msg.From)
+			if err != nil {
+				log.Infof("invalid aggregate signature: no public key for %s: %s", msg.From, err)
+				return false
+			}
+		}
+		if !IsKeyOfAddress(pubKey, msg.From) {
+			log.Infof("invalid aggregate signature: public key does not belong to %s", msg.From)
+			return false
+		}
+
+		bmsg, err := msg.MeteredMessage.Marshal()
+		if err != nil {
+			log.Infof("invalid aggregate signature: %s", err)
+			return false
+		}
+		pubKeys = append(pubKeys, pubKey)
+		data = append(data, bmsg)
+	}
+
+	if len(data) == 0 {
+		return len(b.BLSAggregate) == 0
+	}
+	return wutil.VerifyBLSAggregate(pubKeys, data, b.BLSAggregate)
+}
@@ -0,0 +1,146 @@
+package types
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	bls "github.com/filecoin-project/go-filecoin/bls-signatures"
+	wutil "github.com/filecoin-project/go-filecoin/wallet/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBLSSignedMessage(t *testing.T, nonce uint64) *SignedMessage {
+	prv := bls.PrivateKeyGenerate()
+	ki := &KeyInfo{PrivateKey: prv[:], Curve: BLS}
+	from, err := ki.Address()
+	require.NoError(t, err)
+	pubKey, err := ki.PublicKey()
+	require.NoError(t, err)
+
+	msg := NewMessage(from, address.NewForTestGetter()(), nonce, NewAttoFILFromFIL(1), "", nil)
+	meteredMsg := NewMeteredMessage(*msg, NewGasPrice(0), NewGasUnits(0))
+	bmsg, err := meteredMsg.Marshal()
+	require.NoError(t, err)
+	sig, err := wutil.SignBLS(ki.Key(), bmsg)
+	require.NoError(t, err)
+
+	return &SignedMessage{
+		MeteredMessage: *meteredMsg,
+		Signature:      sig,
+		PublicKey:      pubKey,
+	}
+}
+
+func TestBLSSignedMessage(t *testing.T) {
+	assert := assert.New(t)
+
+	smsg := newBLSSignedMessage(t, 0)
+	assert.True(smsg.From.IsBLS())
+	assert.True(smsg.VerifySignature())
+	assert.False(smsg.IsAggregated())
+
+	// the cid does not cover the signature
+	before, err := smsg.Cid()
+	assert.NoError(err)
+	smsg.Signature = nil
+	after, err := smsg.Cid()
+	assert.NoError(err)
+	assert.Equal(before, after)
+	assert.True(smsg.IsAggregated())
+	assert.False(smsg.VerifySignature())
+}
+
+func noRecordedKey(addr address.Address) ([]byte, error) {
+	return nil, fmt.Errorf("no key recorded for %s", addr)
+}
+
+func TestAggregateBLSSignatures(t *testing.T) {
+	secpMsg := newSignedMessage()
+	blsMsgs := []*SignedMessage{newBLSSignedMessage(t, 0), newBLSSignedMessage(t, 1)}
+
+	t.Run("aggregated signatures verify with the block", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		msgs, aggregate, err := AggregateBLSSignatures([]*SignedMessage{blsMsgs[0], secpMsg, blsMsgs[1]})
+		require.NoError(err)
+		require.Len(msgs, 3)
+		assert.NotNil(aggregate)
+
+		assert.True(msgs[0].IsAggregated())
+		assert.Equal(secpMsg, msgs[1])
+		assert.True(msgs[2].IsAggregated())
+
+		// the original messages keep their signatures
+		assert.NotEmpty(blsMsgs[0].Signature)
+
+		blk := &Block{Messages: msgs, BLSAggregate: aggregate}
+		assert.True(blk.VerifyBLSAggregate(noRecordedKey))
+
+		// changing an aggregated message invalidates the aggregate
+		msgs[2].Nonce++
+		assert.False(blk.VerifyBLSAggregate(noRecordedKey))
+	})
+
+	t.Run("messages without public keys verify with the recorded keys", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		recorded := make(map[address.Address][]byte)
+		var withoutKeys []*SignedMessage
+		for _, msg := range blsMsgs {
+			recorded[msg.From] = msg.PublicKey
+			withoutKey := *msg
+			withoutKey.PublicKey = nil
+			withoutKeys = append(withoutKeys, &withoutKey)
+		}
+
+		msgs, aggregate, err := AggregateBLSSignatures(withoutKeys)
+		require.NoError(err)
+		blk := &Block{Messages: msgs, BLSAggregate: aggregate}
+
+		assert.False(blk.VerifyBLSAggregate(noRecordedKey))
+		assert.True(blk.VerifyBLSAggregate(func(addr address.Address) ([]byte, error) {
+			pubKey, ok := recorded[addr]
+			if !ok {
+				return noRecordedKey(addr)
+			}
+			return pubKey, nil
+		}))
+
+		// a recorded key must belong to the sender
+		assert.False(blk.VerifyBLSAggregate(func(addr address.Address) ([]byte, error) {
+			return blsMsgs[0].PublicKey, nil
+		}))
+	})
+
+	t.Run("aggregates must cover all aggregated messages", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		msgs, aggregate, err := AggregateBLSSignatures(blsMsgs[:1])
+		require.NoError(err)
+		others, _, err := AggregateBLSSignatures(blsMsgs[1:])
+		require.NoError(err)
+
+		blk := &Block{Messages: append(msgs, others...), BLSAggregate: aggregate}
+		assert.False(blk.VerifyBLSAggregate(noRecordedKey))
+	})
+
+	t.Run("blocks without aggregated messages have no aggregate", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		msgs, aggregate, err := AggregateBLSSignatures([]*SignedMessage{secpMsg})
+		require.NoError(err)
+		assert.Nil(aggregate)
+
+		blk := &Block{Messages: msgs}
+		assert.True(blk.VerifyBLSAggregate(noRecordedKey))
+
+		blk.BLSAggregate = blsMsgs[0].Signature
+		assert.False(blk.VerifyBLSAggregate(noRecordedKey))
+	})
+}
@@ -73,9 +73,13 @@ func NewBytesAmount(x uint64) *BytesAmount {
 }
 
 // NewBytesAmountFromBytes allocates and returns a new BytesAmount set
-// to the value of buf as the bytes of a big-endian unsigned integer.
+// to the value of buf as encoded by Bytes.
 func NewBytesAmountFromBytes(buf []byte) *BytesAmount {
 	ba := NewBytesAmount(0)
+	if len(buf) > 1 && buf[0] == 0 {
+		ba.val = new(big.Int).Neg(leb128.ToBigInt(buf[1:]))
+		return ba
+	}
 	ba.val = leb128.ToBigInt(buf)
 	return ba
 }
@@ -164,9 +168,15 @@ func (z *BytesAmount) IsZero() bool {
 	return z.Equal(ZeroBytes)
 }
 
-// Bytes returns the absolute value of x as a big-endian byte slice.
+// Bytes returns x as a leb128 encoded byte slice. Negative values are encoded
+// as a zero byte followed by the encoding of their absolute value; a leading
+// zero byte never starts the encoding of a non-negative value, so this is
+// compatible with the plain unsigned encoding.
 func (z *BytesAmount) Bytes() []byte {
 	ensureBytesAmounts(&z)
+	if z.val.Sign() < 0 {
+		return append([]byte{0}, leb128.FromBigInt(new(big.Int).Neg(z.val))...)
+	}
 	return leb128.FromBigInt(z.val)
 }
 
@@ -179,3 +189,9 @@ func (z *BytesAmount) String() string {
 func (z *BytesAmount) Uint64() uint64 {
 	return z.val.Uint64()
 }
+
+// AsBigInt returns a copy of the bytes amount as a big.Int.
+func (z *BytesAmount) AsBigInt() *big.Int {
+	ensureBytesAmounts(&z)
+	return new(big.Int).Set(z.val)
+}
@@ -167,6 +167,24 @@ func TestBytesAmountCborMarshaling(t *testing.T) {
 		}
 	})
 
+	t.Run("CBOR encoding preserves the sign of negative amounts", func(t *testing.T) {
+		assert := assert.New(t)
+
+		for _, n := range []uint64{1, 127, 128, 1 << 40} {
+			preEncode := ZeroBytes.Sub(NewBytesAmount(n))
+			postDecode := BytesAmount{}
+
+			out, err := cbor.DumpObject(preEncode)
+			assert.NoError(err)
+
+			err = cbor.DecodeInto(out, &postDecode)
+			assert.NoError(err)
+
+			assert.True(preEncode.Equal(&postDecode), "pre: %s post: %s", preEncode.String(), postDecode.String())
+			assert.True(postDecode.IsNegative())
+		}
+	})
+
 	t.Run("cannot CBOR encode nil as *BytesAmount", func(t *testing.T) {
 		assert := assert.New(t)
 
@@ -0,0 +1,191 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	cbor "gx/ipfs/QmRoARq3nkUb13HSKZGepCZSWe5GrVPwx7xURJGZ7KWv9V/go-ipld-cbor"
+	"gx/ipfs/QmSKyB5faguXT4NqbrXpnRXqaVj5DhSm7x9BtzFydBY1UK/go-leb128"
+	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+	"gx/ipfs/QmfWqohMtbivn5NRJvtrLzCW3EU4QmoLvVNtmvo9vbdtVA/refmt/obj/atlas"
+)
+
+// NOTE -- All *ChainWeight methods must call ensureChainWeights with refs to every user-supplied value before use.
+
+func init() {
+	cbor.RegisterCborType(chainWeightAtlasEntry)
+	ZeroWeight = NewChainWeight(0)
+}
+
+// ChainWeightDecimals is the number of decimal digits kept after the point
+// of a ChainWeight.
+const ChainWeightDecimals = 3
+
+// chainWeightScale is 10^ChainWeightDecimals: a ChainWeight is stored as its
+// value multiplied by this scale.
+var chainWeightScale = big.NewInt(1000)
+
+// ZeroWeight represents a ChainWeight of 0
+var ZeroWeight *ChainWeight
+
+// ensureChainWeights takes a variable number of refs -- variables holding *ChainWeight -- and sets their values
+// to ZeroWeight (the zero value for the type) if their values are nil.
+func ensureChainWeights(refs ...**ChainWeight) {
+	for _, ref := range refs {
+		if *ref == nil {
+			*ref = ZeroWeight
+		}
+	}
+}
+
+var chainWeightAtlasEntry = atlas.BuildEntry(ChainWeight{}).Transform().
+	TransformMarshal(atlas.MakeMarshalTransformFunc(
+		func(i ChainWeight) ([]byte, error) {
+			return i.Bytes(), nil
+		})).
+	TransformUnmarshal(atlas.MakeUnmarshalTransformFunc(
+		func(x []byte) (ChainWeight, error) {
+			return *NewChainWeightFromBytes(x), nil
+		})).
+	Complete()
+
+// UnmarshalJSON converts a decimal string to a ChainWeight.
+func (z *ChainWeight) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	w, ok := NewChainWeightFromString(s)
+	if !ok {
+		return errors.New("cannot convert string to chain weight")
+	}
+
+	*z = *w
+
+	return nil
+}
+
+// MarshalJSON converts a ChainWeight to a decimal string.
+func (z ChainWeight) MarshalJSON() ([]byte, error) {
+	return json.Marshal(z.String())
+}
+
+// ChainWeight is the weight a consensus protocol assigns to a chain. It is
+// an arbitrary precision fixed-point number with ChainWeightDecimals digits
+// after the decimal point, so that weights computed from fractions of the
+// network's power compare exactly and cannot overflow.
+type ChainWeight struct{ val *big.Int }
+
+// NewChainWeight allocates and returns a new ChainWeight set to the whole
+// number x.
+func NewChainWeight(x uint64) *ChainWeight {
+	val := big.NewInt(0).SetUint64(x)
+	return &ChainWeight{val: val.Mul(val, chainWeightScale)}
+}
+
+// NewChainWeightFromFloat allocates and returns a new ChainWeight set to f,
+// rounded to ChainWeightDecimals decimal digits.
+func NewChainWeightFromFloat(f *big.Float) *ChainWeight {
+	prec := f.Prec()
+	if prec < 64 {
+		prec = 64
+	}
+	scaled := new(big.Float).SetPrec(prec).SetInt(chainWeightScale)
+	scaled.Mul(scaled, f)
+	if scaled.Sign() >= 0 {
+		scaled.Add(scaled, big.NewFloat(0.5))
+	} else {
+		scaled.Sub(scaled, big.NewFloat(0.5))
+	}
+	val, _ := scaled.Int(nil)
+	return &ChainWeight{val: val}
+}
+
+// NewChainWeightFromBytes allocates and returns a new ChainWeight set to the
+// value encoded in buf by Bytes.
+func NewChainWeightFromBytes(buf []byte) *ChainWeight {
+	w := NewChainWeight(0)
+	if len(buf) > 0 {
+		w.val = leb128.ToBigInt(buf)
+	}
+	return w
+}
+
+// NewChainWeightFromString allocates a new ChainWeight set to the value of
+// the decimal string s and returns it and a boolean indicating success. At
+// most ChainWeightDecimals digits may follow the decimal point.
+func NewChainWeightFromString(s string) (*ChainWeight, bool) {
+	parts := strings.Split(s, ".")
+	if len(parts) > 2 || len(parts[0]) == 0 {
+		return nil, false
+	}
+	frac := ""
+	if len(parts) == 2 {
+		frac = parts[1]
+	}
+	if len(frac) > ChainWeightDecimals {
+		return nil, false
+	}
+	frac += strings.Repeat("0", ChainWeightDecimals-len(frac))
+
+	val, ok := big.NewInt(0).SetString(parts[0]+frac, 10)
+	if !ok {
+		return nil, false
+	}
+	return &ChainWeight{val: val}, true
+}
+
+// Add returns the sum z+y.
+func (z *ChainWeight) Add(y *ChainWeight) *ChainWeight {
+	ensureChainWeights(&z, &y)
+	newVal := big.NewInt(0)
+	newVal.Add(z.val, y.val)
+	return &ChainWeight{val: newVal}
+}
+
+// Equal returns true if z = y
+func (z *ChainWeight) Equal(y *ChainWeight) bool {
+	ensureChainWeights(&z, &y)
+	return z.val.Cmp(y.val) == 0
+}
+
+// LessThan returns true if z < y
+func (z *ChainWeight) LessThan(y *ChainWeight) bool {
+	ensureChainWeights(&z, &y)
+	return z.val.Cmp(y.val) < 0
+}
+
+// GreaterThan returns true if z > y
+func (z *ChainWeight) GreaterThan(y *ChainWeight) bool {
+	ensureChainWeights(&z, &y)
+	return z.val.Cmp(y.val) > 0
+}
+
+// AsBigFloat returns the weight as a big.Float.
+func (z *ChainWeight) AsBigFloat() *big.Float {
+	ensureChainWeights(&z)
+	f := new(big.Float).SetInt(z.val)
+	return f.Quo(f, new(big.Float).SetInt(chainWeightScale))
+}
+
+// Bytes returns the weight in its serialized form.
+func (z *ChainWeight) Bytes() []byte {
+	ensureChainWeights(&z)
+	return leb128.FromBigInt(z.val)
+}
+
+// String returns the weight in decimal, with ChainWeightDecimals digits
+// after the point.
+func (z *ChainWeight) String() string {
+	ensureChainWeights(&z)
+	abs := new(big.Int).Abs(z.val)
+	integral, frac := new(big.Int).QuoRem(abs, chainWeightScale, new(big.Int))
+	sign := ""
+	if z.val.Sign() < 0 {
+		sign = "-"
+	}
+	return fmt.Sprintf("%s%s.%03d", sign, integral, frac.Int64())
+}
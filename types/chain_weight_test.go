@@ -0,0 +1,97 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	cbor "gx/ipfs/QmRoARq3nkUb13HSKZGepCZSWe5GrVPwx7xURJGZ7KWv9V/go-ipld-cbor"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainWeightFromFloat(t *testing.T) {
+	t.Run("rounds to three decimals", func(t *testing.T) {
+		assert := assert.New(t)
+		assert.Equal("30004828.209", NewChainWeightFromFloat(big.NewFloat(30004828.209239083240324)).String())
+		assert.Equal("300.000", NewChainWeightFromFloat(big.NewFloat(300.0000000000000000000000000000001)).String())
+		assert.Equal("4000001.530", NewChainWeightFromFloat(big.NewFloat(4000001.530)).String())
+	})
+
+	t.Run("is not limited to 64 bits", func(t *testing.T) {
+		assert := assert.New(t)
+		f, _, err := big.ParseFloat("18014398509481986555.555", 10, 256, big.ToNearestEven)
+		require.NoError(t, err)
+		assert.Equal("18014398509481986555.555", NewChainWeightFromFloat(f).String())
+	})
+
+	t.Run("round trips through big.Float", func(t *testing.T) {
+		assert := assert.New(t)
+		w := NewChainWeightFromFloat(big.NewFloat(75123.499))
+		assert.True(w.Equal(NewChainWeightFromFloat(w.AsBigFloat())))
+	})
+}
+
+func TestChainWeightFromString(t *testing.T) {
+	assert := assert.New(t)
+
+	w, ok := NewChainWeightFromString("12.5")
+	assert.True(ok)
+	assert.Equal("12.500", w.String())
+
+	w, ok = NewChainWeightFromString("7")
+	assert.True(ok)
+	assert.True(NewChainWeight(7).Equal(w))
+
+	_, ok = NewChainWeightFromString("1.2345")
+	assert.False(ok)
+	_, ok = NewChainWeightFromString("abc")
+	assert.False(ok)
+}
+
+func TestChainWeightArithmetic(t *testing.T) {
+	assert := assert.New(t)
+
+	a := NewChainWeightFromFloat(big.NewFloat(1.25))
+	b := NewChainWeight(2)
+
+	assert.Equal("3.250", a.Add(b).String())
+	assert.True(a.LessThan(b))
+	assert.True(b.GreaterThan(a))
+	assert.False(a.Equal(b))
+
+	var np *ChainWeight
+	assert.True(np.Equal(ZeroWeight))
+	assert.True(a.GreaterThan(np))
+	assert.Equal("1.250", np.Add(a).String())
+}
+
+func TestChainWeightEncoding(t *testing.T) {
+	w := NewChainWeightFromFloat(big.NewFloat(1020.125))
+
+	t.Run("cbor", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		out, err := cbor.DumpObject(w)
+		require.NoError(err)
+
+		var rt ChainWeight
+		require.NoError(cbor.DecodeInto(out, &rt))
+		assert.True(w.Equal(&rt))
+	})
+
+	t.Run("json", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		out, err := json.Marshal(w)
+		require.NoError(err)
+		assert.Equal(`"1020.125"`, string(out))
+
+		var rt ChainWeight
+		require.NoError(json.Unmarshal(out, &rt))
+		assert.True(w.Equal(&rt))
+	})
+}
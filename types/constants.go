@@ -40,6 +40,24 @@ var BootstrapMinerActorCodeObj ipld.Node
 // BootstrapMinerActorCodeCid is the cid of the above object
 var BootstrapMinerActorCodeCid cid.Cid
 
+// MultisigActorCodeObj is the code representation of the builtin multisig actor.
+var MultisigActorCodeObj ipld.Node
+
+// MultisigActorCodeCid is the cid of the above object
+var MultisigActorCodeCid cid.Cid
+
+// InitActorCodeObj is the code representation of the builtin init actor.
+var InitActorCodeObj ipld.Node
+
+// InitActorCodeCid is the cid of the above object
+var InitActorCodeCid cid.Cid
+
+// GovernanceActorCodeObj is the code representation of the builtin governance actor.
+var GovernanceActorCodeObj ipld.Node
+
+// GovernanceActorCodeCid is the cid of the above object
+var GovernanceActorCodeCid cid.Cid
+
 // ActorCodeCidTypeNames maps Actor codeCid's to the name of the associated Actor type.
 var ActorCodeCidTypeNames = make(map[cid.Cid]string)
 
@@ -54,6 +72,12 @@ func init() {
 	MinerActorCodeCid = MinerActorCodeObj.Cid()
 	BootstrapMinerActorCodeObj = dag.NewRawNode([]byte("bootstrapmineractor"))
 	BootstrapMinerActorCodeCid = BootstrapMinerActorCodeObj.Cid()
+	MultisigActorCodeObj = dag.NewRawNode([]byte("multisigactor"))
+	MultisigActorCodeCid = MultisigActorCodeObj.Cid()
+	InitActorCodeObj = dag.NewRawNode([]byte("initactor"))
+	InitActorCodeCid = InitActorCodeObj.Cid()
+	GovernanceActorCodeObj = dag.NewRawNode([]byte("governanceactor"))
+	GovernanceActorCodeCid = GovernanceActorCodeObj.Cid()
 
 	// New Actors need to be added here.
 	// TODO: Make this work with reflection -- but note that nasty import cycles lie on that path.
@@ -63,6 +87,9 @@ func init() {
 	ActorCodeCidTypeNames[PaymentBrokerActorCodeCid] = "PaymentBrokerActor"
 	ActorCodeCidTypeNames[MinerActorCodeCid] = "MinerActor"
 	ActorCodeCidTypeNames[BootstrapMinerActorCodeCid] = "MinerActor"
+	ActorCodeCidTypeNames[MultisigActorCodeCid] = "MultisigActor"
+	ActorCodeCidTypeNames[InitActorCodeCid] = "InitActor"
+	ActorCodeCidTypeNames[GovernanceActorCodeCid] = "GovernanceActor"
 }
 
 // ActorCodeTypeName returns the (string) name of the Go type of the actor with cid, code.
@@ -0,0 +1,25 @@
+package types
+
+import (
+	cbor "gx/ipfs/QmRoARq3nkUb13HSKZGepCZSWe5GrVPwx7xURJGZ7KWv9V/go-ipld-cbor"
+
+	"github.com/filecoin-project/go-filecoin/address"
+)
+
+func init() {
+	cbor.RegisterCborType(Event{})
+}
+
+// Event is emitted by an actor while processing a message, so that clients
+// can follow what actors do without polling their state. Events are recorded
+// in the receipt of the message, unless it fails.
+type Event struct {
+	// Actor is the address of the actor that emitted the event.
+	Actor address.Address `json:"actor"`
+	// Topic names the kind of event, and is what events are looked up by.
+	Topic string `json:"topic"`
+	// Types are the names of the ABI types of the values of the event.
+	Types []string `json:"types"`
+	// Data holds the ABI encoded values of the event.
+	Data Bytes `json:"data"`
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/crypto"
 	cu "github.com/filecoin-project/go-filecoin/crypto/util"
+	wutil "github.com/filecoin-project/go-filecoin/wallet/util"
 )
 
 func init() {
@@ -59,7 +60,7 @@ func (ki *KeyInfo) Equals(other *KeyInfo) bool {
 	return bytes.Equal(ki.PrivateKey, other.PrivateKey)
 }
 
-// Address returns the address for this keyinfo
+// Address returns the address for this keyinfo. BLS keys have BLS addresses.
 func (ki *KeyInfo) Address() (address.Address, error) {
 	pub, err := ki.PublicKey()
 	if err != nil {
@@ -69,11 +70,19 @@ func (ki *KeyInfo) Address() (address.Address, error) {
 	addrHash := address.Hash(pub)
 
 	// TODO: Use the address type we are running on from the config.
+	if ki.Curve == BLS {
+		return address.NewBLSAddress(address.Mainnet, addrHash), nil
+	}
 	return address.NewMainnet(addrHash), nil
 }
 
-// PublicKey returns the public key part as uncompressed bytes.
+// PublicKey returns the public key part as uncompressed bytes, or compressed
+// for BLS keys.
 func (ki *KeyInfo) PublicKey() ([]byte, error) {
+	if ki.Curve == BLS {
+		return wutil.BLSPublicKey(ki.Key())
+	}
+
 	prv, err := crypto.BytesToECDSA(ki.Key())
 	if err != nil {
 		return nil, err
@@ -20,4 +20,7 @@ type MessageReceipt struct {
 
 	// GasAttoFIL Charge is the actual amount of FIL transferred from the sender to the miner for processing the message
 	GasAttoFIL *AttoFIL `json:"gasAttoFIL"`
+
+	// Events are the events emitted by actors while processing the message.
+	Events []*Event `json:"events,omitempty" refmt:",omitempty"`
 }
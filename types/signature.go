@@ -25,3 +25,34 @@ func IsValidSignature(data []byte, addr address.Address, sig Signature) bool {
 
 	return address.NewMainnet(maybeAddrHash) == addr
 }
+
+// IsValidSignatureWithKey cryptographically verifies that 'sig' is the signed
+// hash of 'data' with the private key of 'pubKey'. The signature scheme is the
+// one of `addr`: BLS for BLS addresses, secp256k1 otherwise. Unlike
+// IsValidSignature it does not depend on recovering the key from the
+// signature.
+func IsValidSignatureWithKey(data []byte, addr address.Address, pubKey []byte, sig Signature) bool {
+	if len(sig) == 0 {
+		return false
+	}
+	if addr.IsBLS() {
+		return wutil.VerifyBLS(pubKey, data, sig)
+	}
+
+	valid, err := wutil.Verify(pubKey, data, sig)
+	if err != nil {
+		log.Infof("error in signature validation: %s", err)
+		return false
+	}
+	return valid
+}
+
+// IsKeyOfAddress returns true if `addr` is the address of the public key
+// 'pubKey'.
+func IsKeyOfAddress(pubKey []byte, addr address.Address) bool {
+	hash := address.Hash(pubKey)
+	if addr.IsBLS() {
+		return address.NewBLSAddress(address.Mainnet, hash) == addr
+	}
+	return address.NewMainnet(hash) == addr
+}
@@ -9,6 +9,7 @@ import (
 	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
 
 	"github.com/filecoin-project/go-filecoin/address"
+	wutil "github.com/filecoin-project/go-filecoin/wallet/util"
 )
 
 var (
@@ -28,6 +29,12 @@ func init() {
 type SignedMessage struct {
 	MeteredMessage `json:"meteredMessage"`
 	Signature      Signature `json:"signature"`
+	// PublicKey is the public key of the sender. It is only needed on the
+	// first message of an account, which records it; later messages are
+	// verified against the recorded key. It is covered by neither the
+	// signature nor the CID, so that adding or dropping it cannot change the
+	// identity of a message.
+	PublicKey Bytes `json:"publicKey,omitempty" refmt:",omitempty"`
 }
 
 // Unmarshal a SignedMessage from the given bytes.
@@ -40,10 +47,19 @@ func (smsg *SignedMessage) Marshal() ([]byte, error) {
 	return cbor.DumpObject(smsg)
 }
 
-// Cid returns the canonical CID for the SignedMessage.
+// Cid returns the canonical CID for the SignedMessage. The CID does not cover
+// the public key of the sender, which is not signed. The CID of messages from
+// BLS addresses does not cover their signature either, so it stays the same
+// once a block aggregates the signature.
 // TODO: can we avoid returning an error?
 func (smsg *SignedMessage) Cid() (cid.Cid, error) {
-	obj, err := cbor.WrapObject(smsg, DefaultHashFunction, -1)
+	canonical := *smsg
+	canonical.PublicKey = nil
+	if smsg.From.IsBLS() {
+		canonical.Signature = nil
+	}
+
+	obj, err := cbor.WrapObject(&canonical, DefaultHashFunction, -1)
 	if err != nil {
 		return cid.Undef, errors.Wrap(err, "failed to marshal to cbor")
 	}
@@ -73,15 +89,66 @@ func (smsg *SignedMessage) RecoverAddress(r Recoverer) (address.Address, error)
 
 }
 
-// VerifySignature returns true iff the signature over the message as calculated
-// from EC recover matches the message sender address.
+// VerifySignature returns true iff the message is signed by its sender. The
+// signature is verified against the public key the message carries, which must
+// belong to the sender address, or, if it carries none, against the key
+// recovered from the signature.
 func (smsg *SignedMessage) VerifySignature() bool {
 	bmsg, err := smsg.MeteredMessage.Marshal()
 	if err != nil {
 		log.Infof("invalid signature: %s", err)
 		return false
 	}
-	return IsValidSignature(bmsg, smsg.From, smsg.Signature)
+	if len(smsg.PublicKey) == 0 {
+		return IsValidSignature(bmsg, smsg.From, smsg.Signature)
+	}
+	if !IsKeyOfAddress(smsg.PublicKey, smsg.From) {
+		log.Infof("invalid signature: public key does not belong to %s", smsg.From)
+		return false
+	}
+	return IsValidSignatureWithKey(bmsg, smsg.From, smsg.PublicKey, smsg.Signature)
+}
+
+// VerifySignatureWithKey returns true iff the signature over the message was
+// made with the private key of pubKey, the key recorded for the sender.
+func (smsg *SignedMessage) VerifySignatureWithKey(pubKey []byte) bool {
+	bmsg, err := smsg.MeteredMessage.Marshal()
+	if err != nil {
+		log.Infof("invalid signature: %s", err)
+		return false
+	}
+	return IsValidSignatureWithKey(bmsg, smsg.From, pubKey, smsg.Signature)
+}
+
+// SenderPublicKey returns the public key the message carries, or, if it carries
+// none, the key recovered from its signature. It does not check that the key
+// belongs to the sender; use VerifySignature for that.
+func (smsg *SignedMessage) SenderPublicKey() ([]byte, error) {
+	if len(smsg.PublicKey) != 0 {
+		return smsg.PublicKey, nil
+	}
+	if len(smsg.Signature) < 1 {
+		return nil, ErrMessageUnsigned
+	}
+
+	bmsg, err := smsg.MeteredMessage.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return wutil.Ecrecover(bmsg, smsg.Signature)
+}
+
+// IsAggregated returns true if the message is from a BLS address and has no
+// signature of its own, because the block including it aggregates it.
+func (smsg *SignedMessage) IsAggregated() bool {
+	return smsg.From.IsBLS() && len(smsg.Signature) == 0
+}
+
+// withoutSignature returns a copy of the message without its signature.
+func (smsg *SignedMessage) withoutSignature() *SignedMessage {
+	unsigned := *smsg
+	unsigned.Signature = nil
+	return &unsigned
 }
 
 func (smsg *SignedMessage) String() string {
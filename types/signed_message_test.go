@@ -35,6 +35,55 @@ func TestSignedMessageRecover(t *testing.T) {
 	assert.Equal(mockSigner.Addresses[0], addr)
 }
 
+func TestSignedMessageVerifySignature(t *testing.T) {
+	pubKey, err := ki[0].PublicKey()
+	require.NoError(t, err)
+	otherKi := MustGenerateKeyInfo(1, GenerateKeyInfoSeed())[0]
+	otherPubKey, err := otherKi.PublicKey()
+	require.NoError(t, err)
+
+	t.Run("with a recovered key", func(t *testing.T) {
+		assert := assert.New(t)
+
+		smsg := newSignedMessage()
+		assert.True(smsg.VerifySignature())
+
+		smsg.Nonce++
+		assert.False(smsg.VerifySignature())
+	})
+
+	t.Run("with the key of the sender", func(t *testing.T) {
+		assert := assert.New(t)
+
+		smsg := newSignedMessage()
+		smsg.PublicKey = pubKey
+		assert.True(smsg.VerifySignature())
+
+		senderKey, err := smsg.SenderPublicKey()
+		assert.NoError(err)
+		assert.Equal(pubKey, senderKey)
+	})
+
+	t.Run("with the key of someone else", func(t *testing.T) {
+		assert := assert.New(t)
+
+		smsg := newSignedMessage()
+		smsg.PublicKey = otherPubKey
+		assert.False(smsg.VerifySignature())
+	})
+
+	t.Run("with a recorded key", func(t *testing.T) {
+		assert := assert.New(t)
+
+		smsg := newSignedMessage()
+		assert.True(smsg.VerifySignatureWithKey(pubKey))
+		assert.False(smsg.VerifySignatureWithKey(otherPubKey))
+
+		smsg.Signature = nil
+		assert.False(smsg.VerifySignatureWithKey(pubKey))
+	})
+}
+
 func TestSignedMessageMarshal(t *testing.T) {
 	assert := assert.New(t)
 
@@ -69,4 +118,12 @@ func TestSignedMessageCid(t *testing.T) {
 	assert.NoError(err)
 
 	assert.NotEqual(c1.String(), c2.String())
+
+	// the unsigned public key does not change the cid
+	pubKey, err := ki[0].PublicKey()
+	assert.NoError(err)
+	smsg1.PublicKey = pubKey
+	c1WithKey, err := smsg1.Cid()
+	assert.NoError(err)
+	assert.Equal(c1, c1WithKey)
 }
@@ -150,9 +150,8 @@ func NewMessageForTestGetter() func() *Message {
 // has not been persisted into the store.
 func NewBlockForTest(parent *Block, nonce uint64) *Block {
 	block := &Block{
-		Nonce:           Uint64(nonce),
-		Messages:        []*SignedMessage{},
-		MessageReceipts: []*MessageReceipt{},
+		Nonce:    Uint64(nonce),
+		Messages: []*SignedMessage{},
 	}
 
 	if parent != nil {
@@ -61,8 +61,8 @@ func (ts TipSet) AddBlock(b *Block) error {
 	if !b.Parents.Equals(p) {
 		return errors.Errorf("block parents %s don't match tipset parents %s", b.Parents.String(), p.String())
 	}
-	if uint64(b.ParentWeight) != weight {
-		return errors.Errorf("bBlock parent weight: %d doesn't match existing tipset parent weight: %d", uint64(b.ParentWeight), weight)
+	if !b.ParentWeight.Equal(weight) {
+		return errors.Errorf("bBlock parent weight: %s doesn't match existing tipset parent weight: %s", b.ParentWeight, weight)
 	}
 
 	id := b.Cid()
@@ -146,10 +146,10 @@ func (ts TipSet) Parents() (SortedCidSet, error) {
 	return ts.ToSlice()[0].Parents, nil
 }
 
-// ParentWeight returns the tipset's ParentWeight in fixed point form.
-func (ts TipSet) ParentWeight() (uint64, error) {
+// ParentWeight returns the tipset's ParentWeight.
+func (ts TipSet) ParentWeight() (*ChainWeight, error) {
 	if len(ts) == 0 {
-		return uint64(0), ErrEmptyTipSet
+		return nil, ErrEmptyTipSet
 	}
-	return uint64(ts.ToSlice()[0].ParentWeight), nil
+	return ts.ToSlice()[0].ParentWeight, nil
 }
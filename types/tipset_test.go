@@ -38,16 +38,15 @@ func block(require *require.Assertions, height int, parentCid cid.Cid, parentWei
 	m1 := NewMessage(mockSignerForTest.Addresses[0], addrGetter(), 0, NewAttoFILFromFIL(10), "hello", []byte(msg))
 	sm1, err := NewSignedMessage(*m1, &mockSignerForTest, NewGasPrice(0), NewGasUnits(0))
 	require.NoError(err)
-	ret := []byte{1, 2}
 
 	return &Block{
 		Parents:         NewSortedCidSet(parentCid),
-		ParentWeight:    Uint64(parentWeight),
+		ParentWeight:    NewChainWeight(parentWeight),
 		Height:          Uint64(42 + uint64(height)),
 		Nonce:           7,
 		Messages:        []*SignedMessage{sm1},
 		StateRoot:       SomeCid(),
-		MessageReceipts: []*MessageReceipt{{ExitCode: 1, Return: []Bytes{ret}}},
+		MessageReceipts: SomeCid(),
 	}
 }
 
@@ -90,7 +89,7 @@ func TestTipSet(t *testing.T) {
 func RequireTestBlocks(t *testing.T) (*Block, *Block, *Block) {
 	require := require.New(t)
 
-	pW := uint64(1337000)
+	pW := uint64(1337)
 
 	b1 := block(require, 1, cid1, pW, "1")
 	b1.Ticket = []byte{0}
@@ -139,7 +138,7 @@ func TestTipSetAddBlock(t *testing.T) {
 	b2.Parents = b1.Parents
 
 	// Invalid weight
-	b2.ParentWeight = Uint64(3000)
+	b2.ParentWeight = NewChainWeight(3)
 	ts = TipSet{}
 	requireTipSetAdd(require, b1, ts)
 	err = ts.AddBlock(b2)
@@ -173,7 +172,7 @@ func TestNewTipSet(t *testing.T) {
 	b1.Parents = b2.Parents
 
 	// Invalid parent weights
-	b1.ParentWeight = Uint64(3000)
+	b1.ParentWeight = NewChainWeight(3)
 	ts, err = NewTipSet(b1, b2, b3)
 	assert.Error(err)
 	assert.Nil(ts)
@@ -209,7 +208,7 @@ func TestTipSetParentWeight(t *testing.T) {
 	ts := RequireTestTipSet(t)
 	w, err := ts.ParentWeight()
 	assert.NoError(err)
-	assert.Equal(w, uint64(1337000))
+	assert.True(NewChainWeight(1337).Equal(w))
 }
 
 func TestTipSetToSortedCidSet(t *testing.T) {
@@ -9,6 +9,7 @@ import (
 
 	"github.com/filecoin-project/go-filecoin/abi"
 	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/account"
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/exec"
 	"github.com/filecoin-project/go-filecoin/state"
@@ -28,12 +29,23 @@ type Context struct {
 	blockHeight *types.BlockHeight
 	ancestors   []types.TipSet
 	lookBack    int
+	trace       *ExecutionTrace
+	events      *EventLog
+	origin      *origin
 
 	deps *deps // Inject external dependencies so we can unit test robustly.
 }
 
 var _ exec.VMContext = (*Context)(nil)
 
+// origin is the message whose execution a context and the contexts of all
+// its nested sends are part of.
+type origin struct {
+	message *types.Message
+	// actorsCreated counts the addresses computed for new actors.
+	actorsCreated uint64
+}
+
 // NewContextParams is passed to NewVMContext to construct a new context.
 type NewContextParams struct {
 	From        *actor.Actor
@@ -45,10 +57,21 @@ type NewContextParams struct {
 	BlockHeight *types.BlockHeight
 	Ancestors   []types.TipSet
 	LookBack    int
+	// Trace, if set, records the message pass and every nested send.
+	Trace *ExecutionTrace
+	// Events, if set, collects the events emitted by actors.
+	Events *EventLog
+	// Origin is the message, as it was signed, that Message executes. It
+	// defaults to Message.
+	Origin *types.Message
 }
 
 // NewVMContext returns an initialized context.
 func NewVMContext(params NewContextParams) *Context {
+	originMsg := params.Origin
+	if originMsg == nil {
+		originMsg = params.Message
+	}
 	return &Context{
 		from:        params.From,
 		to:          params.To,
@@ -59,6 +82,9 @@ func NewVMContext(params NewContextParams) *Context {
 		blockHeight: params.BlockHeight,
 		ancestors:   params.Ancestors,
 		lookBack:    params.LookBack,
+		trace:       params.Trace,
+		events:      params.Events,
+		origin:      &origin{message: originMsg},
 		deps:        makeDeps(params.State),
 	}
 }
@@ -66,8 +92,11 @@ func NewVMContext(params NewContextParams) *Context {
 var _ exec.VMContext = (*Context)(nil)
 
 // Storage returns an implementation of the storage module for this context.
+// Operations on it are charged to the context's gas tracker.
 func (ctx *Context) Storage() exec.Storage {
-	return ctx.storageMap.NewStorage(ctx.message.To, ctx.to)
+	storage := ctx.storageMap.NewStorage(ctx.message.To, ctx.to)
+	storage.gasTracker = ctx.gasTracker
+	return storage
 }
 
 // Message retrieves the message associated with this context.
@@ -75,56 +104,98 @@ func (ctx *Context) Message() *types.Message {
 	return ctx.message
 }
 
-// ReadStorage reads the storage from the associated to actor.
-func (ctx *Context) ReadStorage() ([]byte, error) {
-	storage := ctx.Storage()
-
-	memory, err := storage.Get(storage.Head())
-	if err != nil {
-		if err == ErrNotFound {
-			return nil, errors.NewRevertErrorf("actor state not found at cid %s", storage.Head())
-		}
-		return nil, err
-	}
-
-	out := make([]byte, len(memory))
-	copy(out, memory)
-
-	return out, nil
-}
-
 // Charge attempts to add the given cost to the accrued gas cost of this transaction
 func (ctx *Context) Charge(cost types.GasUnits) error {
 	return ctx.gasTracker.Charge(cost)
 }
 
-// GasUnits retrieves the gas cost so far
-func (ctx *Context) GasUnits() types.GasUnits {
-	return ctx.gasTracker.gasConsumedByMessage
+// VerifySignature charges for and verifies that sig is a valid signature of
+// data by signer. Signatures of accounts that have recorded their public key
+// are verified against it, others against the key recovered from sig.
+func (ctx *Context) VerifySignature(data []byte, signer address.Address, sig types.Signature) (bool, error) {
+	if err := ctx.gasTracker.Charge(ctx.gasTracker.Schedule.VerifySignature); err != nil {
+		return false, err
+	}
+
+	pubKey, err := ctx.publicKey(signer)
+	if err != nil {
+		return false, err
+	}
+	if pubKey == nil {
+		return types.IsValidSignature(data, signer, sig), nil
+	}
+	return types.IsValidSignatureWithKey(data, signer, pubKey, sig), nil
 }
 
-// WriteStorage writes to the storage of the associated to actor.
-func (ctx *Context) WriteStorage(memory interface{}) error {
-	stage := ctx.Storage()
+// publicKey returns the public key recorded by the account actor at addr, or
+// nil if there is no such account or it has not recorded a key yet.
+func (ctx *Context) publicKey(addr address.Address) ([]byte, error) {
+	act, err := ctx.state.GetActor(context.Background(), addr)
+	if state.IsActorNotFoundError(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.FaultErrorWrapf(err, "failed to get actor %s", addr)
+	}
+	if !act.Code.Equals(types.AccountActorCodeCid) {
+		return nil, nil
+	}
 
-	cid, err := stage.Put(memory)
+	pubKey, err := account.LoadPublicKey(ctx.storageMap.NewStorage(addr, act))
 	if err != nil {
-		return errors.RevertErrorWrap(err, "Could not stage memory chunk")
+		return nil, errors.FaultErrorWrapf(err, "failed to load public key of %s", addr)
 	}
+	return pubKey, nil
+}
 
-	err = stage.Commit(cid, stage.Head())
+// EmitEvent charges for and records an event with the given topic and values,
+// emitted by the actor the message is sent to.
+func (ctx *Context) EmitEvent(topic string, values ...interface{}) error {
+	vals, err := ctx.deps.ToValues(values)
 	if err != nil {
-		return errors.RevertErrorWrap(err, "Could not commit actor memory")
+		return errors.FaultErrorWrap(err, "failed to convert event values to abi values")
+	}
+	data, err := ctx.deps.EncodeValues(vals)
+	if err != nil {
+		return errors.FaultErrorWrap(err, "failed to encode event values")
+	}
+
+	schedule := ctx.gasTracker.Schedule
+	cost := schedule.EventBase + PerWord(schedule.EventPerWord, len(topic)+len(data))
+	if err := ctx.gasTracker.Charge(cost); err != nil {
+		return errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
+	if ctx.events == nil {
+		return nil
+	}
+	event := &types.Event{
+		Actor: ctx.message.To,
+		Topic: topic,
+		Data:  data,
+	}
+	for _, val := range vals {
+		event.Types = append(event.Types, val.Type.String())
+	}
+	ctx.events.emit(event)
 	return nil
 }
 
+// GasUnits retrieves the gas cost so far
+func (ctx *Context) GasUnits() types.GasUnits {
+	return ctx.gasTracker.gasConsumedByMessage
+}
+
 // BlockHeight returns the block height of the block currently being processed
 func (ctx *Context) BlockHeight() *types.BlockHeight {
 	return ctx.blockHeight
 }
 
+// MyBalance returns the balance of the actor the message was sent to,
+// including the value of the message.
+func (ctx *Context) MyBalance() *types.AttoFIL {
+	return ctx.to.Balance
+}
+
 // IsFromAccountActor returns true if the message is being sent by an account actor.
 func (ctx *Context) IsFromAccountActor() bool {
 	return ctx.from.Code.Defined() && types.AccountActorCodeCid.Equals(ctx.from.Code)
@@ -135,10 +206,19 @@ func (ctx *Context) IsFromAccountActor() bool {
 func (ctx *Context) Send(to address.Address, method string, value *types.AttoFIL, params []interface{}) ([][]byte, uint8, error) {
 	deps := ctx.deps
 
+	if err := ctx.gasTracker.Charge(ctx.gasTracker.Schedule.Send); err != nil {
+		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
 	// the message sender is the `to` actor, so this is what we set as `from` in the new message
 	from := ctx.Message().To
 	fromActor := ctx.to
 
+	to, err := ResolveAddress(context.TODO(), ctx.state, ctx.storageMap, to)
+	if err != nil {
+		return nil, errors.CodeError(err), err
+	}
+
 	vals, err := deps.ToValues(params)
 	if err != nil {
 		return nil, 1, errors.FaultErrorWrap(err, "failed to convert inputs to abi values")
@@ -172,43 +252,70 @@ func (ctx *Context) Send(to address.Address, method string, value *types.AttoFIL
 		BlockHeight: ctx.blockHeight,
 		Ancestors:   ctx.ancestors,
 	}
+	if ctx.trace != nil {
+		innerParams.Trace = ctx.trace.newSubcall()
+	}
+	eventsMark := 0
+	if ctx.events != nil {
+		innerParams.Events = ctx.events
+		eventsMark = ctx.events.mark()
+	}
 	innerCtx := NewVMContext(innerParams)
+	innerCtx.origin = ctx.origin
 
 	out, ret, err := deps.Send(context.Background(), innerCtx)
 	if err != nil {
+		// the events of a failed send are dropped along with its changes
+		if ctx.events != nil {
+			ctx.events.revertTo(eventsMark)
+		}
 		return nil, ret, err
 	}
 
 	return out, ret, nil
 }
 
-// AddressForNewActor creates computes the address for a new actor in the same
-// way that ethereum does.  Note that this will not work if we allow the
-// creation of multiple contracts in a given invocation (nonce will remain the
-// same, resulting in the same address back)
+// AddressForNewActor computes the address for a new actor from the message
+// whose execution created it and the number of addresses computed for that
+// message before. Unlike ID addresses, these addresses do not change when a
+// reorg changes the order in which messages are applied.
 func (ctx *Context) AddressForNewActor() (address.Address, error) {
-	return computeActorAddress(ctx.message.From, uint64(ctx.from.Nonce))
+	originCid, err := ctx.origin.message.Cid()
+	if err != nil {
+		return address.Address{}, err
+	}
+
+	addr, err := computeActorAddress(ctx.origin.message.From.Network(), originCid, ctx.origin.actorsCreated)
+	if err != nil {
+		return address.Address{}, err
+	}
+	ctx.origin.actorsCreated++
+	return addr, nil
 }
 
-func computeActorAddress(creator address.Address, nonce uint64) (address.Address, error) {
+func computeActorAddress(network address.Network, origin cid.Cid, index uint64) (address.Address, error) {
 	buf := new(bytes.Buffer)
 
-	if _, err := buf.Write(creator.Bytes()); err != nil {
+	if _, err := buf.Write(origin.Bytes()); err != nil {
 		return address.Address{}, err
 	}
 
-	if err := binary.Write(buf, binary.BigEndian, nonce); err != nil {
+	if err := binary.Write(buf, binary.BigEndian, index); err != nil {
 		return address.Address{}, err
 	}
 
 	hash := address.Hash(buf.Bytes())
 
-	return address.NewMainnet(hash), nil
+	return address.New(network, hash), nil
 }
 
 // CreateNewActor creates and initializes an actor at the given address.
 // If the address is occupied by a non-empty actor, this method will fail.
 func (ctx *Context) CreateNewActor(addr address.Address, code cid.Cid, initializerData interface{}) error {
+	if err := ctx.gasTracker.Charge(ctx.gasTracker.Schedule.CreateActor); err != nil {
+		return errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
 	// Check existing address. If nothing there, create empty actor.
 	newActor, err := ctx.state.GetOrCreateActor(context.TODO(), addr, func() (*actor.Actor, error) {
 		return &actor.Actor{}, nil
@@ -226,6 +333,7 @@ func (ctx *Context) CreateNewActor(addr address.Address, code cid.Cid, initializ
 	newActor.Code = code
 
 	childStorage := ctx.storageMap.NewStorage(addr, newActor)
+	childStorage.gasTracker = ctx.gasTracker
 	execActor, err := ctx.state.GetBuiltinActorCode(code)
 	if err != nil {
 		return errors.NewRevertErrorf("attempt to create executable actor from non-existent code %s", code.String())
@@ -239,30 +347,33 @@ func (ctx *Context) CreateNewActor(addr address.Address, code cid.Cid, initializ
 		return err
 	}
 
-	return nil
+	return RegisterActorID(context.TODO(), ctx.state, ctx.storageMap, addr)
 }
 
-// Rand samples the chain randomness for the tipset at the given height.  The
-// tipset providing randomness for the tipset at sampleHeight is guaranteed to
+// Rand samples the chain randomness for the tipset at the given height, or,
+// if that height is a null round, for the last tipset before it.  The
+// tipset providing randomness for the sampled tipset is guaranteed to
 // be in ancestors, and Rand will return a fault error if it is not.
+// Ancestors are ordered from the newest tipset to the oldest, as returned by
+// chain.GetRecentAncestors, so the tipset providing randomness is lookBack
+// tipsets after the sampled one.
 func (ctx *Context) Rand(sampleHeight *types.BlockHeight) ([]byte, error) {
 	sampleIndex := -1
-	var firstHeight uint64
 	for i := 0; i < len(ctx.ancestors); i++ {
-
 		height, err := ctx.ancestors[i].Height()
 		if err != nil {
 			return nil, errors.FaultErrorWrap(err, "Error sampling randomness from chain")
 		}
-		if i == 0 {
-			firstHeight = height
+		// Heights above the newest ancestor have not been mined yet.
+		if i == 0 && types.NewBlockHeight(height).LessThan(sampleHeight) {
+			break
 		}
-		if types.NewBlockHeight(height).Equal(sampleHeight) {
+		if types.NewBlockHeight(height).LessEqual(sampleHeight) {
 			sampleIndex = i
 			break
 		}
 	}
-	// Fault if a tipset of this height does not exist in ancestors.
+	// Fault if no tipset at or below this height exists in ancestors.
 	if sampleIndex == -1 {
 		return nil, errors.NewFaultError("rand sample height out of range")
 	}
@@ -272,10 +383,15 @@ func (ctx *Context) Rand(sampleHeight *types.BlockHeight) ([]byte, error) {
 	// randomness from the genesis block.
 	// TODO: security, spec, bootstrap implications.
 	// See issue https://github.com/filecoin-project/go-filecoin/issues/1872
-	lookBackIndex := sampleIndex - ctx.lookBack
-	if lookBackIndex < 0 {
-		if firstHeight == uint64(0) {
-			lookBackIndex = 0
+	lookBackIndex := sampleIndex + ctx.lookBack
+	if lookBackIndex >= len(ctx.ancestors) {
+		lastIndex := len(ctx.ancestors) - 1
+		lastHeight, err := ctx.ancestors[lastIndex].Height()
+		if err != nil {
+			return nil, errors.FaultErrorWrap(err, "Error sampling randomness from chain")
+		}
+		if lastHeight == uint64(0) {
+			lookBackIndex = lastIndex
 		} else {
 			return nil, errors.NewFaultError("rand lookBack height out of range")
 		}
@@ -13,11 +13,13 @@ import (
 	"gx/ipfs/Qmf4xQhNomPNhrtZc67qSnfJSjxjXs9LWvknJtSXwimPrM/go-datastore"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/filecoin-project/go-filecoin/abi"
 	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/account"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/initactor"
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/exec"
 	"github.com/filecoin-project/go-filecoin/state"
@@ -44,6 +46,9 @@ func TestVMContextStorage(t *testing.T) {
 	assert.NoError(st.SetActor(ctx, toAddr, toActor))
 	msg := types.NewMessage(addrGetter(), toAddr, 0, nil, "hello", nil)
 
+	gasTracker := NewGasTracker()
+	gasTracker.MsgGasLimit = types.NewGasUnits(1000)
+
 	to, err := cstate.GetActor(ctx, toAddr)
 	assert.NoError(err)
 	vmCtxParams := NewContextParams{
@@ -52,7 +57,7 @@ func TestVMContextStorage(t *testing.T) {
 		Message:     msg,
 		State:       cstate,
 		StorageMap:  vms,
-		GasTracker:  NewGasTracker(),
+		GasTracker:  gasTracker,
 		BlockHeight: types.NewBlockHeight(0),
 	}
 	vmCtx := NewVMContext(vmCtxParams)
@@ -60,16 +65,24 @@ func TestVMContextStorage(t *testing.T) {
 	node, err := cbor.WrapObject([]byte("hello"), types.DefaultHashFunction, -1)
 	assert.NoError(err)
 
-	assert.NoError(vmCtx.WriteStorage(node.RawData()))
+	storage := vmCtx.Storage()
+	id, err := storage.Put(node.RawData())
+	assert.NoError(err)
+	assert.NoError(storage.Commit(id, storage.Head()))
 	assert.NoError(cstate.Commit(ctx))
 
 	// make sure we can read it back
 	toActorBack, err := st.GetActor(ctx, toAddr)
 	assert.NoError(err)
 	vmCtxParams.To = toActorBack
-	storage, err := NewVMContext(vmCtxParams).ReadStorage()
+	storage = NewVMContext(vmCtxParams).Storage()
+	chunk, err := storage.Get(storage.Head())
 	assert.NoError(err)
-	assert.Equal(storage, node.RawData())
+	assert.Equal(chunk, node.RawData())
+
+	var decoded []byte
+	assert.NoError(storage.GetObject(storage.Head(), &decoded))
+	assert.Equal([]byte("hello"), decoded)
 }
 
 func TestVMContextSendFailures(t *testing.T) {
@@ -87,13 +100,16 @@ func TestVMContextSendFailures(t *testing.T) {
 	bs := blockstore.NewBlockstore(datastore.NewMapDatastore())
 	vms := NewStorageMap(bs)
 
+	gasTracker := NewGasTracker()
+	gasTracker.MsgGasLimit = types.NewGasUnits(1000)
+
 	vmCtxParams := NewContextParams{
 		From:        actor1,
 		To:          actor2,
 		Message:     newMsg(),
 		State:       tree,
 		StorageMap:  vms,
-		GasTracker:  NewGasTracker(),
+		GasTracker:  gasTracker,
 		BlockHeight: types.NewBlockHeight(0),
 	}
 
@@ -249,6 +265,11 @@ func TestVMContextSendFailures(t *testing.T) {
 		require := require.New(t)
 
 		ctx := context.Background()
+
+		initAct := initactor.NewActor()
+		require.NoError((&initactor.Actor{}).InitializeState(vms.NewStorage(address.InitAddress, initAct), initactor.NewState(address.Mainnet)))
+		mockStateTree.On("GetActor", mock.Anything, address.InitAddress).Return(initAct, nil)
+
 		vmctx := NewVMContext(vmCtxParams)
 		addr, err := vmctx.AddressForNewActor()
 
@@ -267,6 +288,49 @@ func TestVMContextSendFailures(t *testing.T) {
 		require.NoError(err)
 
 		assert.True(len(chunk) > 0)
+
+		// the new actor is assigned an id
+		idAddr, ok, err := initactor.LookupID(ctx, vms.NewStorage(address.InitAddress, initAct), addr)
+		require.NoError(err)
+		require.True(ok)
+
+		resolved, err := ResolveAddress(ctx, tree, vms, idAddr)
+		require.NoError(err)
+		assert.Equal(addr, resolved)
+	})
+
+	t.Run("derives new actor addresses from the origin message", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		params := vmCtxParams
+		params.Origin = newMsg()
+
+		first, err := NewVMContext(params).AddressForNewActor()
+		require.NoError(err)
+		again, err := NewVMContext(params).AddressForNewActor()
+		require.NoError(err)
+		assert.Equal(first, again)
+
+		// each actor the message creates gets its own address
+		vmctx := NewVMContext(params)
+		_, err = vmctx.AddressForNewActor()
+		require.NoError(err)
+		second, err := vmctx.AddressForNewActor()
+		require.NoError(err)
+		assert.NotEqual(first, second)
+
+		// the address does not depend on the nonce of the sender's actor
+		params.From = actor.NewActor(cid.Undef, types.NewAttoFILFromFIL(100))
+		params.From.Nonce = 5
+		other, err := NewVMContext(params).AddressForNewActor()
+		require.NoError(err)
+		assert.Equal(first, other)
+
+		params.Origin = newMsg()
+		other, err = NewVMContext(params).AddressForNewActor()
+		require.NoError(err)
+		assert.NotEqual(first, other)
 	})
 
 }
@@ -295,20 +359,68 @@ func TestVMContextIsAccountActor(t *testing.T) {
 	assert.False(ctx.IsFromAccountActor())
 }
 
+func TestVMContextVerifySignature(t *testing.T) {
+	kis := types.MustGenerateKeyInfo(2, types.GenerateKeyInfoSeed())
+	mockSigner := types.NewMockSigner(kis[:1])
+	signer := mockSigner.Addresses[0]
+
+	data := []byte("signed data")
+	sig, err := mockSigner.SignBytes(data, signer)
+	require.NoError(t, err)
+
+	verify := func(t *testing.T, pubKey []byte) bool {
+		ctx := context.Background()
+		cstate := state.NewCachedStateTree(state.NewEmptyStateTree(hamt.NewCborStore()))
+		vms := NewStorageMap(blockstore.NewBlockstore(datastore.NewMapDatastore()))
+
+		signerActor, err := account.NewActor(types.NewAttoFILFromFIL(1000))
+		require.NoError(t, err)
+		if pubKey != nil {
+			require.NoError(t, account.RecordPublicKey(vms.NewStorage(signer, signerActor), pubKey))
+		}
+		require.NoError(t, cstate.SetActor(ctx, signer, signerActor))
+
+		gasTracker := NewGasTracker()
+		gasTracker.MsgGasLimit = types.NewGasUnits(1000)
+		vmCtx := NewVMContext(NewContextParams{
+			State:      cstate,
+			StorageMap: vms,
+			GasTracker: gasTracker,
+		})
+		valid, err := vmCtx.VerifySignature(data, signer, sig)
+		require.NoError(t, err)
+		return valid
+	}
+
+	t.Run("recovers the key of accounts without a recorded key", func(t *testing.T) {
+		assert.True(t, verify(t, nil))
+	})
+
+	t.Run("verifies against the recorded key", func(t *testing.T) {
+		pubKey, err := kis[0].PublicKey()
+		require.NoError(t, err)
+		assert.True(t, verify(t, pubKey))
+
+		otherPubKey, err := kis[1].PublicKey()
+		require.NoError(t, err)
+		assert.False(t, verify(t, otherPubKey))
+	})
+}
+
 func TestVMContextRand(t *testing.T) {
 	require := require.New(t)
 	assert := assert.New(t)
 	var ancestors []types.TipSet
-	// setup ancestor chain
+	// setup ancestor chain, newest tipset first
 	head := types.NewBlockForTest(nil, uint64(0))
 	head.Ticket = []byte(strconv.Itoa(0))
 	for i := 0; i < 20; i++ {
-		ancestors = append(ancestors, types.RequireNewTipSet(require, head))
+		ancestors = append([]types.TipSet{types.RequireNewTipSet(require, head)}, ancestors...)
 		newBlock := types.NewBlockForTest(head, uint64(0))
 		newBlock.Ticket = []byte(strconv.Itoa(i + 1))
 		head = newBlock
 	}
-	ancestors = append(ancestors, types.RequireNewTipSet(require, head))
+	ancestors = append([]types.TipSet{types.RequireNewTipSet(require, head)}, ancestors...)
 
 	t.Run("happy path", func(t *testing.T) {
 		vmCtxParams := NewContextParams{
@@ -330,28 +442,37 @@ func TestVMContextRand(t *testing.T) {
 		assert.Equal([]byte(strconv.Itoa(7)), r)
 	})
 
-	t.Run("faults with height out of range", func(t *testing.T) {
+	t.Run("samples the last tipset before a null round", func(t *testing.T) {
 		// edit ancestors to include null blocks
-		baseBlock := ancestors[len(ancestors)-2].ToSlice()[0]
+		baseBlock := ancestors[1].ToSlice()[0]
 		afterNull := types.NewBlockForTest(baseBlock, uint64(0))
 		afterNull.Height += types.Uint64(uint64(5))
 		afterNull.Ticket = []byte(strconv.Itoa(int(afterNull.Height)))
-		modAncestors := append(ancestors[:len(ancestors)-1], types.RequireNewTipSet(require, afterNull))
+		modAncestors := append([]types.TipSet{types.RequireNewTipSet(require, afterNull)}, ancestors[1:]...)
 		vmCtxParams := NewContextParams{
 			Ancestors: modAncestors,
 			LookBack:  3,
 		}
 
 		ctx := NewVMContext(vmCtxParams)
-		_, err := ctx.Rand(types.NewBlockHeight(uint64(22))) // null block here
-		assert.Error(err)
+		r, err := ctx.Rand(types.NewBlockHeight(uint64(22))) // null block here, samples 19
+		assert.NoError(err)
+		assert.Equal([]byte(strconv.Itoa(16)), r)
+	})
 
-		_, err = ctx.Rand(types.NewBlockHeight(uint64(30))) // ancestors all lower height
+	t.Run("faults with height out of range", func(t *testing.T) {
+		vmCtxParams := NewContextParams{
+			Ancestors: ancestors,
+			LookBack:  3,
+		}
+
+		ctx := NewVMContext(vmCtxParams)
+		_, err := ctx.Rand(types.NewBlockHeight(uint64(30))) // ancestors all lower height
 		assert.Error(err)
 	})
 
 	t.Run("faults with lookback out of range", func(t *testing.T) {
-		modAncestors := ancestors[5:]
+		modAncestors := ancestors[:len(ancestors)-5]
 		vmCtxParams := NewContextParams{
 			Ancestors: modAncestors,
 			LookBack:  3,
@@ -373,3 +494,60 @@ func TestVMContextRand(t *testing.T) {
 		assert.Equal([]byte(strconv.Itoa(0)), r)
 	})
 }
+
+func TestVMContextEmitEvent(t *testing.T) {
+	addrGetter := address.NewForTestGetter()
+	to := addrGetter()
+	msg := types.NewMessage(addrGetter(), to, 0, nil, "foo", nil)
+
+	t.Run("records the event in the log and charges gas", func(t *testing.T) {
+		assert := assert.New(t)
+		require := require.New(t)
+
+		gasTracker := NewGasTracker()
+		gasTracker.MsgGasLimit = types.NewGasUnits(1000)
+		events := NewEventLog()
+
+		ctx := NewVMContext(NewContextParams{Message: msg, GasTracker: gasTracker, Events: events})
+		require.NoError(ctx.EmitEvent("ping", uint64(3), "hello"))
+
+		require.Len(events.Events(), 1)
+		event := events.Events()[0]
+		assert.Equal(to, event.Actor)
+		assert.Equal("ping", event.Topic)
+		assert.Equal([]string{abi.SectorID.String(), abi.String.String()}, event.Types)
+
+		vals, err := abi.DecodeValues(event.Data, []abi.Type{abi.SectorID, abi.String})
+		require.NoError(err)
+		assert.Equal(uint64(3), vals[0].Val)
+		assert.Equal("hello", vals[1].Val)
+
+		expected := GasScheduleAt(nil).EventBase + PerWord(GasScheduleAt(nil).EventPerWord, len("ping")+len(event.Data))
+		assert.Equal(expected, ctx.GasUnits())
+	})
+
+	t.Run("only charges gas without a log", func(t *testing.T) {
+		assert := assert.New(t)
+
+		gasTracker := NewGasTracker()
+		gasTracker.MsgGasLimit = types.NewGasUnits(1000)
+
+		ctx := NewVMContext(NewContextParams{Message: msg, GasTracker: gasTracker})
+		assert.NoError(ctx.EmitEvent("ping"))
+		assert.Equal(GasScheduleAt(nil).EventBase, ctx.GasUnits())
+	})
+
+	t.Run("reverts when out of gas", func(t *testing.T) {
+		assert := assert.New(t)
+
+		gasTracker := NewGasTracker()
+		gasTracker.MsgGasLimit = types.NewGasUnits(1)
+		events := NewEventLog()
+
+		ctx := NewVMContext(NewContextParams{Message: msg, GasTracker: gasTracker, Events: events})
+		err := ctx.EmitEvent("ping")
+		assert.Error(err)
+		assert.True(errors.ShouldRevert(err))
+		assert.Empty(events.Events())
+	})
+}
@@ -35,6 +35,11 @@ func (re RevertError) ShouldRevert() bool {
 	return true
 }
 
+// Unwrap returns the wrapped error, if any.
+func (re RevertError) Unwrap() error {
+	return re.err
+}
+
 // NewRevertError creates a new RevertError using the passed in message.
 func NewRevertError(msg string) error {
 	return &RevertError{err: nil, msg: msg, code: 1}
@@ -111,6 +116,11 @@ func (fe FaultError) IsFault() bool {
 	return true
 }
 
+// Unwrap returns the wrapped error, if any.
+func (fe FaultError) Unwrap() error {
+	return fe.err
+}
+
 // NewFaultError creates a new FaultError using the passed in message.
 func NewFaultError(msg string) error {
 	return &FaultError{err: nil, msg: msg}
@@ -145,6 +155,21 @@ func IsFault(err error) bool {
 	return ok && fe.IsFault()
 }
 
+// Unwrap returns the error wrapped by err, looking through the wrappers of
+// this package as well as those implementing Cause(). It returns nil if err
+// does not wrap another error.
+func Unwrap(err error) error {
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		return e.Unwrap()
+	case interface{ Cause() error }:
+		if cause := e.Cause(); cause != err {
+			return cause
+		}
+	}
+	return nil
+}
+
 // IsApplyErrorPermanent returns true if the error returned by ApplyMessage is
 // a permanent failure, the message likely will never result in a valid state
 // transition (eg, trying to send negative value).
@@ -28,6 +28,17 @@ func TestFaultError(t *testing.T) {
 	assert.Equal(fe, errors.Cause(wrapped2))
 }
 
+func TestUnwrap(t *testing.T) {
+	assert := assert.New(t)
+
+	err := errors.New("source")
+	assert.Nil(Unwrap(err))
+	assert.Equal(err, Unwrap(FaultErrorWrap(err, "msg")))
+	assert.Equal(err, Unwrap(RevertErrorWrap(err, "msg")))
+	assert.Equal(err, Unwrap(errors.Wrap(err, "wrapped")))
+	assert.Nil(Unwrap(NewFaultError("boom")))
+}
+
 func TestRevertError(t *testing.T) {
 	assert := assert.New(t)
 
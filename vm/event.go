@@ -0,0 +1,34 @@
+package vm
+
+import (
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// EventLog collects the events emitted by actors while a message is applied.
+// Events emitted by a send that fails are dropped, as the changes it made are.
+type EventLog struct {
+	events []*types.Event
+}
+
+// NewEventLog returns an empty EventLog.
+func NewEventLog() *EventLog {
+	return &EventLog{}
+}
+
+// Events returns the events emitted so far, in order.
+func (l *EventLog) Events() []*types.Event {
+	return l.events
+}
+
+func (l *EventLog) emit(e *types.Event) {
+	l.events = append(l.events, e)
+}
+
+// mark returns a position in the log that revertTo can return to.
+func (l *EventLog) mark() int {
+	return len(l.events)
+}
+
+func (l *EventLog) revertTo(mark int) {
+	l.events = l.events[:mark]
+}
@@ -0,0 +1,99 @@
+package vm
+
+import (
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// gasWordBytes is the number of bytes in a word, the unit in which data sizes
+// are priced.
+const gasWordBytes = 32
+
+// GasSchedule is the price, in gas, of each operation the VM performs on an
+// actor's behalf. It is charged automatically by the VM in addition to
+// whatever the actor itself charges, so that the gas used by a message
+// reflects the work done to execute it. Costs of operations on data are a
+// base cost plus a cost for every word (32 bytes) of data.
+type GasSchedule struct {
+	// Version identifies the schedule. A new schedule must be given a new
+	// version and an activation height in GasSchedules.
+	Version uint64
+
+	// Send is charged for every message an actor sends to another actor.
+	Send types.GasUnits
+	// ParamsPerWord is charged for every word of encoded params of a
+	// message, top level or sent by an actor.
+	ParamsPerWord types.GasUnits
+
+	// StorageGetBase and StorageGetPerWord are charged for reading a chunk
+	// from actor storage.
+	StorageGetBase    types.GasUnits
+	StorageGetPerWord types.GasUnits
+	// StoragePutBase and StoragePutPerWord are charged for writing a chunk
+	// to actor storage.
+	StoragePutBase    types.GasUnits
+	StoragePutPerWord types.GasUnits
+	// StorageCommit is charged for updating the head of an actor's storage.
+	StorageCommit types.GasUnits
+
+	// CreateActor is charged for creating and initializing a new actor.
+	CreateActor types.GasUnits
+	// VerifySignature is charged for every signature an actor verifies.
+	VerifySignature types.GasUnits
+	// EventBase and EventPerWord are charged for every event an actor
+	// emits.
+	EventBase    types.GasUnits
+	EventPerWord types.GasUnits
+}
+
+// GasScheduleV1 is the first gas schedule. It is in effect from genesis, so
+// events are never free.
+var GasScheduleV1 = &GasSchedule{
+	Version:           1,
+	Send:              types.NewGasUnits(10),
+	ParamsPerWord:     types.NewGasUnits(1),
+	StorageGetBase:    types.NewGasUnits(2),
+	StorageGetPerWord: types.NewGasUnits(1),
+	StoragePutBase:    types.NewGasUnits(5),
+	StoragePutPerWord: types.NewGasUnits(1),
+	StorageCommit:     types.NewGasUnits(5),
+	CreateActor:       types.NewGasUnits(30),
+	VerifySignature:   types.NewGasUnits(20),
+	EventBase:         types.NewGasUnits(5),
+	EventPerWord:      types.NewGasUnits(1),
+}
+
+// GasScheduleActivation pairs a gas schedule with the block height from which
+// it is in effect.
+type GasScheduleActivation struct {
+	Height   *types.BlockHeight
+	Schedule *GasSchedule
+}
+
+// GasSchedules lists every gas schedule in order of activation. The first
+// entry must activate at height 0.
+var GasSchedules = []GasScheduleActivation{
+	{Height: types.NewBlockHeight(0), Schedule: GasScheduleV1},
+}
+
+// GasScheduleAt returns the gas schedule in effect for messages in a block at
+// the given height. A nil height selects the latest schedule.
+func GasScheduleAt(bh *types.BlockHeight) *GasSchedule {
+	if bh == nil {
+		return GasSchedules[len(GasSchedules)-1].Schedule
+	}
+
+	schedule := GasSchedules[0].Schedule
+	for _, activation := range GasSchedules {
+		if bh.LessThan(activation.Height) {
+			break
+		}
+		schedule = activation.Schedule
+	}
+	return schedule
+}
+
+// PerWord returns the cost of size bytes of data at perWord gas per word.
+func PerWord(perWord types.GasUnits, size int) types.GasUnits {
+	words := (uint64(size) + gasWordBytes - 1) / gasWordBytes
+	return types.GasUnits(words) * perWord
+}
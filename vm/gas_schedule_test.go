@@ -0,0 +1,53 @@
+package vm
+
+import (
+	"testing"
+
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGasScheduleAt(t *testing.T) {
+	assert := assert.New(t)
+
+	v1 := &GasSchedule{Version: 1}
+	v2 := &GasSchedule{Version: 2}
+	defer func(schedules []GasScheduleActivation) {
+		GasSchedules = schedules
+	}(GasSchedules)
+	GasSchedules = []GasScheduleActivation{
+		{Height: types.NewBlockHeight(0), Schedule: v1},
+		{Height: types.NewBlockHeight(10), Schedule: v2},
+	}
+
+	assert.Equal(v1, GasScheduleAt(types.NewBlockHeight(0)))
+	assert.Equal(v1, GasScheduleAt(types.NewBlockHeight(9)))
+	assert.Equal(v2, GasScheduleAt(types.NewBlockHeight(10)))
+	assert.Equal(v2, GasScheduleAt(types.NewBlockHeight(100)))
+	assert.Equal(v2, GasScheduleAt(nil))
+}
+
+func TestGasSchedules(t *testing.T) {
+	assert := assert.New(t)
+
+	// events are priced from genesis
+	assert.Equal(GasScheduleV1, GasScheduleAt(types.NewBlockHeight(0)))
+	assert.True(GasScheduleAt(types.NewBlockHeight(0)).EventBase > 0)
+
+	// schedules activate in order of their version
+	for i, activation := range GasSchedules {
+		assert.Equal(uint64(i+1), activation.Schedule.Version)
+		if i > 0 {
+			assert.True(activation.Height.GreaterThan(GasSchedules[i-1].Height))
+		}
+	}
+}
+
+func TestPerWord(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(types.NewGasUnits(0), PerWord(types.NewGasUnits(3), 0))
+	assert.Equal(types.NewGasUnits(3), PerWord(types.NewGasUnits(3), 1))
+	assert.Equal(types.NewGasUnits(3), PerWord(types.NewGasUnits(3), 32))
+	assert.Equal(types.NewGasUnits(6), PerWord(types.NewGasUnits(3), 33))
+}
@@ -7,15 +7,21 @@ import (
 
 // GasTracker maintains the state of gas usage throughout the execution of a block and a message
 type GasTracker struct {
-	MsgGasLimit          types.GasUnits
+	MsgGasLimit types.GasUnits
+	// Schedule prices the operations the VM charges for automatically.
+	Schedule             *GasSchedule
 	gasConsumedByBlock   types.GasUnits
 	gasConsumedByMessage types.GasUnits
 }
 
-// NewGasTracker initializes a new empty gas tracker
+// ErrOutOfGas is returned when a charge exceeds the gas limit of the message.
+var ErrOutOfGas = errors.NewRevertError("gas cost exceeds gas limit")
+
+// NewGasTracker initializes a new empty gas tracker using the latest gas schedule
 func NewGasTracker() *GasTracker {
 	return &GasTracker{
 		MsgGasLimit:          types.NewGasUnits(0),
+		Schedule:             GasScheduleAt(nil),
 		gasConsumedByBlock:   types.NewGasUnits(0),
 		gasConsumedByMessage: types.NewGasUnits(0),
 	}
@@ -32,7 +38,7 @@ func (gasTracker *GasTracker) Charge(cost types.GasUnits) error {
 	if gasTracker.gasConsumedByMessage+cost > gasTracker.MsgGasLimit {
 		gasTracker.gasConsumedByMessage = gasTracker.MsgGasLimit
 		gasTracker.gasConsumedByBlock += gasTracker.MsgGasLimit
-		return errors.NewRevertError("gas cost exceeds gas limit")
+		return ErrOutOfGas
 	}
 
 	gasTracker.gasConsumedByMessage += cost
@@ -40,6 +46,19 @@ func (gasTracker *GasTracker) Charge(cost types.GasUnits) error {
 	return nil
 }
 
+// IsOutOfGas returns true if err is ErrOutOfGas, or an error wrapping it.
+// Running out of gas part way through a storage operation may surface from an
+// actor as a fault wrapping ErrOutOfGas.
+func IsOutOfGas(err error) bool {
+	for err != nil {
+		if err == ErrOutOfGas {
+			return true
+		}
+		err = errors.Unwrap(err)
+	}
+	return false
+}
+
 // GasAboveBlockLimit will return true if the MsgGasLimit of the current message is greater than the block gas limit.
 func (gasTracker *GasTracker) GasAboveBlockLimit() bool {
 	return gasTracker.MsgGasLimit > types.BlockGasLimit
@@ -0,0 +1,33 @@
+package vm
+
+import (
+	"testing"
+
+	xerrors "gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
+
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGasTrackerCharge(t *testing.T) {
+	assert := assert.New(t)
+
+	gasTracker := NewGasTracker()
+	gasTracker.MsgGasLimit = types.NewGasUnits(10)
+
+	assert.NoError(gasTracker.Charge(types.NewGasUnits(10)))
+	assert.Equal(ErrOutOfGas, gasTracker.Charge(types.NewGasUnits(1)))
+}
+
+func TestIsOutOfGas(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(IsOutOfGas(ErrOutOfGas))
+	assert.True(IsOutOfGas(errors.FaultErrorWrap(ErrOutOfGas, "could not put chunk")))
+	assert.True(IsOutOfGas(xerrors.Wrap(errors.RevertErrorWrap(ErrOutOfGas, "Insufficient gas"), "wrapped")))
+
+	assert.False(IsOutOfGas(nil))
+	assert.False(IsOutOfGas(errors.NewFaultError("disk on fire")))
+	assert.False(IsOutOfGas(errors.NewRevertError("gas cost exceeds gas limit")))
+}
@@ -0,0 +1,67 @@
+package vm
+
+import (
+	"context"
+
+	"github.com/filecoin-project/go-filecoin/actor/builtin/initactor"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/vm/errors"
+)
+
+// RegisterActorID assigns the next ID address to the actor at addr, unless it
+// already has one, by updating the state of the init actor. States without an
+// init actor, such as those of chains created before it existed, assign no
+// ids.
+func RegisterActorID(ctx context.Context, st *state.CachedTree, storageMap StorageMap, addr address.Address) error {
+	initActor, err := st.GetActor(ctx, address.InitAddress)
+	if state.IsActorNotFoundError(err) {
+		return nil
+	} else if err != nil {
+		return errors.FaultErrorWrap(err, "failed to get init actor")
+	}
+
+	storage := storageMap.NewStorage(address.InitAddress, initActor)
+	initState, err := initactor.LoadState(storage)
+	if err != nil {
+		return errors.FaultErrorWrap(err, "failed to load init actor state")
+	}
+
+	if _, err := initState.Register(ctx, storage, addr); err != nil {
+		return errors.FaultErrorWrapf(err, "failed to register %s", addr)
+	}
+
+	c, err := storage.Put(initState)
+	if err != nil {
+		return errors.FaultErrorWrap(err, "failed to store init actor state")
+	}
+	if err := storage.Commit(c, storage.Head()); err != nil {
+		return errors.FaultErrorWrap(err, "failed to commit init actor state")
+	}
+	return nil
+}
+
+// ResolveAddress returns the robust address of the actor an ID address refers
+// to. Other addresses are returned as they are. It returns a revert error if
+// the id has not been assigned.
+func ResolveAddress(ctx context.Context, st *state.CachedTree, storageMap StorageMap, addr address.Address) (address.Address, error) {
+	if !addr.IsID() {
+		return addr, nil
+	}
+
+	initActor, err := st.GetActor(ctx, address.InitAddress)
+	if state.IsActorNotFoundError(err) {
+		return address.Address{}, initactor.Errors[initactor.ErrUnknownID]
+	} else if err != nil {
+		return address.Address{}, errors.FaultErrorWrap(err, "failed to get init actor")
+	}
+
+	robust, ok, err := initactor.LookupAddress(ctx, storageMap.NewStorage(address.InitAddress, initActor), addr)
+	if err != nil {
+		return address.Address{}, errors.FaultErrorWrap(err, "failed to look up address in init actor state")
+	}
+	if !ok {
+		return address.Address{}, initactor.Errors[initactor.ErrUnknownID]
+	}
+	return robust, nil
+}
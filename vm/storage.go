@@ -26,6 +26,7 @@ var ErrNotFound = errors.New("chunk not found")
 // 2. Stage this storage to permit rollback on message failure.
 // 3. Isolate staged changes across actors to reduce concurrency/message ordering issues.
 // 4. Associate storage with actors by managing actor.Head.
+// 5. Keep actors from referencing chunks outside their own storage.
 
 // storageMap implements StorageMap as a map of Storage structs keyed by actor address.
 type storageMap struct {
@@ -61,8 +62,10 @@ func (s *storageMap) NewStorage(addr address.Address, actor *actor.Actor) Storag
 		storage = Storage{
 			actor:      actor,
 			chunks:     storage.chunks,
+			reachable:  storage.reachable,
 			blockstore: s.blockstore,
 		}
+		storage.reachable.Add(actor.Head)
 	} else {
 		storage = NewStorage(s.blockstore, actor)
 	}
@@ -86,23 +89,35 @@ func (s *storageMap) Flush() error {
 
 // Storage is a place to hold chunks that are created while processing a block.
 type Storage struct {
-	actor      *actor.Actor
-	chunks     map[cid.Cid]ipld.Node
+	actor  *actor.Actor
+	chunks map[cid.Cid]ipld.Node
+	// reachable are the ids of the chunks in the backing store that are known
+	// to belong to the actor, because they are, or are linked from, a head of
+	// the actor. Actors may only link to these and to staged chunks.
+	reachable  *cid.Set
 	blockstore blockstore.Blockstore
+	// gasTracker, when set, is charged for every Put, Get and Commit
+	// according to its gas schedule.
+	gasTracker *GasTracker
 }
 
 var _ exec.Storage = (*Storage)(nil)
 
 // NewStorage creates a datastore backed storage object for the given actor
 func NewStorage(bs blockstore.Blockstore, act *actor.Actor) Storage {
+	reachable := cid.NewSet()
+	reachable.Add(act.Head)
 	return Storage{
 		chunks:     map[cid.Cid]ipld.Node{},
+		reachable:  reachable,
 		actor:      act,
 		blockstore: bs,
 	}
 }
 
-// Put adds a node to temporary storage by id.
+// Put adds a node to temporary storage by id. Blocks and bytes are decoded as
+// cbor, anything else is encoded as cbor. It fails with exec.ErrDecode if the
+// node cannot be decoded or encoded.
 func (s Storage) Put(v interface{}) (cid.Cid, error) {
 	var nd format.Node
 	var err error
@@ -118,6 +133,13 @@ func (s Storage) Put(v interface{}) (cid.Cid, error) {
 		return cid.Undef, exec.Errors[exec.ErrDecode]
 	}
 
+	if s.gasTracker != nil {
+		schedule := s.gasTracker.Schedule
+		if err := s.gasTracker.Charge(schedule.StoragePutBase + PerWord(schedule.StoragePutPerWord, len(nd.RawData()))); err != nil {
+			return cid.Undef, err
+		}
+	}
+
 	c := nd.Cid()
 	s.chunks[c] = nd
 
@@ -127,26 +149,56 @@ func (s Storage) Put(v interface{}) (cid.Cid, error) {
 // Get retrieves a chunk from either temporary storage or its backing store.
 // If the chunk is not found in storage, a vm.ErrNotFound error is returned.
 func (s Storage) Get(cid cid.Cid) ([]byte, error) {
-	n, ok := s.chunks[cid]
-	if ok {
-		return n.RawData(), nil
+	var data []byte
+	if n, ok := s.chunks[cid]; ok {
+		data = n.RawData()
+	} else {
+		blk, err := s.blockstore.Get(cid)
+		if err != nil {
+			if err == blockstore.ErrNotFound {
+				return []byte{}, ErrNotFound
+			}
+			return []byte{}, err
+		}
+		data = blk.RawData()
+		s.markLinksReachable(blk)
 	}
 
-	blk, err := s.blockstore.Get(cid)
-	if err != nil {
-		if err == blockstore.ErrNotFound {
-			return []byte{}, ErrNotFound
+	if s.gasTracker != nil {
+		schedule := s.gasTracker.Schedule
+		if err := s.gasTracker.Charge(schedule.StorageGetBase + PerWord(schedule.StorageGetPerWord, len(data))); err != nil {
+			return []byte{}, err
 		}
-		return []byte{}, err
 	}
 
-	return blk.RawData(), nil
+	return data, nil
+}
+
+// GetObject retrieves the chunk with the given id like Get, and decodes it
+// into out.
+func (s Storage) GetObject(id cid.Cid, out interface{}) error {
+	chunk, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	if err := cbor.DecodeInto(chunk, out); err != nil {
+		return exec.Errors[exec.ErrDecode]
+	}
+	return nil
 }
 
 // Commit updates the head of the current actor to the given cid.
 // The new cid must be the content id of a chunk put in storage.
 // The given oldCid must match the cid of the current actor.
+// Every chunk reachable from the new cid must either be put in storage, or
+// already belong to the actor.
 func (s Storage) Commit(newCid cid.Cid, oldCid cid.Cid) error {
+	if s.gasTracker != nil {
+		if err := s.gasTracker.Charge(s.gasTracker.Schedule.StorageCommit); err != nil {
+			return err
+		}
+	}
+
 	// commit to initialize actor only permitted if Head and expected id are nil
 	if oldCid.Defined() && s.actor.Head.Defined() && !oldCid.Equals(s.actor.Head) {
 		return exec.Errors[exec.ErrStaleHead]
@@ -205,6 +257,22 @@ func (s *Storage) Flush() error {
 	return s.blockstore.PutMany(blks)
 }
 
+// markLinksReachable records the links of a chunk read from the backing store
+// as reachable, if the chunk itself is.
+func (s Storage) markLinksReachable(blk blocks.Block) {
+	if !s.reachable.Has(blk.Cid()) {
+		return
+	}
+	nd, err := cbor.DecodeBlock(blk)
+	if err != nil {
+		// only cbor chunks have links
+		return
+	}
+	for _, link := range nd.Links() {
+		s.reachable.Add(link.Cid)
+	}
+}
+
 // liveDescendantIds returns the ids of all chunks reachable from the given id for this storage.
 // That is the given id , any links in the chunk referenced by the given id, or any links
 // referenced from those links.
@@ -214,6 +282,11 @@ func (s Storage) liveDescendantIds(id cid.Cid) (*cid.Set, error) {
 	}
 	chunk, ok := s.chunks[id]
 	if !ok {
+		// chunks of other actors must not be linked, even if they exist.
+		if !s.reachable.Has(id) {
+			return nil, vmerrors.NewFaultErrorf("linked node, %s, is not in the storage of the actor", id)
+		}
+
 		has, err := s.blockstore.Has(id)
 		if err != nil {
 			return nil, vmerrors.FaultErrorWrapf(err, "linked node, %s, missing from stage during flush", id)
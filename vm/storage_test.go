@@ -338,6 +338,55 @@ func TestValidationAndPruning(t *testing.T) {
 		assert.Equal(exec.Errors[exec.ErrDanglingPointer], err)
 	})
 
+	t.Run("Linking to a chunk of another actor fails in Commit", func(t *testing.T) {
+		bs := blockstore.NewBlockstore(datastore.NewMapDatastore())
+		storage := NewStorageMap(bs)
+
+		// another actor commits memory 2 and flushes it to the blockstore
+		otherActor := actor.NewActor(types.AccountActorCodeCid, types.NewZeroAttoFIL())
+		otherStage := storage.NewStorage(address.TestAddress2, otherActor)
+		otherCid, err := otherStage.Put(memory2.RawData())
+		require.NoError(err)
+		require.NoError(otherStage.Commit(otherCid, otherStage.Head()))
+		require.NoError(storage.Flush())
+
+		testActor := actor.NewActor(types.AccountActorCodeCid, types.NewZeroAttoFIL())
+		stage := storage.NewStorage(address.TestAddress, testActor)
+
+		memory, err := cbor.WrapObject(otherCid, types.DefaultHashFunction, -1)
+		require.NoError(err)
+		cid, err := stage.Put(memory.RawData())
+		require.NoError(err)
+
+		err = stage.Commit(cid, stage.Head())
+		assert.Equal(exec.Errors[exec.ErrDanglingPointer], err)
+	})
+
+	t.Run("Linking to chunks reachable from the head succeeds in Commit", func(t *testing.T) {
+		bs := blockstore.NewBlockstore(datastore.NewMapDatastore())
+
+		// an earlier head links to memory 2
+		memory, err := cbor.WrapObject(memory2.Cid(), types.DefaultHashFunction, -1)
+		require.NoError(err)
+		require.NoError(bs.Put(memory2))
+		require.NoError(bs.Put(memory))
+
+		testActor := actor.NewActor(types.AccountActorCodeCid, types.NewZeroAttoFIL())
+		testActor.Head = memory.Cid()
+		stage := NewStorageMap(bs).NewStorage(address.TestAddress, testActor)
+
+		// memory 2 belongs to the actor once the head linking to it is read
+		_, err = stage.Get(stage.Head())
+		require.NoError(err)
+
+		newMemory, err := cbor.WrapObject([]interface{}{memory2.Cid(), "more"}, types.DefaultHashFunction, -1)
+		require.NoError(err)
+		cid, err := stage.Put(newMemory.RawData())
+		require.NoError(err)
+
+		assert.NoError(stage.Commit(cid, stage.Head()))
+	})
+
 	t.Run("Prune removes unlinked chunks from stage", func(t *testing.T) {
 		bs := blockstore.NewBlockstore(datastore.NewMapDatastore())
 
@@ -372,3 +421,40 @@ func TestValidationAndPruning(t *testing.T) {
 		assert.Equal(memory3.RawData(), chunk)
 	})
 }
+
+func TestStorageChargesGas(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	bs := blockstore.NewBlockstore(datastore.NewMapDatastore())
+	testActor := actor.NewActor(types.AccountActorCodeCid, types.NewZeroAttoFIL())
+
+	gasTracker := NewGasTracker()
+	gasTracker.MsgGasLimit = types.NewGasUnits(1000)
+	schedule := gasTracker.Schedule
+
+	as := NewStorage(bs, testActor)
+	as.gasTracker = gasTracker
+
+	data, err := cbor.DumpObject("some data an actor might store")
+	require.NoError(err)
+	dataCost := PerWord(schedule.StoragePutPerWord, len(data))
+
+	id, err := as.Put(data)
+	require.NoError(err)
+	assert.Equal(schedule.StoragePutBase+dataCost, gasTracker.gasConsumedByMessage)
+
+	_, err = as.Get(id)
+	require.NoError(err)
+	assert.Equal(schedule.StoragePutBase+schedule.StorageGetBase+2*dataCost, gasTracker.gasConsumedByMessage)
+
+	require.NoError(as.Commit(id, as.Head()))
+	assert.Equal(schedule.StoragePutBase+schedule.StorageGetBase+schedule.StorageCommit+2*dataCost, gasTracker.gasConsumedByMessage)
+
+	t.Run("fails when out of gas", func(t *testing.T) {
+		gasTracker.MsgGasLimit = gasTracker.gasConsumedByMessage
+
+		_, err := as.Put(data)
+		assert.Equal(ErrOutOfGas, err)
+	})
+}
@@ -0,0 +1,58 @@
+package vm
+
+import (
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// ExecutionTrace records a message pass through the VM: the message, the gas
+// used handling it, what it returned, and a trace of every message the
+// receiving actor sent in turn.
+type ExecutionTrace struct {
+	From   address.Address `json:"from"`
+	To     address.Address `json:"to"`
+	Method string          `json:"method"`
+	Params []byte          `json:"params"`
+	Value  *types.AttoFIL  `json:"value"`
+
+	// GasUsed is the gas charged for this message pass, including the gas
+	// charged by the messages it sent.
+	GasUsed  types.GasUnits `json:"gasUsed"`
+	Return   []types.Bytes  `json:"return"`
+	ExitCode uint8          `json:"exitCode"`
+	// Error is the message of the error the message pass failed with, if any.
+	Error string `json:"error,omitempty"`
+
+	// Subcalls are the traces of the messages sent while handling this one,
+	// in the order they were sent.
+	Subcalls []*ExecutionTrace `json:"subcalls,omitempty"`
+}
+
+// recordStart fills in the trace from the message about to be passed.
+func (t *ExecutionTrace) recordStart(msg *types.Message) {
+	t.From = msg.From
+	t.To = msg.To
+	t.Method = msg.Method
+	t.Params = msg.Params
+	t.Value = msg.Value
+}
+
+// recordEnd fills in the trace from the outcome of the message pass.
+func (t *ExecutionTrace) recordEnd(gasUsed types.GasUnits, ret [][]byte, exitCode uint8, err error) {
+	t.GasUsed = gasUsed
+	for _, b := range ret {
+		t.Return = append(t.Return, b)
+	}
+	t.ExitCode = exitCode
+	if err != nil {
+		t.Error = err.Error()
+	}
+}
+
+// newSubcall appends an empty trace for a message sent while handling this
+// one and returns it.
+func (t *ExecutionTrace) newSubcall() *ExecutionTrace {
+	sub := &ExecutionTrace{}
+	t.Subcalls = append(t.Subcalls, sub)
+	return sub
+}
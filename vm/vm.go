@@ -8,18 +8,29 @@ import (
 	cbor "gx/ipfs/QmRoARq3nkUb13HSKZGepCZSWe5GrVPwx7xURJGZ7KWv9V/go-ipld-cbor"
 
 	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/exec"
 	"github.com/filecoin-project/go-filecoin/types"
 	"github.com/filecoin-project/go-filecoin/vm/errors"
 )
 
 // Send executes a message pass inside the VM. If error is set it
-// will always satisfy either ShouldRevert() or IsFault().
+// will always satisfy either ShouldRevert() or IsFault(). If the context was
+// given a trace, the message pass is recorded in it.
 func Send(ctx context.Context, vmCtx *Context) ([][]byte, uint8, error) {
 	deps := sendDeps{
 		transfer: Transfer,
 	}
 
-	return send(ctx, deps, vmCtx)
+	trace := vmCtx.trace
+	if trace == nil {
+		return send(ctx, deps, vmCtx)
+	}
+
+	trace.recordStart(vmCtx.message)
+	gasBefore := vmCtx.gasTracker.gasConsumedByMessage
+	ret, code, err := send(ctx, deps, vmCtx)
+	trace.recordEnd(vmCtx.gasTracker.gasConsumedByMessage-gasBefore, ret, code, err)
+	return ret, code, err
 }
 
 type sendDeps struct {
@@ -28,6 +39,11 @@ type sendDeps struct {
 
 // send executes a message pass inside the VM. It exists alongside Send so that we can inject its dependencies during test.
 func send(ctx context.Context, deps sendDeps, vmCtx *Context) ([][]byte, uint8, error) {
+	gasTracker := vmCtx.gasTracker
+	if err := gasTracker.Charge(PerWord(gasTracker.Schedule.ParamsPerWord, len(vmCtx.message.Params))); err != nil {
+		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
 	if vmCtx.message.Value != nil {
 		if err := deps.transfer(vmCtx.from, vmCtx.to, vmCtx.message.Value); err != nil {
 			if errors.ShouldRevert(err) {
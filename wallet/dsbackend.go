@@ -12,6 +12,7 @@ import (
 	dsq "gx/ipfs/Qmf4xQhNomPNhrtZc67qSnfJSjxjXs9LWvknJtSXwimPrM/go-datastore/query"
 
 	"github.com/filecoin-project/go-filecoin/address"
+	bls "github.com/filecoin-project/go-filecoin/bls-signatures"
 	"github.com/filecoin-project/go-filecoin/crypto"
 	"github.com/filecoin-project/go-filecoin/repo"
 	"github.com/filecoin-project/go-filecoin/types"
@@ -21,6 +22,8 @@ import (
 const (
 	// SECP256K1 is a curve used to computer private keys
 	SECP256K1 = "secp256k1"
+	// BLS is the curve of BLS private keys, whose signatures can be aggregated
+	BLS = "bls"
 )
 
 // DSBackendType is the reflect type of the DSBackend.
@@ -116,6 +119,22 @@ func (backend *DSBackend) NewAddress() (address.Address, error) {
 	return ki.Address()
 }
 
+// NewBLSAddress creates a new address of a BLS key and stores it.
+// Safe for concurrent access.
+func (backend *DSBackend) NewBLSAddress() (address.Address, error) {
+	prv := bls.PrivateKeyGenerate()
+	ki := &types.KeyInfo{
+		PrivateKey: prv[:],
+		Curve:      BLS,
+	}
+
+	if err := backend.putKeyInfo(ki); err != nil {
+		return address.Address{}, err
+	}
+
+	return ki.Address()
+}
+
 func (backend *DSBackend) putKeyInfo(ki *types.KeyInfo) error {
 	a, err := ki.Address()
 	if err != nil {
@@ -145,6 +164,10 @@ func (backend *DSBackend) SignBytes(data []byte, addr address.Address) (types.Si
 		return nil, err
 	}
 
+	if ki.Type() == BLS {
+		return wutil.SignBLS(ki.Key(), data)
+	}
+
 	privateKey, _, err := keysFromInfo(ki)
 	if err != nil {
 		return nil, err
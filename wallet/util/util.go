@@ -6,6 +6,7 @@ import (
 	"gx/ipfs/QmVmDhyTTUcQXFD1rRQ64fGLMSAoaQvNH3hwuaCFAPq2hy/errors"
 	"gx/ipfs/QmZp3eKdYQHHAneECmeK6HhiMwTPufmjC8DuuaGKv3unvx/blake2b-simd"
 
+	bls "github.com/filecoin-project/go-filecoin/bls-signatures"
 	"github.com/filecoin-project/go-filecoin/crypto"
 )
 
@@ -38,3 +39,70 @@ func Ecrecover(data, signature []byte) ([]byte, error) {
 	hash := blake2b.Sum256(data)
 	return crypto.Ecrecover(hash[:], signature)
 }
+
+// SignBLS signs `data` using the BLS private key `priv`.
+func SignBLS(priv, data []byte) ([]byte, error) {
+	if len(priv) != bls.PrivateKeyBytes {
+		return nil, errors.Errorf("invalid BLS private key length: %d", len(priv))
+	}
+
+	var privateKey bls.PrivateKey
+	copy(privateKey[:], priv)
+	sig := bls.PrivateKeySign(privateKey, data)
+	return sig[:], nil
+}
+
+// BLSPublicKey returns the public key of the BLS private key `priv`.
+func BLSPublicKey(priv []byte) ([]byte, error) {
+	if len(priv) != bls.PrivateKeyBytes {
+		return nil, errors.Errorf("invalid BLS private key length: %d", len(priv))
+	}
+
+	var privateKey bls.PrivateKey
+	copy(privateKey[:], priv)
+	pub := bls.PrivateKeyPublicKey(privateKey)
+	return pub[:], nil
+}
+
+// VerifyBLS verifies that 'sig' is the BLS signature of 'data' with the public
+// key `pk`.
+func VerifyBLS(pk, data, signature []byte) bool {
+	return VerifyBLSAggregate([][]byte{pk}, [][]byte{data}, signature)
+}
+
+// AggregateBLS aggregates BLS signatures into one, which verifies the signed
+// data of all of them at once.
+func AggregateBLS(signatures [][]byte) ([]byte, error) {
+	sigs := make([]bls.Signature, len(signatures))
+	for i, signature := range signatures {
+		if len(signature) != bls.SignatureBytes {
+			return nil, errors.Errorf("invalid BLS signature length: %d", len(signature))
+		}
+		copy(sigs[i][:], signature)
+	}
+
+	aggregate := bls.Aggregate(sigs)
+	return aggregate[:], nil
+}
+
+// VerifyBLSAggregate verifies that 'signature' aggregates the BLS signatures
+// of each of 'data' with the public key of the same index in `pks`.
+func VerifyBLSAggregate(pks [][]byte, data [][]byte, signature []byte) bool {
+	if len(pks) != len(data) || len(signature) != bls.SignatureBytes {
+		return false
+	}
+
+	publicKeys := make([]bls.PublicKey, len(pks))
+	digests := make([]bls.Digest, len(data))
+	for i := range pks {
+		if len(pks[i]) != bls.PublicKeyBytes {
+			return false
+		}
+		copy(publicKeys[i][:], pks[i])
+		digests[i] = bls.Hash(data[i])
+	}
+
+	var sig bls.Signature
+	copy(sig[:], signature)
+	return bls.Verify(sig, digests, publicKeys)
+}
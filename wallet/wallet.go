@@ -106,6 +106,19 @@ func (w *Wallet) SignBytes(data []byte, addr address.Address) (types.Signature,
 	return backend.SignBytes(data, addr)
 }
 
+// PublicKey returns the public key belonging to address `addr`.
+func (w *Wallet) PublicKey(addr address.Address) ([]byte, error) {
+	backend, err := w.Find(addr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get public key of address: %s", addr)
+	}
+	ki, err := backend.GetKeyInfo(addr)
+	if err != nil {
+		return nil, err
+	}
+	return ki.PublicKey()
+}
+
 // Verify cryptographically verifies that 'sig' is the signed hash of 'data' with
 // the public key `pk`.
 func (w *Wallet) Verify(data []byte, pk []byte, sig types.Signature) (bool, error) {
@@ -130,3 +143,15 @@ func NewAddress(w *Wallet) (address.Address, error) {
 	backend := (backends[0]).(*DSBackend)
 	return backend.NewAddress()
 }
+
+// NewBLSAddress creates a new account address of a BLS key on the default
+// wallet backend.
+func NewBLSAddress(w *Wallet) (address.Address, error) {
+	backends := w.Backends(DSBackendType)
+	if len(backends) == 0 {
+		return address.Address{}, fmt.Errorf("missing default ds backend")
+	}
+
+	backend := (backends[0]).(*DSBackend)
+	return backend.NewBLSAddress()
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/crypto"
+	"github.com/filecoin-project/go-filecoin/types"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -159,3 +160,35 @@ func TestSignErrorCases(t *testing.T) {
 	assert.Error(err)
 	assert.Contains(err.Error(), "failed to sign data")
 }
+
+func TestBLSSignAndVerify(t *testing.T) {
+	assert := assert.New(t)
+
+	fs, err := NewDSBackend(datastore.NewMapDatastore())
+	assert.NoError(err)
+	w := New(fs)
+
+	t.Log("create a new BLS address")
+	addr, err := NewBLSAddress(w)
+	assert.NoError(err)
+	assert.True(addr.IsBLS())
+	assert.True(w.HasAddress(addr))
+
+	pk, err := w.PublicKey(addr)
+	assert.NoError(err)
+	assert.True(types.IsKeyOfAddress(pk, addr))
+
+	dataA := []byte("THIS IS A SIGNED SLICE OF DATA")
+	t.Log("sign content")
+	sig, err := w.SignBytes(dataA, addr)
+	assert.NoError(err)
+
+	t.Log("verify signed content")
+	assert.True(types.IsValidSignatureWithKey(dataA, addr, pk, sig))
+
+	t.Log("verify fails for unsigned content")
+	assert.False(types.IsValidSignatureWithKey([]byte("I AM UNSIGNED DATA!"), addr, pk, sig))
+
+	t.Log("BLS signatures cannot be verified by recovering the key")
+	assert.False(types.IsValidSignature(dataA, addr, sig))
+}